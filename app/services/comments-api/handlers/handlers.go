@@ -13,6 +13,8 @@ import (
 	commentCore "github.com/dudakovict/social-network/business/core/comment"
 	"github.com/dudakovict/social-network/business/sys/auth"
 	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/business/sys/stream"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
 	"github.com/dudakovict/social-network/business/web/v1/mid"
 	"github.com/dudakovict/social-network/foundation/web"
 	"github.com/jmoiron/sqlx"
@@ -63,6 +65,19 @@ type APIMuxConfig struct {
 	Auth     *auth.Auth
 	DB       *sqlx.DB
 	NATS     *nats.NATS
+	Hub      *stream.Hub
+}
+
+// registry holds the codes the errors of the cores are responded with. The
+// codes are part of the API, so clients can match on them and they must not
+// change.
+var registry = v1Web.Registry{
+	{Err: commentCore.ErrInvalidID, Code: "invalid_id", Status: http.StatusBadRequest},
+	{Err: commentCore.ErrNotFound, Code: "comment_not_found", Status: http.StatusNotFound},
+	{Err: commentCore.ErrPostNotFound, Code: "post_not_found", Status: http.StatusNotFound},
+	{Err: commentCore.ErrInvalidParent, Code: "invalid_parent", Status: http.StatusBadRequest},
+	{Err: commentCore.ErrRevisionNotFound, Code: "revision_not_found", Status: http.StatusNotFound},
+	{Err: commentCore.ErrRetentionExpired, Code: "retention_expired", Status: http.StatusGone},
 }
 
 // APIMux constructs an http.Handler with all application routes defined.
@@ -72,7 +87,7 @@ func APIMux(cfg APIMuxConfig) *web.App {
 	app := web.NewApp(
 		cfg.Shutdown,
 		mid.Logger(cfg.Log),
-		mid.Errors(cfg.Log),
+		mid.Errors(cfg.Log, registry),
 		mid.Metrics(),
 		mid.Panics(),
 	)
@@ -90,19 +105,43 @@ func v1(app *web.App, cfg APIMuxConfig) {
 	tgh := v1TestGrp.Handlers{
 		Log: cfg.Log,
 	}
-	app.Handle(http.MethodGet, version, "/test", tgh.Test)
-	app.Handle(http.MethodGet, version, "/testauth", tgh.Test, mid.Authenticate(cfg.Auth), mid.Authorize("ADMIN"))
+	app.Handle(http.MethodGet, version, "/test", tgh.Test).
+		Describe(web.Doc{Summary: "Check the service for development", Security: web.SecurityNone})
+	app.Handle(http.MethodGet, version, "/testauth", tgh.Test, mid.Authenticate(cfg.Auth), mid.Authorize("ADMIN")).
+		Describe(web.Doc{Summary: "Check the service and the token for development"})
 
 	// Register post management and authentication endpoints.
 	cgh := v1CommentGrp.Handlers{
 		Core: commentCore.NewCore(cfg.Log, cfg.DB, cfg.NATS),
 		Auth: cfg.Auth,
+		Hub:  cfg.Hub,
 	}
 
-	app.Handle(http.MethodGet, version, "/comments/:page/:rows", cgh.Query, mid.Authenticate(cfg.Auth))
-	app.Handle(http.MethodGet, version, "/comments/:id", cgh.QueryByID, mid.Authenticate(cfg.Auth))
-	app.Handle(http.MethodPost, version, "/comments", cgh.Create, mid.Authenticate(cfg.Auth))
-	app.Handle(http.MethodPut, version, "/comments/:id", cgh.Update, mid.Authenticate(cfg.Auth))
-	app.Handle(http.MethodDelete, version, "/comments/:id", cgh.Delete, mid.Authenticate(cfg.Auth))
-	app.Handle(http.MethodGet, version, "/posts/:id", cgh.QueryPostsByPostID, mid.Authenticate(cfg.Auth))
+	app.Handle(http.MethodGet, version, "/comments/trash", cgh.QueryTrash, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List your deleted comments", Response: []v1CommentGrp.AppComment{}})
+	app.Handle(http.MethodGet, version, "/comments/:page/:rows", cgh.Query, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List comments", Response: []v1CommentGrp.AppComment{}})
+	app.Handle(http.MethodGet, version, "/comments/:id", cgh.QueryByID, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Get a comment", Response: v1CommentGrp.AppComment{}})
+	app.Handle(http.MethodPost, version, "/comments", cgh.Create, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Create a comment", Request: commentCore.NewComment{}, Response: v1CommentGrp.AppComment{}, Status: http.StatusCreated})
+	app.Handle(http.MethodPut, version, "/comments/:id", cgh.Update, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Update a comment", Request: commentCore.UpdateComment{}, Status: http.StatusNoContent})
+	app.Handle(http.MethodDelete, version, "/comments/:id", cgh.Delete, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Move a comment to the trash", Status: http.StatusNoContent})
+	app.Handle(http.MethodPost, version, "/comments/:id/restore", cgh.Restore, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Restore a comment from the trash", Status: http.StatusNoContent})
+	app.Handle(http.MethodGet, version, "/comments/:id/revisions", cgh.QueryRevisions, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List the revisions of a comment", Response: []commentCore.Revision{}})
+	app.Handle(http.MethodGet, version, "/comments/:id/revisions/:rid", cgh.QueryRevisionByID, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Get a revision of a comment", Response: commentCore.Revision{}})
+	app.Handle(http.MethodGet, version, "/comments/:id/revisions/:from/diff/:to", cgh.DiffRevisions, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Compare two revisions of a comment, either can be current", Response: commentCore.RevisionDiff{}})
+	app.Handle(http.MethodGet, version, "/comments/posts/:id/:page/:rows", cgh.QueryPostWithComments, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Get a post with its comments", Query: []string{"threaded"}, Response: commentCore.PostWithComments{}})
+	app.Handle(http.MethodGet, version, "/comments/posts/:id/stream", cgh.Stream, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Stream the comments of a post as server-sent events", ResponseType: "text/event-stream"})
+
+	// Register the description of the API.
+	app.HandleOpenAPI(version, "/openapi.json", web.OpenAPIConfig{Title: "comments-api", Version: version, Error: v1Web.ErrorResponse{}, ErrorType: v1Web.ProblemContentType})
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/dudakovict/social-network/business/core/comment"
 	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/stream"
 	v1Web "github.com/dudakovict/social-network/business/web/v1"
 	"github.com/dudakovict/social-network/foundation/web"
 )
@@ -18,6 +19,14 @@ import (
 type Handlers struct {
 	Core comment.Core
 	Auth *auth.Auth
+	Hub  *stream.Hub
+}
+
+// AppComment is a comment along with the users mentioned in its
+// description.
+type AppComment struct {
+	comment.Comment
+	Mentions []comment.Mention `json:"mentions"`
 }
 
 // Create adds a new comment to the system.
@@ -27,17 +36,31 @@ func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return web.NewShutdownError("web value missing from context")
 	}
 
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
 	var nc comment.NewComment
 	if err := web.Decode(r, &nc); err != nil {
 		return fmt.Errorf("unable to decode payload: %w", err)
 	}
 
+	// Comments are always made on behalf of the authenticated user so the
+	// visibility of the post is checked against them.
+	nc.UserID = claims.Subject
+
 	c, err := h.Core.Create(ctx, nc, v.Now)
 	if err != nil {
 		return fmt.Errorf("comment[%+v]: %w", &c, err)
 	}
 
-	return web.Respond(ctx, w, c, http.StatusCreated)
+	acs, err := h.toAppComments(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, acs[0], http.StatusCreated)
 }
 
 // Update updates a comment in the system.
@@ -61,7 +84,7 @@ func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 
 	c, err := h.Core.QueryByID(ctx, commentID)
 	if err != nil {
-		return v1Web.NewRequestError(err, http.StatusBadRequest)
+		return fmt.Errorf("ID[%s]: %w", commentID, err)
 	}
 
 	// If you are not an admin and looking to retrieve someone other than yourself.
@@ -69,22 +92,20 @@ func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
 	}
 
-	if err := h.Core.Update(ctx, commentID, upd, v.Now); err != nil {
-		switch {
-		case errors.Is(err, comment.ErrInvalidID):
-			return v1Web.NewRequestError(err, http.StatusBadRequest)
-		case errors.Is(err, comment.ErrNotFound):
-			return v1Web.NewRequestError(err, http.StatusNotFound)
-		default:
-			return fmt.Errorf("ID[%s] Comment[%+v]: %w", commentID, &upd, err)
-		}
+	if err := h.Core.Update(ctx, commentID, claims.Subject, upd, v.Now); err != nil {
+		return fmt.Errorf("ID[%s] Comment[%+v]: %w", commentID, &upd, err)
 	}
 
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
-// Delete removes a comment from the system.
+// Delete moves a comment into the trash.
 func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
 	claims, err := auth.GetClaims(ctx)
 	if err != nil {
 		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
@@ -94,7 +115,7 @@ func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Req
 
 	c, err := h.Core.QueryByID(ctx, commentID)
 	if err != nil {
-		return v1Web.NewRequestError(err, http.StatusBadRequest)
+		return fmt.Errorf("ID[%s]: %w", commentID, err)
 	}
 
 	// If you are not an admin and looking to delete someone other than yourself.
@@ -102,102 +123,291 @@ func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
 	}
 
-	if err := h.Core.Delete(ctx, commentID); err != nil {
-		switch {
-		case errors.Is(err, comment.ErrInvalidID):
-			return v1Web.NewRequestError(err, http.StatusBadRequest)
-		case errors.Is(err, comment.ErrNotFound):
-			return v1Web.NewRequestError(err, http.StatusNotFound)
-		default:
-			return fmt.Errorf("ID[%s]: %w", commentID, err)
+	if err := h.Core.Delete(ctx, commentID, v.Now); err != nil {
+		return fmt.Errorf("ID[%s]: %w", commentID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Restore takes a comment out of the trash.
+func (h Handlers) Restore(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	commentID := web.Param(r, "id")
+
+	c, err := h.Core.QueryDeletedByID(ctx, commentID)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", commentID, err)
+	}
+
+	// If you are not an admin and looking to restore someone other than yourself.
+	if !claims.Authorized(auth.RoleAdmin) && claims.Subject != c.UserID {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	if err := h.Core.Restore(ctx, commentID, v.Now); err != nil {
+
+		// A comment can not be restored while its post is gone, which is a
+		// conflict with the post rather than a missing comment.
+		if errors.Is(err, comment.ErrPostNotFound) {
+			return v1Web.NewRequestError(err, http.StatusConflict)
 		}
+		return fmt.Errorf("ID[%s]: %w", commentID, err)
 	}
 
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
+// QueryTrash returns the comments the authenticated user has in the trash.
+func (h Handlers) QueryTrash(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	comments, err := h.Core.QueryTrash(ctx, claims.Subject)
+	if err != nil {
+		return fmt.Errorf("unable to query for trash: %w", err)
+	}
+
+	acs, err := h.toAppComments(ctx, comments...)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, acs, http.StatusOK)
+}
+
 // Query returns a list of comments with paging.
 func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	page := web.Param(r, "page")
-	pageNumber, err := strconv.Atoi(page)
+	claims, err := auth.GetClaims(ctx)
 	if err != nil {
-		return v1Web.NewRequestError(fmt.Errorf("invalid page format [%s]", page), http.StatusBadRequest)
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
 	}
-	rows := web.Param(r, "rows")
-	rowsPerPage, err := strconv.Atoi(rows)
+
+	pageNumber, rowsPerPage, err := paging(r)
 	if err != nil {
-		return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+		return err
 	}
 
-	comments, err := h.Core.Query(ctx, pageNumber, rowsPerPage)
+	comments, err := h.Core.Query(ctx, claims.Subject, pageNumber, rowsPerPage)
 	if err != nil {
 		return fmt.Errorf("unable to query for comments: %w", err)
 	}
 
-	return web.Respond(ctx, w, comments, http.StatusOK)
+	acs, err := h.toAppComments(ctx, comments...)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, acs, http.StatusOK)
 }
 
 // QueryByID returns a comment by its ID.
 func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	/*
-		claims, err := auth.GetClaims(ctx)
-		if err != nil {
-			return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
-		}
-	*/
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
 
 	commentID := web.Param(r, "id")
 
-	/*
-		// If you are not an admin and looking to retrieve someone other than yourself.
-		if !claims.Authorized(auth.RoleAdmin) && claims.Subject != postID {
-			return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
-		}
-	*/
+	c, err := h.Core.QueryVisibleByID(ctx, commentID, claims.Subject)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", commentID, err)
+	}
 
-	c, err := h.Core.QueryByID(ctx, commentID)
+	acs, err := h.toAppComments(ctx, c)
 	if err != nil {
-		switch {
-		case errors.Is(err, comment.ErrInvalidID):
-			return v1Web.NewRequestError(err, http.StatusBadRequest)
-		case errors.Is(err, comment.ErrNotFound):
-			return v1Web.NewRequestError(err, http.StatusNotFound)
-		default:
-			return fmt.Errorf("ID[%s]: %w", commentID, err)
-		}
+		return err
 	}
 
-	return web.Respond(ctx, w, c, http.StatusOK)
+	return web.Respond(ctx, w, acs[0], http.StatusOK)
 }
 
-func (h Handlers) QueryPostsByPostID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	/*
-		claims, err := auth.GetClaims(ctx)
-		if err != nil {
-			return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+// QueryRevisions returns the previous versions of a comment.
+func (h Handlers) QueryRevisions(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	commentID := web.Param(r, "id")
+
+	if err := h.checkVisible(ctx, commentID); err != nil {
+		return err
+	}
+
+	revs, err := h.Core.QueryRevisions(ctx, commentID)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", commentID, err)
+	}
+
+	return web.Respond(ctx, w, revs, http.StatusOK)
+}
+
+// QueryRevisionByID returns a previous version of a comment by its ID.
+func (h Handlers) QueryRevisionByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	commentID := web.Param(r, "id")
+	revisionID := web.Param(r, "rid")
+
+	if err := h.checkVisible(ctx, commentID); err != nil {
+		return err
+	}
+
+	rev, err := h.Core.QueryRevisionByID(ctx, commentID, revisionID)
+	if err != nil {
+		return fmt.Errorf("ID[%s] RevisionID[%s]: %w", commentID, revisionID, err)
+	}
+
+	return web.Respond(ctx, w, rev, http.StatusOK)
+}
+
+// DiffRevisions returns the differences between two versions of a comment.
+func (h Handlers) DiffRevisions(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	commentID := web.Param(r, "id")
+	fromID := web.Param(r, "from")
+	toID := web.Param(r, "to")
+
+	if err := h.checkVisible(ctx, commentID); err != nil {
+		return err
+	}
+
+	rd, err := h.Core.DiffRevisions(ctx, commentID, fromID, toID)
+	if err != nil {
+		return fmt.Errorf("ID[%s] From[%s] To[%s]: %w", commentID, fromID, toID, err)
+	}
+
+	return web.Respond(ctx, w, rd, http.StatusOK)
+}
+
+// QueryPostWithComments returns a post along with a page of its comments.
+// Comments are nested under their parent when the threaded query parameter
+// is set to true.
+func (h Handlers) QueryPostWithComments(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	postID := web.Param(r, "id")
+
+	pageNumber, rowsPerPage, err := paging(r)
+	if err != nil {
+		return err
+	}
+
+	var threaded bool
+	if t := r.URL.Query().Get("threaded"); t != "" {
+		if threaded, err = strconv.ParseBool(t); err != nil {
+			return v1Web.NewRequestError(fmt.Errorf("invalid threaded format [%s]", t), http.StatusBadRequest)
 		}
-	*/
+	}
+
+	pwc, err := h.Core.QueryPostWithComments(ctx, postID, claims.Subject, pageNumber, rowsPerPage, threaded)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", postID, err)
+	}
+
+	return web.Respond(ctx, w, pwc, http.StatusOK)
+}
+
+// Stream pushes the comments made on a post to the client as they are
+// created, over a WebSocket or as Server-Sent Events.
+func (h Handlers) Stream(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
 
 	postID := web.Param(r, "id")
 
-	/*
-		// If you are not an admin and looking to retrieve someone other than yourself.
-		if !claims.Authorized(auth.RoleAdmin) && claims.Subject != postID {
-			return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	if err := h.Core.CheckVisible(ctx, postID, claims.Subject); err != nil {
+		return fmt.Errorf("ID[%s]: %w", postID, err)
+	}
+
+	// Viewers who can no longer see the post, because it was made private,
+	// deleted or they unfollowed its author, stop receiving its comments on
+	// the streams they already opened.
+	check := func(ctx context.Context) error {
+		err := h.Core.CheckVisible(ctx, postID, claims.Subject)
+		if errors.Is(err, comment.ErrPostNotFound) {
+			return fmt.Errorf("%w: %v", stream.ErrDenied, err)
 		}
-	*/
+		return err
+	}
+
+	if err := h.Hub.Serve(ctx, w, r, postID, check); err != nil {
+		return fmt.Errorf("ID[%s]: %w", postID, err)
+	}
+
+	return nil
+}
+
+// checkVisible makes sure the authenticated user is allowed to see the
+// comment.
+func (h Handlers) checkVisible(ctx context.Context, commentID string) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	if _, err := h.Core.QueryVisibleByID(ctx, commentID, claims.Subject); err != nil {
+		return fmt.Errorf("ID[%s]: %w", commentID, err)
+	}
+
+	return nil
+}
+
+// toAppComments adds the mentions to the comments.
+func (h Handlers) toAppComments(ctx context.Context, comments ...comment.Comment) ([]AppComment, error) {
+	ids := make([]string, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+	}
 
-	ps, err := h.Core.QueryPostsByPostID(ctx, postID)
+	mentions, err := h.Core.QueryMentions(ctx, ids...)
 	if err != nil {
-		switch {
-		case errors.Is(err, comment.ErrInvalidID):
-			return v1Web.NewRequestError(err, http.StatusBadRequest)
-		case errors.Is(err, comment.ErrNotFound):
-			return v1Web.NewRequestError(err, http.StatusNotFound)
-		default:
-			return fmt.Errorf("ID[%s]: %w", postID, err)
+		return nil, fmt.Errorf("unable to query for mentions: %w", err)
+	}
+
+	acs := make([]AppComment, len(comments))
+	for i, c := range comments {
+		acs[i] = AppComment{
+			Comment:  c,
+			Mentions: mentions[c.ID],
+		}
+		if acs[i].Mentions == nil {
+			acs[i].Mentions = []comment.Mention{}
 		}
 	}
 
-	return web.Respond(ctx, w, ps, http.StatusOK)
+	return acs, nil
+}
+
+// maxRowsPerPage caps how many comments are returned in a single page.
+const maxRowsPerPage = 100
+
+// paging reads the page number and the rows per page from the request. Asking
+// for more rows than maxRowsPerPage returns maxRowsPerPage rows.
+func paging(r *http.Request) (int, int, error) {
+	page := web.Param(r, "page")
+	pageNumber, err := strconv.Atoi(page)
+	if err != nil || pageNumber < 1 {
+		return 0, 0, v1Web.NewRequestError(fmt.Errorf("invalid page format [%s]", page), http.StatusBadRequest)
+	}
+	rows := web.Param(r, "rows")
+	rowsPerPage, err := strconv.Atoi(rows)
+	if err != nil || rowsPerPage < 1 {
+		return 0, 0, v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+	}
+	if rowsPerPage > maxRowsPerPage {
+		rowsPerPage = maxRowsPerPage
+	}
+
+	return pageNumber, rowsPerPage, nil
 }
@@ -8,14 +8,17 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/ardanlabs/conf"
 	"github.com/dudakovict/social-network/app/services/comments-api/handlers"
+	"github.com/dudakovict/social-network/business/core/comment"
 	"github.com/dudakovict/social-network/business/sys/auth"
 	"github.com/dudakovict/social-network/business/sys/database"
 	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/business/sys/stream"
 	"github.com/dudakovict/social-network/foundation/keystore"
 	"github.com/dudakovict/social-network/foundation/logger"
 	"go.opentelemetry.io/otel"
@@ -101,6 +104,15 @@ func run(log *zap.SugaredLogger) error {
 			ClientID  string `conf:"default:comments-pod,env:NATS_CLIENT_ID"`
 			Host      string `conf:"default:http://nats-service:4222"`
 		}
+		Stream struct {
+			Buffer       int           `conf:"default:64"`
+			Heartbeat    time.Duration `conf:"default:15s"`
+			WriteTimeout time.Duration `conf:"default:10s"`
+			CheckTTL     time.Duration `conf:"default:10s"`
+		}
+		Trash struct {
+			PurgeInterval time.Duration `conf:"default:1h"`
+		}
 	}{
 		Version: conf.Version{
 			SVN:  build,
@@ -192,6 +204,82 @@ func run(log *zap.SugaredLogger) error {
 		n.Client.Close()
 	}()
 
+	// =========================================================================
+	// Start Event Listener Support
+
+	log.Infow("startup", "status", "initializing post and follow event listeners")
+
+	if err := comment.NewListener(log, db, n).Listen(); err != nil {
+		return fmt.Errorf("listening for events: %w", err)
+	}
+
+	// =========================================================================
+	// Start Trash Purge Support
+
+	// The background tasks are cancelled when the service shuts down and
+	// waited on so they do not outlive the database connection.
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	var bg sync.WaitGroup
+	defer func() {
+		log.Infow("shutdown", "status", "stopping background tasks")
+
+		bgCancel()
+
+		done := make(chan struct{})
+		go func() {
+			bg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(cfg.Web.ShutdownTimeout):
+			log.Errorw("shutdown", "status", "background tasks did not stop")
+		}
+	}()
+
+	log.Infow("startup", "status", "initializing trash purge support", "interval", cfg.Trash.PurgeInterval)
+
+	// Comments stay in the trash for comment.TrashRetention. Purging is
+	// idempotent so every replica of the service can run it.
+	purge := time.NewTicker(cfg.Trash.PurgeInterval)
+	defer purge.Stop()
+
+	bg.Add(1)
+	go func() {
+		defer bg.Done()
+
+		core := comment.NewCore(log, db, n)
+		for {
+			select {
+			case <-bgCtx.Done():
+				return
+			case now := <-purge.C:
+				if err := core.Purge(bgCtx, now.UTC()); err != nil {
+					log.Errorw("purge", "status", "purging trash", "ERROR", err)
+				}
+			}
+		}
+	}()
+
+	// =========================================================================
+	// Start Stream Support
+
+	log.Infow("startup", "status", "initializing stream support")
+
+	// Streams bridge the comment-created events of every instance to the clients
+	// connected to this instance.
+	hub := stream.NewHub(log, stream.Config{
+		Buffer:       cfg.Stream.Buffer,
+		Heartbeat:    cfg.Stream.Heartbeat,
+		WriteTimeout: cfg.Stream.WriteTimeout,
+		CheckTTL:     cfg.Stream.CheckTTL,
+	})
+
+	if err := hub.Bridge(n, "comment-created", comment.StreamCreated); err != nil {
+		return fmt.Errorf("bridging comment-created: %w", err)
+	}
+
 	// =========================================================================
 	// Start Tracing Support
 
@@ -243,6 +331,7 @@ func run(log *zap.SugaredLogger) error {
 		Auth:     auth,
 		DB:       db,
 		NATS:     n,
+		Hub:      hub,
 	})
 
 	// Construct a server to service the requests against the mux.
@@ -281,6 +370,10 @@ func run(log *zap.SugaredLogger) error {
 		ctx, cancel := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
 		defer cancel()
 
+		// Streams have taken over their connections so the server does not
+		// wait for them.
+		hub.Close()
+
 		// Asking listener to shut down and shed load.
 		if err := api.Shutdown(ctx); err != nil {
 			api.Close()
@@ -0,0 +1,96 @@
+// Package mailgrp maintains the group of handlers for inspecting the mail
+// captured in development.
+package mailgrp
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dudakovict/social-network/foundation/mail"
+	"go.uber.org/zap"
+)
+
+// Handlers manages the set of mail endpoints.
+type Handlers struct {
+	Log    *zap.SugaredLogger
+	Memory *mail.Memory
+}
+
+// Mail lists the captured messages, newest first, on GET and drops them on
+// DELETE. The to query parameter only lists the messages to that address.
+func (h Handlers) Mail(w http.ResponseWriter, r *http.Request) {
+	statusCode := http.StatusOK
+
+	switch r.Method {
+	case http.MethodGet:
+		type message struct {
+			From            string    `json:"from"`
+			To              []string  `json:"to"`
+			Subject         string    `json:"subject"`
+			Text            string    `json:"text"`
+			HTML            string    `json:"html"`
+			Date            time.Time `json:"date"`
+			ListUnsubscribe string    `json:"list_unsubscribe"`
+		}
+
+		to := r.URL.Query().Get("to")
+		captured := h.Memory.Messages()
+
+		messages := []message{}
+		for i := len(captured) - 1; i >= 0; i-- {
+			m := captured[i]
+			if to != "" && !contains(m.To, to) {
+				continue
+			}
+			messages = append(messages, message(m))
+		}
+
+		if err := response(w, statusCode, messages); err != nil {
+			h.Log.Errorw("mail", "ERROR", err)
+		}
+
+	case http.MethodDelete:
+		h.Memory.Reset()
+		statusCode = http.StatusNoContent
+		w.WriteHeader(statusCode)
+
+	default:
+		statusCode = http.StatusMethodNotAllowed
+		w.Header().Set("Allow", "GET, DELETE")
+		w.WriteHeader(statusCode)
+	}
+
+	h.Log.Infow("mail", "statusCode", statusCode, "method", r.Method, "path", r.URL.Path, "remoteaddr", r.RemoteAddr)
+}
+
+func contains(addresses []string, address string) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
+
+func response(w http.ResponseWriter, statusCode int, data interface{}) error {
+
+	// Convert the response value to JSON.
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	// Set the content type and headers once we know marshaling has succeeded.
+	w.Header().Set("Content-Type", "application/json")
+
+	// Write the status code to the response.
+	w.WriteHeader(statusCode)
+
+	// Send the result back to the client.
+	if _, err := w.Write(jsonData); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+// Package handlers manages the debug routes and the different versions of
+// the API of the service.
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"os"
+
+	"github.com/dudakovict/social-network/app/services/email-api/handlers/debug/checkgrp"
+	"github.com/dudakovict/social-network/app/services/email-api/handlers/debug/mailgrp"
+	v1ReportGrp "github.com/dudakovict/social-network/app/services/email-api/handlers/v1/reportgrp"
+	emailCore "github.com/dudakovict/social-network/business/core/email"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
+	"github.com/dudakovict/social-network/business/web/v1/mid"
+	"github.com/dudakovict/social-network/foundation/mail"
+	"github.com/dudakovict/social-network/foundation/web"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// DebugStandardLibraryMux registers all the debug routes from the standard library
+// into a new mux bypassing the use of the DefaultServerMux. Using the
+// DefaultServerMux would be a security risk since a dependency could inject a
+// handler into our service without us knowing it.
+func DebugStandardLibraryMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Register all the standard library debug endpoints.
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	return mux
+}
+
+// DebugMux registers all the debug standard library routes and then custom
+// debug application routes for the service. The captured mail is only listed
+// when the service runs with the in-memory transport.
+func DebugMux(build string, log *zap.SugaredLogger, db *sqlx.DB, mem *mail.Memory) http.Handler {
+	mux := DebugStandardLibraryMux()
+
+	// Register debug check endpoints.
+	cgh := checkgrp.Handlers{
+		Build: build,
+		Log:   log,
+		DB:    db,
+	}
+	mux.HandleFunc("/debug/readiness", cgh.Readiness)
+	mux.HandleFunc("/debug/liveness", cgh.Liveness)
+
+	if mem != nil {
+		mgh := mailgrp.Handlers{
+			Log:    log,
+			Memory: mem,
+		}
+		mux.HandleFunc("/debug/mail", mgh.Mail)
+	}
+
+	return mux
+}
+
+// APIMuxConfig contains all the mandatory systems required by handlers.
+type APIMuxConfig struct {
+	Shutdown     chan os.Signal
+	Log          *zap.SugaredLogger
+	Core         emailCore.Core
+	ReportSecret []byte
+}
+
+// registry holds the codes the errors of the cores are responded with. The
+// codes are part of the API, so clients can match on them and they must not
+// change.
+var registry = v1Web.Registry{
+	{Err: mail.ErrNoReport, Code: "no_report", Status: http.StatusUnsupportedMediaType},
+}
+
+// APIMux constructs an http.Handler with all application routes defined.
+func APIMux(cfg APIMuxConfig) *web.App {
+
+	// Construct the web.App which holds all routes.
+	app := web.NewApp(
+		cfg.Shutdown,
+		mid.Logger(cfg.Log),
+		mid.Errors(cfg.Log, registry),
+		mid.Metrics(),
+		mid.Panics(),
+	)
+
+	// Load the routes for the different versions of the API.
+	v1(app, cfg)
+
+	return app
+}
+
+// v1 binds all the version 1 routes.
+func v1(app *web.App, cfg APIMuxConfig) {
+	const version = "v1"
+
+	// Register the webhook mail providers post their reports to.
+	rgh := v1ReportGrp.Handlers{
+		Core:   cfg.Core,
+		Secret: cfg.ReportSecret,
+	}
+	app.Handle(http.MethodPost, version, "/email/reports", rgh.Create).
+		Describe(web.Doc{Summary: "Process a bounce or complaint report", RequestType: "message/rfc822", Response: []emailCore.Bounce{}, Security: web.SecurityBasic})
+
+	// Register the description of the API.
+	app.HandleOpenAPI(version, "/openapi.json", web.OpenAPIConfig{Title: "email-api", Version: version, Error: v1Web.ErrorResponse{}, ErrorType: v1Web.ProblemContentType})
+}
@@ -0,0 +1,58 @@
+// Package reportgrp maintains the group of handlers for the reports mail
+// servers send back about delivered e-mails.
+package reportgrp
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dudakovict/social-network/business/core/email"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
+	"github.com/dudakovict/social-network/foundation/mail"
+	"github.com/dudakovict/social-network/foundation/web"
+)
+
+// maxReportSize is the largest report accepted, the original message is
+// often attached in full.
+const maxReportSize = 10 << 20
+
+// Handlers manages the set of report endpoints. Mail providers can not get
+// our tokens, so they authenticate with the shared Secret as the Basic auth
+// password instead. Without a Secret every report is rejected.
+type Handlers struct {
+	Core   email.Core
+	Secret []byte
+}
+
+// Create processes a delivery status notification or feedback report posted
+// by a mail provider. The body is the report message in the wire format.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	_, pass, ok := r.BasicAuth()
+	if !ok || len(h.Secret) == 0 || subtle.ConstantTimeCompare([]byte(pass), h.Secret) != 1 {
+		err := errors.New("must provide the report secret in Basic auth")
+		return v1Web.NewRequestError(err, http.StatusUnauthorized)
+	}
+
+	rep, err := mail.ParseReport(http.MaxBytesReader(w, r.Body, maxReportSize))
+	if err != nil {
+		if errors.Is(err, mail.ErrNoReport) {
+			return err
+		}
+		return v1Web.NewRequestError(fmt.Errorf("unable to parse report: %w", err), http.StatusBadRequest)
+	}
+
+	bs, err := h.Core.ProcessReport(ctx, rep, v.Now)
+	if err != nil {
+		return fmt.Errorf("unable to process report: %w", err)
+	}
+
+	return web.Respond(ctx, w, bs, http.StatusOK)
+}
@@ -1,20 +1,40 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"expvar"
 	"fmt"
 	"net"
-	"net/smtp"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/ardanlabs/conf"
+	"github.com/dudakovict/social-network/app/services/email-api/handlers"
 	es "github.com/dudakovict/social-network/business/core/email"
 	"github.com/dudakovict/social-network/business/data/email"
+	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/business/sys/rpc"
+	"github.com/dudakovict/social-network/foundation/keystore"
 	"github.com/dudakovict/social-network/foundation/logger"
+	"github.com/dudakovict/social-network/foundation/mail"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/zipkin"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	_ "go.uber.org/automaxprocs/maxprocs"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 var build = "develop"
@@ -63,16 +83,69 @@ func run(log *zap.SugaredLogger) error {
 			KeysFolder string `conf:"default:zarf/keys/"`
 			ActiveKID  string `conf:"default:54bb2165-71e1-41a6-af3e-7da4a0e1e2c1"`
 		}
+		Web struct {
+			APIHost         string        `conf:"default:0.0.0.0:3005"`
+			DebugHost       string        `conf:"default:0.0.0.0:4005"`
+			ReadTimeout     time.Duration `conf:"default:5s"`
+			WriteTimeout    time.Duration `conf:"default:10s"`
+			IdleTimeout     time.Duration `conf:"default:120s"`
+			ShutdownTimeout time.Duration `conf:"default:20s"`
+		}
 		GRPC struct {
-			Network string `conf:"default:tcp"`
-			Address string `conf:"default:0.0.0.0:50084"`
+			Network         string        `conf:"default:tcp"`
+			Address         string        `conf:"default:0.0.0.0:50084"`
+			CertFile        string        `conf:""`
+			KeyFile         string        `conf:""`
+			CAFile          string        `conf:""`
+			ShutdownTimeout time.Duration `conf:"default:20s"`
+		}
+		Zipkin struct {
+			ReporterURI string  `conf:"default:http://localhost:9411/api/v2/spans"`
+			ServiceName string  `conf:"default:email-api"`
+			Probability float64 `conf:"default:0.05"`
+		}
+		DB struct {
+			User         string `conf:"default:postgres"`
+			Password     string `conf:"default:postgres,mask"`
+			Host         string `conf:"default:localhost:5437"`
+			Name         string `conf:"default:postgres"`
+			MaxIdleConns int    `conf:"default:0"`
+			MaxOpenConns int    `conf:"default:0"`
+			DisableTLS   bool   `conf:"default:true"`
+		}
+		Queue struct {
+			Interval        time.Duration `conf:"default:5s"`
+			ShutdownTimeout time.Duration `conf:"default:20s"`
+		}
+		Reports struct {
+			Maildir  string        `conf:""`
+			Interval time.Duration `conf:"default:1m"`
+			Secret   string        `conf:"mask"`
+		}
+		NATS struct {
+			ClusterID string `conf:"default:social-network"`
+			ClientID  string `conf:"default:email-pod,env:NATS_CLIENT_ID"`
+			Host      string `conf:"default:http://nats-service:4222"`
+		}
+		Limits struct {
+			Recipient       int           `conf:"default:20"`
+			RecipientWindow time.Duration `conf:"default:1h"`
+			Global          int           `conf:"default:100"`
+			GlobalWindow    time.Duration `conf:"default:1m"`
+		}
+		Mail struct {
+			Transport   string `conf:"default:smtp"`
+			Sender      string `conf:"default:example@gmail.com"`
+			Maildir     string `conf:"default:zarf/mail/"`
+			MemoryLimit int    `conf:"default:100"`
 		}
 		SMTP struct {
-			Username string `conf:"default:example@gmail.com,env:SMTP_USERNAME,mask"`
-			Password string `conf:"default:jusesendsmtpauth,env:SMTP_PASSWORD,mask"`
-			Host     string `conf:"default:smtp.gmail.com"`
-			Port     string `conf:"default:587"`
-			Address  string `conf:"default:smtp.gmail.com:587"`
+			Username string        `conf:"default:example@gmail.com,env:SMTP_USERNAME,mask"`
+			Password string        `conf:"default:jusesendsmtpauth,env:SMTP_PASSWORD,mask"`
+			Host     string        `conf:"default:smtp.gmail.com"`
+			Port     string        `conf:"default:587"`
+			TLS      string        `conf:"default:starttls"`
+			Timeout  time.Duration `conf:"default:30s"`
 		}
 	}{
 		Version: conf.Version{
@@ -105,27 +178,361 @@ func run(log *zap.SugaredLogger) error {
 
 	expvar.NewString("build").Set(build)
 
+	// =========================================================================
+	// Initialize authentication support
+
+	log.Infow("startup", "status", "initializing authentication support")
+
+	// Construct a key store based on the key files stored in
+	// the specified directory.
+	ks, err := keystore.NewFS(os.DirFS(cfg.Auth.KeysFolder))
+	if err != nil {
+		return fmt.Errorf("reading keys: %w", err)
+	}
+
+	a, err := auth.New(cfg.Auth.ActiveKID, ks)
+	if err != nil {
+		return fmt.Errorf("constructing auth: %w", err)
+	}
+
+	// =========================================================================
+	// Database Support
+
+	// Create connectivity to the database.
+	log.Infow("startup", "status", "initializing database support", "host", cfg.DB.Host)
+
+	db, err := database.Open(database.Config{
+		User:         cfg.DB.User,
+		Password:     cfg.DB.Password,
+		Host:         cfg.DB.Host,
+		Name:         cfg.DB.Name,
+		MaxIdleConns: cfg.DB.MaxIdleConns,
+		MaxOpenConns: cfg.DB.MaxOpenConns,
+		DisableTLS:   cfg.DB.DisableTLS,
+	})
+	if err != nil {
+		return fmt.Errorf("connecting to db: %w", err)
+	}
+	defer func() {
+		log.Infow("shutdown", "status", "stopping database support", "host", cfg.DB.Host)
+		db.Close()
+	}()
+
+	// =========================================================================
+	// NATS Support
+
+	// Create connectivity to the NATS server.
+	log.Infow("startup", "status", "initializing NATS support", "host", cfg.NATS.Host)
+
+	n, err := nats.Connect(nats.Config{
+		ClusterID: cfg.NATS.ClusterID,
+		ClientID:  cfg.NATS.ClientID,
+		Host:      cfg.NATS.Host,
+	})
+
+	if err != nil {
+		return fmt.Errorf("connecting to NATS server: %w", err)
+	}
+	defer func() {
+		log.Infow("shutdown", "status", "stopping NATS support", "host", cfg.NATS.Host)
+		n.Client.Close()
+	}()
+
+	// =========================================================================
+	// Initialize Mail Transport
+
+	log.Infow("startup", "status", "initializing mail transport", "transport", cfg.Mail.Transport)
+
+	var transport mail.Transport
+	var mem *mail.Memory
+
+	switch cfg.Mail.Transport {
+	case "smtp":
+		transport, err = mail.NewSMTP(mail.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			TLS:      cfg.SMTP.TLS,
+			Timeout:  cfg.SMTP.Timeout,
+		})
+	case "maildir":
+		transport, err = mail.NewMaildir(cfg.Mail.Maildir)
+	case "log":
+		transport = mail.NewLog(log)
+	case "memory":
+		mem = mail.NewMemory(cfg.Mail.MemoryLimit)
+		transport = mem
+	default:
+		err = fmt.Errorf("unknown transport %q", cfg.Mail.Transport)
+	}
+	if err != nil {
+		return fmt.Errorf("constructing mail transport: %w", err)
+	}
+
+	// =========================================================================
+	// Start Queue Worker
+
+	log.Infow("startup", "status", "queue worker started", "interval", cfg.Queue.Interval)
+
+	templates, err := es.NewTemplates()
+	if err != nil {
+		return fmt.Errorf("parsing email templates: %w", err)
+	}
+
+	limits := es.Limits{
+		Recipient:       cfg.Limits.Recipient,
+		RecipientWindow: cfg.Limits.RecipientWindow,
+		Global:          cfg.Limits.Global,
+		GlobalWindow:    cfg.Limits.GlobalWindow,
+	}
+
+	core := es.NewCore(log, db, n, templates, transport, cfg.Mail.Sender, limits)
+
+	worker := es.NewWorker(log, core, cfg.Queue.Interval)
+	worker.Start()
+	defer func() {
+		log.Infow("shutdown", "status", "stopping queue worker")
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Queue.ShutdownTimeout)
+		defer cancel()
+
+		if err := worker.Shutdown(ctx); err != nil {
+			log.Errorw("shutdown", "status", "queue worker did not stop", "ERROR", err)
+		}
+	}()
+
+	// =========================================================================
+	// Start Report Mailbox
+
+	// The mail server delivers the reports sent to the bounce address into
+	// the Maildir. Without one reports only come in through the webhook.
+	if cfg.Reports.Maildir != "" {
+		log.Infow("startup", "status", "report mailbox started", "maildir", cfg.Reports.Maildir, "interval", cfg.Reports.Interval)
+
+		maildir, err := mail.NewMaildir(cfg.Reports.Maildir)
+		if err != nil {
+			return fmt.Errorf("opening report maildir: %w", err)
+		}
+
+		mailbox := es.NewMailbox(log, core, maildir, cfg.Reports.Interval)
+		mailbox.Start()
+		defer func() {
+			log.Infow("shutdown", "status", "stopping report mailbox")
+
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Queue.ShutdownTimeout)
+			defer cancel()
+
+			if err := mailbox.Shutdown(ctx); err != nil {
+				log.Errorw("shutdown", "status", "report mailbox did not stop", "ERROR", err)
+			}
+		}()
+	}
+
+	// =========================================================================
+	// Start Tracing Support
+
+	log.Infow("startup", "status", "initializing OT/Zipkin tracing support")
+
+	traceProvider, err := startTracing(
+		cfg.Zipkin.ServiceName,
+		cfg.Zipkin.ReporterURI,
+		cfg.Zipkin.Probability,
+	)
+	if err != nil {
+		return fmt.Errorf("starting tracing: %w", err)
+	}
+	defer traceProvider.Shutdown(context.Background())
+
+	// =========================================================================
+	// Start Debug Service
+
+	log.Infow("startup", "status", "debug router started", "host", cfg.Web.DebugHost)
+
+	// The Debug function returns a mux to listen and serve on for all the debug
+	// related endpoints. This includes the standard library endpoints.
+
+	// Construct the mux for the debug calls.
+	debugMux := handlers.DebugMux(build, log, db, mem)
+
+	// Start the service listening for debug requests.
+	// Not concerned with shutting this down with load shedding.
+	go func() {
+		if err := http.ListenAndServe(cfg.Web.DebugHost, debugMux); err != nil {
+			log.Errorw("shutdown", "status", "debug router closed", "host", cfg.Web.DebugHost, "ERROR", err)
+		}
+	}()
+
 	// =========================================================================
 	// Start gRPC Service
 
-	log.Infow("startup", "status", "gRPC service started", "host", cfg.GRPC.Address)
+	log.Infow("startup", "status", "initializing gRPC support")
+
+	// Without a certificate the server runs in plain text and clients
+	// authenticate with a JWT on every call. With a CA clients can
+	// authenticate with a certificate instead.
+	creds, err := rpc.ServerCredentials(rpc.TLSConfig{
+		CertFile: cfg.GRPC.CertFile,
+		KeyFile:  cfg.GRPC.KeyFile,
+		CAFile:   cfg.GRPC.CAFile,
+	})
+	if err != nil {
+		return fmt.Errorf("loading gRPC credentials: %w", err)
+	}
+
+	// The interceptors run in order for every call, the first one first.
+	interceptors := []rpc.Interceptor{
+		rpc.Trace(),
+		rpc.Logger(log),
+		rpc.Metrics(),
+		rpc.Panics(log),
+		rpc.Authenticate(a, auth.RoleService, auth.RoleAdmin),
+	}
+
+	gs := grpc.NewServer(
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(rpc.Unary(interceptors...)),
+		grpc.ChainStreamInterceptor(rpc.Stream(interceptors...)),
+	)
+
+	server := es.NewEmailServer(log, core)
+	email.RegisterEmailServer(gs, &server)
+
+	// The health service reports the server serving until shutdown starts.
+	hs := health.NewServer()
+	hs.SetServingStatus(email.Email_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(gs, hs)
 
-	conn, err := net.Listen(cfg.GRPC.Network, cfg.GRPC.Address)
+	// Reflection lets tools like grpcurl discover the services.
+	reflection.Register(gs)
 
+	// Make a channel to listen for an interrupt or terminate signal from the OS.
+	// Use a buffered channel because the signal package requires it.
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+
+	lis, err := net.Listen(cfg.GRPC.Network, cfg.GRPC.Address)
 	if err != nil {
 		return fmt.Errorf("announcing on the local network: %w", err)
 	}
 
-	grpc := grpc.NewServer()
+	// Make a channel to listen for errors coming from the listeners. Use a
+	// buffered channel so the goroutines can exit if we don't collect these
+	// errors.
+	serverErrors := make(chan error, 2)
+
+	// Start the service listening for gRPC calls.
+	go func() {
+		log.Infow("startup", "status", "gRPC server started", "host", cfg.GRPC.Address)
+		serverErrors <- gs.Serve(lis)
+	}()
+
+	// =========================================================================
+	// Start API Service
+
+	log.Infow("startup", "status", "initializing V1 API support")
+
+	// Construct the mux for the API calls.
+	apiMux := handlers.APIMux(handlers.APIMuxConfig{
+		Shutdown:     shutdown,
+		Log:          log,
+		Core:         core,
+		ReportSecret: []byte(cfg.Reports.Secret),
+	})
+
+	// Construct a server to service the requests against the mux.
+	api := http.Server{
+		Addr:         cfg.Web.APIHost,
+		Handler:      apiMux,
+		ReadTimeout:  cfg.Web.ReadTimeout,
+		WriteTimeout: cfg.Web.WriteTimeout,
+		IdleTimeout:  cfg.Web.IdleTimeout,
+		ErrorLog:     zap.NewStdLog(log.Desugar()),
+	}
+
+	// Start the service listening for api requests.
+	go func() {
+		log.Infow("startup", "status", "api router started", "host", api.Addr)
+		serverErrors <- api.ListenAndServe()
+	}()
+
+	// =========================================================================
+	// Shutdown
+
+	// Blocking main and waiting for shutdown.
+	select {
+	case err := <-serverErrors:
+		return fmt.Errorf("server error: %w", err)
+
+	case sig := <-shutdown:
+		log.Infow("shutdown", "status", "shutdown started", "signal", sig)
+		defer log.Infow("shutdown", "status", "shutdown complete", "signal", sig)
 
-	auth := smtp.PlainAuth("", cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.Host)
-	es := es.NewEmailServer(log, cfg.SMTP.Address, cfg.SMTP.Username, auth)
+		// Tell health checks to stop routing calls to this instance.
+		hs.Shutdown()
 
-	email.RegisterEmailServer(grpc, &es)
+		// Give outstanding requests a deadline for completion.
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
+		defer cancel()
 
-	if err := grpc.Serve(conn); err != nil {
-		log.Errorw("shutdown", "status", "gRPC v1 server closed", "host", cfg.GRPC.Address, "ERROR", err)
+		// Asking listener to shut down and shed load.
+		if err := api.Shutdown(ctx); err != nil {
+			api.Close()
+			log.Errorw("shutdown", "status", "could not stop api router gracefully", "ERROR", err)
+		}
+
+		// Give outstanding calls a deadline for completion.
+		stopped := make(chan struct{})
+		go func() {
+			gs.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(cfg.GRPC.ShutdownTimeout):
+			gs.Stop()
+			return errors.New("could not stop server gracefully")
+		}
 	}
 
 	return nil
 }
+
+// =============================================================================
+
+// startTracing configure open telemetery to be used with zipkin.
+func startTracing(serviceName string, reporterURI string, probability float64) (*trace.TracerProvider, error) {
+
+	// WARNING: The current settings are using defaults which may not be
+	// compatible with your project. Please review the documentation for
+	// opentelemetry.
+
+	exporter, err := zipkin.New(
+		reporterURI,
+		// zipkin.WithLogger(zap.NewStdLog(log)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating new exporter: %w", err)
+	}
+
+	traceProvider := trace.NewTracerProvider(
+		trace.WithSampler(trace.TraceIDRatioBased(probability)),
+		trace.WithBatcher(exporter,
+			trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
+			trace.WithBatchTimeout(trace.DefaultExportTimeout),
+			trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
+		),
+		trace.WithResource(
+			resource.NewWithAttributes(
+				semconv.SchemaURL,
+				semconv.ServiceNameKey.String(serviceName),
+				attribute.String("exporter", "zipkin"),
+			),
+		),
+	)
+
+	// I can only get this working properly using the singleton :(
+	otel.SetTracerProvider(traceProvider)
+	return traceProvider, nil
+}
@@ -0,0 +1,149 @@
+// Package handlers manages the different versions of the API.
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"os"
+
+	"github.com/dudakovict/social-network/app/services/messages-api/handlers/debug/checkgrp"
+	v1MessageGrp "github.com/dudakovict/social-network/app/services/messages-api/handlers/v1/messagegrp"
+	v1TestGrp "github.com/dudakovict/social-network/app/services/messages-api/handlers/v1/testgrp"
+	messageCore "github.com/dudakovict/social-network/business/core/message"
+	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/business/sys/stream"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
+	"github.com/dudakovict/social-network/business/web/v1/mid"
+	"github.com/dudakovict/social-network/foundation/web"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// DebugStandardLibraryMux registers all the debug routes from the standard library
+// into a new mux bypassing the use of the DefaultServerMux. Using the
+// DefaultServerMux would be a security risk since a dependency could inject a
+// handler into our service without us knowing it.
+func DebugStandardLibraryMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Register all the standard library debug endpoints.
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	return mux
+}
+
+// DebugMux registers all the debug standard library routes and then custom
+// debug application routes for the service. This bypassing the use of the
+// DefaultServerMux. Using the DefaultServerMux would be a security risk since
+// a dependency could inject a handler into our service without us knowing it.
+func DebugMux(build string, log *zap.SugaredLogger, db *sqlx.DB) http.Handler {
+	mux := DebugStandardLibraryMux()
+
+	// Register debug check endpoints.
+	cgh := checkgrp.Handlers{
+		Build: build,
+		Log:   log,
+		DB:    db,
+	}
+	mux.HandleFunc("/debug/readiness", cgh.Readiness)
+	mux.HandleFunc("/debug/liveness", cgh.Liveness)
+
+	return mux
+}
+
+// APIMuxConfig contains all the mandatory systems required by handlers.
+type APIMuxConfig struct {
+	Shutdown chan os.Signal
+	Log      *zap.SugaredLogger
+	Auth     *auth.Auth
+	DB       *sqlx.DB
+	NATS     *nats.NATS
+	Hub      *stream.Hub
+}
+
+// registry holds the codes the errors of the cores are responded with. The
+// codes are part of the API, so clients can match on them and they must not
+// change.
+var registry = v1Web.Registry{
+	{Err: messageCore.ErrInvalidID, Code: "invalid_id", Status: http.StatusBadRequest},
+	{Err: messageCore.ErrInvalidCursor, Code: "invalid_cursor", Status: http.StatusBadRequest},
+	{Err: messageCore.ErrNotFound, Code: "conversation_not_found", Status: http.StatusNotFound},
+	{Err: messageCore.ErrMessageNotFound, Code: "message_not_found", Status: http.StatusNotFound},
+	{Err: messageCore.ErrInvalidMembers, Code: "invalid_members", Status: http.StatusBadRequest},
+	{Err: messageCore.ErrTooManyMembers, Code: "too_many_members", Status: http.StatusBadRequest},
+	{Err: messageCore.ErrDirectConversation, Code: "direct_conversation", Status: http.StatusBadRequest},
+}
+
+// APIMux constructs an http.Handler with all application routes defined.
+func APIMux(cfg APIMuxConfig) *web.App {
+
+	// Construct the web.App which holds all routes.
+	app := web.NewApp(
+		cfg.Shutdown,
+		mid.Logger(cfg.Log),
+		mid.Errors(cfg.Log, registry),
+		mid.Metrics(),
+		mid.Panics(),
+	)
+
+	// Load the routes for the different versions of the API.
+	v1(app, cfg)
+
+	return app
+}
+
+// v1 binds all the version 1 routes.
+func v1(app *web.App, cfg APIMuxConfig) {
+	const version = "v1"
+
+	tgh := v1TestGrp.Handlers{
+		Log: cfg.Log,
+	}
+	app.Handle(http.MethodGet, version, "/test", tgh.Test).
+		Describe(web.Doc{Summary: "Check the service for development", Security: web.SecurityNone})
+	app.Handle(http.MethodGet, version, "/testauth", tgh.Test, mid.Authenticate(cfg.Auth), mid.Authorize("ADMIN")).
+		Describe(web.Doc{Summary: "Check the service and the token for development"})
+
+	// Register conversation and message endpoints.
+	mgh := v1MessageGrp.Handlers{
+		Core: messageCore.NewCore(cfg.Log, cfg.DB, cfg.NATS),
+		Hub:  cfg.Hub,
+	}
+
+	app.Handle(http.MethodPost, version, "/conversations", mgh.Create, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Start a conversation", Request: messageCore.NewConversation{}, Response: messageCore.Conversation{}, Status: http.StatusCreated})
+	app.Handle(http.MethodGet, version, "/conversations/unread", mgh.QueryUnread, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Count your unread messages", Response: messageCore.Unread{}})
+	app.Handle(http.MethodGet, version, "/conversations/:page/:rows", mgh.Query, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List your conversations", Response: []messageCore.Conversation{}})
+	app.Handle(http.MethodGet, version, "/conversations/:id", mgh.QueryByID, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Get a conversation", Response: messageCore.Conversation{}})
+	app.Handle(http.MethodPost, version, "/conversations/:id/members", mgh.AddMembers, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Add members to a conversation", Request: messageCore.NewMembers{}, Status: http.StatusNoContent})
+	app.Handle(http.MethodPost, version, "/conversations/:id/leave", mgh.Leave, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Leave a conversation", Status: http.StatusNoContent})
+	app.Handle(http.MethodPost, version, "/conversations/:id/mute", mgh.Mute, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Mute a conversation", Status: http.StatusNoContent})
+	app.Handle(http.MethodDelete, version, "/conversations/:id/mute", mgh.Unmute, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Unmute a conversation", Status: http.StatusNoContent})
+	app.Handle(http.MethodPost, version, "/conversations/:id/messages", mgh.Send, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Send a message", Request: messageCore.NewMessage{}, Response: messageCore.Message{}, Status: http.StatusCreated})
+	app.Handle(http.MethodGet, version, "/conversations/:id/messages", mgh.QueryMessages, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Get a page of the messages of a conversation", Query: []string{"cursor", "limit"}, Response: messageCore.Page{}})
+	app.Handle(http.MethodPost, version, "/conversations/:id/read", mgh.MarkRead, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Mark messages read, or the whole conversation without a body", Request: messageCore.ReadMessages{}, Status: http.StatusNoContent})
+	app.Handle(http.MethodGet, version, "/conversations/:id/receipts", mgh.QueryReceipts, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List the read receipts of a conversation", Response: []messageCore.Receipt{}})
+	app.Handle(http.MethodGet, version, "/conversations/:id/stream", mgh.Stream, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Stream a conversation as server-sent events", ResponseType: "text/event-stream"})
+
+	// Register the description of the API.
+	app.HandleOpenAPI(version, "/openapi.json", web.OpenAPIConfig{Title: "messages-api", Version: version, Error: v1Web.ErrorResponse{}, ErrorType: v1Web.ProblemContentType})
+}
@@ -0,0 +1,322 @@
+// Package messagegrp maintains the group of handlers for conversation and
+// message access.
+package messagegrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dudakovict/social-network/business/core/message"
+	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/stream"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
+	"github.com/dudakovict/social-network/foundation/web"
+)
+
+// Set of limits on the number of messages returned per page.
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// Handlers manages the set of conversation enpoints.
+type Handlers struct {
+	Core message.Core
+	Hub  *stream.Hub
+}
+
+// Create starts a conversation between the authenticated user and the
+// specified users.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var nc message.NewConversation
+	if err := web.Decode(r, &nc); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	c, err := h.Core.Create(ctx, claims.Subject, nc, v.Now)
+	if err != nil {
+		return fmt.Errorf("conversation[%+v]: %w", &nc, err)
+	}
+
+	return web.Respond(ctx, w, c, http.StatusCreated)
+}
+
+// Query returns a page of the conversations of the authenticated user, most
+// recently active first.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	page := web.Param(r, "page")
+	pageNumber, err := strconv.Atoi(page)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid page format [%s]", page), http.StatusBadRequest)
+	}
+	rows := web.Param(r, "rows")
+	rowsPerPage, err := strconv.Atoi(rows)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+	}
+
+	conversations, err := h.Core.Query(ctx, claims.Subject, pageNumber, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("unable to query for conversations: %w", err)
+	}
+
+	return web.Respond(ctx, w, conversations, http.StatusOK)
+}
+
+// QueryByID returns a conversation of the authenticated user.
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	conversationID := web.Param(r, "id")
+
+	c, err := h.Core.QueryByID(ctx, claims.Subject, conversationID)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", conversationID, err)
+	}
+
+	return web.Respond(ctx, w, c, http.StatusOK)
+}
+
+// QueryUnread returns how many messages the authenticated user has not read
+// yet.
+func (h Handlers) QueryUnread(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	unread, err := h.Core.QueryUnread(ctx, claims.Subject)
+	if err != nil {
+		return fmt.Errorf("unable to query for unread messages: %w", err)
+	}
+
+	return web.Respond(ctx, w, unread, http.StatusOK)
+}
+
+// AddMembers adds users to a group conversation of the authenticated user.
+func (h Handlers) AddMembers(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var nm message.NewMembers
+	if err := web.Decode(r, &nm); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	conversationID := web.Param(r, "id")
+
+	if err := h.Core.AddMembers(ctx, claims.Subject, conversationID, nm, v.Now); err != nil {
+		return fmt.Errorf("ID[%s]: %w", conversationID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Leave takes the authenticated user out of a conversation.
+func (h Handlers) Leave(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	conversationID := web.Param(r, "id")
+
+	if err := h.Core.Leave(ctx, claims.Subject, conversationID, v.Now); err != nil {
+		return fmt.Errorf("ID[%s]: %w", conversationID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Mute mutes a conversation for the authenticated user.
+func (h Handlers) Mute(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return h.mute(ctx, w, r, true)
+}
+
+// Unmute unmutes a conversation for the authenticated user.
+func (h Handlers) Unmute(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return h.mute(ctx, w, r, false)
+}
+
+// Send sends a message from the authenticated user to a conversation.
+func (h Handlers) Send(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var nm message.NewMessage
+	if err := web.Decode(r, &nm); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	conversationID := web.Param(r, "id")
+
+	m, err := h.Core.Send(ctx, claims.Subject, conversationID, nm, v.Now)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", conversationID, err)
+	}
+
+	return web.Respond(ctx, w, m, http.StatusCreated)
+}
+
+// QueryMessages returns a page of the history of a conversation, newest
+// first. The cursor of the returned page fetches the next one.
+func (h Handlers) QueryMessages(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	limit := defaultLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return v1Web.NewRequestError(fmt.Errorf("invalid limit format, must be between 1 and %d [%s]", maxLimit, l), http.StatusBadRequest)
+		}
+	}
+
+	conversationID := web.Param(r, "id")
+
+	page, err := h.Core.QueryMessages(ctx, claims.Subject, conversationID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", conversationID, err)
+	}
+
+	return web.Respond(ctx, w, page, http.StatusOK)
+}
+
+// MarkRead records how far the authenticated user has read a conversation.
+func (h Handlers) MarkRead(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	// The body is optional, without it the whole conversation is read.
+	var rm message.ReadMessages
+	if r.ContentLength != 0 {
+		if err := web.Decode(r, &rm); err != nil {
+			return fmt.Errorf("unable to decode payload: %w", err)
+		}
+	}
+
+	conversationID := web.Param(r, "id")
+
+	if err := h.Core.MarkRead(ctx, claims.Subject, conversationID, rm, v.Now); err != nil {
+		return fmt.Errorf("ID[%s]: %w", conversationID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// QueryReceipts returns how far every member of a conversation of the
+// authenticated user has read it.
+func (h Handlers) QueryReceipts(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	conversationID := web.Param(r, "id")
+
+	receipts, err := h.Core.QueryReceipts(ctx, claims.Subject, conversationID)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", conversationID, err)
+	}
+
+	return web.Respond(ctx, w, receipts, http.StatusOK)
+}
+
+// Stream pushes the new messages and receipts of a conversation of the
+// authenticated user to the client, over a WebSocket or as Server-Sent
+// Events.
+func (h Handlers) Stream(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	conversationID := web.Param(r, "id")
+
+	if err := h.Core.CheckMember(ctx, claims.Subject, conversationID); err != nil {
+		return fmt.Errorf("ID[%s]: %w", conversationID, err)
+	}
+
+	// Members who leave the conversation stop receiving its messages on the
+	// streams they already opened.
+	check := func(ctx context.Context) error {
+		err := h.Core.CheckMember(ctx, claims.Subject, conversationID)
+		if errors.Is(err, message.ErrNotFound) {
+			return fmt.Errorf("%w: %v", stream.ErrDenied, err)
+		}
+		return err
+	}
+
+	if err := h.Hub.Serve(ctx, w, r, conversationID, check); err != nil {
+		return fmt.Errorf("ID[%s]: %w", conversationID, err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// mute mutes or unmutes a conversation for the authenticated user.
+func (h Handlers) mute(ctx context.Context, w http.ResponseWriter, r *http.Request, muted bool) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	conversationID := web.Param(r, "id")
+
+	if err := h.Core.Mute(ctx, claims.Subject, conversationID, muted); err != nil {
+		return fmt.Errorf("ID[%s]: %w", conversationID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
@@ -0,0 +1,138 @@
+// Package handlers manages the different versions of the API.
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"os"
+
+	"github.com/dudakovict/social-network/app/services/notifications-api/handlers/debug/checkgrp"
+	v1NotificationGrp "github.com/dudakovict/social-network/app/services/notifications-api/handlers/v1/notificationgrp"
+	v1TestGrp "github.com/dudakovict/social-network/app/services/notifications-api/handlers/v1/testgrp"
+	notificationCore "github.com/dudakovict/social-network/business/core/notification"
+	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/business/sys/stream"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
+	"github.com/dudakovict/social-network/business/web/v1/mid"
+	"github.com/dudakovict/social-network/foundation/web"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// DebugStandardLibraryMux registers all the debug routes from the standard library
+// into a new mux bypassing the use of the DefaultServerMux. Using the
+// DefaultServerMux would be a security risk since a dependency could inject a
+// handler into our service without us knowing it.
+func DebugStandardLibraryMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Register all the standard library debug endpoints.
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	return mux
+}
+
+// DebugMux registers all the debug standard library routes and then custom
+// debug application routes for the service. This bypassing the use of the
+// DefaultServerMux. Using the DefaultServerMux would be a security risk since
+// a dependency could inject a handler into our service without us knowing it.
+func DebugMux(build string, log *zap.SugaredLogger, db *sqlx.DB) http.Handler {
+	mux := DebugStandardLibraryMux()
+
+	// Register debug check endpoints.
+	cgh := checkgrp.Handlers{
+		Build: build,
+		Log:   log,
+		DB:    db,
+	}
+	mux.HandleFunc("/debug/readiness", cgh.Readiness)
+	mux.HandleFunc("/debug/liveness", cgh.Liveness)
+
+	return mux
+}
+
+// APIMuxConfig contains all the mandatory systems required by handlers.
+type APIMuxConfig struct {
+	Shutdown chan os.Signal
+	Log      *zap.SugaredLogger
+	Auth     *auth.Auth
+	DB       *sqlx.DB
+	NATS     *nats.NATS
+	Hub      *stream.Hub
+	Mailer   notificationCore.Mailer
+}
+
+// registry holds the codes the errors of the cores are responded with. The
+// codes are part of the API, so clients can match on them and they must not
+// change.
+var registry = v1Web.Registry{
+	{Err: notificationCore.ErrInvalidID, Code: "invalid_id", Status: http.StatusBadRequest},
+	{Err: notificationCore.ErrNotFound, Code: "notification_not_found", Status: http.StatusNotFound},
+	{Err: notificationCore.ErrInvalidToken, Code: "invalid_token", Status: http.StatusBadRequest},
+}
+
+// APIMux constructs an http.Handler with all application routes defined.
+func APIMux(cfg APIMuxConfig) *web.App {
+
+	// Construct the web.App which holds all routes.
+	app := web.NewApp(
+		cfg.Shutdown,
+		mid.Logger(cfg.Log),
+		mid.Errors(cfg.Log, registry),
+		mid.Metrics(),
+		mid.Panics(),
+	)
+
+	// Load the routes for the different versions of the API.
+	v1(app, cfg)
+
+	return app
+}
+
+// v1 binds all the version 1 routes.
+func v1(app *web.App, cfg APIMuxConfig) {
+	const version = "v1"
+
+	tgh := v1TestGrp.Handlers{
+		Log: cfg.Log,
+	}
+	app.Handle(http.MethodGet, version, "/test", tgh.Test).
+		Describe(web.Doc{Summary: "Check the service for development", Security: web.SecurityNone})
+	app.Handle(http.MethodGet, version, "/testauth", tgh.Test, mid.Authenticate(cfg.Auth), mid.Authorize("ADMIN")).
+		Describe(web.Doc{Summary: "Check the service and the token for development"})
+
+	// Register notification inbox endpoints.
+	ngh := v1NotificationGrp.Handlers{
+		Core: notificationCore.NewCore(cfg.Log, cfg.DB, cfg.NATS, cfg.Mailer),
+		Hub:  cfg.Hub,
+	}
+
+	app.Handle(http.MethodGet, version, "/notifications/unread", ngh.QueryUnread, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Count your unread notifications", Response: notificationCore.Unread{}})
+	app.Handle(http.MethodGet, version, "/notifications/stream", ngh.Stream, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Stream your notifications as server-sent events", ResponseType: "text/event-stream"})
+	app.Handle(http.MethodGet, version, "/notifications/:page/:rows", ngh.Query, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List your notifications", Response: []notificationCore.Group{}})
+	app.Handle(http.MethodGet, version, "/notifications/preferences", ngh.QueryPreferences, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List your e-mail preferences", Response: []notificationCore.Preference{}})
+	app.Handle(http.MethodPut, version, "/notifications/preferences", ngh.UpdatePreferences, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Update your e-mail preferences", Request: notificationCore.UpdatePreferences{}, Status: http.StatusNoContent})
+	app.Handle(http.MethodGet, version, "/notifications/unsubscribe", ngh.ConfirmUnsubscribe).
+		Describe(web.Doc{Summary: "Confirm unsubscribing with the token of an e-mail", Query: []string{"token"}, ResponseType: "text/html", Security: web.SecurityNone})
+	app.Handle(http.MethodPost, version, "/notifications/unsubscribe", ngh.Unsubscribe).
+		Describe(web.Doc{Summary: "Unsubscribe with the token of an e-mail", Query: []string{"token"}, Status: http.StatusNoContent, Security: web.SecurityNone})
+	app.Handle(http.MethodPost, version, "/notifications/read", ngh.MarkAllRead, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Mark all your notifications read", Status: http.StatusNoContent})
+	app.Handle(http.MethodPost, version, "/notifications/:id/read", ngh.MarkRead, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Mark a notification read", Status: http.StatusNoContent})
+
+	// Register the description of the API.
+	app.HandleOpenAPI(version, "/openapi.json", web.OpenAPIConfig{Title: "notifications-api", Version: version, Error: v1Web.ErrorResponse{}, ErrorType: v1Web.ProblemContentType})
+}
@@ -0,0 +1,211 @@
+// Package notificationgrp maintains the group of handlers for notification
+// access.
+package notificationgrp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/dudakovict/social-network/business/core/notification"
+	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/stream"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
+	"github.com/dudakovict/social-network/foundation/web"
+)
+
+// confirmPage asks for a confirmation before an unsubscribe link is acted on.
+var confirmPage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+<form method="post" action="?token={{.}}">
+<p>Stop receiving these e-mails?</p>
+<button type="submit">Unsubscribe</button>
+</form>
+</body>
+</html>
+`))
+
+// Handlers manages the set of notification enpoints.
+type Handlers struct {
+	Core notification.Core
+	Hub  *stream.Hub
+}
+
+// Query returns a page of the inbox of the authenticated user with similar
+// notifications grouped together.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	page := web.Param(r, "page")
+	pageNumber, err := strconv.Atoi(page)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid page format [%s]", page), http.StatusBadRequest)
+	}
+	rows := web.Param(r, "rows")
+	rowsPerPage, err := strconv.Atoi(rows)
+	if err != nil {
+		return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+	}
+
+	groups, err := h.Core.QueryInbox(ctx, claims.Subject, pageNumber, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("unable to query for notifications: %w", err)
+	}
+
+	return web.Respond(ctx, w, groups, http.StatusOK)
+}
+
+// QueryUnread returns how many notifications the authenticated user has not
+// read yet.
+func (h Handlers) QueryUnread(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	unread, err := h.Core.QueryUnread(ctx, claims.Subject)
+	if err != nil {
+		return fmt.Errorf("unable to query for unread notifications: %w", err)
+	}
+
+	return web.Respond(ctx, w, unread, http.StatusOK)
+}
+
+// MarkRead marks a notification of the authenticated user as read along with
+// the older notifications grouped with it.
+func (h Handlers) MarkRead(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	notificationID := web.Param(r, "id")
+
+	if err := h.Core.MarkRead(ctx, claims.Subject, notificationID, v.Now); err != nil {
+		return fmt.Errorf("ID[%s]: %w", notificationID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// MarkAllRead marks every notification of the authenticated user as read.
+func (h Handlers) MarkAllRead(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	if err := h.Core.MarkAllRead(ctx, claims.Subject, v.Now); err != nil {
+		return fmt.Errorf("unable to mark notifications read: %w", err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// QueryPreferences returns how the authenticated user wants to be e-mailed
+// about every type of notification.
+func (h Handlers) QueryPreferences(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	prefs, err := h.Core.QueryPreferences(ctx, claims.Subject)
+	if err != nil {
+		return fmt.Errorf("unable to query for preferences: %w", err)
+	}
+
+	return web.Respond(ctx, w, prefs, http.StatusOK)
+}
+
+// UpdatePreferences changes how the authenticated user wants to be e-mailed
+// about the specified types of notifications.
+func (h Handlers) UpdatePreferences(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var up notification.UpdatePreferences
+	if err := web.Decode(r, &up); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	if err := h.Core.UpdatePreferences(ctx, claims.Subject, up, v.Now); err != nil {
+		return fmt.Errorf("Preferences[%+v]: %w", &up, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Unsubscribe stops the e-mails an unsubscribe link was issued for. It is
+// the one-click unsubscribe endpoint of RFC 8058 so it takes the signed
+// token in place of authentication.
+func (h Handlers) Unsubscribe(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	if err := h.Core.Unsubscribe(ctx, r.URL.Query().Get("token"), v.Now); err != nil {
+		return fmt.Errorf("unable to unsubscribe: %w", err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// ConfirmUnsubscribe shows the page the unsubscribe link in the body of an
+// e-mail opens. Links are followed with a GET, which mail scanners do on
+// their own as well, so the page asks for a confirmation that is sent to
+// Unsubscribe.
+func (h Handlers) ConfirmUnsubscribe(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	token := r.URL.Query().Get("token")
+	if err := h.Core.CheckUnsubscribe(token); err != nil {
+		return fmt.Errorf("unable to unsubscribe: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := confirmPage.Execute(&buf, token); err != nil {
+		return fmt.Errorf("unable to render page: %w", err)
+	}
+
+	return web.RespondStream(ctx, w, &buf, "text/html; charset=utf-8", http.StatusOK)
+}
+
+// Stream pushes the notifications of the authenticated user to the client as
+// they are created, over a WebSocket or as Server-Sent Events.
+func (h Handlers) Stream(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	if err := h.Hub.Serve(ctx, w, r, claims.Subject, nil); err != nil {
+		return fmt.Errorf("unable to stream notifications: %w", err)
+	}
+
+	return nil
+}
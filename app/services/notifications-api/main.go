@@ -0,0 +1,446 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ardanlabs/conf"
+	"github.com/dudakovict/social-network/app/services/notifications-api/handlers"
+	"github.com/dudakovict/social-network/business/core/notification"
+	"github.com/dudakovict/social-network/business/data/email"
+	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/business/sys/rpc"
+	"github.com/dudakovict/social-network/business/sys/stream"
+	"github.com/dudakovict/social-network/foundation/keystore"
+	"github.com/dudakovict/social-network/foundation/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/zipkin"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	_ "go.uber.org/automaxprocs/maxprocs"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+var build = "develop"
+
+func main() {
+
+	// Construct the application logger.
+	log, err := logger.New("notifications-api")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer log.Sync()
+
+	// Perform the startup and shutdown sequence.
+	if err = run(log); err != nil {
+		log.Errorw("startup", "ERROR", err)
+		log.Sync()
+		os.Exit(1)
+	}
+}
+
+func run(log *zap.SugaredLogger) error {
+
+	// =========================================================================
+	// GOMAXPROCS
+
+	// Want to see what maxprocs reports.
+
+	//opt := maxprocs.Logger(log.Infof)
+
+	// Set the correct number of threads for the service
+	// based on what is available either by the machine or quotas.
+	/*
+		if _, err := maxprocs.Set(opt); err != nil {
+			return fmt.Errorf("maxprocs: %w", err)
+		}
+		log.Infow("startup", "GOMAXPROCS", runtime.GOMAXPROCS(0))
+	*/
+
+	// =========================================================================
+	// Configuration
+	cfg := struct {
+		conf.Version
+		Web struct {
+			APIHost         string        `conf:"default:0.0.0.0:3003"`
+			DebugHost       string        `conf:"default:0.0.0.0:4003"`
+			ReadTimeout     time.Duration `conf:"default:5s"`
+			WriteTimeout    time.Duration `conf:"default:10s"`
+			IdleTimeout     time.Duration `conf:"default:120s"`
+			ShutdownTimeout time.Duration `conf:"default:20s,mask"`
+		}
+		Auth struct {
+			KeysFolder string `conf:"default:zarf/keys/"`
+			ActiveKID  string `conf:"default:54bb2165-71e1-41a6-af3e-7da4a0e1e2c1"`
+		}
+		DB struct {
+			User         string `conf:"default:postgres"`
+			Password     string `conf:"default:postgres,mask"`
+			Host         string `conf:"default:localhost:5435"`
+			Name         string `conf:"default:postgres"`
+			MaxIdleConns int    `conf:"default:0"`
+			MaxOpenConns int    `conf:"default:0"`
+			DisableTLS   bool   `conf:"default:true"`
+		}
+		Zipkin struct {
+			ReporterURI string  `conf:"default:http://localhost:9414/api/v2/spans"`
+			ServiceName string  `conf:"default:notifications-api"`
+			Probability float64 `conf:"default:0.05"`
+		}
+		GRPC struct {
+			Address  string        `conf:"default:email-service:50084"`
+			CertFile string        `conf:""`
+			KeyFile  string        `conf:""`
+			CAFile   string        `conf:""`
+			TokenTTL time.Duration `conf:"default:1h"`
+		}
+		Mail struct {
+			AppURL         string `conf:"default:http://localhost:3000"`
+			UnsubscribeURL string `conf:"default:http://localhost:3003/v1/notifications/unsubscribe"`
+			Secret         string `conf:"mask"`
+		}
+		Digest struct {
+			Interval        time.Duration `conf:"default:10m"`
+			ShutdownTimeout time.Duration `conf:"default:20s"`
+		}
+		NATS struct {
+			ClusterID string `conf:"default:social-network"`
+			ClientID  string `conf:"default:notifications-pod,env:NATS_CLIENT_ID"`
+			Host      string `conf:"default:http://nats-service:4222"`
+		}
+		Stream struct {
+			Buffer       int           `conf:"default:64"`
+			Heartbeat    time.Duration `conf:"default:15s"`
+			WriteTimeout time.Duration `conf:"default:10s"`
+		}
+	}{
+		Version: conf.Version{
+			SVN:  build,
+			Desc: "copyright information here",
+		},
+	}
+
+	const prefix = "NOTIFICATIONS"
+	help, err := conf.ParseOSArgs(prefix, &cfg)
+	if err != nil {
+		if errors.Is(err, conf.ErrHelpWanted) {
+			fmt.Println(help)
+			return nil
+		}
+		return fmt.Errorf("parsing config: %w", err)
+	}
+
+	// =========================================================================
+	// App Starting
+
+	log.Infow("starting service", "version", build)
+	defer log.Infow("shutdown complete")
+
+	out, err := conf.String(&cfg)
+	if err != nil {
+		return fmt.Errorf("generating config for output: %w", err)
+	}
+	log.Infow("startup", "config", out)
+
+	expvar.NewString("build").Set(build)
+
+	// =========================================================================
+	// Initialize authentication support
+
+	log.Infow("startup", "status", "initializing authentication support")
+
+	// Construct a key store based on the key files stored in
+	// the specified directory.
+	ks, err := keystore.NewFS(os.DirFS(cfg.Auth.KeysFolder))
+	if err != nil {
+		return fmt.Errorf("reading keys: %w", err)
+	}
+
+	auth, err := auth.New(cfg.Auth.ActiveKID, ks)
+	if err != nil {
+		return fmt.Errorf("constructing auth: %w", err)
+	}
+
+	// =========================================================================
+	// Database Support
+
+	// Create connectivity to the database.
+	log.Infow("startup", "status", "initializing database support", "host", cfg.DB.Host)
+
+	db, err := database.Open(database.Config{
+		User:         cfg.DB.User,
+		Password:     cfg.DB.Password,
+		Host:         cfg.DB.Host,
+		Name:         cfg.DB.Name,
+		MaxIdleConns: cfg.DB.MaxIdleConns,
+		MaxOpenConns: cfg.DB.MaxOpenConns,
+		DisableTLS:   cfg.DB.DisableTLS,
+	})
+	if err != nil {
+		return fmt.Errorf("connecting to db: %w", err)
+	}
+	defer func() {
+		log.Infow("shutdown", "status", "stopping database support", "host", cfg.DB.Host)
+		db.Close()
+	}()
+
+	// =========================================================================
+	// Start GRPC Support
+
+	log.Infow("startup", "status", "initializing gRPC support", "host", cfg.GRPC.Address)
+
+	// Without a CA the connection is plain text. The service authenticates
+	// with a certificate when it has one and with a JWT on every call.
+	creds, err := rpc.ClientCredentials(rpc.TLSConfig{
+		CertFile: cfg.GRPC.CertFile,
+		KeyFile:  cfg.GRPC.KeyFile,
+		CAFile:   cfg.GRPC.CAFile,
+	})
+	if err != nil {
+		return fmt.Errorf("loading gRPC credentials: %w", err)
+	}
+
+	conn, err := grpc.Dial(cfg.GRPC.Address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithPerRPCCredentials(auth.ServiceToken("notifications-api", cfg.GRPC.TokenTTL, cfg.GRPC.CAFile != "")),
+		grpc.WithUnaryInterceptor(rpc.ClientTrace()),
+	)
+	if err != nil {
+		return fmt.Errorf("connecting to gRPC: %w", err)
+	}
+
+	defer conn.Close()
+
+	// Unsubscribe links are trusted in place of authentication, without a
+	// secret of our own anyone could sign them for any user.
+	if cfg.Mail.Secret == "" {
+		return errors.New("mail secret is required to sign unsubscribe links")
+	}
+
+	mailer := notification.Mailer{
+		Client:         email.NewEmailClient(conn),
+		Secret:         []byte(cfg.Mail.Secret),
+		AppURL:         cfg.Mail.AppURL,
+		UnsubscribeURL: cfg.Mail.UnsubscribeURL,
+	}
+
+	// =========================================================================
+	// NATS Support
+
+	// Create connectivity to the NATS server.
+	log.Infow("startup", "status", "initializing NATS support", "host", cfg.NATS.Host)
+
+	n, err := nats.Connect(nats.Config{
+		ClusterID: cfg.NATS.ClusterID,
+		ClientID:  cfg.NATS.ClientID,
+		Host:      cfg.NATS.Host,
+	})
+
+	if err != nil {
+		return fmt.Errorf("connecting to NATS server: %w", err)
+	}
+	defer func() {
+		log.Infow("shutdown", "status", "stopping NATS support", "host", cfg.NATS.Host)
+		n.Client.Close()
+	}()
+
+	// =========================================================================
+	// Start Event Listener Support
+
+	log.Infow("startup", "status", "initializing notification event listeners")
+
+	if err := notification.NewListener(log, db, n, mailer).Listen(); err != nil {
+		return fmt.Errorf("listening for events: %w", err)
+	}
+
+	// =========================================================================
+	// Start Digest Scheduler
+
+	log.Infow("startup", "status", "digest scheduler started", "interval", cfg.Digest.Interval)
+
+	scheduler := notification.NewScheduler(log, notification.NewCore(log, db, n, mailer), cfg.Digest.Interval)
+	scheduler.Start()
+	defer func() {
+		log.Infow("shutdown", "status", "stopping digest scheduler")
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Digest.ShutdownTimeout)
+		defer cancel()
+
+		if err := scheduler.Shutdown(ctx); err != nil {
+			log.Errorw("shutdown", "status", "digest scheduler did not stop", "ERROR", err)
+		}
+	}()
+
+	// =========================================================================
+	// Start Stream Support
+
+	log.Infow("startup", "status", "initializing stream support")
+
+	// Streams bridge the notification-created events of every instance to the
+	// clients connected to this instance.
+	hub := stream.NewHub(log, stream.Config{
+		Buffer:       cfg.Stream.Buffer,
+		Heartbeat:    cfg.Stream.Heartbeat,
+		WriteTimeout: cfg.Stream.WriteTimeout,
+	})
+
+	if err := hub.Bridge(n, "notification-created", notification.StreamCreated); err != nil {
+		return fmt.Errorf("bridging notification-created: %w", err)
+	}
+
+	// =========================================================================
+	// Start Tracing Support
+
+	log.Infow("startup", "status", "initializing OT/Zipkin tracing support")
+
+	traceProvider, err := startTracing(
+		cfg.Zipkin.ServiceName,
+		cfg.Zipkin.ReporterURI,
+		cfg.Zipkin.Probability,
+	)
+	if err != nil {
+		return fmt.Errorf("starting tracing: %w", err)
+	}
+	defer traceProvider.Shutdown(context.Background())
+
+	// =========================================================================
+	// Start Debug Service
+
+	log.Infow("startup", "status", "debug v1 router started", "host", cfg.Web.DebugHost)
+
+	// The Debug function returns a mux to listen and serve on for all the debug
+	// related endpoints. This includes the standard library endpoints.
+
+	// Construct the mux for the debug calls.
+	debugMux := handlers.DebugMux(build, log, db)
+
+	// Start the service listening for debug requests.
+	// Not concerned with shutting this down with load shedding.
+	go func() {
+		if err := http.ListenAndServe(cfg.Web.DebugHost, debugMux); err != nil {
+			log.Errorw("shutdown", "status", "debug v1 router closed", "host", cfg.Web.DebugHost, "ERROR", err)
+		}
+	}()
+
+	// =========================================================================
+	// Start API Service
+
+	log.Infow("startup", "status", "initializing V1 API support")
+
+	// Make a channel to listen for an interrupt or terminate signal from the OS.
+	// Use a buffered channel because the signal package requires it.
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+
+	// Construct the mux for the API calls.
+	apiMux := handlers.APIMux(handlers.APIMuxConfig{
+		Shutdown: shutdown,
+		Log:      log,
+		Auth:     auth,
+		DB:       db,
+		NATS:     n,
+		Hub:      hub,
+		Mailer:   mailer,
+	})
+
+	// Construct a server to service the requests against the mux.
+	api := http.Server{
+		Addr:         cfg.Web.APIHost,
+		Handler:      apiMux,
+		ReadTimeout:  cfg.Web.ReadTimeout,
+		WriteTimeout: cfg.Web.WriteTimeout,
+		IdleTimeout:  cfg.Web.IdleTimeout,
+		ErrorLog:     zap.NewStdLog(log.Desugar()),
+	}
+
+	// Make a channel to listen for errors coming from the listener. Use a
+	// buffered channel so the goroutine can exit if we don't collect this error.
+	serverErrors := make(chan error, 1)
+
+	// Start the service listening for api requests.
+	go func() {
+		log.Infow("startup", "status", "api router started", "host", api.Addr)
+		serverErrors <- api.ListenAndServe()
+	}()
+
+	// =========================================================================
+	// Shutdown
+
+	// Blocking main and waiting for shutdown.
+	select {
+	case err := <-serverErrors:
+		return fmt.Errorf("server error: %w", err)
+
+	case sig := <-shutdown:
+		log.Infow("shutdown", "status", "shutdown started", "signal", sig)
+		defer log.Infow("shutdown", "status", "shutdown complete", "signal", sig)
+
+		// Give outstanding requests a deadline for completion.
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		// Streams have taken over their connections so the server does not
+		// wait for them.
+		hub.Close()
+
+		// Asking listener to shut down and shed load.
+		if err := api.Shutdown(ctx); err != nil {
+			api.Close()
+			return fmt.Errorf("could not stop server gracefully: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// startTracing configure open telemetery to be used with zipkin.
+func startTracing(serviceName string, reporterURI string, probability float64) (*trace.TracerProvider, error) {
+
+	// WARNING: The current settings are using defaults which may not be
+	// compatible with your project. Please review the documentation for
+	// opentelemetry.
+
+	exporter, err := zipkin.New(
+		reporterURI,
+		// zipkin.WithLogger(zap.NewStdLog(log)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating new exporter: %w", err)
+	}
+
+	traceProvider := trace.NewTracerProvider(
+		trace.WithSampler(trace.TraceIDRatioBased(probability)),
+		trace.WithBatcher(exporter,
+			trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
+			trace.WithBatchTimeout(trace.DefaultExportTimeout),
+			trace.WithMaxExportBatchSize(trace.DefaultMaxExportBatchSize),
+		),
+		trace.WithResource(
+			resource.NewWithAttributes(
+				semconv.SchemaURL,
+				semconv.ServiceNameKey.String(serviceName),
+				attribute.String("exporter", "zipkin"),
+			),
+		),
+	)
+
+	// I can only get this working properly using the singleton :(
+	otel.SetTracerProvider(traceProvider)
+	return traceProvider, nil
+}
@@ -8,12 +8,20 @@ import (
 	"os"
 
 	"github.com/dudakovict/social-network/app/services/posts-api/handlers/debug/checkgrp"
+	v1FeedGrp "github.com/dudakovict/social-network/app/services/posts-api/handlers/v1/feedgrp"
+	v1MediaGrp "github.com/dudakovict/social-network/app/services/posts-api/handlers/v1/mediagrp"
 	v1PostGrp "github.com/dudakovict/social-network/app/services/posts-api/handlers/v1/postgrp"
 	v1TestGrp "github.com/dudakovict/social-network/app/services/posts-api/handlers/v1/testgrp"
+	v1TrendingGrp "github.com/dudakovict/social-network/app/services/posts-api/handlers/v1/trendinggrp"
+	feedCore "github.com/dudakovict/social-network/business/core/feed"
+	mediaCore "github.com/dudakovict/social-network/business/core/media"
 	postCore "github.com/dudakovict/social-network/business/core/post"
+	trendingCore "github.com/dudakovict/social-network/business/core/trending"
 	"github.com/dudakovict/social-network/business/sys/auth"
 	"github.com/dudakovict/social-network/business/sys/nats"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
 	"github.com/dudakovict/social-network/business/web/v1/mid"
+	"github.com/dudakovict/social-network/foundation/storage"
 	"github.com/dudakovict/social-network/foundation/web"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
@@ -63,6 +71,36 @@ type APIMuxConfig struct {
 	Auth     *auth.Auth
 	DB       *sqlx.DB
 	NATS     *nats.NATS
+	Storage  storage.Storage
+
+	// FanOutLimit is the number of followers above which the posts of an
+	// author are merged into home timelines on read.
+	FanOutLimit int
+}
+
+// registry holds the codes the errors of the cores are responded with. The
+// codes are part of the API, so clients can match on them and they must not
+// change.
+var registry = v1Web.Registry{
+	{Err: postCore.ErrInvalidID, Code: "invalid_id", Status: http.StatusBadRequest},
+	{Err: postCore.ErrNotFound, Code: "post_not_found", Status: http.StatusNotFound},
+	{Err: postCore.ErrRevisionNotFound, Code: "revision_not_found", Status: http.StatusNotFound},
+	{Err: postCore.ErrRetentionExpired, Code: "retention_expired", Status: http.StatusGone},
+	{Err: postCore.ErrInvalidSchedule, Code: "invalid_schedule", Status: http.StatusBadRequest},
+	{Err: postCore.ErrAlreadyPublished, Code: "already_published", Status: http.StatusConflict},
+	{Err: postCore.ErrInvalidAttachment, Code: "invalid_attachment", Status: http.StatusBadRequest},
+	{Err: mediaCore.ErrInvalidID, Code: "invalid_id", Status: http.StatusBadRequest},
+	{Err: mediaCore.ErrNotFound, Code: "attachment_not_found", Status: http.StatusNotFound},
+	{Err: mediaCore.ErrVariantNotFound, Code: "variant_not_found", Status: http.StatusNotFound},
+	{Err: mediaCore.ErrUnsupportedType, Code: "unsupported_type", Status: http.StatusUnsupportedMediaType},
+	{Err: mediaCore.ErrContentMismatch, Code: "content_mismatch", Status: http.StatusUnsupportedMediaType},
+	{Err: mediaCore.ErrTooLarge, Code: "too_large", Status: http.StatusRequestEntityTooLarge},
+	{Err: mediaCore.ErrSizeMismatch, Code: "size_mismatch", Status: http.StatusBadRequest},
+	{Err: mediaCore.ErrChecksumMismatch, Code: "checksum_mismatch", Status: http.StatusBadRequest},
+	{Err: mediaCore.ErrNotReady, Code: "not_ready", Status: http.StatusConflict},
+	{Err: feedCore.ErrInvalidID, Code: "invalid_id", Status: http.StatusBadRequest},
+	{Err: feedCore.ErrInvalidCursor, Code: "invalid_cursor", Status: http.StatusBadRequest},
+	{Err: trendingCore.ErrInvalidWindow, Code: "invalid_window", Status: http.StatusBadRequest},
 }
 
 // APIMux constructs an http.Handler with all application routes defined.
@@ -72,7 +110,7 @@ func APIMux(cfg APIMuxConfig) *web.App {
 	app := web.NewApp(
 		cfg.Shutdown,
 		mid.Logger(cfg.Log),
-		mid.Errors(cfg.Log),
+		mid.Errors(cfg.Log, registry),
 		mid.Metrics(),
 		mid.Panics(),
 	)
@@ -90,17 +128,68 @@ func v1(app *web.App, cfg APIMuxConfig) {
 	tgh := v1TestGrp.Handlers{
 		Log: cfg.Log,
 	}
-	app.Handle(http.MethodGet, version, "/test", tgh.Test)
-	app.Handle(http.MethodGet, version, "/testauth", tgh.Test, mid.Authenticate(cfg.Auth), mid.Authorize("ADMIN"))
+	app.Handle(http.MethodGet, version, "/test", tgh.Test).
+		Describe(web.Doc{Summary: "Check the service for development", Security: web.SecurityNone})
+	app.Handle(http.MethodGet, version, "/testauth", tgh.Test, mid.Authenticate(cfg.Auth), mid.Authorize("ADMIN")).
+		Describe(web.Doc{Summary: "Check the service and the token for development"})
 
 	// Register post management and authentication endpoints.
 	pgh := v1PostGrp.Handlers{
-		Core: postCore.NewCore(cfg.Log, cfg.DB, cfg.NATS),
-		Auth: cfg.Auth,
+		Core:  postCore.NewCore(cfg.Log, cfg.DB, cfg.NATS),
+		Media: mediaCore.NewCore(cfg.Log, cfg.DB, cfg.NATS, cfg.Storage),
+		Auth:  cfg.Auth,
+	}
+	app.Handle(http.MethodGet, version, "/posts/trash", pgh.QueryTrash, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List your deleted posts", Response: []v1PostGrp.AppPost{}})
+	app.Handle(http.MethodGet, version, "/posts/drafts", pgh.QueryDrafts, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List your drafts", Response: []v1PostGrp.AppPost{}})
+	app.Handle(http.MethodGet, version, "/posts/:page/:rows", pgh.Query, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List posts", Response: []v1PostGrp.AppPost{}})
+	app.Handle(http.MethodGet, version, "/posts/:id", pgh.QueryByID, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Get a post", Response: v1PostGrp.AppPost{}})
+	app.Handle(http.MethodPost, version, "/posts", pgh.Create, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Create a post", Request: postCore.NewPost{}, Response: v1PostGrp.AppPost{}, Status: http.StatusCreated})
+	app.Handle(http.MethodPut, version, "/posts/:id", pgh.Update, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Update a post", Request: postCore.UpdatePost{}, Status: http.StatusNoContent})
+	app.Handle(http.MethodDelete, version, "/posts/:id", pgh.Delete, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Move a post to the trash", Status: http.StatusNoContent})
+	app.Handle(http.MethodPost, version, "/posts/:id/restore", pgh.Restore, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Restore a post from the trash", Status: http.StatusNoContent})
+	app.Handle(http.MethodGet, version, "/posts/:id/revisions", pgh.QueryRevisions, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List the revisions of a post", Response: []postCore.Revision{}})
+	app.Handle(http.MethodGet, version, "/posts/:id/revisions/:rid", pgh.QueryRevisionByID, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Get a revision of a post", Response: postCore.Revision{}})
+	app.Handle(http.MethodGet, version, "/posts/:id/revisions/:from/diff/:to", pgh.DiffRevisions, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Compare two revisions of a post, either can be current", Response: postCore.RevisionDiff{}})
+
+	// Register trending posts endpoints.
+	tph := v1TrendingGrp.Handlers{
+		Core: trendingCore.NewCore(cfg.Log, cfg.DB),
+	}
+	app.Handle(http.MethodGet, version, "/posts/trending", tph.Query, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List the trending posts", Query: []string{"window", "limit"}, Response: []trendingCore.Post{}})
+
+	// Register home timeline endpoints.
+	fgh := v1FeedGrp.Handlers{
+		Core: feedCore.NewCore(cfg.Log, cfg.DB, cfg.FanOutLimit),
+	}
+	app.Handle(http.MethodGet, version, "/feed", fgh.Query, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Get a page of your home timeline", Query: []string{"cursor", "limit"}, Response: feedCore.Page{}})
+
+	// Register attachment endpoints.
+	mgh := v1MediaGrp.Handlers{
+		Core: mediaCore.NewCore(cfg.Log, cfg.DB, cfg.NATS, cfg.Storage),
+		Post: postCore.NewCore(cfg.Log, cfg.DB, cfg.NATS),
 	}
-	app.Handle(http.MethodGet, version, "/posts/:page/:rows", pgh.Query, mid.Authenticate(cfg.Auth))
-	app.Handle(http.MethodGet, version, "/posts/:id", pgh.QueryByID, mid.Authenticate(cfg.Auth))
-	app.Handle(http.MethodPost, version, "/posts", pgh.Create, mid.Authenticate(cfg.Auth))
-	app.Handle(http.MethodPut, version, "/posts/:id", pgh.Update, mid.Authenticate(cfg.Auth))
-	app.Handle(http.MethodDelete, version, "/posts/:id", pgh.Delete, mid.Authenticate(cfg.Auth))
+	app.Handle(http.MethodPost, version, "/attachments", mgh.Create, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Upload an attachment", RequestType: "*/*", Response: v1MediaGrp.AppAttachment{}, Status: http.StatusCreated})
+	app.Handle(http.MethodGet, version, "/attachments/:id", mgh.QueryByID, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Get an attachment", Response: v1MediaGrp.AppAttachment{}})
+	app.Handle(http.MethodGet, version, "/attachments/:id/content", mgh.QueryContent, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Download the content of an attachment", ResponseType: "*/*"})
+	app.Handle(http.MethodGet, version, "/attachments/:id/variants/:variant", mgh.QueryVariant, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Download a variant of an image attachment", ResponseType: "image/*"})
+
+	// Register the description of the API.
+	app.HandleOpenAPI(version, "/openapi.json", web.OpenAPIConfig{Title: "posts-api", Version: version, Error: v1Web.ErrorResponse{}, ErrorType: v1Web.ProblemContentType})
 }
@@ -0,0 +1,20 @@
+package handlers_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dudakovict/social-network/app/services/posts-api/handlers"
+	"github.com/dudakovict/social-network/business/web/v1/apitest"
+	"go.uber.org/zap"
+)
+
+func TestOpenAPI(t *testing.T) {
+	app := handlers.APIMux(handlers.APIMuxConfig{
+		Shutdown: make(chan os.Signal, 1),
+		Log:      zap.NewNop().Sugar(),
+	})
+
+	t.Log("Given the need to describe the API to its consumers.")
+	apitest.CheckOpenAPI(t, app, "testdata/openapi.json")
+}
@@ -0,0 +1,49 @@
+// Package feedgrp maintains the group of handlers for home timeline access.
+package feedgrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dudakovict/social-network/business/core/feed"
+	"github.com/dudakovict/social-network/business/sys/auth"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
+	"github.com/dudakovict/social-network/foundation/web"
+)
+
+// Set of limits on the number of posts returned per page.
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// Handlers manages the set of home timeline enpoints.
+type Handlers struct {
+	Core feed.Core
+}
+
+// Query returns a page of the home timeline of the authenticated user. The
+// cursor of the returned page fetches the next one.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	limit := defaultLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return v1Web.NewRequestError(fmt.Errorf("invalid limit format, must be between 1 and %d [%s]", maxLimit, l), http.StatusBadRequest)
+		}
+	}
+
+	page, err := h.Core.Query(ctx, claims.Subject, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		return fmt.Errorf("userID[%s]: %w", claims.Subject, err)
+	}
+
+	return web.Respond(ctx, w, page, http.StatusOK)
+}
@@ -0,0 +1,161 @@
+// Package mediagrp maintains the group of handlers for attachment access.
+package mediagrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dudakovict/social-network/business/core/media"
+	"github.com/dudakovict/social-network/business/core/post"
+	"github.com/dudakovict/social-network/business/sys/auth"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
+	"github.com/dudakovict/social-network/foundation/web"
+)
+
+// Handlers manages the set of attachment enpoints.
+type Handlers struct {
+	Core media.Core
+	Post post.Core
+}
+
+// AppAttachment is an attachment along with the URLs its content and
+// variants are served from.
+type AppAttachment struct {
+	media.Attachment
+	URL      string            `json:"url"`
+	Variants map[string]string `json:"variants"`
+}
+
+// NewAppAttachment constructs the response for an attachment.
+func NewAppAttachment(a media.Attachment) AppAttachment {
+	url := "/v1/attachments/" + a.ID
+
+	variants := make(map[string]string, len(a.Variants))
+	for _, name := range a.Variants {
+		variants[name] = url + "/variants/" + name
+	}
+
+	return AppAttachment{
+		Attachment: a,
+		URL:        url + "/content",
+		Variants:   variants,
+	}
+}
+
+// Create uploads a new attachment. The content is the raw request body, its
+// type is the Content-Type header and the optional X-Content-SHA256 header
+// carries the hex encoded checksum of the content.
+func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	if r.ContentLength < 0 {
+		return v1Web.NewRequestError(errors.New("content length is required"), http.StatusLengthRequired)
+	}
+
+	na := media.NewAttachment{
+		UserID:      claims.Subject,
+		ContentType: r.Header.Get("Content-Type"),
+		Size:        r.ContentLength,
+		Checksum:    r.Header.Get("X-Content-SHA256"),
+	}
+
+	a, err := h.Core.Create(ctx, na, r.Body, v.Now)
+	if err != nil {
+		return fmt.Errorf("attachment[%+v]: %w", &na, err)
+	}
+
+	return web.Respond(ctx, w, NewAppAttachment(a), http.StatusCreated)
+}
+
+// QueryByID returns the details of an attachment.
+func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := web.Param(r, "id")
+
+	a, err := h.queryVisible(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, NewAppAttachment(a), http.StatusOK)
+}
+
+// QueryContent streams the content of an attachment.
+func (h Handlers) QueryContent(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := web.Param(r, "id")
+
+	if _, err := h.queryVisible(ctx, id); err != nil {
+		return err
+	}
+
+	a, content, err := h.Core.Open(ctx, id)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", id, err)
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("ETag", `"`+a.Checksum+`"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	return web.RespondStream(ctx, w, content, a.ContentType, http.StatusOK)
+}
+
+// QueryVariant streams the content of a resized variant of an image.
+func (h Handlers) QueryVariant(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := web.Param(r, "id")
+	name := web.Param(r, "variant")
+
+	if _, err := h.queryVisible(ctx, id); err != nil {
+		return err
+	}
+
+	a, content, err := h.Core.OpenVariant(ctx, id, name)
+	if err != nil {
+		return fmt.Errorf("ID[%s] variant[%s]: %w", id, name, err)
+	}
+	defer content.Close()
+
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	return web.RespondStream(ctx, w, content, media.VariantType(a.ContentType), http.StatusOK)
+}
+
+// queryVisible gets the specified attachment as long as the authenticated user
+// uploaded it or is allowed to see a post it is attached to. Attachments the
+// user can not see are reported as not found.
+func (h Handlers) queryVisible(ctx context.Context, id string) (media.Attachment, error) {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return media.Attachment{}, v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	a, err := h.Core.QueryByID(ctx, id)
+	if err != nil {
+		return media.Attachment{}, fmt.Errorf("ID[%s]: %w", id, err)
+	}
+
+	if a.UserID == claims.Subject {
+		return a, nil
+	}
+
+	ok, err := h.Post.CanViewAttachment(ctx, id, claims.Subject)
+	if err != nil {
+		return media.Attachment{}, fmt.Errorf("ID[%s]: %w", id, err)
+	}
+	if !ok {
+		return media.Attachment{}, fmt.Errorf("ID[%s]: %w", id, media.ErrNotFound)
+	}
+
+	return a, nil
+}
@@ -3,11 +3,12 @@ package postgrp
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	"github.com/dudakovict/social-network/app/services/posts-api/handlers/v1/mediagrp"
+	"github.com/dudakovict/social-network/business/core/media"
 	"github.com/dudakovict/social-network/business/core/post"
 	"github.com/dudakovict/social-network/business/sys/auth"
 	v1Web "github.com/dudakovict/social-network/business/web/v1"
@@ -16,8 +17,17 @@ import (
 
 // Handlers manages the set of post enpoints.
 type Handlers struct {
-	Core post.Core
-	Auth *auth.Auth
+	Core  post.Core
+	Media media.Core
+	Auth  *auth.Auth
+}
+
+// AppPost is a post along with its attachments and the users mentioned in
+// its description.
+type AppPost struct {
+	post.Post
+	Attachments []mediagrp.AppAttachment `json:"attachments"`
+	Mentions    []post.Mention           `json:"mentions"`
 }
 
 // Create adds a new post to the system.
@@ -27,17 +37,31 @@ func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return web.NewShutdownError("web value missing from context")
 	}
 
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
 	var np post.NewPost
 	if err := web.Decode(r, &np); err != nil {
 		return fmt.Errorf("unable to decode payload: %w", err)
 	}
 
+	// Posts are always authored by the authenticated user so they can only
+	// attach their own uploads.
+	np.UserID = claims.Subject
+
 	p, err := h.Core.Create(ctx, np, v.Now)
 	if err != nil {
 		return fmt.Errorf("post[%+v]: %w", &p, err)
 	}
 
-	return web.Respond(ctx, w, p, http.StatusCreated)
+	aps, err := h.toAppPosts(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, aps[0], http.StatusCreated)
 }
 
 // Update updates a post in the system.
@@ -61,7 +85,7 @@ func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 
 	p, err := h.Core.QueryByID(ctx, postID)
 	if err != nil {
-		return v1Web.NewRequestError(err, http.StatusBadRequest)
+		return fmt.Errorf("ID[%s]: %w", postID, err)
 	}
 
 	// If you are not an admin and looking to retrieve someone other than yourself.
@@ -69,22 +93,20 @@ func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
 	}
 
-	if err := h.Core.Update(ctx, postID, upd, v.Now); err != nil {
-		switch {
-		case errors.Is(err, post.ErrInvalidID):
-			return v1Web.NewRequestError(err, http.StatusBadRequest)
-		case errors.Is(err, post.ErrNotFound):
-			return v1Web.NewRequestError(err, http.StatusNotFound)
-		default:
-			return fmt.Errorf("ID[%s] Post[%+v]: %w", postID, &upd, err)
-		}
+	if err := h.Core.Update(ctx, postID, claims.Subject, upd, v.Now); err != nil {
+		return fmt.Errorf("ID[%s] Post[%+v]: %w", postID, &upd, err)
 	}
 
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
-// Delete removes a post from the system.
+// Delete moves a post into the trash.
 func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
 	claims, err := auth.GetClaims(ctx)
 	if err != nil {
 		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
@@ -94,7 +116,7 @@ func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Req
 
 	p, err := h.Core.QueryByID(ctx, postID)
 	if err != nil {
-		return v1Web.NewRequestError(err, http.StatusBadRequest)
+		return fmt.Errorf("ID[%s]: %w", postID, err)
 	}
 
 	// If you are not an admin and looking to delete someone other than yourself.
@@ -102,22 +124,92 @@ func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
 	}
 
-	if err := h.Core.Delete(ctx, postID); err != nil {
-		switch {
-		case errors.Is(err, post.ErrInvalidID):
-			return v1Web.NewRequestError(err, http.StatusBadRequest)
-		case errors.Is(err, post.ErrNotFound):
-			return v1Web.NewRequestError(err, http.StatusNotFound)
-		default:
-			return fmt.Errorf("ID[%s]: %w", postID, err)
-		}
+	if err := h.Core.Delete(ctx, postID, v.Now); err != nil {
+		return fmt.Errorf("ID[%s]: %w", postID, err)
 	}
 
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
+// Restore takes a post out of the trash.
+func (h Handlers) Restore(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	postID := web.Param(r, "id")
+
+	p, err := h.Core.QueryDeletedByID(ctx, postID)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", postID, err)
+	}
+
+	// If you are not an admin and looking to restore someone other than yourself.
+	if !claims.Authorized(auth.RoleAdmin) && claims.Subject != p.UserID {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	if err := h.Core.Restore(ctx, postID, v.Now); err != nil {
+		return fmt.Errorf("ID[%s]: %w", postID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// QueryDrafts returns the drafts and scheduled posts of the authenticated
+// user.
+func (h Handlers) QueryDrafts(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	posts, err := h.Core.QueryDrafts(ctx, claims.Subject)
+	if err != nil {
+		return fmt.Errorf("unable to query for drafts: %w", err)
+	}
+
+	aps, err := h.toAppPosts(ctx, posts...)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, aps, http.StatusOK)
+}
+
+// QueryTrash returns the posts the authenticated user has in the trash.
+func (h Handlers) QueryTrash(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	posts, err := h.Core.QueryTrash(ctx, claims.Subject)
+	if err != nil {
+		return fmt.Errorf("unable to query for trash: %w", err)
+	}
+
+	aps, err := h.toAppPosts(ctx, posts...)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, aps, http.StatusOK)
+}
+
 // Query returns a list of posts with paging.
 func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
 	page := web.Param(r, "page")
 	pageNumber, err := strconv.Atoi(page)
 	if err != nil {
@@ -129,43 +221,146 @@ func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		return v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
 	}
 
-	posts, err := h.Core.Query(ctx, pageNumber, rowsPerPage)
+	posts, err := h.Core.Query(ctx, claims.Subject, pageNumber, rowsPerPage)
 	if err != nil {
 		return fmt.Errorf("unable to query for posts: %w", err)
 	}
 
-	return web.Respond(ctx, w, posts, http.StatusOK)
+	aps, err := h.toAppPosts(ctx, posts...)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, aps, http.StatusOK)
 }
 
 // QueryByID returns a post by its ID.
 func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-	/*
-		claims, err := auth.GetClaims(ctx)
-		if err != nil {
-			return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
-		}
-	*/
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
 
 	postID := web.Param(r, "id")
 
-	/*
-		// If you are not an admin and looking to retrieve someone other than yourself.
-		if !claims.Authorized(auth.RoleAdmin) && claims.Subject != postID {
-			return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
-		}
-	*/
+	p, err := h.Core.QueryVisibleByID(ctx, postID, claims.Subject)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", postID, err)
+	}
 
-	p, err := h.Core.QueryByID(ctx, postID)
+	aps, err := h.toAppPosts(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, aps[0], http.StatusOK)
+}
+
+// QueryRevisions returns the previous versions of a post.
+func (h Handlers) QueryRevisions(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	postID := web.Param(r, "id")
+
+	if err := h.checkVisible(ctx, postID); err != nil {
+		return err
+	}
+
+	revs, err := h.Core.QueryRevisions(ctx, postID)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", postID, err)
+	}
+
+	return web.Respond(ctx, w, revs, http.StatusOK)
+}
+
+// QueryRevisionByID returns a previous version of a post by its ID.
+func (h Handlers) QueryRevisionByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	postID := web.Param(r, "id")
+	revisionID := web.Param(r, "rid")
+
+	if err := h.checkVisible(ctx, postID); err != nil {
+		return err
+	}
+
+	rev, err := h.Core.QueryRevisionByID(ctx, postID, revisionID)
 	if err != nil {
-		switch {
-		case errors.Is(err, post.ErrInvalidID):
-			return v1Web.NewRequestError(err, http.StatusBadRequest)
-		case errors.Is(err, post.ErrNotFound):
-			return v1Web.NewRequestError(err, http.StatusNotFound)
-		default:
-			return fmt.Errorf("ID[%s]: %w", postID, err)
+		return fmt.Errorf("ID[%s] RevisionID[%s]: %w", postID, revisionID, err)
+	}
+
+	return web.Respond(ctx, w, rev, http.StatusOK)
+}
+
+// DiffRevisions returns the differences between two versions of a post.
+func (h Handlers) DiffRevisions(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	postID := web.Param(r, "id")
+	fromID := web.Param(r, "from")
+	toID := web.Param(r, "to")
+
+	if err := h.checkVisible(ctx, postID); err != nil {
+		return err
+	}
+
+	rd, err := h.Core.DiffRevisions(ctx, postID, fromID, toID)
+	if err != nil {
+		return fmt.Errorf("ID[%s] From[%s] To[%s]: %w", postID, fromID, toID, err)
+	}
+
+	return web.Respond(ctx, w, rd, http.StatusOK)
+}
+
+// checkVisible makes sure the authenticated user is allowed to see the post.
+func (h Handlers) checkVisible(ctx context.Context, postID string) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	if _, err := h.Core.QueryVisibleByID(ctx, postID, claims.Subject); err != nil {
+		return fmt.Errorf("ID[%s]: %w", postID, err)
+	}
+
+	return nil
+}
+
+// toAppPosts adds the attachments, keeping the order in which they were
+// attached, and the mentions to the posts.
+func (h Handlers) toAppPosts(ctx context.Context, posts ...post.Post) ([]AppPost, error) {
+	var ids, postIDs []string
+	for _, p := range posts {
+		ids = append(ids, p.AttachmentIDs...)
+		postIDs = append(postIDs, p.ID)
+	}
+
+	mentions, err := h.Core.QueryMentions(ctx, postIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query for mentions: %w", err)
+	}
+
+	as, err := h.Media.QueryByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query for attachments: %w", err)
+	}
+
+	byID := make(map[string]media.Attachment, len(as))
+	for _, a := range as {
+		byID[a.ID] = a
+	}
+
+	aps := make([]AppPost, len(posts))
+	for i, p := range posts {
+		aps[i] = AppPost{
+			Post:        p,
+			Attachments: []mediagrp.AppAttachment{},
+			Mentions:    mentions[p.ID],
+		}
+		if aps[i].Mentions == nil {
+			aps[i].Mentions = []post.Mention{}
+		}
+		for _, id := range p.AttachmentIDs {
+			if a, ok := byID[id]; ok {
+				aps[i].Attachments = append(aps[i].Attachments, mediagrp.NewAppAttachment(a))
+			}
 		}
 	}
 
-	return web.Respond(ctx, w, p, http.StatusOK)
+	return aps, nil
 }
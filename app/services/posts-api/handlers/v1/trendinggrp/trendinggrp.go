@@ -0,0 +1,53 @@
+// Package trendinggrp maintains the group of handlers for trending posts.
+package trendinggrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dudakovict/social-network/business/core/trending"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
+	"github.com/dudakovict/social-network/foundation/web"
+)
+
+// Set of defaults for the trending query.
+const (
+	defaultWindow = "24h"
+	defaultLimit  = 20
+	maxLimit      = 100
+)
+
+// Handlers manages the set of trending enpoints.
+type Handlers struct {
+	Core trending.Core
+}
+
+// Query returns the top posts over the window given in the query string.
+func (h Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = defaultWindow
+	}
+
+	limit := defaultLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return v1Web.NewRequestError(fmt.Errorf("invalid limit format, must be between 1 and %d [%s]", maxLimit, l), http.StatusBadRequest)
+		}
+	}
+
+	posts, err := h.Core.Query(ctx, window, limit, v.Now)
+	if err != nil {
+		return fmt.Errorf("window[%s]: %w", window, err)
+	}
+
+	return web.Respond(ctx, w, posts, http.StatusOK)
+}
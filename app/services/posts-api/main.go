@@ -9,16 +9,22 @@ import (
 	"os"
 	"os/signal"
 	_ "runtime"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/ardanlabs/conf"
 	"github.com/dudakovict/social-network/app/services/posts-api/handlers"
+	"github.com/dudakovict/social-network/business/core/feed"
+	"github.com/dudakovict/social-network/business/core/media"
+	"github.com/dudakovict/social-network/business/core/post"
+	"github.com/dudakovict/social-network/business/core/trending"
 	"github.com/dudakovict/social-network/business/sys/auth"
 	"github.com/dudakovict/social-network/business/sys/database"
 	"github.com/dudakovict/social-network/business/sys/nats"
 	"github.com/dudakovict/social-network/foundation/keystore"
 	"github.com/dudakovict/social-network/foundation/logger"
+	"github.com/dudakovict/social-network/foundation/storage"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/zipkin"
@@ -73,12 +79,13 @@ func run(log *zap.SugaredLogger) error {
 	cfg := struct {
 		conf.Version
 		Web struct {
-			APIHost         string        `conf:"default:0.0.0.0:3001"`
-			DebugHost       string        `conf:"default:0.0.0.0:4001"`
-			ReadTimeout     time.Duration `conf:"default:5s"`
-			WriteTimeout    time.Duration `conf:"default:10s"`
-			IdleTimeout     time.Duration `conf:"default:120s"`
-			ShutdownTimeout time.Duration `conf:"default:20s,mask"`
+			APIHost           string        `conf:"default:0.0.0.0:3001"`
+			DebugHost         string        `conf:"default:0.0.0.0:4001"`
+			ReadHeaderTimeout time.Duration `conf:"default:5s"`
+			ReadTimeout       time.Duration `conf:"default:120s"`
+			WriteTimeout      time.Duration `conf:"default:120s"`
+			IdleTimeout       time.Duration `conf:"default:120s"`
+			ShutdownTimeout   time.Duration `conf:"default:20s,mask"`
 		}
 		Auth struct {
 			KeysFolder string `conf:"default:zarf/keys/"`
@@ -103,6 +110,24 @@ func run(log *zap.SugaredLogger) error {
 			ClientID  string `conf:"default:posts-pod,env:NATS_CLIENT_ID"`
 			Host      string `conf:"default:http://nats-service:4222"`
 		}
+		Trash struct {
+			PurgeInterval time.Duration `conf:"default:1h"`
+		}
+		Scheduler struct {
+			Interval time.Duration `conf:"default:15s"`
+		}
+		Feed struct {
+			FanOutLimit int `conf:"default:10000"`
+		}
+		Storage struct {
+			Backend     string `conf:"default:fs"`
+			FSRoot      string `conf:"default:/tmp/attachments"`
+			S3Endpoint  string `conf:"default:http://minio-service:9000"`
+			S3Region    string `conf:"default:us-east-1"`
+			S3Bucket    string `conf:"default:attachments"`
+			S3AccessKey string `conf:"default:minioadmin"`
+			S3SecretKey string `conf:"default:minioadmin,mask"`
+		}
 	}{
 		Version: conf.Version{
 			SVN:  build,
@@ -174,6 +199,30 @@ func run(log *zap.SugaredLogger) error {
 		db.Close()
 	}()
 
+	// =========================================================================
+	// Blob Storage Support
+
+	log.Infow("startup", "status", "initializing blob storage support", "backend", cfg.Storage.Backend)
+
+	var store storage.Storage
+	switch cfg.Storage.Backend {
+	case "fs":
+		store, err = storage.NewFS(cfg.Storage.FSRoot)
+	case "s3":
+		store, err = storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.Storage.S3Endpoint,
+			Region:    cfg.Storage.S3Region,
+			Bucket:    cfg.Storage.S3Bucket,
+			AccessKey: cfg.Storage.S3AccessKey,
+			SecretKey: cfg.Storage.S3SecretKey,
+		})
+	default:
+		err = fmt.Errorf("unknown backend %q", cfg.Storage.Backend)
+	}
+	if err != nil {
+		return fmt.Errorf("constructing blob storage: %w", err)
+	}
+
 	// =========================================================================
 	// NATS Support
 
@@ -194,6 +243,114 @@ func run(log *zap.SugaredLogger) error {
 		n.Client.Close()
 	}()
 
+	// =========================================================================
+	// Start Event Listener Support
+
+	log.Infow("startup", "status", "initializing follow event listeners")
+
+	if err := post.NewListener(log, db, n).Listen(); err != nil {
+		return fmt.Errorf("listening for follow events: %w", err)
+	}
+
+	log.Infow("startup", "status", "initializing home timeline event listeners", "fanOutLimit", cfg.Feed.FanOutLimit)
+
+	if err := feed.NewListener(log, db, n, cfg.Feed.FanOutLimit).Listen(); err != nil {
+		return fmt.Errorf("listening for home timeline events: %w", err)
+	}
+
+	log.Infow("startup", "status", "initializing trending event listeners")
+
+	if err := trending.NewListener(log, db, n).Listen(); err != nil {
+		return fmt.Errorf("listening for trending events: %w", err)
+	}
+
+	log.Infow("startup", "status", "initializing image processing event listeners")
+
+	if err := media.NewListener(log, db, n, store).Listen(); err != nil {
+		return fmt.Errorf("listening for attachment events: %w", err)
+	}
+
+	// =========================================================================
+	// Start Trash Purge Support
+
+	// The background tasks are cancelled when the service shuts down and
+	// waited on so they do not outlive the database connection.
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	var bg sync.WaitGroup
+	defer func() {
+		log.Infow("shutdown", "status", "stopping background tasks")
+
+		bgCancel()
+
+		done := make(chan struct{})
+		go func() {
+			bg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(cfg.Web.ShutdownTimeout):
+			log.Errorw("shutdown", "status", "background tasks did not stop")
+		}
+	}()
+
+	log.Infow("startup", "status", "initializing trash purge support", "interval", cfg.Trash.PurgeInterval)
+
+	// Posts stay in the trash for post.TrashRetention. Purging is idempotent
+	// so every replica of the service can run it.
+	purge := time.NewTicker(cfg.Trash.PurgeInterval)
+	defer purge.Stop()
+
+	bg.Add(1)
+	go func() {
+		defer bg.Done()
+
+		core := post.NewCore(log, db, n)
+		for {
+			select {
+			case <-bgCtx.Done():
+				return
+			case now := <-purge.C:
+				if err := core.Purge(bgCtx, now.UTC()); err != nil {
+					log.Errorw("purge", "status", "purging trash", "ERROR", err)
+				}
+			}
+		}
+	}()
+
+	// =========================================================================
+	// Start Publishing Scheduler Support
+
+	log.Infow("startup", "status", "initializing publishing scheduler support", "interval", cfg.Scheduler.Interval)
+
+	// Every replica runs the scheduler. Due posts are claimed with row locks
+	// that other replicas skip and announced after the claim is committed,
+	// so each post is announced at most once.
+	scheduler := time.NewTicker(cfg.Scheduler.Interval)
+	defer scheduler.Stop()
+
+	bg.Add(1)
+	go func() {
+		defer bg.Done()
+
+		core := post.NewCore(log, db, n)
+		for {
+			select {
+			case <-bgCtx.Done():
+				return
+			case now := <-scheduler.C:
+				posts, err := core.PublishDue(bgCtx, now.UTC())
+				if err != nil {
+					log.Errorw("scheduler", "status", "publishing due posts", "ERROR", err)
+				}
+				if len(posts) > 0 {
+					log.Infow("scheduler", "status", "published due posts", "count", len(posts))
+				}
+			}
+		}
+	}()
+
 	// =========================================================================
 	// Start Tracing Support
 
@@ -245,16 +402,20 @@ func run(log *zap.SugaredLogger) error {
 		Auth:     auth,
 		DB:       db,
 		NATS:     n,
+		Storage:  store,
+
+		FanOutLimit: cfg.Feed.FanOutLimit,
 	})
 
 	// Construct a server to service the requests against the mux.
 	api := http.Server{
-		Addr:         cfg.Web.APIHost,
-		Handler:      apiMux,
-		ReadTimeout:  cfg.Web.ReadTimeout,
-		WriteTimeout: cfg.Web.WriteTimeout,
-		IdleTimeout:  cfg.Web.IdleTimeout,
-		ErrorLog:     zap.NewStdLog(log.Desugar()),
+		Addr:              cfg.Web.APIHost,
+		Handler:           apiMux,
+		ReadHeaderTimeout: cfg.Web.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Web.ReadTimeout,
+		WriteTimeout:      cfg.Web.WriteTimeout,
+		IdleTimeout:       cfg.Web.IdleTimeout,
+		ErrorLog:          zap.NewStdLog(log.Desugar()),
 	}
 
 	// Make a channel to listen for errors coming from the listener. Use a
@@ -8,11 +8,16 @@ import (
 	"os"
 
 	"github.com/dudakovict/social-network/app/services/users-api/handlers/debug/checkgrp"
+	v1FollowGrp "github.com/dudakovict/social-network/app/services/users-api/handlers/v1/followgrp"
+	v1ProfileGrp "github.com/dudakovict/social-network/app/services/users-api/handlers/v1/profilegrp"
 	v1TestGrp "github.com/dudakovict/social-network/app/services/users-api/handlers/v1/testgrp"
 	v1UserGrp "github.com/dudakovict/social-network/app/services/users-api/handlers/v1/usergrp"
+	followCore "github.com/dudakovict/social-network/business/core/follow"
 	userCore "github.com/dudakovict/social-network/business/core/user"
 	"github.com/dudakovict/social-network/business/data/email"
 	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
 	"github.com/dudakovict/social-network/business/web/v1/mid"
 	"github.com/dudakovict/social-network/foundation/web"
 	"github.com/jmoiron/sqlx"
@@ -63,6 +68,23 @@ type APIMuxConfig struct {
 	Auth     *auth.Auth
 	DB       *sqlx.DB
 	EC       email.EmailClient
+	NATS     *nats.NATS
+}
+
+// registry holds the codes the errors of the cores are responded with. The
+// codes are part of the API, so clients can match on them and they must not
+// change.
+var registry = v1Web.Registry{
+	{Err: userCore.ErrInvalidID, Code: "invalid_id", Status: http.StatusBadRequest},
+	{Err: userCore.ErrNotFound, Code: "user_not_found", Status: http.StatusNotFound},
+	{Err: userCore.ErrAuthenticationFailure, Code: "authentication_failed", Status: http.StatusUnauthorized},
+	{Err: userCore.ErrHandleTaken, Code: "handle_taken", Status: http.StatusConflict},
+	{Err: userCore.ErrInvalidHandle, Code: "invalid_handle", Status: http.StatusBadRequest},
+	{Err: followCore.ErrInvalidID, Code: "invalid_id", Status: http.StatusBadRequest},
+	{Err: followCore.ErrNotFound, Code: "user_not_found", Status: http.StatusNotFound},
+	{Err: followCore.ErrSelfFollow, Code: "self_follow", Status: http.StatusBadRequest},
+	{Err: followCore.ErrRequestNotFound, Code: "follow_request_not_found", Status: http.StatusNotFound},
+	{Err: followCore.ErrPrivate, Code: "private_user", Status: http.StatusForbidden},
 }
 
 // APIMux constructs an http.Handler with all application routes defined.
@@ -72,7 +94,7 @@ func APIMux(cfg APIMuxConfig) *web.App {
 	app := web.NewApp(
 		cfg.Shutdown,
 		mid.Logger(cfg.Log),
-		mid.Errors(cfg.Log),
+		mid.Errors(cfg.Log, registry),
 		mid.Metrics(),
 		mid.Panics(),
 	)
@@ -90,18 +112,61 @@ func v1(app *web.App, cfg APIMuxConfig) {
 	tgh := v1TestGrp.Handlers{
 		Log: cfg.Log,
 	}
-	app.Handle(http.MethodGet, version, "/test", tgh.Test)
-	app.Handle(http.MethodGet, version, "/testauth", tgh.Test, mid.Authenticate(cfg.Auth), mid.Authorize("ADMIN"))
+	app.Handle(http.MethodGet, version, "/test", tgh.Test).
+		Describe(web.Doc{Summary: "Check the service for development", Security: web.SecurityNone})
+	app.Handle(http.MethodGet, version, "/testauth", tgh.Test, mid.Authenticate(cfg.Auth), mid.Authorize("ADMIN")).
+		Describe(web.Doc{Summary: "Check the service and the token for development"})
 
 	// Register user management and authentication endpoints.
 	ugh := v1UserGrp.Handlers{
-		Core: userCore.NewCore(cfg.Log, cfg.DB, cfg.EC),
+		Core: userCore.NewCore(cfg.Log, cfg.DB, cfg.NATS, cfg.EC),
 		Auth: cfg.Auth,
 	}
-	app.Handle(http.MethodGet, version, "/users/token", ugh.Token)
-	app.Handle(http.MethodGet, version, "/users/:page/:rows", ugh.Query, mid.Authenticate(cfg.Auth), mid.Authorize(auth.RoleAdmin))
-	app.Handle(http.MethodGet, version, "/users/:id", ugh.QueryByID, mid.Authenticate(cfg.Auth))
-	app.Handle(http.MethodPost, version, "/users", ugh.Create, mid.Authenticate(cfg.Auth), mid.Authorize(auth.RoleAdmin))
-	app.Handle(http.MethodPut, version, "/users/:id", ugh.Update, mid.Authenticate(cfg.Auth), mid.Authorize(auth.RoleAdmin))
-	app.Handle(http.MethodDelete, version, "/users/:id", ugh.Delete, mid.Authenticate(cfg.Auth), mid.Authorize(auth.RoleAdmin))
+	app.Handle(http.MethodGet, version, "/users/token", ugh.Token).
+		Describe(web.Doc{Summary: "Get a token for the email and password", Response: v1UserGrp.AppToken{}, Security: web.SecurityBasic})
+	app.Handle(http.MethodGet, version, "/users/:page/:rows", ugh.Query, mid.Authenticate(cfg.Auth), mid.Authorize(auth.RoleAdmin)).
+		Describe(web.Doc{Summary: "List users", Response: []userCore.User{}})
+	app.Handle(http.MethodGet, version, "/users/:id", ugh.QueryByID, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Get a user", Response: userCore.User{}})
+	app.Handle(http.MethodPost, version, "/users", ugh.Create, mid.Authenticate(cfg.Auth), mid.Authorize(auth.RoleAdmin)).
+		Describe(web.Doc{Summary: "Create a user", Request: userCore.NewUser{}, Response: userCore.User{}, Status: http.StatusCreated})
+	app.Handle(http.MethodPut, version, "/users/:id", ugh.Update, mid.Authenticate(cfg.Auth), mid.Authorize(auth.RoleAdmin)).
+		Describe(web.Doc{Summary: "Update a user", Request: userCore.UpdateUser{}, Status: http.StatusNoContent})
+	app.Handle(http.MethodDelete, version, "/users/:id", ugh.Delete, mid.Authenticate(cfg.Auth), mid.Authorize(auth.RoleAdmin)).
+		Describe(web.Doc{Summary: "Delete a user", Status: http.StatusNoContent})
+
+	// Register follow graph endpoints.
+	fgh := v1FollowGrp.Handlers{
+		Core: followCore.NewCore(cfg.Log, cfg.DB, cfg.NATS),
+	}
+	app.Handle(http.MethodPost, version, "/users/:id/follow", fgh.Follow, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Follow a user, or ask to when they are private", Response: followCore.Relationship{}})
+	app.Handle(http.MethodDelete, version, "/users/:id/follow", fgh.Unfollow, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Unfollow a user", Status: http.StatusNoContent})
+	app.Handle(http.MethodGet, version, "/users/:id/relationship", fgh.Relationship, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Get the relationship with a user", Response: followCore.Relationship{}})
+	app.Handle(http.MethodGet, version, "/users/:id/followers/:page/:rows", fgh.QueryFollowers, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List the followers of a user", Response: []followCore.Follow{}})
+	app.Handle(http.MethodGet, version, "/users/:id/following/:page/:rows", fgh.QueryFollowing, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List the users a user follows", Response: []followCore.Follow{}})
+	app.Handle(http.MethodGet, version, "/users/:id/counts", fgh.QueryCounts, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Count the followers and followees of a user", Response: followCore.Counts{}})
+	app.Handle(http.MethodGet, version, "/follow-requests/:page/:rows", fgh.QueryRequests, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "List the pending follow requests", Response: []followCore.Request{}})
+	app.Handle(http.MethodPost, version, "/follow-requests/:id/accept", fgh.AcceptRequest, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Accept a follow request", Status: http.StatusNoContent})
+	app.Handle(http.MethodDelete, version, "/follow-requests/:id", fgh.RejectRequest, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Reject a follow request", Status: http.StatusNoContent})
+
+	// Register public profile endpoints.
+	pgh := v1ProfileGrp.Handlers{
+		Core: userCore.NewCore(cfg.Log, cfg.DB, cfg.NATS, cfg.EC),
+	}
+	app.Handle(http.MethodGet, version, "/profiles/:handle", pgh.QueryByHandle).
+		Describe(web.Doc{Summary: "Get the public profile of a user", Response: userCore.Profile{}, Security: web.SecurityNone})
+	app.Handle(http.MethodPut, version, "/profiles", pgh.Update, mid.Authenticate(cfg.Auth)).
+		Describe(web.Doc{Summary: "Update your profile", Request: userCore.UpdateProfile{}, Status: http.StatusNoContent})
+
+	// Register the description of the API.
+	app.HandleOpenAPI(version, "/openapi.json", web.OpenAPIConfig{Title: "users-api", Version: version, Error: v1Web.ErrorResponse{}, ErrorType: v1Web.ProblemContentType})
 }
@@ -0,0 +1,206 @@
+// Package followgrp maintains the group of handlers for follow access.
+package followgrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dudakovict/social-network/business/core/follow"
+	"github.com/dudakovict/social-network/business/sys/auth"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
+	"github.com/dudakovict/social-network/foundation/web"
+)
+
+// Handlers manages the set of follow enpoints.
+type Handlers struct {
+	Core follow.Core
+}
+
+// Follow makes the authenticated user follow another user, or asks to when
+// that user has a private account.
+func (h Handlers) Follow(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	userID := web.Param(r, "id")
+
+	rel, err := h.Core.Follow(ctx, claims.Subject, userID, v.Now)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", userID, err)
+	}
+
+	return web.Respond(ctx, w, rel, http.StatusOK)
+}
+
+// Unfollow stops the authenticated user from following another user.
+func (h Handlers) Unfollow(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	userID := web.Param(r, "id")
+
+	if err := h.Core.Unfollow(ctx, claims.Subject, userID); err != nil {
+		return fmt.Errorf("ID[%s]: %w", userID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Relationship returns how the authenticated user and another user are
+// connected.
+func (h Handlers) Relationship(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	userID := web.Param(r, "id")
+
+	rel, err := h.Core.Relationship(ctx, claims.Subject, userID)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", userID, err)
+	}
+
+	return web.Respond(ctx, w, rel, http.StatusOK)
+}
+
+// QueryFollowers returns a list of the followers of a user with paging.
+func (h Handlers) QueryFollowers(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	userID := web.Param(r, "id")
+	pageNumber, rowsPerPage, err := paging(r)
+	if err != nil {
+		return err
+	}
+
+	follows, err := h.Core.QueryFollowers(ctx, claims.Subject, userID, pageNumber, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", userID, err)
+	}
+
+	return web.Respond(ctx, w, follows, http.StatusOK)
+}
+
+// QueryFollowing returns a list of the users a user follows with paging.
+func (h Handlers) QueryFollowing(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	userID := web.Param(r, "id")
+	pageNumber, rowsPerPage, err := paging(r)
+	if err != nil {
+		return err
+	}
+
+	follows, err := h.Core.QueryFollowing(ctx, claims.Subject, userID, pageNumber, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", userID, err)
+	}
+
+	return web.Respond(ctx, w, follows, http.StatusOK)
+}
+
+// QueryCounts returns how many followers a user has and how many users they
+// follow.
+func (h Handlers) QueryCounts(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	userID := web.Param(r, "id")
+
+	counts, err := h.Core.QueryCounts(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("ID[%s]: %w", userID, err)
+	}
+
+	return web.Respond(ctx, w, counts, http.StatusOK)
+}
+
+// QueryRequests returns a list of the pending requests to follow the
+// authenticated user with paging.
+func (h Handlers) QueryRequests(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	pageNumber, rowsPerPage, err := paging(r)
+	if err != nil {
+		return err
+	}
+
+	requests, err := h.Core.QueryRequests(ctx, claims.Subject, pageNumber, rowsPerPage)
+	if err != nil {
+		return fmt.Errorf("unable to query for follow requests: %w", err)
+	}
+
+	return web.Respond(ctx, w, requests, http.StatusOK)
+}
+
+// AcceptRequest lets another user follow the authenticated user.
+func (h Handlers) AcceptRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	requesterID := web.Param(r, "id")
+
+	if err := h.Core.AcceptRequest(ctx, claims.Subject, requesterID, v.Now); err != nil {
+		return fmt.Errorf("ID[%s]: %w", requesterID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// RejectRequest turns down the request of another user to follow the
+// authenticated user.
+func (h Handlers) RejectRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	requesterID := web.Param(r, "id")
+
+	if err := h.Core.RejectRequest(ctx, claims.Subject, requesterID); err != nil {
+		return fmt.Errorf("ID[%s]: %w", requesterID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// paging reads the page number and the rows per page from the request.
+func paging(r *http.Request) (int, int, error) {
+	page := web.Param(r, "page")
+	pageNumber, err := strconv.Atoi(page)
+	if err != nil || pageNumber < 1 {
+		return 0, 0, v1Web.NewRequestError(fmt.Errorf("invalid page format [%s]", page), http.StatusBadRequest)
+	}
+	rows := web.Param(r, "rows")
+	rowsPerPage, err := strconv.Atoi(rows)
+	if err != nil || rowsPerPage < 1 {
+		return 0, 0, v1Web.NewRequestError(fmt.Errorf("invalid rows format [%s]", rows), http.StatusBadRequest)
+	}
+
+	return pageNumber, rowsPerPage, nil
+}
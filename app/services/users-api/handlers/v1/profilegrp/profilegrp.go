@@ -0,0 +1,54 @@
+// Package profilegrp maintains the group of handlers for public profiles.
+package profilegrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/dudakovict/social-network/business/core/user"
+	"github.com/dudakovict/social-network/business/sys/auth"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
+	"github.com/dudakovict/social-network/foundation/web"
+)
+
+// Handlers manages the set of profile enpoints.
+type Handlers struct {
+	Core user.Core
+}
+
+// QueryByHandle returns the public profile of a user by their handle.
+func (h Handlers) QueryByHandle(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	handle := web.Param(r, "handle")
+
+	prf, err := h.Core.QueryProfileByHandle(ctx, handle)
+	if err != nil {
+		return fmt.Errorf("handle[%s]: %w", handle, err)
+	}
+
+	return web.Respond(ctx, w, prf, http.StatusOK)
+}
+
+// Update updates the profile of the authenticated user.
+func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	var upd user.UpdateProfile
+	if err := web.Decode(r, &upd); err != nil {
+		return fmt.Errorf("unable to decode payload: %w", err)
+	}
+
+	if err := h.Core.UpdateProfile(ctx, claims.Subject, upd, v.Now); err != nil {
+		return fmt.Errorf("ID[%s] Profile[%+v]: %w", claims.Subject, &upd, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
@@ -10,10 +10,16 @@ import (
 
 	"github.com/dudakovict/social-network/business/core/user"
 	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/locale"
 	v1Web "github.com/dudakovict/social-network/business/web/v1"
 	"github.com/dudakovict/social-network/foundation/web"
 )
 
+// AppToken is the API token handed out for the credentials of a user.
+type AppToken struct {
+	Token string `json:"token"`
+}
+
 // Handlers manages the set of user enpoints.
 type Handlers struct {
 	Core user.Core
@@ -32,6 +38,12 @@ func (h Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return fmt.Errorf("unable to decode payload: %w", err)
 	}
 
+	// Users are e-mailed in the language their client asks for, unless they
+	// pick one.
+	if nu.Locale == "" {
+		nu.Locale = locale.Match(web.Languages(r)...)
+	}
+
 	usr, err := h.Core.Create(ctx, nu, v.Now)
 	if err != nil {
 		return fmt.Errorf("user[%+v]: %w", &usr, err)
@@ -65,14 +77,7 @@ func (h Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Req
 	}
 
 	if err := h.Core.Update(ctx, userID, upd, v.Now); err != nil {
-		switch {
-		case errors.Is(err, user.ErrInvalidID):
-			return v1Web.NewRequestError(err, http.StatusBadRequest)
-		case errors.Is(err, user.ErrNotFound):
-			return v1Web.NewRequestError(err, http.StatusNotFound)
-		default:
-			return fmt.Errorf("ID[%s] User[%+v]: %w", userID, &upd, err)
-		}
+		return fmt.Errorf("ID[%s] User[%+v]: %w", userID, &upd, err)
 	}
 
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
@@ -93,14 +98,7 @@ func (h Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Req
 	}
 
 	if err := h.Core.Delete(ctx, userID); err != nil {
-		switch {
-		case errors.Is(err, user.ErrInvalidID):
-			return v1Web.NewRequestError(err, http.StatusBadRequest)
-		case errors.Is(err, user.ErrNotFound):
-			return v1Web.NewRequestError(err, http.StatusNotFound)
-		default:
-			return fmt.Errorf("ID[%s]: %w", userID, err)
-		}
+		return fmt.Errorf("ID[%s]: %w", userID, err)
 	}
 
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
@@ -143,14 +141,7 @@ func (h Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.
 
 	usr, err := h.Core.QueryByID(ctx, userID)
 	if err != nil {
-		switch {
-		case errors.Is(err, user.ErrInvalidID):
-			return v1Web.NewRequestError(err, http.StatusBadRequest)
-		case errors.Is(err, user.ErrNotFound):
-			return v1Web.NewRequestError(err, http.StatusNotFound)
-		default:
-			return fmt.Errorf("ID[%s]: %w", userID, err)
-		}
+		return fmt.Errorf("ID[%s]: %w", userID, err)
 	}
 
 	return web.Respond(ctx, w, usr, http.StatusOK)
@@ -171,19 +162,10 @@ func (h Handlers) Token(ctx context.Context, w http.ResponseWriter, r *http.Requ
 
 	claims, err := h.Core.Authenticate(ctx, v.Now, email, pass)
 	if err != nil {
-		switch {
-		case errors.Is(err, user.ErrNotFound):
-			return v1Web.NewRequestError(err, http.StatusNotFound)
-		case errors.Is(err, user.ErrAuthenticationFailure):
-			return v1Web.NewRequestError(err, http.StatusUnauthorized)
-		default:
-			return fmt.Errorf("authenticating: %w", err)
-		}
+		return fmt.Errorf("authenticating: %w", err)
 	}
 
-	var tkn struct {
-		Token string `json:"token"`
-	}
+	var tkn AppToken
 	tkn.Token, err = h.Auth.GenerateToken(claims)
 	if err != nil {
 		return fmt.Errorf("generating token: %w", err)
@@ -14,9 +14,12 @@ import (
 
 	"github.com/ardanlabs/conf"
 	"github.com/dudakovict/social-network/app/services/users-api/handlers"
+	"github.com/dudakovict/social-network/business/core/user"
 	"github.com/dudakovict/social-network/business/data/email"
 	"github.com/dudakovict/social-network/business/sys/auth"
 	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/business/sys/rpc"
 	"github.com/dudakovict/social-network/foundation/keystore"
 	"github.com/dudakovict/social-network/foundation/logger"
 	"go.opentelemetry.io/otel"
@@ -100,7 +103,16 @@ func run(log *zap.SugaredLogger) error {
 			Probability float64 `conf:"default:0.05"`
 		}
 		GRPC struct {
-			Address string `conf:"default:email-service:50084"`
+			Address  string        `conf:"default:email-service:50084"`
+			CertFile string        `conf:""`
+			KeyFile  string        `conf:""`
+			CAFile   string        `conf:""`
+			TokenTTL time.Duration `conf:"default:1h"`
+		}
+		NATS struct {
+			ClusterID string `conf:"default:social-network"`
+			ClientID  string `conf:"default:users-pod,env:NATS_CLIENT_ID"`
+			Host      string `conf:"default:http://nats-service:4222"`
 		}
 	}{
 		Version: conf.Version{
@@ -193,7 +205,22 @@ func run(log *zap.SugaredLogger) error {
 
 	log.Infow("startup", "status", "initializing gRPC support", "host", cfg.GRPC.Address)
 
-	conn, err := grpc.Dial(cfg.GRPC.Address, grpc.WithInsecure())
+	// Without a CA the connection is plain text. The service authenticates
+	// with a certificate when it has one and with a JWT on every call.
+	creds, err := rpc.ClientCredentials(rpc.TLSConfig{
+		CertFile: cfg.GRPC.CertFile,
+		KeyFile:  cfg.GRPC.KeyFile,
+		CAFile:   cfg.GRPC.CAFile,
+	})
+	if err != nil {
+		return fmt.Errorf("loading gRPC credentials: %w", err)
+	}
+
+	conn, err := grpc.Dial(cfg.GRPC.Address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithPerRPCCredentials(auth.ServiceToken("users-api", cfg.GRPC.TokenTTL, cfg.GRPC.CAFile != "")),
+		grpc.WithUnaryInterceptor(rpc.ClientTrace()),
+	)
 	if err != nil {
 		return fmt.Errorf("connecting to gRPC: %w", err)
 	}
@@ -202,6 +229,35 @@ func run(log *zap.SugaredLogger) error {
 
 	client := email.NewEmailClient(conn)
 
+	// =========================================================================
+	// NATS Support
+
+	// Create connectivity to the NATS server.
+	log.Infow("startup", "status", "initializing NATS support", "host", cfg.NATS.Host)
+
+	n, err := nats.Connect(nats.Config{
+		ClusterID: cfg.NATS.ClusterID,
+		ClientID:  cfg.NATS.ClientID,
+		Host:      cfg.NATS.Host,
+	})
+
+	if err != nil {
+		return fmt.Errorf("connecting to NATS server: %w", err)
+	}
+	defer func() {
+		log.Infow("shutdown", "status", "stopping NATS support", "host", cfg.NATS.Host)
+		n.Client.Close()
+	}()
+
+	// =========================================================================
+	// Start Event Listener Support
+
+	log.Infow("startup", "status", "initializing post event listeners")
+
+	if err := user.NewListener(log, db, n).Listen(); err != nil {
+		return fmt.Errorf("listening for post events: %w", err)
+	}
+
 	// =========================================================================
 	// Start Debug Service
 
@@ -238,6 +294,7 @@ func run(log *zap.SugaredLogger) error {
 		Auth:     auth,
 		DB:       db,
 		EC:       client,
+		NATS:     n,
 	})
 
 	// Construct a server to service the requests against the mux.
@@ -57,6 +57,8 @@ func TestUsers(t *testing.T) {
 	t.Run("deleteUserNotFound", tests.deleteUserNotFound)
 	t.Run("putUser404", tests.putUser404)
 	t.Run("crudUsers", tests.crudUser)
+	t.Run("getProfile200", tests.getProfile200)
+	t.Run("getProfile404", tests.getProfile404)
 }
 
 // getToken401 ensures an unknown user can't generate a token.
@@ -134,6 +136,11 @@ func (ut *UserTests) postUser400(t *testing.T) {
 			}
 			t.Logf("\t%s\tTest %d:\tShould receive a status code of 400 for the response.", dbtest.Success, testID)
 
+			if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Fatalf("\t%s\tTest %d:\tShould receive problem details : %s", dbtest.Failed, testID, ct)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive problem details.", dbtest.Success, testID)
+
 			var got v1Web.ErrorResponse
 			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the response to an error type : %v", dbtest.Failed, testID, err)
@@ -147,21 +154,47 @@ func (ut *UserTests) postUser400(t *testing.T) {
 				{Field: "password", Error: "password is a required field"},
 			}
 			exp := v1Web.ErrorResponse{
-				Error:  "data validation error",
+				Type:   v1Web.ProblemType + v1Web.CodeValidation,
+				Code:   v1Web.CodeValidation,
+				Title:  "data validation error",
+				Status: http.StatusBadRequest,
 				Fields: fields.Fields(),
 			}
 
 			// We can't rely on the order of the field errors so they have to be
-			// sorted. Tell the cmp package how to sort them.
+			// sorted. Tell the cmp package how to sort them. The instance is the
+			// trace ID of the request.
 			sorter := cmpopts.SortSlices(func(a, b validate.FieldError) bool {
 				return a.Field < b.Field
 			})
+			instance := cmpopts.IgnoreFields(v1Web.ErrorResponse{}, "Instance")
 
-			if diff := cmp.Diff(got, exp, sorter); diff != "" {
+			if diff := cmp.Diff(got, exp, sorter, instance); diff != "" {
 				t.Fatalf("\t%s\tTest %d:\tShould get the expected result. Diff:\n%s", dbtest.Failed, testID, diff)
 			}
 			t.Logf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Success, testID)
 		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen asking for the errors in croatian.", testID)
+		{
+			r := httptest.NewRequest(http.MethodPost, "/v1/users", bytes.NewBuffer(body))
+			w := httptest.NewRecorder()
+
+			r.Header.Set("Authorization", "Bearer "+ut.adminToken)
+			r.Header.Set("Accept-Language", "hr-HR,hr;q=0.9,en;q=0.8")
+			ut.app.ServeHTTP(w, r)
+
+			var got v1Web.ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the response to an error type : %v", dbtest.Failed, testID, err)
+			}
+
+			if msg := got.Fields["name"]; msg != "name je obavezno polje" {
+				t.Fatalf("\t%s\tTest %d:\tShould get the errors in croatian : %q.", dbtest.Failed, testID, msg)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the errors in croatian.", dbtest.Success, testID)
+		}
 	}
 }
 
@@ -239,12 +272,19 @@ func (ut *UserTests) getUser400(t *testing.T) {
 			}
 			t.Logf("\t%s\tTest %d:\tShould receive a status code of 400 for the response.", dbtest.Success, testID)
 
-			got := w.Body.String()
-			exp := `{"error":"ID is not in its proper form"}`
-			if got != exp {
-				t.Logf("\t\tTest %d:\tGot : %v", testID, got)
-				t.Logf("\t\tTest %d:\tExp: %v", testID, exp)
-				t.Fatalf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Failed, testID)
+			var got v1Web.ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the response to an error type : %v", dbtest.Failed, testID, err)
+			}
+
+			exp := v1Web.ErrorResponse{
+				Type:   v1Web.ProblemType + "invalid_id",
+				Code:   "invalid_id",
+				Title:  "ID is not in its proper form",
+				Status: http.StatusBadRequest,
+			}
+			if diff := cmp.Diff(got, exp, cmpopts.IgnoreFields(v1Web.ErrorResponse{}, "Instance")); diff != "" || got.Instance == "" {
+				t.Fatalf("\t%s\tTest %d:\tShould get the expected result. Diff:\n%s", dbtest.Failed, testID, diff)
 			}
 			t.Logf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Success, testID)
 		}
@@ -270,11 +310,12 @@ func (ut *UserTests) getUser403(t *testing.T) {
 			}
 			t.Logf("\t%s\tTest %d:\tShould receive a status code of 403 for the response.", dbtest.Success, testID)
 
-			recv := w.Body.String()
-			resp := `{"error":"attempted action is not allowed"}`
-			if resp != recv {
-				t.Log("Got :", recv)
-				t.Log("Want:", resp)
+			var got v1Web.ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the response to an error type : %v", dbtest.Failed, testID, err)
+			}
+			if got.Code != "forbidden" || got.Status != http.StatusForbidden {
+				t.Log("Got :", got)
 				t.Fatalf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Failed, testID)
 			}
 			t.Logf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Success, testID)
@@ -318,11 +359,13 @@ func (ut *UserTests) getUser404(t *testing.T) {
 			}
 			t.Logf("\t%s\tTest %d:\tShould receive a status code of 404 for the response.", dbtest.Success, testID)
 
-			got := w.Body.String()
-			exp := "not found"
-			if !strings.Contains(got, exp) {
-				t.Logf("\t\tTest %d:\tGot : %v", testID, got)
-				t.Logf("\t\tTest %d:\tExp: %v", testID, exp)
+			var got v1Web.ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the response to an error type : %v", dbtest.Failed, testID, err)
+			}
+			if got.Code != "user_not_found" {
+				t.Logf("\t\tTest %d:\tGot : %v", testID, got.Code)
+				t.Logf("\t\tTest %d:\tExp: %v", testID, "user_not_found")
 				t.Fatalf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Failed, testID)
 			}
 			t.Logf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Success, testID)
@@ -381,11 +424,13 @@ func (ut *UserTests) putUser404(t *testing.T) {
 			}
 			t.Logf("\t%s\tTest %d:\tShould receive a status code of 404 for the response.", dbtest.Success, testID)
 
-			got := w.Body.String()
-			exp := "not found"
-			if !strings.Contains(got, exp) {
-				t.Logf("\t\tTest %d:\tGot : %v", testID, got)
-				t.Logf("\t\tTest %d:\tExp: %v", testID, exp)
+			var got v1Web.ErrorResponse
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the response to an error type : %v", dbtest.Failed, testID, err)
+			}
+			if got.Code != "user_not_found" {
+				t.Logf("\t\tTest %d:\tGot : %v", testID, got.Code)
+				t.Logf("\t\tTest %d:\tExp: %v", testID, "user_not_found")
 				t.Fatalf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Failed, testID)
 			}
 			t.Logf("\t%s\tTest %d:\tShould get the expected result.", dbtest.Success, testID)
@@ -422,6 +467,7 @@ func (ut *UserTests) postUser201(t *testing.T) user.User {
 	w := httptest.NewRecorder()
 
 	r.Header.Set("Authorization", "Bearer "+ut.adminToken)
+	r.Header.Set("Accept-Language", "hr-HR,en;q=0.8")
 	ut.app.ServeHTTP(w, r)
 
 	// This needs to be returned for other dbtest.
@@ -447,6 +493,7 @@ func (ut *UserTests) postUser201(t *testing.T) user.User {
 			exp.Name = "Timon Dudaković"
 			exp.Email = "dudakovict@gmail.com"
 			exp.Roles = []string{auth.RoleAdmin}
+			exp.Locale = "hr"
 
 			if diff := cmp.Diff(got, exp); diff != "" {
 				t.Fatalf("\t%s\tTest %d:\tShould get the expected result. Diff:\n%s", dbtest.Failed, testID, diff)
@@ -589,3 +636,59 @@ func (ut *UserTests) putUser403(t *testing.T, id string) {
 		}
 	}
 }
+
+// getProfile200 validates a public profile can be retrieved without
+// authentication and does not expose the email of the user.
+func (ut *UserTests) getProfile200(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/profiles/@admin_gopher", nil)
+	w := httptest.NewRecorder()
+
+	ut.app.ServeHTTP(w, r)
+
+	t.Log("Given the need to validate getting a public profile.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using the seeded admin handle.", testID)
+		{
+			if w.Code != http.StatusOK {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 200 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 200 for the response.", dbtest.Success, testID)
+
+			if strings.Contains(w.Body.String(), "admin@example.com") {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT expose the email : %s", dbtest.Failed, testID, w.Body.String())
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT expose the email.", dbtest.Success, testID)
+
+			var got user.Profile
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the response : %v", dbtest.Failed, testID, err)
+			}
+
+			if got.Handle != "admin_gopher" || got.DisplayName != "Admin Gopher" {
+				t.Fatalf("\t%s\tTest %d:\tShould get the expected profile : %+v", dbtest.Failed, testID, got)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the expected profile.", dbtest.Success, testID)
+		}
+	}
+}
+
+// getProfile404 validates a profile request for a handle that does not exist.
+func (ut *UserTests) getProfile404(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/profiles/nobody_here", nil)
+	w := httptest.NewRecorder()
+
+	ut.app.ServeHTTP(w, r)
+
+	t.Log("Given the need to validate getting a profile with an unknown handle.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen using the handle nobody_here.", testID)
+		{
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("\t%s\tTest %d:\tShould receive a status code of 404 for the response : %v", dbtest.Failed, testID, w.Code)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive a status code of 404 for the response.", dbtest.Success, testID)
+		}
+	}
+}
@@ -42,7 +42,7 @@ func GenToken(log *zap.SugaredLogger, cfg database.Config, userID string, kid st
 
 	ec := email.NewEmailClient(conn)
 
-	user := user.NewCore(log, db, ec)
+	user := user.NewCore(log, db, nil, ec)
 
 	usr, err := user.QueryByID(ctx, userID)
 	if err != nil {
@@ -39,7 +39,7 @@ func UserAdd(log *zap.SugaredLogger, cfg database.Config, name, email, password
 
 	ec := em.NewEmailClient(conn)
 
-	core := user.NewCore(log, db, ec)
+	core := user.NewCore(log, db, nil, ec)
 
 	nu := user.NewUser{
 		Name:            name,
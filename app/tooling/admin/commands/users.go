@@ -46,7 +46,7 @@ func Users(log *zap.SugaredLogger, cfg database.Config, pageNumber string, rowsP
 
 	ec := email.NewEmailClient(conn)
 
-	user := user.NewCore(log, db, ec)
+	user := user.NewCore(log, db, nil, ec)
 
 	users, err := user.Query(ctx, page, rows)
 	if err != nil {
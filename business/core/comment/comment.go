@@ -4,15 +4,19 @@
 package comment
 
 import (
+	"bytes"
 	"context"
+	"encoding/gob"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/dudakovict/social-network/business/core/comment/db"
 	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/mention"
 	"github.com/dudakovict/social-network/business/sys/nats"
 	"github.com/dudakovict/social-network/business/sys/validate"
+	"github.com/dudakovict/social-network/foundation/diff"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
@@ -22,8 +26,19 @@ var (
 	ErrNotFound              = errors.New("comment not found")
 	ErrInvalidID             = errors.New("ID is not in its proper form")
 	ErrAuthenticationFailure = errors.New("authentication failed")
+	ErrPostNotFound          = errors.New("post not found")
+	ErrInvalidParent         = errors.New("parent comment does not belong to the post")
+	ErrRevisionNotFound      = errors.New("revision not found")
+	ErrRetentionExpired      = errors.New("comment can no longer be restored")
 )
 
+// TrashRetention is how long a deleted comment is kept in the trash before it
+// is purged for good.
+const TrashRetention = 30 * 24 * time.Hour
+
+// CurrentRevision stands for the comment as it is now when comparing revisions.
+const CurrentRevision = "current"
+
 // Core manages the set of API's for comment access.
 type Core struct {
 	store db.Store
@@ -37,40 +52,69 @@ func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS) Core {
 		nats:  nats,
 	}
 
-	l := Listener{
-		log:   log,
-		nats:  c.nats,
-		store: c.store,
-	}
-
-	// Listen on events
-	l.PostCreated()
-	l.PostUpdated()
-	l.PostDeleted()
-
 	return c
 }
 
-// Create inserts a new comment into the database.
+// Create inserts a new comment into the database. Users can only comment on
+// posts they are allowed to see.
 func (c Core) Create(ctx context.Context, nc NewComment, now time.Time) (Comment, error) {
 	if err := validate.Check(nc); err != nil {
 		return Comment{}, fmt.Errorf("validating data: %w", err)
 	}
 
+	dbP, err := c.store.QueryPostByID(ctx, nc.PostID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Comment{}, ErrPostNotFound
+		}
+		return Comment{}, fmt.Errorf("query post: %w", err)
+	}
+
+	// Commenting on a post the user can not see is reported as if the post
+	// does not exist so its existence is not revealed.
+	ok, err := c.canView(ctx, dbP, nc.UserID)
+	if err != nil {
+		return Comment{}, fmt.Errorf("can view: %w", err)
+	}
+	if !ok {
+		return Comment{}, ErrPostNotFound
+	}
+
+	if nc.ParentID != nil {
+		parent, err := c.store.QueryByID(ctx, *nc.ParentID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return Comment{}, ErrInvalidParent
+			}
+			return Comment{}, fmt.Errorf("query parent: %w", err)
+		}
+
+		if parent.PostID != nc.PostID {
+			return Comment{}, ErrInvalidParent
+		}
+	}
+
 	dbC := db.Comment{
 		ID:          validate.GenerateID(),
 		Description: nc.Description,
 		PostID:      nc.PostID,
 		UserID:      nc.UserID,
+		ParentID:    nc.ParentID,
 		DateCreated: now,
 		DateUpdated: now,
 	}
 
 	// This provides an example of how to execute a transaction if required.
+	var mentions []db.Mention
 	tran := func(tx sqlx.ExtContext) error {
 		if err := c.store.Tran(tx).Create(ctx, dbC); err != nil {
 			return fmt.Errorf("create: %w", err)
 		}
+
+		var err error
+		if mentions, _, err = c.mention(ctx, c.store.Tran(tx), dbC, now); err != nil {
+			return fmt.Errorf("mention: %w", err)
+		}
 		return nil
 	}
 
@@ -82,11 +126,20 @@ func (c Core) Create(ctx context.Context, nc NewComment, now time.Time) (Comment
 	// 	return Post{}, fmt.Errorf("create: %w", err)
 	// }
 
+	if err := c.publish("comment-created", dbC); err != nil {
+		return Comment{}, fmt.Errorf("pub: %w", err)
+	}
+
+	if err := c.notify(ctx, dbP, mentions); err != nil {
+		return Comment{}, fmt.Errorf("notify: %w", err)
+	}
+
 	return toComment(dbC), nil
 }
 
-// Update replaces a comment document in the database.
-func (c Core) Update(ctx context.Context, commentID string, uc UpdateComment, now time.Time) error {
+// Update replaces a comment document in the database. The version being
+// replaced is kept as a revision attributed to the editor.
+func (c Core) Update(ctx context.Context, commentID string, editorID string, uc UpdateComment, now time.Time) error {
 	if err := validate.CheckID(commentID); err != nil {
 		return ErrInvalidID
 	}
@@ -103,34 +156,180 @@ func (c Core) Update(ctx context.Context, commentID string, uc UpdateComment, no
 		return fmt.Errorf("updating comment commentID[%s]: %w", commentID, err)
 	}
 
+	dbRev := db.Revision{
+		ID:          validate.GenerateID(),
+		CommentID:   dbC.ID,
+		Description: dbC.Description,
+		EditorID:    editorID,
+		DateCreated: now,
+	}
+
 	if uc.Description != nil {
 		dbC.Description = *uc.Description
 	}
 	dbC.DateUpdated = now
+	dbC.Edited = true
+
+	var added []db.Mention
+	tran := func(tx sqlx.ExtContext) error {
+		if err := c.store.Tran(tx).CreateRevision(ctx, dbRev); err != nil {
+			return fmt.Errorf("create revision: %w", err)
+		}
+		if err := c.store.Tran(tx).Update(ctx, dbC); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
 
-	if err := c.store.Update(ctx, dbC); err != nil {
-		return fmt.Errorf("udpate: %w", err)
+		var err error
+		if _, added, err = c.mention(ctx, c.store.Tran(tx), dbC, now); err != nil {
+			return fmt.Errorf("mention: %w", err)
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	// Only the users mentioned by the edit are told about it.
+	if len(added) > 0 {
+		dbP, err := c.store.QueryPostByID(ctx, dbC.PostID)
+		if err != nil {
+			return fmt.Errorf("query post: %w", err)
+		}
+
+		if err := c.notify(ctx, dbP, added); err != nil {
+			return fmt.Errorf("notify: %w", err)
+		}
 	}
 
 	return nil
 }
 
-// Delete removes a comment from the database.
-func (c Core) Delete(ctx context.Context, commentID string) error {
+// Delete moves a comment into the trash. It can be restored until it is
+// purged once the TrashRetention period is over.
+func (c Core) Delete(ctx context.Context, commentID string, now time.Time) error {
 	if err := validate.CheckID(commentID); err != nil {
 		return ErrInvalidID
 	}
 
-	if err := c.store.Delete(ctx, commentID); err != nil {
+	dbC, err := c.store.QueryByID(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("deleting comment commentID[%s]: %w", commentID, err)
+	}
+
+	dbC.DeletedAt = &now
+
+	if err := c.store.Delete(ctx, dbC); err != nil {
 		return fmt.Errorf("delete: %w", err)
 	}
 
+	if err := c.publish("comment-deleted", dbC); err != nil {
+		return fmt.Errorf("pub: %w", err)
+	}
+
+	return nil
+}
+
+// Restore takes a comment out of the trash as long as it was deleted within
+// the TrashRetention period and the post it was made on still exists.
+func (c Core) Restore(ctx context.Context, commentID string, now time.Time) error {
+	if err := validate.CheckID(commentID); err != nil {
+		return ErrInvalidID
+	}
+
+	dbC, err := c.store.QueryDeletedByID(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("restoring comment commentID[%s]: %w", commentID, err)
+	}
+
+	if dbC.DeletedAt.Before(now.Add(-TrashRetention)) {
+		return ErrRetentionExpired
+	}
+
+	if _, err := c.store.QueryPostByID(ctx, dbC.PostID); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrPostNotFound
+		}
+		return fmt.Errorf("query post: %w", err)
+	}
+
+	if err := c.store.Restore(ctx, commentID); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+
+	dbC.DeletedAt = nil
+	if err := c.publish("comment-restored", dbC); err != nil {
+		return fmt.Errorf("pub: %w", err)
+	}
+
 	return nil
 }
 
-// Query retrieves a list of existing comments from the database.
-func (c Core) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Comment, error) {
-	dbComments, err := c.store.Query(ctx, pageNumber, rowsPerPage)
+// Purge permanently removes the comments and posts that have been in the
+// trash for longer than the TrashRetention period. Comments stay in the trash
+// while they have replies, so purging them never takes the replies along.
+func (c Core) Purge(ctx context.Context, now time.Time) error {
+	before := now.Add(-TrashRetention)
+
+	tran := func(tx sqlx.ExtContext) error {
+		if err := c.store.Tran(tx).Purge(ctx, before); err != nil {
+			return fmt.Errorf("purge comments: %w", err)
+		}
+		if err := c.store.Tran(tx).PurgePosts(ctx, before); err != nil {
+			return fmt.Errorf("purge posts: %w", err)
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// QueryDeletedByID gets the specified comment from the trash.
+func (c Core) QueryDeletedByID(ctx context.Context, commentID string) (Comment, error) {
+	if err := validate.CheckID(commentID); err != nil {
+		return Comment{}, ErrInvalidID
+	}
+
+	dbC, err := c.store.QueryDeletedByID(ctx, commentID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Comment{}, ErrNotFound
+		}
+		return Comment{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toComment(dbC), nil
+}
+
+// QueryTrash retrieves the comments of the specified user that are in the
+// trash.
+func (c Core) QueryTrash(ctx context.Context, userID string) ([]Comment, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	dbComments, err := c.store.QueryDeletedByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toCommentSlice(dbComments), nil
+}
+
+// Query retrieves a list of existing comments made on posts the user is
+// allowed to see.
+func (c Core) Query(ctx context.Context, userID string, pageNumber int, rowsPerPage int) ([]Comment, error) {
+	dbComments, err := c.store.Query(ctx, userID, pageNumber, rowsPerPage)
 	if err != nil {
 		if errors.Is(err, database.ErrDBNotFound) {
 			return nil, ErrNotFound
@@ -158,6 +357,25 @@ func (c Core) QueryByID(ctx context.Context, commentID string) (Comment, error)
 	return toComment(dbC), nil
 }
 
+// QueryVisibleByID gets the specified comment as long as the user is allowed
+// to see the post it was made on. Comments on posts the user can not see are
+// reported as not found.
+func (c Core) QueryVisibleByID(ctx context.Context, commentID string, userID string) (Comment, error) {
+	cm, err := c.QueryByID(ctx, commentID)
+	if err != nil {
+		return Comment{}, err
+	}
+
+	if err := c.CheckVisible(ctx, cm.PostID, userID); err != nil {
+		if errors.Is(err, ErrPostNotFound) {
+			return Comment{}, ErrNotFound
+		}
+		return Comment{}, fmt.Errorf("check visible: %w", err)
+	}
+
+	return cm, nil
+}
+
 func (c Core) QueryByUserID(ctx context.Context, userID string) ([]Comment, error) {
 	if err := validate.CheckID(userID); err != nil {
 		return nil, ErrInvalidID
@@ -186,16 +404,328 @@ func (c Core) QueryByPostID(ctx context.Context, postID string) ([]Comment, erro
 	return toCommentSlice(dbComments), nil
 }
 
-func (c Core) QueryPostsByPostID(ctx context.Context, postID string) (interface{}, error) {
+// QueryRevisions retrieves the previous versions of the specified comment
+// from the newest to the oldest.
+func (c Core) QueryRevisions(ctx context.Context, commentID string) ([]Revision, error) {
+	if _, err := c.QueryByID(ctx, commentID); err != nil {
+		return nil, err
+	}
+
+	dbRevs, err := c.store.QueryRevisions(ctx, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toRevisionSlice(dbRevs), nil
+}
+
+// QueryRevisionByID gets the specified revision of a comment from the database.
+func (c Core) QueryRevisionByID(ctx context.Context, commentID string, revisionID string) (Revision, error) {
+	if err := validate.CheckID(commentID); err != nil {
+		return Revision{}, ErrInvalidID
+	}
+	if err := validate.CheckID(revisionID); err != nil {
+		return Revision{}, ErrInvalidID
+	}
+
+	dbRev, err := c.store.QueryRevisionByID(ctx, commentID, revisionID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Revision{}, ErrRevisionNotFound
+		}
+		return Revision{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toRevision(dbRev), nil
+}
+
+// DiffRevisions computes the differences between two revisions of a
+// comment. Either revision can be CurrentRevision to compare with the comment
+// as it is now.
+func (c Core) DiffRevisions(ctx context.Context, commentID string, fromID string, toID string) (RevisionDiff, error) {
+	from, err := c.revision(ctx, commentID, fromID)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+
+	to, err := c.revision(ctx, commentID, toID)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+
+	rd := RevisionDiff{
+		From:        from.ID,
+		To:          to.ID,
+		Description: diff.Lines(from.Description, to.Description),
+	}
+
+	return rd, nil
+}
+
+// revision gets the specified revision of a comment, or the comment as it is
+// now for CurrentRevision.
+func (c Core) revision(ctx context.Context, commentID string, revisionID string) (Revision, error) {
+	if revisionID != CurrentRevision {
+		return c.QueryRevisionByID(ctx, commentID, revisionID)
+	}
+
+	cm, err := c.QueryByID(ctx, commentID)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	rev := Revision{
+		ID:          CurrentRevision,
+		CommentID:   cm.ID,
+		Description: cm.Description,
+		EditorID:    cm.UserID,
+		DateCreated: cm.DateUpdated,
+	}
+
+	return rev, nil
+}
+
+// QueryPostWithComments gets the specified post along with a page of its
+// comments and the total number of comments made on it, as long as the user
+// is allowed to see the post. When threaded is true the page is made up of top
+// level comments with their replies nested inside.
+func (c Core) QueryPostWithComments(ctx context.Context, postID string, userID string, pageNumber int, rowsPerPage int, threaded bool) (PostWithComments, error) {
 	if err := validate.CheckID(postID); err != nil {
-		return nil, ErrInvalidID
+		return PostWithComments{}, ErrInvalidID
 	}
 
-	dbPosts, err := c.store.QueryPostsByPostID(ctx, postID)
+	dbP, err := c.store.QueryPostByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return PostWithComments{}, ErrPostNotFound
+		}
+		return PostWithComments{}, fmt.Errorf("query post: %w", err)
+	}
+
+	ok, err := c.canView(ctx, dbP, userID)
+	if err != nil {
+		return PostWithComments{}, fmt.Errorf("can view: %w", err)
+	}
+	if !ok {
+		return PostWithComments{}, ErrPostNotFound
+	}
+
+	dbComments, err := c.store.QueryPageByPostID(ctx, postID, threaded, pageNumber, rowsPerPage)
+	if err != nil {
+		return PostWithComments{}, fmt.Errorf("query comments: %w", err)
+	}
+
+	var dbReplies []db.Comment
+	if threaded && len(dbComments) > 0 {
+		ids := make([]string, len(dbComments))
+		for i, dbC := range dbComments {
+			ids[i] = dbC.ID
+		}
+
+		if dbReplies, err = c.store.QueryReplies(ctx, ids); err != nil {
+			return PostWithComments{}, fmt.Errorf("query replies: %w", err)
+		}
+	}
+
+	count, err := c.store.CountByPostID(ctx, postID)
+	if err != nil {
+		return PostWithComments{}, fmt.Errorf("count: %w", err)
+	}
+
+	ids := make([]string, 0, len(dbComments)+len(dbReplies))
+	for _, dbC := range dbComments {
+		ids = append(ids, dbC.ID)
+	}
+	for _, dbC := range dbReplies {
+		ids = append(ids, dbC.ID)
+	}
+
+	var dbMentions []db.Mention
+	if len(ids) > 0 {
+		if dbMentions, err = c.store.QueryMentions(ctx, ids); err != nil {
+			return PostWithComments{}, fmt.Errorf("query mentions: %w", err)
+		}
+	}
 
+	pwc := PostWithComments{
+		Post:         toPost(dbP),
+		Comments:     toCommentThreads(dbComments, dbReplies, toMentionMap(dbMentions)),
+		CommentCount: count,
+	}
+
+	return pwc, nil
+}
+
+// QueryMentions gets the mentions of the specified comments keyed by comment
+// ID.
+func (c Core) QueryMentions(ctx context.Context, commentIDs ...string) (map[string][]Mention, error) {
+	if len(commentIDs) == 0 {
+		return make(map[string][]Mention), nil
+	}
+
+	dbMentions, err := c.store.QueryMentions(ctx, commentIDs)
 	if err != nil {
 		return nil, fmt.Errorf("query: %w", err)
 	}
 
-	return toPostCommentSlice(dbPosts), nil
+	return toMentionMap(dbMentions), nil
+}
+
+// CheckVisible returns ErrPostNotFound unless the post exists and the user is
+// allowed to see it.
+func (c Core) CheckVisible(ctx context.Context, postID string, userID string) error {
+	if err := validate.CheckID(postID); err != nil {
+		return ErrInvalidID
+	}
+
+	dbP, err := c.store.QueryPostByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrPostNotFound
+		}
+		return fmt.Errorf("query post: %w", err)
+	}
+
+	ok, err := c.canView(ctx, dbP, userID)
+	if err != nil {
+		return fmt.Errorf("can view: %w", err)
+	}
+	if !ok {
+		return ErrPostNotFound
+	}
+
+	return nil
+}
+
+// canView reports whether the user is allowed to see the post. Authors see
+// all of their posts, everyone else only sees public posts or followers only
+// posts of users they follow.
+func (c Core) canView(ctx context.Context, dbP db.Post, userID string) (bool, error) {
+	if dbP.UserID == userID {
+		return true, nil
+	}
+
+	switch dbP.Visibility {
+	case VisibilityPublic:
+		return true, nil
+	case VisibilityFollowers:
+		return c.store.IsFollower(ctx, dbP.UserID, userID)
+	}
+
+	return false, nil
+}
+
+// mention resolves the handles mentioned in the description of the comment
+// and stores the mentions, replacing the previous ones. Authors mentioning
+// themselves and handles nobody goes by are ignored. It returns all the
+// mentions along with those of users the comment did not mention before.
+func (c Core) mention(ctx context.Context, store db.Store, dbC db.Comment, now time.Time) ([]db.Mention, []db.Mention, error) {
+	previous, err := store.QueryMentions(ctx, []string{dbC.ID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("query mentions: %w", err)
+	}
+
+	mentioned := make(map[string]bool)
+	for _, dbM := range previous {
+		mentioned[dbM.UserID] = true
+	}
+
+	if err := store.DeleteMentions(ctx, dbC.ID); err != nil {
+		return nil, nil, fmt.Errorf("delete mentions: %w", err)
+	}
+
+	parsed := mention.Parse(dbC.Description)
+	if len(parsed) == 0 {
+		return nil, nil, nil
+	}
+
+	dbHandles, err := store.QueryHandles(ctx, mention.Handles(parsed))
+	if err != nil {
+		return nil, nil, fmt.Errorf("query handles: %w", err)
+	}
+
+	userIDs := make(map[string]string)
+	for _, dbH := range dbHandles {
+		userIDs[dbH.Handle] = dbH.UserID
+	}
+
+	var mentions, added []db.Mention
+	notified := make(map[string]bool)
+	for _, m := range parsed {
+		userID, exists := userIDs[m.Handle]
+		if !exists || userID == dbC.UserID {
+			continue
+		}
+
+		dbM := db.Mention{
+			CommentID:   dbC.ID,
+			PostID:      dbC.PostID,
+			UserID:      userID,
+			AuthorID:    dbC.UserID,
+			Handle:      m.Handle,
+			Start:       m.Start,
+			End:         m.End,
+			DateCreated: now,
+		}
+		if err := store.CreateMention(ctx, dbM); err != nil {
+			return nil, nil, fmt.Errorf("create mention: %w", err)
+		}
+
+		mentions = append(mentions, dbM)
+		if !mentioned[userID] && !notified[userID] {
+			notified[userID] = true
+			added = append(added, dbM)
+		}
+	}
+
+	return mentions, added, nil
+}
+
+// notify publishes a user-mentioned event for every user mentioned in the
+// comment that is allowed to see the post. Users mentioned more than once
+// are only told once.
+func (c Core) notify(ctx context.Context, dbP db.Post, mentions []db.Mention) error {
+	seen := make(map[string]bool)
+	for _, dbM := range mentions {
+		if seen[dbM.UserID] {
+			continue
+		}
+		seen[dbM.UserID] = true
+
+		ok, err := c.canView(ctx, dbP, dbM.UserID)
+		if err != nil {
+			return fmt.Errorf("can view: %w", err)
+		}
+		if !ok {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(&dbM); err != nil {
+			return fmt.Errorf("encoding: %w", err)
+		}
+
+		if err := c.nats.Client.Publish("user-mentioned", buf.Bytes()); err != nil {
+			return fmt.Errorf("publishing user-mentioned: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// publish sends the comment to the services listening on the specified
+// subject.
+func (c Core) publish(subject string, dbC db.Comment) error {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	if err := enc.Encode(&dbC); err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+
+	if err := c.nats.Client.Publish(subject, buf.Bytes()); err != nil {
+		return fmt.Errorf("publishing %s: %w", subject, err)
+	}
+
+	return nil
 }
@@ -51,9 +51,13 @@ func TestComment(t *testing.T) {
 			ctx := context.Background()
 			now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
 
+			if err := dbschema.Seed(ctx, db); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to seed the database : %s.", dbtest.Failed, testID, err)
+			}
+
 			nc := comment.NewComment{
 				Description: "Check out my new song!",
-				PostID:      "45b5fbd3-755f-4379-8f07-a58d4a30fa2f",
+				PostID:      "3dc0a440-2e05-11ed-a261-0242ac120002",
 				UserID:      "45b5fbd3-755f-4379-8f07-a58d4a30fa2f",
 			}
 
@@ -84,7 +88,7 @@ func TestComment(t *testing.T) {
 				Description: dbtest.StringPointer("I've just released a new album!"),
 			}
 
-			if err := core.Update(ctx, c.ID, upd, now); err != nil {
+			if err := core.Update(ctx, c.ID, c.UserID, upd, now); err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to update comment : %s.", dbtest.Failed, testID, err)
 			}
 			t.Logf("\t%s\tTest %d:\tShould be able to update comment.", dbtest.Success, testID)
@@ -103,11 +107,64 @@ func TestComment(t *testing.T) {
 				t.Logf("\t%s\tTest %d:\tShould be able to see updates to Description.", dbtest.Success, testID)
 			}
 
-			if err := core.Delete(ctx, c.ID); err != nil {
+			if !saved.Edited {
+				t.Errorf("\t%s\tTest %d:\tShould be able to see the comment marked as edited.", dbtest.Failed, testID)
+			} else {
+				t.Logf("\t%s\tTest %d:\tShould be able to see the comment marked as edited.", dbtest.Success, testID)
+			}
+
+			revs, err := core.QueryRevisions(ctx, c.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve comment revisions : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve comment revisions.", dbtest.Success, testID)
+
+			if len(revs) != 1 || revs[0].Description != c.Description || revs[0].EditorID != c.UserID {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the original comment as a revision : %+v.", dbtest.Failed, testID, revs)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back the original comment as a revision.", dbtest.Success, testID)
+
+			if err := core.Delete(ctx, c.ID, now); err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to delete comment : %s.", dbtest.Failed, testID, err)
 			}
 			t.Logf("\t%s\tTest %d:\tShould be able to delete comment.", dbtest.Success, testID)
 
+			trash, err := core.QueryTrash(ctx, c.UserID)
+			if err != nil || len(trash) != 1 || trash[0].ID != c.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to find the comment in the trash : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to find the comment in the trash.", dbtest.Success, testID)
+
+			late := now.Add(comment.TrashRetention + time.Hour)
+			if err := core.Restore(ctx, c.ID, late); !errors.Is(err, comment.ErrRetentionExpired) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to restore comment after retention : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to restore comment after retention.", dbtest.Success, testID)
+
+			if err := core.Restore(ctx, c.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to restore comment : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to restore comment.", dbtest.Success, testID)
+
+			if _, err := core.QueryByID(ctx, c.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve restored comment : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve restored comment.", dbtest.Success, testID)
+
+			if err := core.Delete(ctx, c.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete comment : %s.", dbtest.Failed, testID, err)
+			}
+
+			if err := core.Purge(ctx, late); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to purge the trash : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to purge the trash.", dbtest.Success, testID)
+
+			if _, err := core.QueryDeletedByID(ctx, c.ID); !errors.Is(err, comment.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to find purged comment : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to find purged comment.", dbtest.Success, testID)
+
 			_, err = core.QueryByID(ctx, c.ID)
 			if !errors.Is(err, comment.ErrNotFound) {
 				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to retrieve comment : %s.", dbtest.Failed, testID, err)
@@ -129,6 +186,148 @@ func TestComment(t *testing.T) {
 	}
 }
 
+func TestPurgeReplies(t *testing.T) {
+	log, db, n, teardown := dbtest.NewUnit(t, nc, dbc, "testpurgereplies")
+	t.Cleanup(teardown)
+
+	core := comment.NewCore(log, db, n)
+
+	t.Log("Given the need to purge the trash without losing replies.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen purging a comment that has replies.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+			late := now.Add(comment.TrashRetention + time.Hour)
+
+			if err := dbschema.Seed(ctx, db); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to seed the database : %s.", dbtest.Failed, testID, err)
+			}
+
+			parent, err := core.Create(ctx, comment.NewComment{
+				Description: "Check out my new song!",
+				PostID:      "3dc0a440-2e05-11ed-a261-0242ac120002",
+				UserID:      "45b5fbd3-755f-4379-8f07-a58d4a30fa2f",
+			}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create comment : %s.", dbtest.Failed, testID, err)
+			}
+
+			reply, err := core.Create(ctx, comment.NewComment{
+				Description: "Love it!",
+				PostID:      parent.PostID,
+				UserID:      "5cf37266-3473-4006-984f-9325122678b7",
+				ParentID:    &parent.ID,
+			}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to reply to the comment : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a comment with a reply.", dbtest.Success, testID)
+
+			if err := core.Delete(ctx, parent.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete comment : %s.", dbtest.Failed, testID, err)
+			}
+
+			if err := core.Purge(ctx, late); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to purge the trash : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to purge the trash.", dbtest.Success, testID)
+
+			if _, err := core.QueryByID(ctx, reply.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould still be able to retrieve the reply : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould still be able to retrieve the reply.", dbtest.Success, testID)
+
+			if _, err := core.QueryDeletedByID(ctx, parent.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould keep the comment in the trash while it has replies : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould keep the comment in the trash while it has replies.", dbtest.Success, testID)
+
+			if err := core.Delete(ctx, reply.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete the reply : %s.", dbtest.Failed, testID, err)
+			}
+
+			for i := 0; i < 2; i++ {
+				if err := core.Purge(ctx, late); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to purge the trash : %s.", dbtest.Failed, testID, err)
+				}
+			}
+
+			for _, id := range []string{reply.ID, parent.ID} {
+				if _, err := core.QueryDeletedByID(ctx, id); !errors.Is(err, comment.ErrNotFound) {
+					t.Fatalf("\t%s\tTest %d:\tShould purge the comment once its replies are gone : %v.", dbtest.Failed, testID, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould purge the comment once its replies are gone.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestCommentVisibility(t *testing.T) {
+	log, db, n, teardown := dbtest.NewUnit(t, nc, dbc, "testvisibility")
+	t.Cleanup(teardown)
+
+	core := comment.NewCore(log, db, n)
+
+	t.Log("Given the need to only comment on visible posts.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen commenting on restricted posts.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+			postID := "3dc0a440-2e05-11ed-a261-0242ac120002"
+			authorID := "5cf37266-3473-4006-984f-9325122678b7"
+			userID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+			if err := dbschema.Seed(ctx, db); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to seed the database : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := db.ExecContext(ctx, `UPDATE posts SET visibility = 'followers' WHERE post_id = $1`, postID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to restrict the post : %s.", dbtest.Failed, testID, err)
+			}
+
+			nc := comment.NewComment{
+				Description: "Great song!",
+				PostID:      postID,
+				UserID:      userID,
+			}
+
+			if _, err := core.Create(ctx, nc, now); !errors.Is(err, comment.ErrPostNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to comment on a followers only post : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to comment on a followers only post.", dbtest.Success, testID)
+
+			const q = `INSERT INTO followers (user_id, follower_id, date_created) VALUES ($1, $2, $3)`
+			if _, err := db.ExecContext(ctx, q, authorID, userID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to follow the author : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.Create(ctx, nc, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to comment on a followers only post as a follower : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to comment on a followers only post as a follower.", dbtest.Success, testID)
+
+			if _, err := db.ExecContext(ctx, `UPDATE posts SET visibility = 'private' WHERE post_id = $1`, postID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to make the post private : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.Create(ctx, nc, now); !errors.Is(err, comment.ErrPostNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to comment on a private post : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to comment on a private post.", dbtest.Success, testID)
+
+			nc.UserID = authorID
+			if _, err := core.Create(ctx, nc, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to comment on own private post : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to comment on own private post.", dbtest.Success, testID)
+		}
+	}
+}
+
 func TestPagingComment(t *testing.T) {
 	log, db, n, teardown := dbtest.NewUnit(t, nc, dbc, "testpaging")
 	t.Cleanup(teardown)
@@ -146,8 +345,9 @@ func TestPagingComment(t *testing.T) {
 		t.Logf("\tTest %d:\tWhen paging through 2 comments.", testID)
 		{
 			ctx := context.Background()
+			viewerID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
 
-			comments1, err := comment.Query(ctx, 1, 1)
+			comments1, err := comment.Query(ctx, viewerID, 1, 1)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve comments for page 1 : %s.", dbtest.Failed, testID, err)
 			}
@@ -158,7 +358,7 @@ func TestPagingComment(t *testing.T) {
 			}
 			t.Logf("\t%s\tTest %d:\tShould have a single comment.", dbtest.Success, testID)
 
-			comments2, err := comment.Query(ctx, 2, 1)
+			comments2, err := comment.Query(ctx, viewerID, 2, 1)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve comments for page 2 : %s.", dbtest.Failed, testID, err)
 			}
@@ -178,3 +378,100 @@ func TestPagingComment(t *testing.T) {
 		}
 	}
 }
+
+func TestPostWithComments(t *testing.T) {
+	log, db, n, teardown := dbtest.NewUnit(t, nc, dbc, "testpostwithcomments")
+	t.Cleanup(teardown)
+
+	core := comment.NewCore(log, db, n)
+
+	t.Log("Given the need to retrieve a post along with its comments.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a post with a reply to a comment.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+			const postID = "3dc0a440-2e05-11ed-a261-0242ac120002"
+			const parentID = "7f6edd62-2e05-11ed-a261-0242ac120002"
+
+			nc := comment.NewComment{
+				Description: "Thanks!",
+				PostID:      postID,
+				UserID:      "5cf37266-3473-4006-984f-9325122678b7",
+				ParentID:    dbtest.StringPointer(parentID),
+			}
+
+			reply, err := core.Create(ctx, nc, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to reply to a comment : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to reply to a comment.", dbtest.Success, testID)
+
+			nc.PostID = "47d0e86e-2e05-11ed-a261-0242ac120002"
+			if _, err := core.Create(ctx, nc, now); !errors.Is(err, comment.ErrInvalidParent) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to reply to a comment on another post : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to reply to a comment on another post.", dbtest.Success, testID)
+
+			flat, err := core.QueryPostWithComments(ctx, postID, nc.UserID, 1, 10, false)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve the post with flat comments : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve the post with flat comments.", dbtest.Success, testID)
+
+			if flat.CommentCount != 2 || len(flat.Comments) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould get back 2 flat comments : count[%d] len[%d].", dbtest.Failed, testID, flat.CommentCount, len(flat.Comments))
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back 2 flat comments.", dbtest.Success, testID)
+
+			threaded, err := core.QueryPostWithComments(ctx, postID, nc.UserID, 1, 10, true)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve the post with threaded comments : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve the post with threaded comments.", dbtest.Success, testID)
+
+			if threaded.CommentCount != 2 || len(threaded.Comments) != 1 || len(threaded.Comments[0].Replies) != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould get back a single thread with a single reply : %+v.", dbtest.Failed, testID, threaded)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back a single thread with a single reply.", dbtest.Success, testID)
+
+			if diff := cmp.Diff(reply, threaded.Comments[0].Replies[0].Comment); diff != "" {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the same reply. Diff:\n%s", dbtest.Failed, testID, diff)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back the same reply.", dbtest.Success, testID)
+
+			empty, err := core.QueryPostWithComments(ctx, postID, nc.UserID, 2, 10, true)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve an empty page of comments : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve an empty page of comments.", dbtest.Success, testID)
+
+			if empty.Post.ID != postID || len(empty.Comments) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould still get back the post without comments : %+v.", dbtest.Failed, testID, empty)
+			}
+			t.Logf("\t%s\tTest %d:\tShould still get back the post without comments.", dbtest.Success, testID)
+
+			if _, err := db.ExecContext(ctx, `UPDATE posts SET visibility = 'private' WHERE post_id = $1`, postID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to make the post private : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.QueryPostWithComments(ctx, postID, reply.UserID, 1, 10, false); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve own private post : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve own private post.", dbtest.Success, testID)
+
+			const viewerID = "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+			if _, err := core.QueryPostWithComments(ctx, postID, viewerID, 1, 10, false); !errors.Is(err, comment.ErrPostNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to retrieve someone else's private post : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to retrieve someone else's private post.", dbtest.Success, testID)
+
+			if _, err := core.QueryVisibleByID(ctx, parentID, viewerID); !errors.Is(err, comment.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to retrieve a comment on someone else's private post : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to retrieve a comment on someone else's private post.", dbtest.Success, testID)
+		}
+	}
+}
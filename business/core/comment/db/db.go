@@ -4,9 +4,11 @@ package db
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/dudakovict/social-network/business/sys/database"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -49,9 +51,9 @@ func (s Store) Tran(tx sqlx.ExtContext) Store {
 func (s Store) Create(ctx context.Context, c Comment) error {
 	const q = `
 	INSERT INTO comments
-		(comment_id, description, post_id, user_id, date_created, date_updated)
+		(comment_id, description, post_id, user_id, parent_id, date_created, date_updated)
 	VALUES
-		(:comment_id, :description, :post_id, :user_id, :date_created, :date_updated)`
+		(:comment_id, :description, :post_id, :user_id, :parent_id, :date_created, :date_updated)`
 
 	if err := database.NamedExecContext(ctx, s.log, s.db, q, c); err != nil {
 		return fmt.Errorf("inserting comment: %w", err)
@@ -67,7 +69,8 @@ func (s Store) Update(ctx context.Context, c Comment) error {
 		comments
 	SET 
 		"description" = :description,
-		"date_updated" = :date_updated
+		"date_updated" = :date_updated,
+		"edited" = :edited
 	WHERE
 		comment_id = :comment_id`
 
@@ -78,8 +81,27 @@ func (s Store) Update(ctx context.Context, c Comment) error {
 	return nil
 }
 
-// Delete removes a comment from the database.
-func (s Store) Delete(ctx context.Context, commentID string) error {
+// Delete marks a comment as deleted in the database. The comment stays in
+// the trash until it is restored or purged.
+func (s Store) Delete(ctx context.Context, c Comment) error {
+	const q = `
+	UPDATE
+		comments
+	SET
+		"deleted_at" = :deleted_at
+	WHERE
+		comment_id = :comment_id AND
+		deleted_at IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, c); err != nil {
+		return fmt.Errorf("deleting commentID[%s]: %w", c.ID, err)
+	}
+
+	return nil
+}
+
+// Restore takes a comment out of the trash.
+func (s Store) Restore(ctx context.Context, commentID string) error {
 	data := struct {
 		CommentID string `db:"comment_id"`
 	}{
@@ -87,26 +109,73 @@ func (s Store) Delete(ctx context.Context, commentID string) error {
 	}
 
 	const q = `
-	DELETE FROM
+	UPDATE
 		comments
+	SET
+		"deleted_at" = NULL
 	WHERE
 		comment_id = :comment_id`
 
 	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
-		return fmt.Errorf("deleting commentID[%s]: %w", commentID, err)
+		return fmt.Errorf("restoring commentID[%s]: %w", commentID, err)
 	}
 
 	return nil
 }
 
-// Query retrieves a list of existing comments from the database.
-func (s Store) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Comment, error) {
+// Purge removes the comments that were deleted before the specified time
+// from the database for good. Comments that still have replies are kept, so
+// deleting them does not cascade to the replies. They are purged once their
+// replies are gone, which takes a run per level for trashed replies.
+func (s Store) Purge(ctx context.Context, before time.Time) error {
 	data := struct {
-		Offset      int `db:"offset"`
-		RowsPerPage int `db:"rows_per_page"`
+		Before time.Time `db:"before"`
 	}{
-		Offset:      (pageNumber - 1) * rowsPerPage,
-		RowsPerPage: rowsPerPage,
+		Before: before,
+	}
+
+	const q = `
+	DELETE FROM
+		comments AS c
+	WHERE
+		c.deleted_at < :before AND
+		NOT EXISTS (SELECT 1 FROM comments AS r WHERE r.parent_id = c.comment_id)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("purging comments: %w", err)
+	}
+
+	return nil
+}
+
+// PurgePosts removes the posts that were deleted before the specified time
+// from the database for good along with their comments.
+func (s Store) PurgePosts(ctx context.Context, before time.Time) error {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before,
+	}
+
+	const q = `
+	DELETE FROM
+		posts
+	WHERE
+		deleted_at < :before`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("purging posts: %w", err)
+	}
+
+	return nil
+}
+
+// QueryDeletedByID gets the specified comment from the trash.
+func (s Store) QueryDeletedByID(ctx context.Context, commentID string) (Comment, error) {
+	data := struct {
+		CommentID string `db:"comment_id"`
+	}{
+		CommentID: commentID,
 	}
 
 	const q = `
@@ -114,8 +183,82 @@ func (s Store) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Co
 		*
 	FROM
 		comments
+	WHERE
+		comment_id = :comment_id AND
+		deleted_at IS NOT NULL`
+
+	var c Comment
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &c); err != nil {
+		return Comment{}, fmt.Errorf("selecting deleted commentID[%q]: %w", commentID, err)
+	}
+
+	return c, nil
+}
+
+// QueryDeletedByUserID retrieves the comments of the specified user that are
+// in the trash, most recently deleted first. Comments that were deleted
+// along with their post are left out since they come back with the post.
+func (s Store) QueryDeletedByUserID(ctx context.Context, userID string) ([]Comment, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		c.*
+	FROM
+		comments AS c
+	JOIN
+		posts AS p ON p.post_id = c.post_id
+	WHERE
+		c.user_id = :user_id AND
+		c.deleted_at IS NOT NULL AND
+		p.deleted_at IS NULL
+	ORDER BY
+		c.deleted_at DESC`
+
+	var comms []Comment
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &comms); err != nil {
+		return nil, fmt.Errorf("selecting deleted comments userID[%s]: %w", userID, err)
+	}
+
+	return comms, nil
+}
+
+// Query retrieves a list of existing comments made on posts the viewer is
+// allowed to see: public posts, their own posts and the followers only posts
+// of the users they follow.
+func (s Store) Query(ctx context.Context, viewerID string, pageNumber int, rowsPerPage int) ([]Comment, error) {
+	data := struct {
+		ViewerID    string `db:"viewer_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		ViewerID:    viewerID,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		c.*
+	FROM
+		comments AS c
+	JOIN
+		posts AS p ON p.post_id = c.post_id
+	WHERE
+		c.deleted_at IS NULL AND
+		p.deleted_at IS NULL AND (
+			p.visibility = 'public' OR
+			p.user_id = :viewer_id OR
+			(p.visibility = 'followers' AND EXISTS (
+				SELECT 1 FROM followers f WHERE f.user_id = p.user_id AND f.follower_id = :viewer_id
+			))
+		)
 	ORDER BY
-		comment_id
+		c.comment_id
 	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
 
 	var comms []Comment
@@ -140,7 +283,8 @@ func (s Store) QueryByID(ctx context.Context, commentID string) (Comment, error)
 	FROM
 		comments
 	WHERE 
-		comment_id = :comment_id`
+		comment_id = :comment_id AND
+		deleted_at IS NULL`
 
 	var c Comment
 	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &c); err != nil {
@@ -163,7 +307,8 @@ func (s Store) QueryByUserID(ctx context.Context, userID string) ([]Comment, err
 	FROM
 		comments
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND
+		deleted_at IS NULL`
 
 	var comms []Comment
 	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &comms); err != nil {
@@ -186,7 +331,8 @@ func (s Store) QueryByPostID(ctx context.Context, postID string) ([]Comment, err
 	FROM
 		comments
 	WHERE
-		post_id = :post_id`
+		post_id = :post_id AND
+		deleted_at IS NULL`
 
 	var comms []Comment
 	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &comms); err != nil {
@@ -199,9 +345,9 @@ func (s Store) QueryByPostID(ctx context.Context, postID string) ([]Comment, err
 func (s Store) CreatePost(ctx context.Context, p Post) error {
 	const q = `
 	INSERT INTO posts
-		(post_id, title, description, user_id, date_created, date_updated)
+		(post_id, title, description, user_id, date_created, date_updated, visibility)
 	VALUES
-		(:post_id, :title, :description, :user_id, :date_created, :date_updated)`
+		(:post_id, :title, :description, :user_id, :date_created, :date_updated, :visibility)`
 
 	if err := database.NamedExecContext(ctx, s.log, s.db, q, p); err != nil {
 		return fmt.Errorf("inserting post: %w", err)
@@ -217,7 +363,8 @@ func (s Store) UpdatePost(ctx context.Context, p Post) error {
 	SET 
 		"title" = :title,
 		"description" = :description,
-		"date_updated" = :date_updated
+		"date_updated" = :date_updated,
+		"visibility" = :visibility
 	WHERE
 		post_id = :post_id`
 
@@ -228,7 +375,25 @@ func (s Store) UpdatePost(ctx context.Context, p Post) error {
 	return nil
 }
 
-func (s Store) DeletePost(ctx context.Context, postID string) error {
+// DeletePost marks a post as deleted in the database.
+func (s Store) DeletePost(ctx context.Context, p Post) error {
+	const q = `
+	UPDATE
+		posts
+	SET
+		"deleted_at" = :deleted_at
+	WHERE
+		post_id = :post_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, p); err != nil {
+		return fmt.Errorf("deleting postID[%s]: %w", p.ID, err)
+	}
+
+	return nil
+}
+
+// RestorePost takes a post out of the trash.
+func (s Store) RestorePost(ctx context.Context, postID string) error {
 	data := struct {
 		PostID string `db:"post_id"`
 	}{
@@ -236,19 +401,76 @@ func (s Store) DeletePost(ctx context.Context, postID string) error {
 	}
 
 	const q = `
-	DELETE FROM
+	UPDATE
 		posts
+	SET
+		"deleted_at" = NULL
 	WHERE
 		post_id = :post_id`
 
 	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
-		return fmt.Errorf("deleting commentID[%s]: %w", postID, err)
+		return fmt.Errorf("restoring postID[%s]: %w", postID, err)
+	}
+
+	return nil
+}
+
+// DeleteByPostID marks every comment made on the post that is not already in
+// the trash as deleted at the specified time.
+func (s Store) DeleteByPostID(ctx context.Context, postID string, deletedAt time.Time) error {
+	data := struct {
+		PostID    string    `db:"post_id"`
+		DeletedAt time.Time `db:"deleted_at"`
+	}{
+		PostID:    postID,
+		DeletedAt: deletedAt,
+	}
+
+	const q = `
+	UPDATE
+		comments
+	SET
+		"deleted_at" = :deleted_at
+	WHERE
+		post_id = :post_id AND
+		deleted_at IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting comments postID[%s]: %w", postID, err)
+	}
+
+	return nil
+}
+
+// RestoreByPostID takes the comments made on the post that were deleted at the
+// specified time out of the trash.
+func (s Store) RestoreByPostID(ctx context.Context, postID string, deletedAt time.Time) error {
+	data := struct {
+		PostID    string    `db:"post_id"`
+		DeletedAt time.Time `db:"deleted_at"`
+	}{
+		PostID:    postID,
+		DeletedAt: deletedAt,
+	}
+
+	const q = `
+	UPDATE
+		comments
+	SET
+		"deleted_at" = NULL
+	WHERE
+		post_id = :post_id AND
+		deleted_at = :deleted_at`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("restoring comments postID[%s]: %w", postID, err)
 	}
 
 	return nil
 }
 
-func (s Store) QueryPostsByPostID(ctx context.Context, postID string) ([]PostComment, error) {
+// QueryPostByID gets the specified post from the database.
+func (s Store) QueryPostByID(ctx context.Context, postID string) (Post, error) {
 	data := struct {
 		PostID string `db:"post_id"`
 	}{
@@ -257,20 +479,380 @@ func (s Store) QueryPostsByPostID(ctx context.Context, postID string) ([]PostCom
 
 	const q = `
 	SELECT
-		p.*, c.comment_id, c.description as comment_description, c.user_id as comment_user_id, c.date_created as comment_date_created, c.date_updated as comment_date_updated
+		*
 	FROM
-		posts as p
-	LEFT JOIN
-		comments as c
-	ON
-		p.post_id = c.post_id
+		posts
 	WHERE
-		p.post_id = :post_id`
+		post_id = :post_id AND
+		deleted_at IS NULL`
+
+	var p Post
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &p); err != nil {
+		return Post{}, fmt.Errorf("selecting postID[%q]: %w", postID, err)
+	}
 
-	var pscomms []PostComment
-	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &pscomms); err != nil {
+	return p, nil
+}
+
+// QueryPageByPostID retrieves a page of comments for the specified post
+// ordered from oldest to newest. When topLevel is true only comments that
+// are not replies to another comment are returned.
+func (s Store) QueryPageByPostID(ctx context.Context, postID string, topLevel bool, pageNumber int, rowsPerPage int) ([]Comment, error) {
+	data := struct {
+		PostID      string `db:"post_id"`
+		TopLevel    bool   `db:"top_level"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		PostID:      postID,
+		TopLevel:    topLevel,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		comments
+	WHERE
+		post_id = :post_id AND
+		deleted_at IS NULL AND
+		(NOT :top_level OR parent_id IS NULL)
+	ORDER BY
+		date_created, comment_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var comms []Comment
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &comms); err != nil {
 		return nil, fmt.Errorf("selecting comments postID[%s]: %w", postID, err)
 	}
 
-	return pscomms, nil
+	return comms, nil
+}
+
+// QueryReplies retrieves every reply, at any depth, to the specified comments
+// ordered from oldest to newest.
+func (s Store) QueryReplies(ctx context.Context, commentIDs []string) ([]Comment, error) {
+	data := struct {
+		CommentIDs pq.StringArray `db:"comment_ids"`
+	}{
+		CommentIDs: commentIDs,
+	}
+
+	const q = `
+	WITH RECURSIVE replies AS (
+		SELECT
+			*
+		FROM
+			comments
+		WHERE
+			parent_id = ANY(CAST(:comment_ids AS UUID[])) AND
+			deleted_at IS NULL
+		UNION ALL
+		SELECT
+			c.*
+		FROM
+			comments AS c
+		JOIN
+			replies AS r ON c.parent_id = r.comment_id
+		WHERE
+			c.deleted_at IS NULL
+	)
+	SELECT
+		*
+	FROM
+		replies
+	ORDER BY
+		date_created, comment_id`
+
+	var comms []Comment
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &comms); err != nil {
+		return nil, fmt.Errorf("selecting replies: %w", err)
+	}
+
+	return comms, nil
+}
+
+// CountByPostID returns the number of comments made on the specified post.
+func (s Store) CountByPostID(ctx context.Context, postID string) (int, error) {
+	data := struct {
+		PostID string `db:"post_id"`
+	}{
+		PostID: postID,
+	}
+
+	const q = `
+	SELECT
+		count(*) AS count
+	FROM
+		comments
+	WHERE
+		post_id = :post_id AND
+		deleted_at IS NULL`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return 0, fmt.Errorf("counting comments postID[%s]: %w", postID, err)
+	}
+
+	return result.Count, nil
+}
+
+// CreateFollower inserts a copy of a follow into the database. Receiving the
+// same follow twice is not an error.
+func (s Store) CreateFollower(ctx context.Context, f Follower) error {
+	const q = `
+	INSERT INTO followers
+		(user_id, follower_id, date_created)
+	VALUES
+		(:user_id, :follower_id, :date_created)
+	ON CONFLICT DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, f); err != nil {
+		return fmt.Errorf("inserting follower: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteFollower removes the copy of a follow from the database.
+func (s Store) DeleteFollower(ctx context.Context, f Follower) error {
+	const q = `
+	DELETE FROM
+		followers
+	WHERE
+		user_id = :user_id AND
+		follower_id = :follower_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, f); err != nil {
+		return fmt.Errorf("deleting follower userID[%s] followerID[%s]: %w", f.FolloweeID, f.FollowerID, err)
+	}
+
+	return nil
+}
+
+// IsFollower reports whether the follower follows the specified user.
+func (s Store) IsFollower(ctx context.Context, userID string, followerID string) (bool, error) {
+	data := struct {
+		UserID     string `db:"user_id"`
+		FollowerID string `db:"follower_id"`
+	}{
+		UserID:     userID,
+		FollowerID: followerID,
+	}
+
+	const q = `
+	SELECT
+		EXISTS (
+			SELECT 1 FROM followers WHERE user_id = :user_id AND follower_id = :follower_id
+		) AS following`
+
+	var result struct {
+		Following bool `db:"following"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return false, fmt.Errorf("selecting follower userID[%s] followerID[%s]: %w", userID, followerID, err)
+	}
+
+	return result.Following, nil
+}
+
+// CreateRevision inserts a new comment revision into the database.
+func (s Store) CreateRevision(ctx context.Context, rev Revision) error {
+	const q = `
+	INSERT INTO comment_revisions
+		(revision_id, comment_id, description, editor_id, date_created)
+	VALUES
+		(:revision_id, :comment_id, :description, :editor_id, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, rev); err != nil {
+		return fmt.Errorf("inserting revision: %w", err)
+	}
+
+	return nil
+}
+
+// QueryRevisions retrieves the revisions of the specified comment from the
+// newest to the oldest.
+func (s Store) QueryRevisions(ctx context.Context, commentID string) ([]Revision, error) {
+	data := struct {
+		CommentID string `db:"comment_id"`
+	}{
+		CommentID: commentID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		comment_revisions
+	WHERE
+		comment_id = :comment_id
+	ORDER BY
+		date_created DESC`
+
+	var revs []Revision
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &revs); err != nil {
+		return nil, fmt.Errorf("selecting revisions commentID[%s]: %w", commentID, err)
+	}
+
+	return revs, nil
+}
+
+// QueryRevisionByID gets the specified revision of a comment from the database.
+func (s Store) QueryRevisionByID(ctx context.Context, commentID string, revisionID string) (Revision, error) {
+	data := struct {
+		CommentID  string `db:"comment_id"`
+		RevisionID string `db:"revision_id"`
+	}{
+		CommentID:  commentID,
+		RevisionID: revisionID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		comment_revisions
+	WHERE
+		comment_id = :comment_id AND
+		revision_id = :revision_id`
+
+	var rev Revision
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &rev); err != nil {
+		return Revision{}, fmt.Errorf("selecting revisionID[%q]: %w", revisionID, err)
+	}
+
+	return rev, nil
+}
+
+// SaveHandle stores the copy of the handle a user goes by. Any other user
+// holding the handle has released it by now.
+func (s Store) SaveHandle(ctx context.Context, h Handle) error {
+	const q = `
+	INSERT INTO handles
+		(user_id, handle)
+	VALUES
+		(:user_id, :handle)`
+
+	tran := func(tx sqlx.ExtContext) error {
+		if err := s.Tran(tx).DeleteHandle(ctx, h); err != nil {
+			return err
+		}
+
+		if err := database.NamedExecContext(ctx, s.log, tx, q, h); err != nil {
+			return fmt.Errorf("inserting handle: %w", err)
+		}
+
+		return nil
+	}
+
+	return s.WithinTran(ctx, tran)
+}
+
+// DeleteHandle removes the copy of the handle of the user along with any
+// other user holding the same handle.
+func (s Store) DeleteHandle(ctx context.Context, h Handle) error {
+	const q = `
+	DELETE FROM
+		handles
+	WHERE
+		user_id = :user_id OR
+		handle = :handle`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, h); err != nil {
+		return fmt.Errorf("deleting handle of userID[%s]: %w", h.UserID, err)
+	}
+
+	return nil
+}
+
+// QueryHandles gets the users going by the specified handles.
+func (s Store) QueryHandles(ctx context.Context, handles []string) ([]Handle, error) {
+	data := struct {
+		Handles pq.StringArray `db:"handles"`
+	}{
+		Handles: handles,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		handles
+	WHERE
+		handle = ANY(:handles)`
+
+	var hs []Handle
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &hs); err != nil {
+		return nil, fmt.Errorf("selecting handles: %w", err)
+	}
+
+	return hs, nil
+}
+
+// CreateMention inserts a new mention into the database.
+func (s Store) CreateMention(ctx context.Context, m Mention) error {
+	const q = `
+	INSERT INTO comment_mentions
+		(comment_id, post_id, user_id, author_id, handle, start_offset, end_offset, date_created)
+	VALUES
+		(:comment_id, :post_id, :user_id, :author_id, :handle, :start_offset, :end_offset, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, m); err != nil {
+		return fmt.Errorf("inserting mention: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteMentions removes the mentions of the specified comment.
+func (s Store) DeleteMentions(ctx context.Context, commentID string) error {
+	data := struct {
+		CommentID string `db:"comment_id"`
+	}{
+		CommentID: commentID,
+	}
+
+	const q = `
+	DELETE FROM
+		comment_mentions
+	WHERE
+		comment_id = :comment_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting mentions of commentID[%s]: %w", commentID, err)
+	}
+
+	return nil
+}
+
+// QueryMentions gets the mentions of the specified comments in the order
+// they appear.
+func (s Store) QueryMentions(ctx context.Context, commentIDs []string) ([]Mention, error) {
+	data := struct {
+		CommentIDs pq.StringArray `db:"comment_ids"`
+	}{
+		CommentIDs: commentIDs,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		comment_mentions
+	WHERE
+		CAST(comment_id AS TEXT) = ANY(:comment_ids)
+	ORDER BY
+		comment_id, start_offset`
+
+	var ms []Mention
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ms); err != nil {
+		return nil, fmt.Errorf("selecting mentions: %w", err)
+	}
+
+	return ms, nil
 }
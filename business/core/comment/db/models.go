@@ -7,33 +7,65 @@ import (
 // Comment represent the structure we need for moving data
 // between the app and the database.
 type Comment struct {
-	ID          string    `db:"comment_id"`
+	ID          string     `db:"comment_id"`
+	Description string     `db:"description"`
+	UserID      string     `db:"user_id"`
+	PostID      string     `db:"post_id"`
+	DateCreated time.Time  `db:"date_created"`
+	DateUpdated time.Time  `db:"date_updated"`
+	ParentID    *string    `db:"parent_id"`
+	Edited      bool       `db:"edited"`
+	DeletedAt   *time.Time `db:"deleted_at"`
+}
+
+// Revision represents a previous version of a comment that was replaced by
+// an edit.
+type Revision struct {
+	ID          string    `db:"revision_id"`
+	CommentID   string    `db:"comment_id"`
 	Description string    `db:"description"`
-	UserID      string    `db:"user_id"`
-	PostID      string    `db:"post_id"`
+	EditorID    string    `db:"editor_id"`
 	DateCreated time.Time `db:"date_created"`
-	DateUpdated time.Time `db:"date_updated"`
 }
 
+// Post represents the copy of a post that is kept in sync with the posts
+// service through events.
 type Post struct {
-	ID          string    `db:"post_id"`
-	Title       string    `db:"title"`
-	Description string    `db:"description"`
-	UserID      string    `db:"user_id"`
+	ID          string     `db:"post_id"`
+	Title       string     `db:"title"`
+	Description string     `db:"description"`
+	UserID      string     `db:"user_id"`
+	DateCreated time.Time  `db:"date_created"`
+	DateUpdated time.Time  `db:"date_updated"`
+	DeletedAt   *time.Time `db:"deleted_at"`
+	Visibility  string     `db:"visibility"`
+}
+
+// Follower represents a user following the author of posts. It mirrors the
+// follow graph owned by the users service so the field names match the
+// user-followed events.
+type Follower struct {
+	FollowerID  string    `db:"follower_id"`
+	FolloweeID  string    `db:"user_id"`
 	DateCreated time.Time `db:"date_created"`
-	DateUpdated time.Time `db:"date_updated"`
 }
 
-type PostComment struct {
-	ID                 string    `db:"post_id"`
-	Title              string    `db:"title"`
-	Description        string    `db:"description"`
-	UserID             string    `db:"user_id"`
-	DateCreated        time.Time `db:"date_created"`
-	DateUpdated        time.Time `db:"date_updated"`
-	CommentID          string    `db:"comment_id"`
-	CommentDescription string    `db:"comment_description"`
-	CommentUserID      string    `db:"comment_user_id"`
-	CommentDateCreated time.Time `db:"comment_date_created"`
-	CommentDateUpdated time.Time `db:"comment_date_updated"`
+// Handle is the copy of the handle a user goes by, kept so mentions can be
+// resolved locally.
+type Handle struct {
+	UserID string `db:"user_id"`
+	Handle string `db:"handle"`
+}
+
+// Mention records that a comment mentions a user. Start and End are the
+// offsets of the mention in the description of the comment.
+type Mention struct {
+	CommentID   string    `db:"comment_id"`
+	PostID      string    `db:"post_id"`
+	UserID      string    `db:"user_id"`
+	AuthorID    string    `db:"author_id"`
+	Handle      string    `db:"handle"`
+	Start       int       `db:"start_offset"`
+	End         int       `db:"end_offset"`
+	DateCreated time.Time `db:"date_created"`
 }
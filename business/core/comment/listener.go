@@ -4,75 +4,256 @@ import (
 	"bytes"
 	"context"
 	"encoding/gob"
+	"fmt"
 
 	"github.com/dudakovict/social-network/business/core/comment/db"
 	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/jmoiron/sqlx"
 	"github.com/nats-io/stan.go"
 	"go.uber.org/zap"
 )
 
+// Listener keeps the local copies of the posts, the follow graph and the
+// user handles in sync with the events published by the posts and users
+// services.
 type Listener struct {
 	log   *zap.SugaredLogger
 	nats  *nats.NATS
 	store db.Store
 }
 
+// NewListener constructs a listener for post, follow and handle events.
+func NewListener(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS) Listener {
+	return Listener{
+		log:   log,
+		nats:  nats,
+		store: db.NewStore(log, sqlxDB),
+	}
+}
+
+// Listen subscribes to all the post, follow and handle events.
+func (l Listener) Listen() error {
+	if err := l.PostCreated(); err != nil {
+		return fmt.Errorf("post-created: %w", err)
+	}
+	if err := l.PostUpdated(); err != nil {
+		return fmt.Errorf("post-updated: %w", err)
+	}
+	if err := l.PostDeleted(); err != nil {
+		return fmt.Errorf("post-deleted: %w", err)
+	}
+	if err := l.PostRestored(); err != nil {
+		return fmt.Errorf("post-restored: %w", err)
+	}
+	if err := l.UserFollowed(); err != nil {
+		return fmt.Errorf("user-followed: %w", err)
+	}
+	if err := l.UserUnfollowed(); err != nil {
+		return fmt.Errorf("user-unfollowed: %w", err)
+	}
+	if err := l.UserHandleChanged(); err != nil {
+		return fmt.Errorf("user-handle-changed: %w", err)
+	}
+
+	return nil
+}
+
+// PostCreated stores a copy of a newly created post.
 func (l Listener) PostCreated() error {
-	l.nats.Subscribe("post-created", "posts", func(m *stan.Msg) {
+	return l.nats.Subscribe("post-created", "posts", func(m *stan.Msg) {
 		buf := bytes.NewReader(m.Data)
 		dec := gob.NewDecoder(buf)
 
 		var dbP db.Post
 
-		err := dec.Decode(&dbP)
-		if err != nil {
-			l.log.Infof("decoding: %w", err)
+		if err := dec.Decode(&dbP); err != nil {
+			l.log.Errorw("post-created", "ERROR", fmt.Errorf("decoding: %w", err))
+			return
 		}
 
 		if err := l.store.CreatePost(context.Background(), dbP); err != nil {
-			l.log.Infof("create: %w", err)
+			l.log.Errorw("post-created", "ERROR", fmt.Errorf("create: %w", err))
+			return
 		}
-	})
 
-	return nil
+		m.Ack()
+	})
 }
 
+// PostUpdated replaces the copy of an updated post.
 func (l Listener) PostUpdated() error {
-	l.nats.Subscribe("post-updated", "posts", func(m *stan.Msg) {
+	return l.nats.Subscribe("post-updated", "posts", func(m *stan.Msg) {
 		buf := bytes.NewReader(m.Data)
 		dec := gob.NewDecoder(buf)
 
 		var dbP db.Post
 
-		err := dec.Decode(&dbP)
-		if err != nil {
-			l.log.Infof("decoding: %w", err)
+		if err := dec.Decode(&dbP); err != nil {
+			l.log.Errorw("post-updated", "ERROR", fmt.Errorf("decoding: %w", err))
+			return
 		}
-		l.log.Infow("postupdated", dbP)
+
 		if err := l.store.UpdatePost(context.Background(), dbP); err != nil {
-			l.log.Infof("create: %w", err)
+			l.log.Errorw("post-updated", "ERROR", fmt.Errorf("update: %w", err))
+			return
 		}
-	})
 
-	return nil
+		m.Ack()
+	})
 }
 
+// PostDeleted moves the copy of a deleted post into the trash along with the
+// comments made on it, using the post's deletion time for both so they can be
+// restored together.
 func (l Listener) PostDeleted() error {
-	l.nats.Subscribe("post-deleted", "posts", func(m *stan.Msg) {
+	return l.nats.Subscribe("post-deleted", "posts", func(m *stan.Msg) {
 		buf := bytes.NewReader(m.Data)
 		dec := gob.NewDecoder(buf)
 
-		var postID string
+		var dbP db.Post
+
+		if err := dec.Decode(&dbP); err != nil {
+			l.log.Errorw("post-deleted", "ERROR", fmt.Errorf("decoding: %w", err))
+			return
+		}
+
+		if dbP.DeletedAt == nil {
+			l.log.Errorw("post-deleted", "ERROR", fmt.Errorf("postID[%s] has no deletion time", dbP.ID))
+			m.Ack()
+			return
+		}
+
+		ctx := context.Background()
 
-		err := dec.Decode(&postID)
-		if err != nil {
-			l.log.Infof("decoding: %w", err)
+		tran := func(tx sqlx.ExtContext) error {
+			if err := l.store.Tran(tx).DeleteByPostID(ctx, dbP.ID, *dbP.DeletedAt); err != nil {
+				return fmt.Errorf("delete comments: %w", err)
+			}
+			if err := l.store.Tran(tx).DeletePost(ctx, dbP); err != nil {
+				return fmt.Errorf("delete post: %w", err)
+			}
+			return nil
 		}
 
-		if err := l.store.DeletePost(context.Background(), postID); err != nil {
-			l.log.Infof("create: %w", err)
+		if err := l.store.WithinTran(ctx, tran); err != nil {
+			l.log.Errorw("post-deleted", "ERROR", fmt.Errorf("tran: %w", err))
+			return
 		}
+
+		m.Ack()
 	})
+}
 
-	return nil
+// PostRestored takes the copy of a restored post out of the trash along with
+// the comments that were deleted together with it.
+func (l Listener) PostRestored() error {
+	return l.nats.Subscribe("post-restored", "posts", func(m *stan.Msg) {
+		buf := bytes.NewReader(m.Data)
+		dec := gob.NewDecoder(buf)
+
+		var dbP db.Post
+
+		if err := dec.Decode(&dbP); err != nil {
+			l.log.Errorw("post-restored", "ERROR", fmt.Errorf("decoding: %w", err))
+			return
+		}
+
+		if dbP.DeletedAt == nil {
+			l.log.Errorw("post-restored", "ERROR", fmt.Errorf("postID[%s] has no deletion time", dbP.ID))
+			m.Ack()
+			return
+		}
+
+		ctx := context.Background()
+
+		tran := func(tx sqlx.ExtContext) error {
+			if err := l.store.Tran(tx).RestoreByPostID(ctx, dbP.ID, *dbP.DeletedAt); err != nil {
+				return fmt.Errorf("restore comments: %w", err)
+			}
+			if err := l.store.Tran(tx).RestorePost(ctx, dbP.ID); err != nil {
+				return fmt.Errorf("restore post: %w", err)
+			}
+			return nil
+		}
+
+		if err := l.store.WithinTran(ctx, tran); err != nil {
+			l.log.Errorw("post-restored", "ERROR", fmt.Errorf("tran: %w", err))
+			return
+		}
+
+		m.Ack()
+	})
+}
+
+// UserFollowed stores a copy of a new follow so followers only posts can be
+// checked locally.
+func (l Listener) UserFollowed() error {
+	return l.nats.Subscribe("user-followed", "comments", func(m *stan.Msg) {
+		buf := bytes.NewReader(m.Data)
+		dec := gob.NewDecoder(buf)
+
+		var dbF db.Follower
+
+		if err := dec.Decode(&dbF); err != nil {
+			l.log.Errorw("user-followed", "ERROR", fmt.Errorf("decoding: %w", err))
+			return
+		}
+
+		if err := l.store.CreateFollower(context.Background(), dbF); err != nil {
+			l.log.Errorw("user-followed", "ERROR", fmt.Errorf("create: %w", err))
+			return
+		}
+
+		m.Ack()
+	})
+}
+
+// UserUnfollowed removes the copy of a follow.
+func (l Listener) UserUnfollowed() error {
+	return l.nats.Subscribe("user-unfollowed", "comments", func(m *stan.Msg) {
+		buf := bytes.NewReader(m.Data)
+		dec := gob.NewDecoder(buf)
+
+		var dbF db.Follower
+
+		if err := dec.Decode(&dbF); err != nil {
+			l.log.Errorw("user-unfollowed", "ERROR", fmt.Errorf("decoding: %w", err))
+			return
+		}
+
+		if err := l.store.DeleteFollower(context.Background(), dbF); err != nil {
+			l.log.Errorw("user-unfollowed", "ERROR", fmt.Errorf("delete: %w", err))
+			return
+		}
+
+		m.Ack()
+	})
+}
+
+// UserHandleChanged stores a copy of the handle a user goes by so mentions
+// can be resolved locally. An empty handle means it was released.
+func (l Listener) UserHandleChanged() error {
+	return l.nats.Subscribe("user-handle-changed", "comments", func(m *stan.Msg) {
+		buf := bytes.NewReader(m.Data)
+		dec := gob.NewDecoder(buf)
+
+		var dbH db.Handle
+
+		if err := dec.Decode(&dbH); err != nil {
+			l.log.Errorw("user-handle-changed", "ERROR", fmt.Errorf("decoding: %w", err))
+			return
+		}
+
+		save := l.store.SaveHandle
+		if dbH.Handle == "" {
+			save = l.store.DeleteHandle
+		}
+
+		if err := save(context.Background(), dbH); err != nil {
+			l.log.Errorw("user-handle-changed", "ERROR", fmt.Errorf("save: %w", err))
+			return
+		}
+
+		m.Ack()
+	})
 }
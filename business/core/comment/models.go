@@ -5,46 +5,96 @@ import (
 	"unsafe"
 
 	"github.com/dudakovict/social-network/business/core/comment/db"
+	"github.com/dudakovict/social-network/foundation/diff"
 )
 
 // Comment represents an individual comment.
 type Comment struct {
+	ID          string     `json:"id"`
+	Description string     `json:"description"`
+	UserID      string     `json:"user_id"`
+	PostID      string     `json:"post_id"`
+	DateCreated time.Time  `json:"date_created"`
+	DateUpdated time.Time  `json:"date_updated"`
+	ParentID    *string    `json:"parent_id,omitempty"`
+	Edited      bool       `json:"edited"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Revision represents a previous version of a comment. The editor is the
+// user whose edit replaced this version.
+type Revision struct {
 	ID          string    `json:"id"`
+	CommentID   string    `json:"comment_id"`
 	Description string    `json:"description"`
-	UserID      string    `json:"user_id"`
-	PostID      string    `json:"post_id"`
+	EditorID    string    `json:"editor_id"`
 	DateCreated time.Time `json:"date_created"`
-	DateUpdated time.Time `json:"date_updated"`
 }
 
-type Post struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
+// Mention represents a user mentioned in the description of a comment.
+// Start and End are offsets in Unicode code points, End is exclusive,
+// covering the @ sign and the handle.
+type Mention struct {
+	CommentID   string    `json:"-"`
+	PostID      string    `json:"-"`
 	UserID      string    `json:"user_id"`
-	DateCreated time.Time `json:"date_created"`
-	DateUpdated time.Time `json:"date_updated"`
+	AuthorID    string    `json:"-"`
+	Handle      string    `json:"handle"`
+	Start       int       `json:"start"`
+	End         int       `json:"end"`
+	DateCreated time.Time `json:"-"`
 }
 
-type PostComment struct {
-	ID                 string    `json:"id"`
-	Title              string    `json:"title"`
-	Description        string    `json:"description"`
-	UserID             string    `json:"user_id"`
-	DateCreated        time.Time `json:"date_created"`
-	DateUpdated        time.Time `json:"date_updated"`
-	CommentID          string    `json:"comment_id"`
-	CommentDescription string    `json:"comment_description"`
-	CommentUserID      string    `json:"comment_user_id"`
-	CommentDateCreated time.Time `json:"comment_date_created"`
-	CommentDateUpdated time.Time `json:"comment_date_updated"`
+// RevisionDiff represents the line by line differences between two
+// revisions of a comment.
+type RevisionDiff struct {
+	From        string      `json:"from"`
+	To          string      `json:"to"`
+	Description []diff.Line `json:"description"`
+}
+
+// Set of audiences a post can be shared with.
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityPrivate   = "private"
+)
+
+// Post represents an individual post as known to the comments service.
+type Post struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	UserID      string     `json:"user_id"`
+	DateCreated time.Time  `json:"date_created"`
+	DateUpdated time.Time  `json:"date_updated"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	Visibility  string     `json:"visibility"`
+}
+
+// CommentThread is a comment together with the users it mentions and the
+// replies made to it. Replies are only populated when comments are requested
+// in threaded form.
+type CommentThread struct {
+	Comment
+	Mentions []Mention       `json:"mentions"`
+	Replies  []CommentThread `json:"replies,omitempty"`
+}
+
+// PostWithComments represents a post along with a page of its comments and
+// the total number of comments made on it.
+type PostWithComments struct {
+	Post         Post            `json:"post"`
+	Comments     []CommentThread `json:"comments"`
+	CommentCount int             `json:"comment_count"`
 }
 
 // NewComment contains information needed to create a new Comment.
 type NewComment struct {
-	Description string `json:"description" validate:"required"`
-	UserID      string `json:"user_id" validate:"required"`
-	PostID      string `json:"post_id" validate:"required"`
+	Description string  `json:"description" validate:"required,max=5000"`
+	UserID      string  `json:"user_id" validate:"required"`
+	PostID      string  `json:"post_id" validate:"required"`
+	ParentID    *string `json:"parent_id" validate:"omitempty,uuid"`
 }
 
 // UpdateComment defines what information may be provided to modify an existing
@@ -54,7 +104,7 @@ type NewComment struct {
 // we do not want to use pointers to basic types but we make exceptions around
 // marshalling/unmarshalling.
 type UpdateComment struct {
-	Description *string `json:"description"`
+	Description *string `json:"description" validate:"omitempty,max=5000"`
 }
 
 // =============================================================================
@@ -72,15 +122,65 @@ func toCommentSlice(dbCs []db.Comment) []Comment {
 	return comments
 }
 
-func toPostComment(dbPcomm db.PostComment) PostComment {
-	pcu := (*PostComment)(unsafe.Pointer(&dbPcomm))
-	return *pcu
+func toRevision(dbRev db.Revision) Revision {
+	ru := (*Revision)(unsafe.Pointer(&dbRev))
+	return *ru
+}
+
+func toRevisionSlice(dbRevs []db.Revision) []Revision {
+	revs := make([]Revision, len(dbRevs))
+	for i, dbRev := range dbRevs {
+		revs[i] = toRevision(dbRev)
+	}
+	return revs
+}
+
+func toPost(dbP db.Post) Post {
+	pu := (*Post)(unsafe.Pointer(&dbP))
+	return *pu
+}
+
+func toMention(dbM db.Mention) Mention {
+	mu := (*Mention)(unsafe.Pointer(&dbM))
+	return *mu
+}
+
+// toMentionMap groups the mentions by the comment they are made in.
+func toMentionMap(dbMentions []db.Mention) map[string][]Mention {
+	mentions := make(map[string][]Mention)
+	for _, dbM := range dbMentions {
+		mentions[dbM.CommentID] = append(mentions[dbM.CommentID], toMention(dbM))
+	}
+	return mentions
 }
 
-func toPostCommentSlice(dbPsComms []db.PostComment) []PostComment {
-	pscomms := make([]PostComment, len(dbPsComms))
-	for i, dbPcomm := range dbPsComms {
-		pscomms[i] = toPostComment(dbPcomm)
+// toCommentThreads arranges the top level comments and their replies into
+// threads. Replies are attached to their parent in the order provided.
+func toCommentThreads(dbRoots []db.Comment, dbReplies []db.Comment, mentions map[string][]Mention) []CommentThread {
+	children := make(map[string][]db.Comment)
+	for _, dbC := range dbReplies {
+		if dbC.ParentID != nil {
+			children[*dbC.ParentID] = append(children[*dbC.ParentID], dbC)
+		}
 	}
-	return pscomms
+
+	var build func(dbCs []db.Comment) []CommentThread
+	build = func(dbCs []db.Comment) []CommentThread {
+		threads := make([]CommentThread, len(dbCs))
+		for i, dbC := range dbCs {
+			threads[i] = CommentThread{
+				Comment:  toComment(dbC),
+				Mentions: mentions[dbC.ID],
+			}
+			if threads[i].Mentions == nil {
+				threads[i].Mentions = []Mention{}
+			}
+			if replies, exists := children[dbC.ID]; exists {
+				threads[i].Replies = build(replies)
+			}
+		}
+		return threads
+	}
+
+	return build(dbRoots)
 }
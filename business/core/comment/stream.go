@@ -0,0 +1,25 @@
+package comment
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/dudakovict/social-network/business/core/comment/db"
+	"github.com/dudakovict/social-network/business/sys/stream"
+)
+
+// StreamCreated turns a comment-created event into the message pushed to the
+// clients streaming the comments of its post.
+func StreamCreated(data []byte) (string, stream.Message, error) {
+	var dbC db.Comment
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dbC); err != nil {
+		return "", stream.Message{}, err
+	}
+
+	m := stream.Message{
+		Type: "comment",
+		Data: toComment(dbC),
+	}
+
+	return dbC.PostID, m, nil
+}
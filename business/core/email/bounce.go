@@ -0,0 +1,192 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/email/db"
+	"github.com/dudakovict/social-network/business/sys/validate"
+	"github.com/dudakovict/social-network/foundation/mail"
+	"github.com/jmoiron/sqlx"
+)
+
+// Set of kinds of bounces. A hard bounce means the address will never take
+// mail, a soft bounce that it could not take it this time.
+const (
+	BounceHard      = "hard"
+	BounceSoft      = "soft"
+	BounceComplaint = "complaint"
+)
+
+// Set of settings for soft bounces. An address is suppressed once it soft
+// bounced SoftBounceLimit times within SoftBounceWindow.
+const (
+	SoftBounceLimit  = 3
+	SoftBounceWindow = 7 * 24 * time.Hour
+)
+
+// Bounce is a classified entry of a report sent back by a mail server.
+type Bounce struct {
+	Address    string `json:"address"`
+	Kind       string `json:"kind"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail"`
+	Suppressed bool   `json:"suppressed"`
+}
+
+// Classify sorts the recipients of the report into hard bounces, soft
+// bounces and complaints. Recipients the message was delivered to are left
+// out, and so are those it is only delayed for since the server is still
+// trying and may send a notice every few hours until it gives up.
+func Classify(rep mail.Report) []Bounce {
+	var bs []Bounce
+	for _, rr := range rep.Recipients {
+		b := Bounce{
+			Address: strings.ToLower(rr.Address),
+			Status:  rr.Status,
+			Detail:  rr.Diagnostic,
+		}
+
+		switch {
+		case rep.Type == mail.ReportFeedback:
+			b.Kind = BounceComplaint
+		case rr.Action == "failed":
+			b.Kind = failedKind(rr.Status)
+		default:
+			continue
+		}
+
+		bs = append(bs, b)
+	}
+
+	return bs
+}
+
+// ProcessReport classifies the report and records every bounce. Hard
+// bounces, complaints and addresses that keep soft bouncing are suppressed,
+// and announced on the email-undeliverable subject so the address stops
+// being treated as verified. Reports are recorded once by their Message-ID,
+// bounces of a report delivered again are left out.
+func (c Core) ProcessReport(ctx context.Context, rep mail.Report, now time.Time) ([]Bounce, error) {
+	var reportID *string
+	if rep.MessageID != "" {
+		reportID = &rep.MessageID
+	}
+
+	var bs []Bounce
+	var sups []db.Suppression
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		for _, b := range Classify(rep) {
+			dbB := db.Bounce{
+				ID:          validate.GenerateID(),
+				ReportID:    reportID,
+				Address:     b.Address,
+				Kind:        b.Kind,
+				Status:      b.Status,
+				Detail:      b.Detail,
+				DateCreated: now,
+			}
+
+			created, err := store.CreateBounce(ctx, dbB)
+			if err != nil {
+				return fmt.Errorf("create bounce: %w", err)
+			}
+			if !created {
+				continue
+			}
+
+			reason := ReasonBounce
+			switch b.Kind {
+			case BounceComplaint:
+				reason = ReasonComplaint
+			case BounceSoft:
+				n, err := store.CountBounces(ctx, b.Address, BounceSoft, now.Add(-SoftBounceWindow))
+				if err != nil {
+					return fmt.Errorf("count bounces: %w", err)
+				}
+				if n < SoftBounceLimit {
+					bs = append(bs, b)
+					continue
+				}
+			}
+
+			dbS := db.Suppression{
+				Address:     b.Address,
+				Reason:      reason,
+				Detail:      strings.TrimSpace(b.Kind + " " + b.Status + " " + b.Detail),
+				DateCreated: now,
+			}
+
+			if err := store.Suppress(ctx, dbS); err != nil {
+				return fmt.Errorf("suppress: %w", err)
+			}
+			b.Suppressed = true
+
+			bs = append(bs, b)
+			sups = append(sups, dbS)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return nil, fmt.Errorf("tran: %w", err)
+	}
+
+	for _, dbS := range sups {
+		if err := c.publishUndeliverable(dbS); err != nil {
+			return nil, fmt.Errorf("pub: %w", err)
+		}
+	}
+
+	return bs, nil
+}
+
+// =============================================================================
+
+// failedKind tells a hard bounce from a soft one by the status code. Full
+// mailboxes, messages too big and routing problems may go away, although
+// the server gave up on this message.
+func failedKind(status string) string {
+	switch {
+	case strings.HasPrefix(status, "4."),
+		status == "5.2.2",
+		status == "5.3.4",
+		strings.HasPrefix(status, "5.4."):
+		return BounceSoft
+	}
+	return BounceHard
+}
+
+// publishUndeliverable announces the address on the email-undeliverable
+// subject.
+func (c Core) publishUndeliverable(dbS db.Suppression) error {
+	if c.nats == nil {
+		return nil
+	}
+
+	dbU := db.Undeliverable{
+		Email:  dbS.Address,
+		Reason: dbS.Reason,
+		Detail: dbS.Detail,
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	if err := enc.Encode(&dbU); err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+
+	if err := c.nats.Client.Publish("email-undeliverable", buf.Bytes()); err != nil {
+		return fmt.Errorf("publishing email-undeliverable: %w", err)
+	}
+
+	return nil
+}
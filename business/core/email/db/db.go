@@ -0,0 +1,441 @@
+// Package db contains email queue related CRUD functionality.
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of API's for email access.
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+// NewStore constructs a data for api access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create inserts a new email into the queue. It reports false when an email
+// with the same ID is already queued, which is left untouched.
+func (s Store) Create(ctx context.Context, e Email) (bool, error) {
+	const q = `
+	INSERT INTO emails
+		(email_id, address, template, locale, vars, status, attempts, next_attempt, date_created, date_updated)
+	VALUES
+		(:email_id, :address, :template, :locale, :vars, :status, :attempts, :next_attempt, :date_created, :date_updated)
+	ON CONFLICT (email_id) DO NOTHING
+	RETURNING
+		email_id`
+
+	var result struct {
+		ID string `db:"email_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, e, &result); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("inserting email: %w", err)
+	}
+
+	return true, nil
+}
+
+// QueryByID gets the specified email from the database.
+func (s Store) QueryByID(ctx context.Context, emailID string) (Email, error) {
+	data := struct {
+		ID string `db:"email_id"`
+	}{
+		ID: emailID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		emails
+	WHERE
+		email_id = :email_id`
+
+	var e Email
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &e); err != nil {
+		return Email{}, fmt.Errorf("selecting emailID[%q]: %w", emailID, err)
+	}
+
+	return e, nil
+}
+
+// Claim takes up to limit emails that are due for delivery and marks them as
+// being sent until the lease expires. Emails whose lease expired, because the
+// worker sending them died, are due again. Concurrent workers never claim the
+// same email.
+func (s Store) Claim(ctx context.Context, now time.Time, lease time.Time, limit int) ([]Email, error) {
+	data := struct {
+		Now   time.Time `db:"now"`
+		Lease time.Time `db:"lease"`
+		Limit int       `db:"limit"`
+	}{
+		Now:   now,
+		Lease: lease,
+		Limit: limit,
+	}
+
+	const q = `
+	UPDATE
+		emails
+	SET
+		"status" = 'sending',
+		"attempts" = attempts + 1,
+		"next_attempt" = :lease,
+		"date_updated" = :now
+	WHERE
+		email_id IN (
+			SELECT
+				email_id
+			FROM
+				emails
+			WHERE
+				status IN ('queued', 'retrying', 'sending') AND
+				next_attempt <= :now
+			ORDER BY
+				next_attempt
+			LIMIT :limit
+			FOR UPDATE SKIP LOCKED
+		)
+	RETURNING
+		*`
+
+	var emails []Email
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &emails); err != nil {
+		return nil, fmt.Errorf("claiming emails: %w", err)
+	}
+
+	return emails, nil
+}
+
+// MarkSent records that a claimed email was delivered.
+func (s Store) MarkSent(ctx context.Context, emailID string, now time.Time) error {
+	data := struct {
+		ID  string    `db:"email_id"`
+		Now time.Time `db:"now"`
+	}{
+		ID:  emailID,
+		Now: now,
+	}
+
+	const q = `
+	UPDATE
+		emails
+	SET
+		"status" = 'sent',
+		"last_error" = NULL,
+		"date_sent" = :now,
+		"date_updated" = :now
+	WHERE
+		email_id = :email_id AND
+		status = 'sending'`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("marking sent emailID[%s]: %w", emailID, err)
+	}
+
+	return nil
+}
+
+// MarkUnsent records that the delivery of a claimed email failed. The status
+// is either retrying, with the time of the next attempt, or failed.
+func (s Store) MarkUnsent(ctx context.Context, emailID string, status string, lastError string, next time.Time, now time.Time) error {
+	data := struct {
+		ID          string    `db:"email_id"`
+		Status      string    `db:"status"`
+		LastError   string    `db:"last_error"`
+		NextAttempt time.Time `db:"next_attempt"`
+		Now         time.Time `db:"now"`
+	}{
+		ID:          emailID,
+		Status:      status,
+		LastError:   lastError,
+		NextAttempt: next,
+		Now:         now,
+	}
+
+	const q = `
+	UPDATE
+		emails
+	SET
+		"status" = :status,
+		"last_error" = :last_error,
+		"next_attempt" = :next_attempt,
+		"date_updated" = :now
+	WHERE
+		email_id = :email_id AND
+		status = 'sending'`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("marking unsent emailID[%s]: %w", emailID, err)
+	}
+
+	return nil
+}
+
+// LockAddress takes a lock on the address that is released when the
+// transaction ends. It must be called within a transaction.
+func (s Store) LockAddress(ctx context.Context, address string) error {
+	data := struct {
+		Address string `db:"address"`
+	}{
+		Address: address,
+	}
+
+	const q = `SELECT pg_advisory_xact_lock(hashtext(:address))`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("locking address[%s]: %w", address, err)
+	}
+
+	return nil
+}
+
+// CountCreated counts the emails queued for the address since the specified
+// time, exclusive.
+func (s Store) CountCreated(ctx context.Context, address string, since time.Time) (int, error) {
+	data := struct {
+		Address string    `db:"address"`
+		Since   time.Time `db:"since"`
+	}{
+		Address: address,
+		Since:   since,
+	}
+
+	const q = `
+	SELECT
+		count(*) AS count
+	FROM
+		emails
+	WHERE
+		LOWER(address) = :address AND
+		date_created > :since`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return 0, fmt.Errorf("counting emails created address[%s]: %w", address, err)
+	}
+
+	return result.Count, nil
+}
+
+// CountSent counts the emails delivered since the specified time, exclusive.
+func (s Store) CountSent(ctx context.Context, since time.Time) (int, error) {
+	data := struct {
+		Since time.Time `db:"since"`
+	}{
+		Since: since,
+	}
+
+	const q = `
+	SELECT
+		count(*) AS count
+	FROM
+		emails
+	WHERE
+		date_sent > :since`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return 0, fmt.Errorf("counting emails sent: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+// Suppress adds the address to the suppression list, replacing the reason
+// it was suppressed for before.
+func (s Store) Suppress(ctx context.Context, sup Suppression) error {
+	const q = `
+	INSERT INTO suppressions
+		(address, reason, detail, date_created)
+	VALUES
+		(:address, :reason, :detail, :date_created)
+	ON CONFLICT (address) DO UPDATE SET
+		"reason" = EXCLUDED.reason,
+		"detail" = EXCLUDED.detail,
+		"date_created" = EXCLUDED.date_created`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, sup); err != nil {
+		return fmt.Errorf("inserting suppression: %w", err)
+	}
+
+	return nil
+}
+
+// Unsuppress removes the address from the suppression list.
+func (s Store) Unsuppress(ctx context.Context, address string) error {
+	data := struct {
+		Address string `db:"address"`
+	}{
+		Address: address,
+	}
+
+	const q = `
+	DELETE FROM
+		suppressions
+	WHERE
+		address = :address
+	RETURNING
+		address`
+
+	var result struct {
+		Address string `db:"address"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return fmt.Errorf("deleting suppression address[%s]: %w", address, err)
+	}
+
+	return nil
+}
+
+// QuerySuppression gets the suppression of the address from the database.
+func (s Store) QuerySuppression(ctx context.Context, address string) (Suppression, error) {
+	data := struct {
+		Address string `db:"address"`
+	}{
+		Address: address,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		suppressions
+	WHERE
+		address = :address`
+
+	var sup Suppression
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &sup); err != nil {
+		return Suppression{}, fmt.Errorf("selecting suppression address[%s]: %w", address, err)
+	}
+
+	return sup, nil
+}
+
+// QuerySuppressions retrieves a list of suppressed addresses, the most
+// recent first.
+func (s Store) QuerySuppressions(ctx context.Context, pageNumber int, rowsPerPage int) ([]Suppression, error) {
+	data := struct {
+		Offset      int `db:"offset"`
+		RowsPerPage int `db:"rows_per_page"`
+	}{
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		suppressions
+	ORDER BY
+		date_created DESC, address
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var sups []Suppression
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &sups); err != nil {
+		return nil, fmt.Errorf("selecting suppressions: %w", err)
+	}
+
+	return sups, nil
+}
+
+// CreateBounce records a bounce or complaint reported for an address. It
+// reports false when the report was already recorded for the address, which
+// is left untouched.
+func (s Store) CreateBounce(ctx context.Context, b Bounce) (bool, error) {
+	const q = `
+	INSERT INTO bounces
+		(bounce_id, report_id, address, kind, status, detail, date_created)
+	VALUES
+		(:bounce_id, :report_id, :address, :kind, :status, :detail, :date_created)
+	ON CONFLICT (report_id, address) DO NOTHING
+	RETURNING
+		bounce_id`
+
+	var result struct {
+		ID string `db:"bounce_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, b, &result); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("inserting bounce: %w", err)
+	}
+
+	return true, nil
+}
+
+// CountBounces counts the bounces of the kind reported for the address since
+// the specified time, exclusive.
+func (s Store) CountBounces(ctx context.Context, address string, kind string, since time.Time) (int, error) {
+	data := struct {
+		Address string    `db:"address"`
+		Kind    string    `db:"kind"`
+		Since   time.Time `db:"since"`
+	}{
+		Address: address,
+		Kind:    kind,
+		Since:   since,
+	}
+
+	const q = `
+	SELECT
+		count(*) AS count
+	FROM
+		bounces
+	WHERE
+		address = :address AND
+		kind = :kind AND
+		date_created > :since`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return 0, fmt.Errorf("counting bounces address[%s]: %w", address, err)
+	}
+
+	return result.Count, nil
+}
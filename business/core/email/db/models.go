@@ -0,0 +1,49 @@
+package db
+
+import "time"
+
+// Email represent the structure we need for moving data
+// between the app and the database.
+type Email struct {
+	ID          string     `db:"email_id"`
+	Address     string     `db:"address"`
+	Template    string     `db:"template"`
+	Locale      string     `db:"locale"`
+	Vars        string     `db:"vars"`
+	Status      string     `db:"status"`
+	Attempts    int        `db:"attempts"`
+	LastError   *string    `db:"last_error"`
+	NextAttempt time.Time  `db:"next_attempt"`
+	DateCreated time.Time  `db:"date_created"`
+	DateUpdated time.Time  `db:"date_updated"`
+	DateSent    *time.Time `db:"date_sent"`
+}
+
+// Suppression represent the structure we need for moving data
+// between the app and the database.
+type Suppression struct {
+	Address     string    `db:"address"`
+	Reason      string    `db:"reason"`
+	Detail      string    `db:"detail"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// Bounce represent the structure we need for moving data
+// between the app and the database.
+type Bounce struct {
+	ID          string    `db:"bounce_id"`
+	ReportID    *string   `db:"report_id"`
+	Address     string    `db:"address"`
+	Kind        string    `db:"kind"`
+	Status      string    `db:"status"`
+	Detail      string    `db:"detail"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// Undeliverable announces an address mail can not be delivered to, so the
+// services keeping addresses stop treating it as verified.
+type Undeliverable struct {
+	Email  string `db:"email"`
+	Reason string `db:"reason"`
+	Detail string `db:"detail"`
+}
@@ -1,42 +1,372 @@
-// Package email provides support for sending e-mail messages
+// Package email provides support for sending e-mail messages. E-mails are
+// queued in the database and delivered in the background, so callers never
+// wait on the mail server and nothing is lost while it is down.
 package email
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
 
-	"github.com/dudakovict/social-network/business/data/email"
+	"github.com/dudakovict/social-network/business/core/email/db"
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/business/sys/validate"
+	"github.com/dudakovict/social-network/foundation/mail"
+	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
-type EmailServer struct {
-	log     *zap.SugaredLogger
-	address string
-	sender  string
-	auth    smtp.Auth
-	email.UnimplementedEmailServer
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound            = errors.New("email not found")
+	ErrSuppressed          = errors.New("address is suppressed")
+	ErrRateLimited         = errors.New("address is over its rate limit")
+	ErrSuppressionNotFound = errors.New("suppression not found")
+)
+
+// Set of reasons an address is suppressed for.
+const (
+	ReasonBounce    = "bounce"
+	ReasonComplaint = "complaint"
+	ReasonManual    = "manual"
+)
+
+// Set of delivery statuses of an e-mail.
+const (
+	StatusQueued   = "queued"
+	StatusSending  = "sending"
+	StatusRetrying = "retrying"
+	StatusSent     = "sent"
+	StatusFailed   = "failed"
+)
+
+// Set of settings for delivering the queue. An e-mail is attempted up to
+// MaxAttempts times, waiting twice as long after every failure.
+const (
+	MaxAttempts = 8
+	minBackoff  = 30 * time.Second
+	maxBackoff  = time.Hour
+	lease       = 5 * time.Minute
+	batchSize   = 20
+)
+
+// Limits caps how many e-mails go out. No more than Recipient e-mails are
+// queued for an address within RecipientWindow, and no more than Global
+// e-mails are sent within GlobalWindow. A zero limit means no limit.
+type Limits struct {
+	Recipient       int
+	RecipientWindow time.Duration
+	Global          int
+	GlobalWindow    time.Duration
+}
+
+// Core manages the set of API's for email access.
+type Core struct {
+	log       *zap.SugaredLogger
+	store     db.Store
+	nats      *nats.NATS
+	templates *Templates
+	transport mail.Transport
+	sender    string
+	limits    Limits
+	wake      chan struct{}
+}
+
+// NewCore constructs a core for email api access. Undeliverable addresses
+// are published to NATS, a nil NATS keeps them to the suppression list.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS, templates *Templates, transport mail.Transport, sender string, limits Limits) Core {
+	return Core{
+		log:       log,
+		store:     db.NewStore(log, sqlxDB),
+		nats:      nats,
+		templates: templates,
+		transport: transport,
+		sender:    sender,
+		limits:    limits,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Enqueue validates the e-mail, renders it once to reject unknown templates
+// and missing variables early, and queues it for delivery. Queuing the same
+// request ID again returns the e-mail queued the first time. E-mails to
+// suppressed addresses and to addresses over their rate limit are rejected.
+func (c Core) Enqueue(ctx context.Context, ne NewEmail, now time.Time) (Email, error) {
+	if err := validate.Check(ne); err != nil {
+		return Email{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	if _, err := c.templates.Render(ne.Template, ne.Locale, ne.Vars); err != nil {
+		return Email{}, err
+	}
+
+	// A request queued before is not rejected when it is retried.
+	if ne.RequestID != "" {
+		e, err := c.QueryByID(ctx, ne.RequestID)
+		switch {
+		case err == nil:
+			return e, nil
+		case !errors.Is(err, ErrNotFound):
+			return Email{}, err
+		}
+	}
+
+	if ne.Vars == nil {
+		ne.Vars = map[string]string{}
+	}
+	vars, err := json.Marshal(ne.Vars)
+	if err != nil {
+		return Email{}, fmt.Errorf("encoding vars: %w", err)
+	}
+
+	id := ne.RequestID
+	if id == "" {
+		id = validate.GenerateID()
+	}
+
+	dbE := db.Email{
+		ID:          id,
+		Address:     ne.Address,
+		Template:    ne.Template,
+		Locale:      ne.Locale,
+		Vars:        string(vars),
+		Status:      StatusQueued,
+		NextAttempt: now,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	// The e-mails of an address are counted and queued under a lock, so
+	// concurrent requests can not all pass the rate limit.
+	var created bool
+	tran := func(tx sqlx.ExtContext) error {
+		if err := c.admit(ctx, c.store.Tran(tx), ne.Address, now); err != nil {
+			return err
+		}
+
+		var err error
+		if created, err = c.store.Tran(tx).Create(ctx, dbE); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Email{}, fmt.Errorf("tran: %w", err)
+	}
+
+	if !created {
+		return c.QueryByID(ctx, id)
+	}
+
+	// Let an idle worker know there is something to deliver.
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+
+	return toEmail(dbE), nil
+}
+
+// QueryByID gets the specified e-mail from the queue.
+func (c Core) QueryByID(ctx context.Context, emailID string) (Email, error) {
+	dbE, err := c.store.QueryByID(ctx, emailID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Email{}, ErrNotFound
+		}
+		return Email{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toEmail(dbE), nil
+}
+
+// Deliver claims a batch of due e-mails and sends them. It returns how many
+// e-mails were claimed, a full batch means more may be due. The batch is
+// smaller when the global rate limit is close.
+func (c Core) Deliver(ctx context.Context, now time.Time) (int, error) {
+	limit := batchSize
+	if c.limits.Global > 0 {
+		sent, err := c.store.CountSent(ctx, now.Add(-c.limits.GlobalWindow))
+		if err != nil {
+			return 0, fmt.Errorf("count sent: %w", err)
+		}
+		if left := c.limits.Global - sent; left < limit {
+			limit = left
+		}
+		if limit <= 0 {
+			return 0, nil
+		}
+	}
+
+	dbEs, err := c.store.Claim(ctx, now, now.Add(lease), limit)
+	if err != nil {
+		return 0, fmt.Errorf("claim: %w", err)
+	}
+
+	for _, dbE := range dbEs {
+		e := toEmail(dbE)
+
+		sendErr := c.send(ctx, e, now)
+		if sendErr == nil {
+			if err := c.store.MarkSent(ctx, e.ID, now); err != nil {
+				return len(dbEs), fmt.Errorf("mark sent: %w", err)
+			}
+			continue
+		}
+
+		status := StatusRetrying
+		next := now.Add(backoff(e.Attempts))
+		if e.Attempts >= MaxAttempts || permanent(sendErr) {
+			status = StatusFailed
+		}
+
+		c.log.Infow("deliver", "ID", e.ID, "status", status, "attempts", e.Attempts, "ERROR", sendErr)
+
+		if err := c.store.MarkUnsent(ctx, e.ID, status, sendErr.Error(), next, now); err != nil {
+			return len(dbEs), fmt.Errorf("mark unsent: %w", err)
+		}
+	}
+
+	return len(dbEs), nil
+}
+
+// QuerySuppressions retrieves a list of suppressed addresses.
+func (c Core) QuerySuppressions(ctx context.Context, pageNumber int, rowsPerPage int) ([]Suppression, error) {
+	dbSs, err := c.store.QuerySuppressions(ctx, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toSuppressionSlice(dbSs), nil
+}
+
+// Suppress adds the address to the suppression list. Suppressing an address
+// again replaces the reason it was suppressed for.
+func (c Core) Suppress(ctx context.Context, ns NewSuppression, now time.Time) (Suppression, error) {
+	if err := validate.Check(ns); err != nil {
+		return Suppression{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	dbS := db.Suppression{
+		Address:     strings.ToLower(ns.Address),
+		Reason:      ns.Reason,
+		Detail:      ns.Detail,
+		DateCreated: now,
+	}
+
+	if err := c.store.Suppress(ctx, dbS); err != nil {
+		return Suppression{}, fmt.Errorf("suppress: %w", err)
+	}
+
+	return toSuppression(dbS), nil
 }
 
-func NewEmailServer(log *zap.SugaredLogger, address string, sender string, auth smtp.Auth) EmailServer {
-	return EmailServer{
-		log:     log,
-		address: address,
-		sender:  sender,
-		auth:    auth,
+// Unsuppress removes the address from the suppression list.
+func (c Core) Unsuppress(ctx context.Context, address string) error {
+	if err := c.store.Unsuppress(ctx, strings.ToLower(address)); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrSuppressionNotFound
+		}
+		return fmt.Errorf("unsuppress: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// admit checks the address is not suppressed and has not been sent too many
+// e-mails lately. It locks the address until the transaction of the store
+// ends.
+func (c Core) admit(ctx context.Context, store db.Store, address string, now time.Time) error {
+	if err := c.suppressed(ctx, address); err != nil {
+		return err
+	}
+
+	if c.limits.Recipient > 0 {
+		if err := store.LockAddress(ctx, strings.ToLower(address)); err != nil {
+			return fmt.Errorf("lock address: %w", err)
+		}
+
+		n, err := store.CountCreated(ctx, strings.ToLower(address), now.Add(-c.limits.RecipientWindow))
+		if err != nil {
+			return fmt.Errorf("count created: %w", err)
+		}
+		if n >= c.limits.Recipient {
+			return ErrRateLimited
+		}
+	}
+
+	return nil
+}
+
+// suppressed returns ErrSuppressed when the address is on the suppression
+// list.
+func (c Core) suppressed(ctx context.Context, address string) error {
+	_, err := c.store.QuerySuppression(ctx, strings.ToLower(address))
+	switch {
+	case err == nil:
+		return ErrSuppressed
+	case errors.Is(err, database.ErrDBNotFound):
+		return nil
+	default:
+		return fmt.Errorf("query suppression: %w", err)
 	}
 }
 
-func (es *EmailServer) Send(ctx context.Context, req *email.EmailRequest) (*email.EmailResponse, error) {
-	message := []byte("Subject: Social network\n" + "Welcome to my social network!")
-	err := smtp.SendMail(es.address, es.auth, es.sender, []string{req.Email}, message)
+// send renders the e-mail and hands it to the transport. The address may
+// have been suppressed since the e-mail was queued.
+func (c Core) send(ctx context.Context, e Email, now time.Time) error {
+	if err := c.suppressed(ctx, e.Address); err != nil {
+		return err
+	}
+
+	content, err := c.templates.Render(e.Template, e.Locale, e.Vars)
 	if err != nil {
-		return nil, fmt.Errorf("send: %w", err)
+		return err
+	}
+
+	m := mail.Message{
+		From:    c.sender,
+		To:      []string{e.Address},
+		Subject: content.Subject,
+		Text:    content.Text,
+		HTML:    content.HTML,
+		Date:    now,
+
+		ListUnsubscribe: e.Vars["unsubscribe"],
 	}
 
-	res := &email.EmailResponse{
-		Message: "Successfuly sent an email to " + req.Email,
+	return c.transport.Send(ctx, m)
+}
+
+// backoff returns how long to wait after the specified number of failed
+// attempts.
+func backoff(attempts int) time.Duration {
+	d := minBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// permanent reports whether retrying can not fix the error: the template can
+// not be rendered, the address is suppressed or the mail server rejected the
+// message for good.
+func permanent(err error) bool {
+	if errors.Is(err, ErrUnknownTemplate) || errors.Is(err, ErrInvalidVars) || errors.Is(err, ErrSuppressed) {
+		return true
 	}
 
-	return res, nil
+	var tpErr *textproto.Error
+	return errors.As(err, &tpErr) && tpErr.Code >= 500
 }
@@ -0,0 +1,355 @@
+package email_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/email"
+	"github.com/dudakovict/social-network/business/data/email/dbtest"
+	"github.com/dudakovict/social-network/business/sys/validate"
+	"github.com/dudakovict/social-network/foundation/docker"
+	"github.com/dudakovict/social-network/foundation/mail"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+// transportFunc lets a test decide how every delivery goes.
+type transportFunc func(ctx context.Context, m mail.Message) error
+
+func (f transportFunc) Send(ctx context.Context, m mail.Message) error {
+	return f(ctx, m)
+}
+
+func TestQueue(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testqueue")
+	t.Cleanup(teardown)
+
+	templates, err := email.NewTemplates()
+	if err != nil {
+		t.Fatalf("Should be able to parse the templates : %s.", err)
+	}
+
+	mem := mail.NewMemory(0)
+	var sendErr error
+	transport := transportFunc(func(ctx context.Context, m mail.Message) error {
+		if sendErr != nil {
+			return sendErr
+		}
+		return mem.Send(ctx, m)
+	})
+
+	limits := email.Limits{
+		Recipient:       3,
+		RecipientWindow: time.Hour,
+		Global:          2,
+		GlobalWindow:    time.Minute,
+	}
+
+	core := email.NewCore(log, db, nil, templates, transport, "noreply@example.com", limits)
+
+	t.Log("Given the need to queue e-mails.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen queuing an e-mail.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+			ne := email.NewEmail{
+				RequestID: "welcome:test",
+				Address:   "user@example.com",
+				Template:  email.TemplateWelcome,
+				Locale:    "hr",
+				Vars:      map[string]string{"name": "Tomislav"},
+			}
+
+			e, err := core.Enqueue(ctx, ne, now)
+			if err != nil || e.ID != ne.RequestID || e.Status != email.StatusQueued {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to queue an e-mail : %+v %v.", dbtest.Failed, testID, e, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to queue an e-mail.", dbtest.Success, testID)
+
+			if _, err := core.Enqueue(ctx, ne, now.Add(time.Minute)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to queue the same request again : %s.", dbtest.Failed, testID, err)
+			}
+
+			if n, err := core.Deliver(ctx, now); err != nil || n != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould deliver the request once : %d %v.", dbtest.Failed, testID, n, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould deliver the request once.", dbtest.Success, testID)
+
+			got := mem.Messages()
+			if len(got) != 1 || got[0].To[0] != ne.Address || got[0].Subject != "Dobro došli na društvenu mrežu" {
+				t.Fatalf("\t%s\tTest %d:\tShould send the rendered message : %+v.", dbtest.Failed, testID, got)
+			}
+			t.Logf("\t%s\tTest %d:\tShould send the rendered message.", dbtest.Success, testID)
+
+			e, err = core.QueryByID(ctx, ne.RequestID)
+			if err != nil || e.Status != email.StatusSent || e.Attempts != 1 || e.DateSent == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be marked sent : %+v %v.", dbtest.Failed, testID, e, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be marked sent.", dbtest.Success, testID)
+
+			bad := []email.NewEmail{
+				{Address: "not an address", Template: email.TemplateWelcome, Vars: ne.Vars},
+				{Address: ne.Address, Template: "unknown"},
+				{Address: ne.Address, Template: email.TemplateVerification, Vars: ne.Vars},
+			}
+			for _, ne := range bad {
+				_, err := core.Enqueue(ctx, ne, now)
+				if !validate.IsFieldErrors(err) && !errors.Is(err, email.ErrUnknownTemplate) && !errors.Is(err, email.ErrInvalidVars) {
+					t.Fatalf("\t%s\tTest %d:\tShould NOT queue an invalid e-mail : %v.", dbtest.Failed, testID, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT queue invalid e-mails.", dbtest.Success, testID)
+		}
+
+		testID = 1
+		t.Logf("\tTest %d:\tWhen the mail server fails.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.April, 2, 0, 0, 0, 0, time.UTC)
+
+			ne := email.NewEmail{
+				Address:  "user@example.com",
+				Template: email.TemplateWelcome,
+				Vars:     map[string]string{"name": "Tomislav"},
+			}
+
+			e, err := core.Enqueue(ctx, ne, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to queue an e-mail : %s.", dbtest.Failed, testID, err)
+			}
+
+			sendErr = errors.New("connection refused")
+			if _, err := core.Deliver(ctx, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to deliver : %s.", dbtest.Failed, testID, err)
+			}
+
+			e, err = core.QueryByID(ctx, e.ID)
+			if err != nil || e.Status != email.StatusRetrying || e.LastError == nil || !e.NextAttempt.Equal(now.Add(30*time.Second)) {
+				t.Fatalf("\t%s\tTest %d:\tShould retry after a while : %+v %v.", dbtest.Failed, testID, e, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould retry after a while.", dbtest.Success, testID)
+
+			if n, err := core.Deliver(ctx, now.Add(10*time.Second)); err != nil || n != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT retry too early : %d %v.", dbtest.Failed, testID, n, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT retry too early.", dbtest.Success, testID)
+
+			sendErr = &textproto.Error{Code: 550, Msg: "mailbox unavailable"}
+			if n, err := core.Deliver(ctx, now.Add(time.Minute)); err != nil || n != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould retry when due : %d %v.", dbtest.Failed, testID, n, err)
+			}
+
+			e, err = core.QueryByID(ctx, e.ID)
+			if err != nil || e.Status != email.StatusFailed || e.Attempts != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould give up on a permanent error : %+v %v.", dbtest.Failed, testID, e, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould give up on a permanent error.", dbtest.Success, testID)
+
+			if _, err := core.QueryByID(ctx, "unknown"); !errors.Is(err, email.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT find an unknown e-mail : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT find an unknown e-mail.", dbtest.Success, testID)
+		}
+
+		testID = 2
+		t.Logf("\tTest %d:\tWhen sending too much or to suppressed addresses.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.April, 3, 0, 0, 0, 0, time.UTC)
+			sendErr = nil
+
+			ne := email.NewEmail{
+				Address:  "limited@example.com",
+				Template: email.TemplateWelcome,
+				Vars:     map[string]string{"name": "Tomislav"},
+			}
+
+			for i := 0; i < limits.Recipient; i++ {
+				if _, err := core.Enqueue(ctx, ne, now); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to queue up to the limit : %s.", dbtest.Failed, testID, err)
+				}
+			}
+			if _, err := core.Enqueue(ctx, ne, now); !errors.Is(err, email.ErrRateLimited) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT queue over the limit : %v.", dbtest.Failed, testID, err)
+			}
+			if _, err := core.Enqueue(ctx, ne, now.Add(limits.RecipientWindow)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to queue once the window passed : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould limit the e-mails to an address.", dbtest.Success, testID)
+
+			if n, err := core.Deliver(ctx, now); err != nil || n != limits.Global {
+				t.Fatalf("\t%s\tTest %d:\tShould deliver up to the global limit : %d %v.", dbtest.Failed, testID, n, err)
+			}
+			if n, err := core.Deliver(ctx, now); err != nil || n != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT deliver over the global limit : %d %v.", dbtest.Failed, testID, n, err)
+			}
+			if n, err := core.Deliver(ctx, now.Add(limits.GlobalWindow)); err != nil || n != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould deliver the rest once the window passed : %d %v.", dbtest.Failed, testID, n, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould limit the e-mails sent.", dbtest.Success, testID)
+
+			queued, err := core.Enqueue(ctx, email.NewEmail{Address: "Bounced@example.com", Template: email.TemplateWelcome, Vars: ne.Vars}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to queue an e-mail : %s.", dbtest.Failed, testID, err)
+			}
+
+			ns := email.NewSuppression{
+				Address: "bounced@example.com",
+				Reason:  email.ReasonBounce,
+				Detail:  "550 mailbox unavailable",
+			}
+			if _, err := core.Suppress(ctx, ns, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to suppress an address : %s.", dbtest.Failed, testID, err)
+			}
+
+			sups, err := core.QuerySuppressions(ctx, 1, 10)
+			if err != nil || len(sups) != 1 || sups[0].Address != ns.Address || sups[0].Reason != ns.Reason {
+				t.Fatalf("\t%s\tTest %d:\tShould list the suppressed address : %+v %v.", dbtest.Failed, testID, sups, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould list the suppressed address.", dbtest.Success, testID)
+
+			if _, err := core.Enqueue(ctx, email.NewEmail{Address: "BOUNCED@example.com", Template: email.TemplateWelcome, Vars: ne.Vars}, now); !errors.Is(err, email.ErrSuppressed) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT queue to a suppressed address : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT queue to a suppressed address.", dbtest.Success, testID)
+
+			later := now.Add(time.Hour)
+			if _, err := core.Deliver(ctx, later); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to deliver : %s.", dbtest.Failed, testID, err)
+			}
+			e, err := core.QueryByID(ctx, queued.ID)
+			if err != nil || e.Status != email.StatusFailed {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT send e-mails queued before the address was suppressed : %+v %v.", dbtest.Failed, testID, e, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT send e-mails queued before the address was suppressed.", dbtest.Success, testID)
+
+			if err := core.Unsuppress(ctx, ns.Address); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unsuppress an address : %s.", dbtest.Failed, testID, err)
+			}
+			if err := core.Unsuppress(ctx, ns.Address); !errors.Is(err, email.ErrSuppressionNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT unsuppress an address twice : %v.", dbtest.Failed, testID, err)
+			}
+			if _, err := core.Enqueue(ctx, email.NewEmail{Address: ns.Address, Template: email.TemplateWelcome, Vars: ne.Vars}, later); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to queue once unsuppressed : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to queue once unsuppressed.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestReports(t *testing.T) {
+	log, db, teardown := dbtest.NewUnit(t, c, "testreports")
+	t.Cleanup(teardown)
+
+	templates, err := email.NewTemplates()
+	if err != nil {
+		t.Fatalf("Should be able to parse the templates : %s.", err)
+	}
+
+	core := email.NewCore(log, db, nil, templates, mail.NewMemory(0), "noreply@example.com", email.Limits{})
+
+	bounce := func(address string, action string, status string) mail.Report {
+		return mail.Report{
+			Type:       mail.ReportDeliveryStatus,
+			Recipients: []mail.ReportRecipient{{Address: address, Action: action, Status: status}},
+		}
+	}
+
+	t.Log("Given the need to process the reports mail servers send back.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen classifying reports.", testID)
+		{
+			tt := []struct {
+				rep  mail.Report
+				kind string
+			}{
+				{bounce("a@example.com", "failed", "5.1.1"), email.BounceHard},
+				{bounce("a@example.com", "failed", "5.2.2"), email.BounceSoft},
+				{bounce("a@example.com", "failed", "4.4.1"), email.BounceSoft},
+				{bounce("a@example.com", "delayed", "4.2.2"), ""},
+				{bounce("a@example.com", "delivered", "2.0.0"), ""},
+				{mail.Report{Type: mail.ReportFeedback, Recipients: []mail.ReportRecipient{{Address: "a@example.com"}}}, email.BounceComplaint},
+			}
+			for _, tc := range tt {
+				bs := email.Classify(tc.rep)
+				var kind string
+				if len(bs) > 0 {
+					kind = bs[0].Kind
+				}
+				if kind != tc.kind {
+					t.Fatalf("\t%s\tTest %d:\tShould classify %+v as %q : got %q.", dbtest.Failed, testID, tc.rep.Recipients[0], tc.kind, kind)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould classify hard and soft bounces and complaints.", dbtest.Success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen processing reports.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+			bs, err := core.ProcessReport(ctx, bounce("Gone@example.com", "failed", "5.1.1"), now)
+			if err != nil || len(bs) != 1 || !bs[0].Suppressed {
+				t.Fatalf("\t%s\tTest %d:\tShould suppress a hard bounce : %+v %v.", dbtest.Failed, testID, bs, err)
+			}
+			ne := email.NewEmail{Address: "gone@example.com", Template: email.TemplateWelcome, Vars: map[string]string{"name": "Tomislav"}}
+			if _, err := core.Enqueue(ctx, ne, now); !errors.Is(err, email.ErrSuppressed) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT queue to a hard bounced address : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould suppress a hard bounce.", dbtest.Success, testID)
+
+			rep := bounce("twice@example.com", "failed", "5.2.2")
+			rep.MessageID = "20190401000000.1@mx.example.com"
+			for i := 0; i < 2; i++ {
+				bs, err := core.ProcessReport(ctx, rep, now)
+				if err != nil || len(bs) != 1-i {
+					t.Fatalf("\t%s\tTest %d:\tShould record a report delivered twice once : %d %+v %v.", dbtest.Failed, testID, i, bs, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould record a report delivered twice once.", dbtest.Success, testID)
+
+			for i := 1; i <= email.SoftBounceLimit; i++ {
+				bs, err := core.ProcessReport(ctx, bounce("slow@example.com", "delayed", "4.4.7"), now.Add(time.Duration(i)*time.Hour))
+				if err != nil || len(bs) != 0 {
+					t.Fatalf("\t%s\tTest %d:\tShould NOT count delay notices as bounces : %d %+v %v.", dbtest.Failed, testID, i, bs, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT count delay notices as bounces.", dbtest.Success, testID)
+
+			for i := 1; i <= email.SoftBounceLimit; i++ {
+				bs, err := core.ProcessReport(ctx, bounce("full@example.com", "failed", "4.2.2"), now.Add(time.Duration(i)*time.Hour))
+				if err != nil || len(bs) != 1 || bs[0].Suppressed != (i == email.SoftBounceLimit) {
+					t.Fatalf("\t%s\tTest %d:\tShould only suppress repeated soft bounces : %d %+v %v.", dbtest.Failed, testID, i, bs, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould only suppress repeated soft bounces.", dbtest.Success, testID)
+
+			sups, err := core.QuerySuppressions(ctx, 1, 10)
+			if err != nil || len(sups) != 2 || sups[0].Address != "full@example.com" || sups[1].Reason != email.ReasonBounce {
+				t.Fatalf("\t%s\tTest %d:\tShould list the suppressed addresses : %+v %v.", dbtest.Failed, testID, sups, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould list the suppressed addresses.", dbtest.Success, testID)
+		}
+	}
+}
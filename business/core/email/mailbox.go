@@ -0,0 +1,93 @@
+package email
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/dudakovict/social-network/foundation/mail"
+	"go.uber.org/zap"
+)
+
+// Mailbox processes the reports mail servers send to the bounce address in
+// the background. The reports are read from the Maildir the mail server
+// delivers that address into, on every tick.
+type Mailbox struct {
+	log      *zap.SugaredLogger
+	core     Core
+	maildir  *mail.Maildir
+	interval time.Duration
+	shutdown chan struct{}
+	done     chan struct{}
+}
+
+// NewMailbox constructs a mailbox for processing the reports in the Maildir.
+func NewMailbox(log *zap.SugaredLogger, core Core, maildir *mail.Maildir, interval time.Duration) *Mailbox {
+	return &Mailbox{
+		log:      log,
+		core:     core,
+		maildir:  maildir,
+		interval: interval,
+		shutdown: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start begins processing the mailbox in a goroutine.
+func (m *Mailbox) Start() {
+	go func() {
+		defer close(m.done)
+
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			m.receive()
+
+			select {
+			case <-m.shutdown:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown waits for the reports being processed to finish, or for the
+// context to be done.
+func (m *Mailbox) Shutdown(ctx context.Context) error {
+	close(m.shutdown)
+
+	select {
+	case <-m.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// receive processes every new message. Messages that are not reports are
+// skipped, a message that fails to process stays for the next tick.
+func (m *Mailbox) receive() {
+	fn := func(r io.Reader) error {
+		rep, err := mail.ParseReport(r)
+		if err != nil {
+			m.log.Infow("mailbox", "status", "skipping message", "ERROR", err)
+			return nil
+		}
+
+		bs, err := m.core.ProcessReport(context.Background(), rep, time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, b := range bs {
+			m.log.Infow("mailbox", "address", b.Address, "kind", b.Kind, "status", b.Status, "suppressed", b.Suppressed)
+		}
+		return nil
+	}
+
+	if _, err := m.maildir.Receive(fn); err != nil {
+		m.log.Errorw("mailbox", "ERROR", err)
+	}
+}
@@ -0,0 +1,88 @@
+package email
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/email/db"
+)
+
+// Email represents an e-mail in the delivery queue.
+type Email struct {
+	ID          string            `json:"id"`
+	Address     string            `json:"address"`
+	Template    string            `json:"template"`
+	Locale      string            `json:"locale"`
+	Vars        map[string]string `json:"vars"`
+	Status      string            `json:"status"`
+	Attempts    int               `json:"attempts"`
+	LastError   *string           `json:"last_error,omitempty"`
+	NextAttempt time.Time         `json:"next_attempt"`
+	DateCreated time.Time         `json:"date_created"`
+	DateUpdated time.Time         `json:"date_updated"`
+	DateSent    *time.Time        `json:"date_sent,omitempty"`
+}
+
+// NewEmail contains information needed to queue an e-mail. Queuing the same
+// request ID again returns the e-mail queued the first time.
+type NewEmail struct {
+	RequestID string            `json:"request_id" validate:"omitempty,max=128"`
+	Address   string            `json:"address" validate:"required,email"`
+	Template  string            `json:"template" validate:"required"`
+	Locale    string            `json:"locale" validate:"omitempty,max=35"`
+	Vars      map[string]string `json:"vars"`
+}
+
+// Suppression represents an address no e-mail is sent to.
+type Suppression struct {
+	Address     string    `json:"address"`
+	Reason      string    `json:"reason"`
+	Detail      string    `json:"detail"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+// NewSuppression contains information needed to suppress an address.
+type NewSuppression struct {
+	Address string `json:"address" validate:"required,email"`
+	Reason  string `json:"reason" validate:"required,oneof=bounce complaint manual"`
+	Detail  string `json:"detail" validate:"omitempty,max=1000"`
+}
+
+// =============================================================================
+
+func toEmail(dbE db.Email) Email {
+	var vars map[string]string
+	json.Unmarshal([]byte(dbE.Vars), &vars)
+
+	return Email{
+		ID:          dbE.ID,
+		Address:     dbE.Address,
+		Template:    dbE.Template,
+		Locale:      dbE.Locale,
+		Vars:        vars,
+		Status:      dbE.Status,
+		Attempts:    dbE.Attempts,
+		LastError:   dbE.LastError,
+		NextAttempt: dbE.NextAttempt,
+		DateCreated: dbE.DateCreated,
+		DateUpdated: dbE.DateUpdated,
+		DateSent:    dbE.DateSent,
+	}
+}
+
+func toSuppression(dbS db.Suppression) Suppression {
+	return Suppression{
+		Address:     dbS.Address,
+		Reason:      dbS.Reason,
+		Detail:      dbS.Detail,
+		DateCreated: dbS.DateCreated,
+	}
+}
+
+func toSuppressionSlice(dbSs []db.Suppression) []Suppression {
+	sups := make([]Suppression, len(dbSs))
+	for i, dbS := range dbSs {
+		sups[i] = toSuppression(dbS)
+	}
+	return sups
+}
@@ -0,0 +1,202 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dudakovict/social-network/business/data/email"
+	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/validate"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EmailServer implements the gRPC API of the e-mail queue.
+type EmailServer struct {
+	log  *zap.SugaredLogger
+	core Core
+	email.UnimplementedEmailServer
+}
+
+// NewEmailServer constructs a gRPC server for the core.
+func NewEmailServer(log *zap.SugaredLogger, core Core) EmailServer {
+	return EmailServer{
+		log:  log,
+		core: core,
+	}
+}
+
+// Send queues the requested template for delivery to the address. Sending
+// the same request ID again does not queue it twice. Sends to suppressed
+// addresses and to addresses over their rate limit are rejected with the
+// codes of the email package.
+func (es *EmailServer) Send(ctx context.Context, req *email.EmailRequest) (*email.EmailResponse, error) {
+	ne := NewEmail{
+		RequestID: req.RequestId,
+		Address:   req.Email,
+		Template:  req.Template,
+		Locale:    req.Locale,
+		Vars:      req.Vars,
+	}
+
+	e, err := es.core.Enqueue(ctx, ne, time.Now())
+	if err != nil {
+		switch {
+		case validate.IsFieldErrors(err), errors.Is(err, ErrUnknownTemplate), errors.Is(err, ErrInvalidVars):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, ErrSuppressed):
+			return nil, status.Error(email.CodeSuppressed, err.Error())
+		case errors.Is(err, ErrRateLimited):
+			return nil, status.Error(email.CodeRateLimited, err.Error())
+		default:
+			return nil, fmt.Errorf("enqueue: %w", err)
+		}
+	}
+
+	res := &email.EmailResponse{
+		Message: "Successfuly queued an email to " + req.Email,
+		Id:      e.ID,
+		Status:  e.Status,
+	}
+
+	return res, nil
+}
+
+// Status returns the delivery status of a queued e-mail.
+func (es *EmailServer) Status(ctx context.Context, req *email.StatusRequest) (*email.StatusResponse, error) {
+	e, err := es.core.QueryByID(ctx, req.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return nil, status.Error(codes.NotFound, err.Error())
+		default:
+			return nil, fmt.Errorf("query: %w", err)
+		}
+	}
+
+	res := &email.StatusResponse{
+		Id:          e.ID,
+		Email:       e.Address,
+		Template:    e.Template,
+		Status:      e.Status,
+		Attempts:    int32(e.Attempts),
+		NextAttempt: e.NextAttempt.Format(time.RFC3339),
+		DateCreated: e.DateCreated.Format(time.RFC3339),
+		DateUpdated: e.DateUpdated.Format(time.RFC3339),
+	}
+	if e.LastError != nil {
+		res.LastError = *e.LastError
+	}
+	if e.DateSent != nil {
+		res.DateSent = e.DateSent.Format(time.RFC3339)
+	}
+
+	return res, nil
+}
+
+// ListSuppressions returns a page of the suppression list. Only admins can
+// see it.
+func (es *EmailServer) ListSuppressions(ctx context.Context, req *email.ListSuppressionsRequest) (*email.ListSuppressionsResponse, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	page, rows := int(req.Page), int(req.Rows)
+	if page < 1 {
+		page = 1
+	}
+	if rows < 1 {
+		rows = 50
+	}
+
+	sups, err := es.core.QuerySuppressions(ctx, page, rows)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	res := &email.ListSuppressionsResponse{
+		Suppressions: make([]*email.Suppression, len(sups)),
+	}
+	for i, sup := range sups {
+		res.Suppressions[i] = toProtoSuppression(sup)
+	}
+
+	return res, nil
+}
+
+// AddSuppression stops all e-mails to the address. Only admins can add to
+// the list.
+func (es *EmailServer) AddSuppression(ctx context.Context, req *email.AddSuppressionRequest) (*email.Suppression, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	ns := NewSuppression{
+		Address: req.Email,
+		Reason:  req.Reason,
+		Detail:  req.Detail,
+	}
+	if ns.Reason == "" {
+		ns.Reason = ReasonManual
+	}
+
+	sup, err := es.core.Suppress(ctx, ns, time.Now())
+	if err != nil {
+		switch {
+		case validate.IsFieldErrors(err):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		default:
+			return nil, fmt.Errorf("suppress: %w", err)
+		}
+	}
+
+	return toProtoSuppression(sup), nil
+}
+
+// RemoveSuppression lets e-mails go to the address again. Only admins can
+// remove from the list.
+func (es *EmailServer) RemoveSuppression(ctx context.Context, req *email.RemoveSuppressionRequest) (*email.RemoveSuppressionResponse, error) {
+	if err := authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := es.core.Unsuppress(ctx, req.Email); err != nil {
+		switch {
+		case errors.Is(err, ErrSuppressionNotFound):
+			return nil, status.Error(codes.NotFound, err.Error())
+		default:
+			return nil, fmt.Errorf("unsuppress: %w", err)
+		}
+	}
+
+	return &email.RemoveSuppressionResponse{}, nil
+}
+
+// =============================================================================
+
+// authorizeAdmin only lets admins through. Services may send e-mails but not
+// edit the suppression list.
+func authorizeAdmin(ctx context.Context) error {
+	claims, err := auth.GetClaims(ctx)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, "claims missing from context")
+	}
+
+	if !claims.Authorized(auth.RoleAdmin) {
+		return status.Errorf(codes.PermissionDenied, "you are not authorized for that action, claims[%v] roles[%v]", claims.Roles, []string{auth.RoleAdmin})
+	}
+
+	return nil
+}
+
+func toProtoSuppression(sup Suppression) *email.Suppression {
+	return &email.Suppression{
+		Email:       sup.Address,
+		Reason:      sup.Reason,
+		Detail:      sup.Detail,
+		DateCreated: sup.DateCreated.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,266 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"unicode/utf8"
+
+	"github.com/dudakovict/social-network/business/sys/locale"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// Set of templates an e-mail can be rendered from, with the variables each
+// of them needs.
+const (
+	TemplateWelcome       = "welcome"        // name
+	TemplateVerification  = "verification"   // name, link
+	TemplatePasswordReset = "password_reset" // name, link, expires
+	TemplateDigest        = "digest"         // name, count, items (one per line), link
+	TemplateAlert         = "alert"          // name, event, time
+	TemplateNotification  = "notification"   // name, summary, link
+)
+
+// Every template takes an optional unsubscribe variable. When set, the
+// footer links to it and the e-mail carries the one-click unsubscribe
+// headers.
+
+// Set of error variables for rendering templates.
+var (
+	ErrUnknownTemplate = errors.New("unknown template")
+	ErrInvalidVars     = errors.New("invalid template variables")
+)
+
+//go:embed templates templates/_*
+var templateFS embed.FS
+
+// Content is a rendered e-mail.
+type Content struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// pair holds the plain text and the HTML version of a template. The subject
+// is defined in both, the plain text one is used.
+type pair struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Templates holds the embedded templates by locale and name.
+type Templates struct {
+	locales map[string]map[string]pair
+}
+
+// NewTemplates parses the embedded templates for every supported locale.
+// Every template exists as a .txt and a .html file shared by the locales,
+// the HTML ones are wrapped in the shared layout. Files starting with an
+// underscore are partials shared by every template. The messages come from
+// the catalog of the locale, named after it in the catalogs directory.
+func NewTemplates() (*Templates, error) {
+	uni := locale.New()
+
+	catalogs, err := fs.Glob(templateFS, "templates/catalogs/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range catalogs {
+		f, err := templateFS.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("opening %s: %w", file, err)
+		}
+		err = uni.ImportByReader(ut.FormatJSON, f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("importing %s: %w", file, err)
+		}
+	}
+
+	if err := uni.VerifyTranslations(); err != nil {
+		return nil, fmt.Errorf("verifying catalogs: %w", err)
+	}
+
+	layout, err := fs.ReadFile(templateFS, "templates/layout.html")
+	if err != nil {
+		return nil, fmt.Errorf("reading layout: %w", err)
+	}
+
+	files, err := fs.Glob(templateFS, "templates/*.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	t := Templates{
+		locales: make(map[string]map[string]pair),
+	}
+
+	for _, l := range locale.Supported() {
+		name := locale.Normalize(l.Locale())
+		funcs := catalogFuncs(uni, name)
+
+		t.locales[name] = make(map[string]pair)
+		for _, file := range files {
+			tmpl := strings.TrimSuffix(path.Base(file), ".txt")
+			if strings.HasPrefix(tmpl, "_") {
+				continue
+			}
+
+			text, err := texttemplate.New(tmpl).Funcs(funcs).Option("missingkey=error").ParseFS(templateFS, "templates/_*.txt", file)
+			if err != nil {
+				return nil, fmt.Errorf("parsing %s: %w", file, err)
+			}
+
+			html, err := htmltemplate.New(tmpl).Funcs(funcs).Option("missingkey=error").Parse(string(layout))
+			if err != nil {
+				return nil, fmt.Errorf("parsing layout: %w", err)
+			}
+			if html, err = html.ParseFS(templateFS, "templates/_*.html", path.Join("templates", tmpl+".html")); err != nil {
+				return nil, fmt.Errorf("parsing %s: %w", tmpl, err)
+			}
+
+			t.locales[name][tmpl] = pair{
+				text: text.Lookup(path.Base(file)),
+				html: html.Lookup("layout"),
+			}
+		}
+	}
+
+	return &t, nil
+}
+
+// Render renders the named template for the locale with the variables. An
+// unsupported locale falls back to its base language and then to the
+// default locale. Every variable the template uses must be provided.
+func (t *Templates) Render(name string, locale string, vars map[string]string) (Content, error) {
+	p, ok := t.lookup(name, locale)
+	if !ok {
+		return Content{}, fmt.Errorf("%w: %q", ErrUnknownTemplate, name)
+	}
+
+	if vars == nil {
+		vars = map[string]string{}
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := p.text.ExecuteTemplate(&subject, "subject", vars); err != nil {
+		return Content{}, fmt.Errorf("%w: %s", ErrInvalidVars, err)
+	}
+	if err := p.text.Execute(&text, vars); err != nil {
+		return Content{}, fmt.Errorf("%w: %s", ErrInvalidVars, err)
+	}
+	if err := p.text.ExecuteTemplate(&text, "footer", vars); err != nil {
+		return Content{}, fmt.Errorf("%w: %s", ErrInvalidVars, err)
+	}
+	if err := p.html.Execute(&html, vars); err != nil {
+		return Content{}, fmt.Errorf("%w: %s", ErrInvalidVars, err)
+	}
+
+	c := Content{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}
+
+	return c, nil
+}
+
+// lookup finds the template for the first supported locale of the fallback
+// chain.
+func (t *Templates) lookup(name string, requested string) (pair, bool) {
+	for _, l := range locale.Chain(requested) {
+		if tmpls, ok := t.locales[l]; ok {
+			p, ok := tmpls[name]
+			return p, ok
+		}
+	}
+
+	return pair{}, false
+}
+
+// catalogFuncs returns the functions templates use for the messages of the
+// locale. A message missing from its catalog falls back to the catalogs of
+// the base language and the default locale.
+//
+//	t      the message with the parameters
+//	c      the plural form of the message for the count
+//	wrap   wraps a message of the plain text version into lines
+//	lines  splits a variable holding one item per line
+//	locale the name of the locale
+func catalogFuncs(uni *ut.UniversalTranslator, name string) map[string]interface{} {
+	var chain []ut.Translator
+	for _, l := range locale.Chain(name) {
+		if trans, ok := uni.GetTranslator(l); ok {
+			chain = append(chain, trans)
+		}
+	}
+
+	return map[string]interface{}{
+		"t": func(key string, params ...string) (string, error) {
+			for _, trans := range chain {
+				if msg, err := trans.T(key, params...); err == nil {
+					return msg, nil
+				}
+			}
+			return "", fmt.Errorf("no message for %q", key)
+		},
+		"c": func(key string, count string) (string, error) {
+			n, err := strconv.ParseFloat(count, 64)
+			if err != nil {
+				return "", fmt.Errorf("count for %q: %w", key, err)
+			}
+			for _, trans := range chain {
+				if msg, err := trans.C(key, n, 0, trans.FmtNumber(n, 0)); err == nil {
+					return msg, nil
+				}
+			}
+			return "", fmt.Errorf("no message for %q", key)
+		},
+		"wrap":  wrap,
+		"lines": lines,
+		"locale": func() string {
+			return name
+		},
+	}
+}
+
+// wrap breaks the message into lines of at most 72 characters where it can.
+func wrap(s string) string {
+	const width = 72
+
+	var b strings.Builder
+	n := 0
+	for _, word := range strings.Fields(s) {
+		switch {
+		case n == 0:
+		case n+1+utf8.RuneCountInString(word) > width:
+			b.WriteString("\n")
+			n = 0
+		default:
+			b.WriteString(" ")
+			n++
+		}
+		b.WriteString(word)
+		n += utf8.RuneCountInString(word)
+	}
+	return b.String()
+}
+
+// lines splits a variable holding one item per line, skipping blank lines.
+func lines(s string) []string {
+	var items []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			items = append(items, l)
+		}
+	}
+	return items
+}
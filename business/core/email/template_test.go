@@ -0,0 +1,136 @@
+package email_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dudakovict/social-network/business/core/email"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestTemplates(t *testing.T) {
+	templates, err := email.NewTemplates()
+	if err != nil {
+		t.Fatalf("Should be able to parse the templates : %s.", err)
+	}
+
+	vars := map[string]map[string]string{
+		email.TemplateWelcome:       {"name": "Tomislav"},
+		email.TemplateVerification:  {"name": "Tomislav", "link": "https://example.com/verify?token=abc"},
+		email.TemplatePasswordReset: {"name": "Tomislav", "link": "https://example.com/reset?token=abc", "expires": "1h"},
+		email.TemplateDigest:        {"name": "Tomislav", "count": "2", "items": "Ana liked your post\nIvan followed you", "link": "https://example.com"},
+		email.TemplateAlert:         {"name": "Tomislav", "event": "Password changed", "time": "2019-04-01 10:00 UTC"},
+		email.TemplateNotification:  {"name": "Tomislav", "summary": "Ana liked your post", "link": "https://example.com"},
+	}
+
+	t.Log("Given the need to render e-mail templates.")
+	{
+		testID := 0
+		for _, locale := range []string{"en", "hr"} {
+			t.Logf("\tTest %d:\tWhen rendering every template in %q.", testID, locale)
+			{
+				for name, v := range vars {
+					c, err := templates.Render(name, locale, v)
+					if err != nil {
+						t.Fatalf("\t%s\tTest %d:\tShould be able to render %q : %s.", failed, testID, name, err)
+					}
+					if c.Subject == "" || !strings.Contains(c.Text, "Tomislav") || !strings.Contains(c.HTML, "Tomislav") {
+						t.Fatalf("\t%s\tTest %d:\tShould render the variables of %q : %+v.", failed, testID, name, c)
+					}
+				}
+				t.Logf("\t%s\tTest %d:\tShould be able to render every template.", success, testID)
+			}
+			testID++
+		}
+
+		t.Logf("\tTest %d:\tWhen rendering in an unsupported locale.", testID)
+		{
+			hr, err := templates.Render(email.TemplateWelcome, "hr_HR", vars[email.TemplateWelcome])
+			if err != nil || !strings.HasPrefix(hr.Text, "Bok") {
+				t.Fatalf("\t%s\tTest %d:\tShould fall back to the base language : %+v %v.", failed, testID, hr, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould fall back to the base language.", success, testID)
+
+			de, err := templates.Render(email.TemplateWelcome, "de", vars[email.TemplateWelcome])
+			if err != nil || !strings.HasPrefix(de.Text, "Hi") {
+				t.Fatalf("\t%s\tTest %d:\tShould fall back to the default locale : %+v %v.", failed, testID, de, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould fall back to the default locale.", success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen rendering counts.", testID)
+		{
+			subjects := []struct {
+				locale string
+				count  string
+				want   string
+			}{
+				{locale: "en", count: "1", want: "You have 1 new notification"},
+				{locale: "en", count: "5", want: "You have 5 new notifications"},
+				{locale: "hr", count: "21", want: "Imate 21 novu obavijest"},
+				{locale: "hr", count: "3", want: "Imate 3 nove obavijesti"},
+				{locale: "hr", count: "1000", want: "Imate 1.000 novih obavijesti"},
+			}
+
+			for _, s := range subjects {
+				v := map[string]string{"name": "Tomislav", "count": s.count, "items": "Ana liked your post", "link": "https://example.com"}
+				c, err := templates.Render(email.TemplateDigest, s.locale, v)
+				if err != nil || c.Subject != s.want {
+					t.Fatalf("\t%s\tTest %d:\tShould use the plural form of %q : got %q, want %q : %v.", failed, testID, s.locale, c.Subject, s.want, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould use the plural form of the locale.", success, testID)
+
+			c, err := templates.Render(email.TemplateWelcome, "hr", vars[email.TemplateWelcome])
+			if err != nil || !strings.Contains(c.HTML, `<html lang="hr">`) {
+				t.Fatalf("\t%s\tTest %d:\tShould set the language of the HTML : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould set the language of the HTML.", success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen rendering with bad input.", testID)
+		{
+			if _, err := templates.Render("unknown", "en", nil); !errors.Is(err, email.ErrUnknownTemplate) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT render an unknown template : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT render an unknown template.", success, testID)
+
+			if _, err := templates.Render(email.TemplateVerification, "en", map[string]string{"name": "Tomislav"}); !errors.Is(err, email.ErrInvalidVars) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT render with a missing variable : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT render with a missing variable.", success, testID)
+
+			c, err := templates.Render(email.TemplateWelcome, "en", map[string]string{"name": "<script>alert(1)</script>"})
+			if err != nil || strings.Contains(c.HTML, "<script>") {
+				t.Fatalf("\t%s\tTest %d:\tShould escape the variables in HTML : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould escape the variables in HTML.", success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen rendering with an unsubscribe link.", testID)
+		{
+			c, err := templates.Render(email.TemplateWelcome, "en", vars[email.TemplateWelcome])
+			if err != nil || strings.Contains(c.Text, "Unsubscribe") || strings.Contains(c.HTML, "Unsubscribe") {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT render the footer without a link : %+v %v.", failed, testID, c, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT render the footer without a link.", success, testID)
+
+			link := "https://example.com/v1/notifications/unsubscribe?token=abc"
+			v := map[string]string{"name": "Tomislav", "unsubscribe": link}
+			c, err = templates.Render(email.TemplateWelcome, "en", v)
+			if err != nil || !strings.HasSuffix(c.Text, "Unsubscribe: "+link+"\n") || !strings.Contains(c.HTML, `href="`+link+`"`) {
+				t.Fatalf("\t%s\tTest %d:\tShould render the footer with the link : %+v %v.", failed, testID, c, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould render the footer with the link.", success, testID)
+		}
+	}
+}
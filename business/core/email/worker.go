@@ -0,0 +1,84 @@
+package email
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Worker delivers the queued e-mails in the background. It checks the queue
+// on every tick and right away when an e-mail is queued.
+type Worker struct {
+	log      *zap.SugaredLogger
+	core     Core
+	interval time.Duration
+	shutdown chan struct{}
+	done     chan struct{}
+}
+
+// NewWorker constructs a worker for delivering the queue of the core.
+func NewWorker(log *zap.SugaredLogger, core Core, interval time.Duration) *Worker {
+	return &Worker{
+		log:      log,
+		core:     core,
+		interval: interval,
+		shutdown: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start begins delivering the queue in a goroutine.
+func (w *Worker) Start() {
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			w.deliver()
+
+			select {
+			case <-w.shutdown:
+				return
+			case <-ticker.C:
+			case <-w.core.wake:
+			}
+		}
+	}()
+}
+
+// Shutdown waits for the batch being delivered to finish, or for the context
+// to be done.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	close(w.shutdown)
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliver sends batches until no more e-mails are due.
+func (w *Worker) deliver() {
+	for {
+		n, err := w.core.Deliver(context.Background(), time.Now())
+		if err != nil {
+			w.log.Errorw("worker", "ERROR", err)
+			return
+		}
+
+		if n < batchSize {
+			return
+		}
+
+		select {
+		case <-w.shutdown:
+			return
+		default:
+		}
+	}
+}
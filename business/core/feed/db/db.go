@@ -0,0 +1,289 @@
+// Package db contains home timeline related CRUD functionality.
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of API's for timeline access.
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+// NewStore constructs a data for api access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create adds a post to a single timeline or refreshes it when it is
+// already there.
+func (s Store) Create(ctx context.Context, e Entry) error {
+	const q = `
+	INSERT INTO timelines
+		(user_id, post_id, author_id, date_published, title, description, date_created, date_updated, edited, publish_at, visibility, fan_out_on_read)
+	VALUES
+		(:user_id, :post_id, :author_id, :date_published, :title, :description, :date_created, :date_updated, :edited, :publish_at, :visibility, :fan_out_on_read)
+	ON CONFLICT (user_id, post_id) DO UPDATE SET
+		date_published = EXCLUDED.date_published,
+		title = EXCLUDED.title,
+		description = EXCLUDED.description,
+		date_updated = EXCLUDED.date_updated,
+		edited = EXCLUDED.edited,
+		publish_at = EXCLUDED.publish_at,
+		visibility = EXCLUDED.visibility,
+		fan_out_on_read = EXCLUDED.fan_out_on_read`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, e); err != nil {
+		return fmt.Errorf("inserting entry: %w", err)
+	}
+
+	return nil
+}
+
+// FanOut adds a post to the timelines of all the followers of its author or
+// refreshes it where it is already there.
+func (s Store) FanOut(ctx context.Context, e Entry) error {
+	const q = `
+	INSERT INTO timelines
+		(user_id, post_id, author_id, date_published, title, description, date_created, date_updated, edited, publish_at, visibility)
+	SELECT
+		follower_id,
+		CAST(:post_id AS UUID),
+		CAST(:author_id AS UUID),
+		CAST(:date_published AS TIMESTAMP),
+		:title,
+		:description,
+		CAST(:date_created AS TIMESTAMP),
+		CAST(:date_updated AS TIMESTAMP),
+		:edited,
+		CAST(:publish_at AS TIMESTAMP),
+		:visibility
+	FROM
+		followers
+	WHERE
+		user_id = :author_id
+	ON CONFLICT (user_id, post_id) DO UPDATE SET
+		date_published = EXCLUDED.date_published,
+		title = EXCLUDED.title,
+		description = EXCLUDED.description,
+		date_updated = EXCLUDED.date_updated,
+		edited = EXCLUDED.edited,
+		publish_at = EXCLUDED.publish_at,
+		visibility = EXCLUDED.visibility`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, e); err != nil {
+		return fmt.Errorf("fanning out postID[%s]: %w", e.PostID, err)
+	}
+
+	return nil
+}
+
+// Backfill adds the most recent fanned out posts of an author to the
+// timeline of a new follower. They are copied from the timeline of the
+// author, posts fanned out on read are left alone.
+func (s Store) Backfill(ctx context.Context, f Follower, limit int) error {
+	data := struct {
+		Follower
+		Limit int `db:"limit"`
+	}{
+		Follower: f,
+		Limit:    limit,
+	}
+
+	const q = `
+	INSERT INTO timelines
+		(user_id, post_id, author_id, date_published, title, description, date_created, date_updated, edited, publish_at, visibility)
+	SELECT
+		CAST(:follower_id AS UUID),
+		post_id,
+		author_id,
+		date_published,
+		title,
+		description,
+		date_created,
+		date_updated,
+		edited,
+		publish_at,
+		visibility
+	FROM
+		timelines
+	WHERE
+		user_id = :user_id AND
+		author_id = :user_id AND
+		visibility != 'private' AND
+		NOT fan_out_on_read
+	ORDER BY
+		date_published DESC, post_id DESC
+	LIMIT :limit
+	ON CONFLICT DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("backfilling userID[%s]: %w", f.FollowerID, err)
+	}
+
+	return nil
+}
+
+// DeleteByPostID removes a post from every timeline.
+func (s Store) DeleteByPostID(ctx context.Context, postID string) error {
+	data := struct {
+		PostID string `db:"post_id"`
+	}{
+		PostID: postID,
+	}
+
+	const q = `
+	DELETE FROM
+		timelines
+	WHERE
+		post_id = :post_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting postID[%s]: %w", postID, err)
+	}
+
+	return nil
+}
+
+// DeleteByAuthorID removes all the posts of an author from the timeline of
+// a user.
+func (s Store) DeleteByAuthorID(ctx context.Context, userID string, authorID string) error {
+	data := struct {
+		UserID   string `db:"user_id"`
+		AuthorID string `db:"author_id"`
+	}{
+		UserID:   userID,
+		AuthorID: authorID,
+	}
+
+	const q = `
+	DELETE FROM
+		timelines
+	WHERE
+		user_id = :user_id AND
+		author_id = :author_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting authorID[%s]: %w", authorID, err)
+	}
+
+	return nil
+}
+
+// QueryFollowerCount returns how many followers the specified user has.
+func (s Store) QueryFollowerCount(ctx context.Context, userID string) (int, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		COUNT(*) AS count
+	FROM
+		followers
+	WHERE
+		user_id = :user_id`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("counting followers of userID[%s]: %w", userID, err)
+	}
+
+	return count.Count, nil
+}
+
+// Query retrieves a page of the home timeline of a user, newest first. Posts
+// of followed authors marked fan out on read are merged in from the
+// timelines of those authors. Only posts published before the cursor are
+// returned.
+func (s Store) Query(ctx context.Context, userID string, before time.Time, beforeID string, limit int) ([]Post, error) {
+	data := struct {
+		UserID   string    `db:"user_id"`
+		Before   time.Time `db:"before"`
+		BeforeID string    `db:"before_id"`
+		Limit    int       `db:"limit"`
+	}{
+		UserID:   userID,
+		Before:   before,
+		BeforeID: beforeID,
+		Limit:    limit,
+	}
+
+	const q = `
+	SELECT
+		post_id, title, description, user_id, date_created, date_updated, edited, publish_at, visibility
+	FROM (
+		(
+			SELECT
+				post_id, title, description, author_id AS user_id, date_created, date_updated, edited, publish_at, visibility, date_published
+			FROM
+				timelines
+			WHERE
+				user_id = :user_id AND
+				(date_published, post_id) < (CAST(:before AS TIMESTAMP), CAST(:before_id AS UUID))
+			ORDER BY
+				date_published DESC, post_id DESC
+			LIMIT :limit
+		)
+		UNION ALL
+		(
+			SELECT
+				t.post_id, t.title, t.description, t.author_id AS user_id, t.date_created, t.date_updated, t.edited, t.publish_at, t.visibility, t.date_published
+			FROM
+				timelines t
+			JOIN
+				followers f ON f.user_id = t.user_id AND f.follower_id = :user_id
+			WHERE
+				t.fan_out_on_read AND
+				(t.date_published, t.post_id) < (CAST(:before AS TIMESTAMP), CAST(:before_id AS UUID))
+			ORDER BY
+				t.date_published DESC, t.post_id DESC
+			LIMIT :limit
+		)
+	) AS feed
+	ORDER BY
+		date_published DESC, post_id DESC
+	LIMIT :limit`
+
+	var ps []Post
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ps); err != nil {
+		return nil, fmt.Errorf("selecting timeline of userID[%s]: %w", userID, err)
+	}
+
+	return ps, nil
+}
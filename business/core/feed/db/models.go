@@ -0,0 +1,47 @@
+package db
+
+import (
+	"time"
+)
+
+// Post represent the structure we need for moving data
+// between the app and the database. The field names match the post events
+// so they can be decoded straight into it.
+type Post struct {
+	ID          string     `db:"post_id"`
+	Title       string     `db:"title"`
+	Description string     `db:"description"`
+	UserID      string     `db:"user_id"`
+	DateCreated time.Time  `db:"date_created"`
+	DateUpdated time.Time  `db:"date_updated"`
+	Edited      bool       `db:"edited"`
+	PublishAt   *time.Time `db:"publish_at"`
+	Visibility  string     `db:"visibility"`
+}
+
+// Entry represents a post delivered to the home timeline of a user. The
+// post is copied onto the timeline so timelines are read on their own. The
+// entry of an author on their own timeline is marked FanOutOnRead when the
+// post is read from there by the followers instead.
+type Entry struct {
+	UserID        string     `db:"user_id"`
+	PostID        string     `db:"post_id"`
+	AuthorID      string     `db:"author_id"`
+	DatePublished time.Time  `db:"date_published"`
+	Title         string     `db:"title"`
+	Description   string     `db:"description"`
+	DateCreated   time.Time  `db:"date_created"`
+	DateUpdated   time.Time  `db:"date_updated"`
+	Edited        bool       `db:"edited"`
+	PublishAt     *time.Time `db:"publish_at"`
+	Visibility    string     `db:"visibility"`
+	FanOutOnRead  bool       `db:"fan_out_on_read"`
+}
+
+// Follower represents a user following the author of posts. The field names
+// match the user-followed events.
+type Follower struct {
+	FollowerID  string    `db:"follower_id"`
+	FolloweeID  string    `db:"user_id"`
+	DateCreated time.Time `db:"date_created"`
+}
@@ -0,0 +1,213 @@
+// Package feed provides the core business API for home timelines. Posts are
+// fanned out to the timelines of the followers of their author as they are
+// published. Authors with a lot of followers are skipped and their posts are
+// merged in from the timeline of the author when the timeline is read
+// instead.
+package feed
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/feed/db"
+	"github.com/dudakovict/social-network/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Set of error variables for timeline access.
+var (
+	ErrInvalidID     = errors.New("ID is not in its proper form")
+	ErrInvalidCursor = errors.New("cursor is not in its proper form")
+)
+
+// backfillLimit caps how many posts of an author are added to the timeline
+// of a new follower.
+const backfillLimit = 50
+
+// visibilityPrivate marks posts only their author can see.
+const visibilityPrivate = "private"
+
+// Core manages the set of API's for timeline access.
+type Core struct {
+	store       db.Store
+	fanOutLimit int
+}
+
+// NewCore constructs a core for timeline api access. Authors with at least
+// fanOutLimit followers are not fanned out.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB, fanOutLimit int) Core {
+	return Core{
+		store:       db.NewStore(log, sqlxDB),
+		fanOutLimit: fanOutLimit,
+	}
+}
+
+// Query retrieves a page of the home timeline of the specified user. An
+// empty cursor starts from the newest post.
+func (c Core) Query(ctx context.Context, userID string, cursor string, limit int) (Page, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return Page{}, ErrInvalidID
+	}
+
+	before, beforeID, err := decodeCursor(cursor)
+	if err != nil {
+		return Page{}, err
+	}
+
+	// Ask for one extra post to know if there is a next page.
+	dbPosts, err := c.store.Query(ctx, userID, before, beforeID, limit+1)
+	if err != nil {
+		return Page{}, fmt.Errorf("query: %w", err)
+	}
+
+	var page Page
+	if len(dbPosts) > limit {
+		dbPosts = dbPosts[:limit]
+		last := dbPosts[limit-1]
+		page.Cursor = encodeCursor(published(last), last.ID)
+	}
+	page.Posts = toPostSlice(dbPosts)
+
+	return page, nil
+}
+
+// Add delivers a published post to the timelines it belongs on. It is safe
+// to add the same post again after it changed, private posts and posts of
+// authors with too many followers are taken off the timelines of followers.
+func (c Core) Add(ctx context.Context, dbP db.Post) error {
+	e := db.Entry{
+		UserID:        dbP.UserID,
+		PostID:        dbP.ID,
+		AuthorID:      dbP.UserID,
+		DatePublished: published(dbP),
+		Title:         dbP.Title,
+		Description:   dbP.Description,
+		DateCreated:   dbP.DateCreated,
+		DateUpdated:   dbP.DateUpdated,
+		Edited:        dbP.Edited,
+		PublishAt:     dbP.PublishAt,
+		Visibility:    dbP.Visibility,
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		fanOut := dbP.Visibility != visibilityPrivate
+		if fanOut {
+			count, err := store.QueryFollowerCount(ctx, dbP.UserID)
+			if err != nil {
+				return fmt.Errorf("count: %w", err)
+			}
+			e.FanOutOnRead = count >= c.fanOutLimit
+			fanOut = !e.FanOutOnRead
+		}
+
+		// Take the post off the timelines of followers it no longer
+		// belongs on.
+		if !fanOut {
+			if err := store.DeleteByPostID(ctx, dbP.ID); err != nil {
+				return fmt.Errorf("delete: %w", err)
+			}
+		}
+
+		if err := store.Create(ctx, e); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		if !fanOut {
+			return nil
+		}
+
+		if err := store.FanOut(ctx, e); err != nil {
+			return fmt.Errorf("fan out: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// Remove takes a deleted post off every timeline.
+func (c Core) Remove(ctx context.Context, dbP db.Post) error {
+	if err := c.store.DeleteByPostID(ctx, dbP.ID); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// Follow adds the recent posts of a followed author to the timeline of the
+// new follower.
+func (c Core) Follow(ctx context.Context, dbF db.Follower) error {
+	if err := c.store.Backfill(ctx, dbF, backfillLimit); err != nil {
+		return fmt.Errorf("backfill: %w", err)
+	}
+
+	return nil
+}
+
+// Unfollow takes the posts of an unfollowed author off the timeline of the
+// former follower.
+func (c Core) Unfollow(ctx context.Context, dbF db.Follower) error {
+	if err := c.store.DeleteByAuthorID(ctx, dbF.FollowerID, dbF.FolloweeID); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// published returns when the post went live. Posts created before scheduling
+// existed have no publish time.
+func published(dbP db.Post) time.Time {
+	if dbP.PublishAt != nil {
+		return *dbP.PublishAt
+	}
+	return dbP.DateCreated
+}
+
+// encodeCursor makes an opaque cursor pointing past the specified post.
+func encodeCursor(date time.Time, postID string) string {
+	s := date.UTC().Format(time.RFC3339Nano) + "|" + postID
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// decodeCursor returns the position a cursor points to. The empty cursor
+// points past the newest possible post.
+func decodeCursor(cursor string) (time.Time, string, error) {
+	if cursor == "" {
+		return time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC), "ffffffff-ffff-ffff-ffff-ffffffffffff", nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	date, postID, ok := strings.Cut(string(b), "|")
+	if !ok {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, date)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	if err := validate.CheckID(postID); err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	return t, postID, nil
+}
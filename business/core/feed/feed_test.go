@@ -0,0 +1,209 @@
+package feed_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/feed"
+	feedDB "github.com/dudakovict/social-network/business/core/feed/db"
+	"github.com/dudakovict/social-network/business/core/post"
+	"github.com/dudakovict/social-network/business/data/post/dbtest"
+	"github.com/dudakovict/social-network/foundation/docker"
+)
+
+var nc *docker.Container
+var dbc *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	nc, err = dbtest.StartNATS()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	dbc, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	defer dbtest.StopNATS(nc)
+	defer dbtest.StopDB(dbc)
+
+	m.Run()
+}
+
+func TestFeed(t *testing.T) {
+	log, db, n, teardown := dbtest.NewUnit(t, nc, dbc, "testfeed")
+	t.Cleanup(teardown)
+
+	postCore := post.NewCore(log, db, n)
+	core := feed.NewCore(log, db, 10)
+
+	t.Log("Given the need to work with home timelines.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen fanning out posts on write.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+			authorID := "5cf37266-3473-4006-984f-9325122678b7"
+			followerID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+			if _, err := db.ExecContext(ctx, `INSERT INTO followers (user_id, follower_id, date_created) VALUES ($1, $2, $3)`, authorID, followerID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add a follower : %s.", dbtest.Failed, testID, err)
+			}
+
+			var posts []post.Post
+			for i := 0; i < 3; i++ {
+				np := post.NewPost{
+					Title:       fmt.Sprintf("Post %d", i),
+					Description: "Check out my new song!",
+					UserID:      authorID,
+				}
+
+				p, err := postCore.Create(ctx, np, now.Add(time.Duration(i)*time.Hour))
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to create post : %s.", dbtest.Failed, testID, err)
+				}
+
+				if err := core.Add(ctx, toDB(p)); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to add post : %s.", dbtest.Failed, testID, err)
+				}
+				posts = append(posts, p)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to add posts.", dbtest.Success, testID)
+
+			page, err := core.Query(ctx, followerID, "", 2)
+			if err != nil || len(page.Posts) != 2 || page.Cursor == "" {
+				t.Fatalf("\t%s\tTest %d:\tShould get the first page of the timeline : %+v %v.", dbtest.Failed, testID, page, err)
+			}
+			if page.Posts[0].ID != posts[2].ID || page.Posts[1].ID != posts[1].ID {
+				t.Fatalf("\t%s\tTest %d:\tShould get the newest posts first.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the first page of the timeline.", dbtest.Success, testID)
+
+			page, err = core.Query(ctx, followerID, page.Cursor, 2)
+			if err != nil || len(page.Posts) != 1 || page.Cursor != "" || page.Posts[0].ID != posts[0].ID {
+				t.Fatalf("\t%s\tTest %d:\tShould get the last page of the timeline : %+v %v.", dbtest.Failed, testID, page, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the last page of the timeline.", dbtest.Success, testID)
+
+			if err := core.Remove(ctx, toDB(posts[2])); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to remove post : %s.", dbtest.Failed, testID, err)
+			}
+
+			page, err = core.Query(ctx, followerID, "", 10)
+			if err != nil || len(page.Posts) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT see removed posts : %+v %v.", dbtest.Failed, testID, page, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT see removed posts.", dbtest.Success, testID)
+
+			if _, err := core.Query(ctx, followerID, "bad-cursor", 10); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT accept an invalid cursor.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT accept an invalid cursor.", dbtest.Success, testID)
+
+			f := feedDB.Follower{FollowerID: followerID, FolloweeID: authorID}
+			if err := core.Unfollow(ctx, f); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unfollow : %s.", dbtest.Failed, testID, err)
+			}
+
+			page, err = core.Query(ctx, followerID, "", 10)
+			if err != nil || len(page.Posts) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT see posts of unfollowed users : %+v %v.", dbtest.Failed, testID, page, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT see posts of unfollowed users.", dbtest.Success, testID)
+
+			if err := core.Follow(ctx, f); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to follow : %s.", dbtest.Failed, testID, err)
+			}
+
+			page, err = core.Query(ctx, followerID, "", 10)
+			if err != nil || len(page.Posts) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould see recent posts of followed users : %+v %v.", dbtest.Failed, testID, page, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould see recent posts of followed users.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestFeedFanOutOnRead(t *testing.T) {
+	log, db, n, teardown := dbtest.NewUnit(t, nc, dbc, "testfeedread")
+	t.Cleanup(teardown)
+
+	postCore := post.NewCore(log, db, n)
+	core := feed.NewCore(log, db, 1)
+
+	t.Log("Given the need to read the posts of authors with many followers.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen the author is not fanned out.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+			authorID := "5cf37266-3473-4006-984f-9325122678b7"
+			followerID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+			if _, err := db.ExecContext(ctx, `INSERT INTO followers (user_id, follower_id, date_created) VALUES ($1, $2, $3)`, authorID, followerID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add a follower : %s.", dbtest.Failed, testID, err)
+			}
+
+			np := post.NewPost{
+				Title:       "New Song",
+				Description: "Check out my new song!",
+				UserID:      authorID,
+			}
+
+			p, err := postCore.Create(ctx, np, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create post : %s.", dbtest.Failed, testID, err)
+			}
+
+			if err := core.Add(ctx, toDB(p)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add post : %s.", dbtest.Failed, testID, err)
+			}
+
+			var count int
+			if err := db.GetContext(ctx, &count, `SELECT COUNT(*) FROM timelines WHERE user_id = $1`, followerID); err != nil || count != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT fan out the post : %d %v.", dbtest.Failed, testID, count, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT fan out the post.", dbtest.Success, testID)
+
+			page, err := core.Query(ctx, followerID, "", 10)
+			if err != nil || len(page.Posts) != 1 || page.Posts[0].ID != p.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould merge in the post on read : %+v %v.", dbtest.Failed, testID, page, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould merge in the post on read.", dbtest.Success, testID)
+
+			p.Title = "New Song (Remastered)"
+			if err := core.Add(ctx, toDB(p)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add the edited post : %s.", dbtest.Failed, testID, err)
+			}
+
+			page, err = core.Query(ctx, followerID, "", 10)
+			if err != nil || len(page.Posts) != 1 || page.Posts[0].Title != p.Title {
+				t.Fatalf("\t%s\tTest %d:\tShould read the edited post : %+v %v.", dbtest.Failed, testID, page, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould read the edited post.", dbtest.Success, testID)
+		}
+	}
+}
+
+// toDB converts a post into the shape of the post events.
+func toDB(p post.Post) feedDB.Post {
+	return feedDB.Post{
+		ID:          p.ID,
+		Title:       p.Title,
+		Description: p.Description,
+		UserID:      p.UserID,
+		DateCreated: p.DateCreated,
+		DateUpdated: p.DateUpdated,
+		Edited:      p.Edited,
+		PublishAt:   p.PublishAt,
+		Visibility:  p.Visibility,
+	}
+}
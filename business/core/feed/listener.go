@@ -0,0 +1,45 @@
+package feed
+
+import (
+	"fmt"
+
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Listener keeps the home timelines in sync with the post and follow events.
+type Listener struct {
+	log  *zap.SugaredLogger
+	nats *nats.NATS
+	core Core
+}
+
+// NewListener constructs a listener for timeline events.
+func NewListener(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS, fanOutLimit int) Listener {
+	return Listener{
+		log:  log,
+		nats: nats,
+		core: NewCore(log, sqlxDB, fanOutLimit),
+	}
+}
+
+// Listen subscribes to all the timeline events.
+func (l Listener) Listen() error {
+	for _, subject := range []string{"post-created", "post-updated", "post-restored"} {
+		if err := nats.Handle(l.nats, l.log, subject, "feed", l.core.Add); err != nil {
+			return fmt.Errorf("%s: %w", subject, err)
+		}
+	}
+	if err := nats.Handle(l.nats, l.log, "post-deleted", "feed", l.core.Remove); err != nil {
+		return fmt.Errorf("post-deleted: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "user-followed", "feed", l.core.Follow); err != nil {
+		return fmt.Errorf("user-followed: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "user-unfollowed", "feed", l.core.Unfollow); err != nil {
+		return fmt.Errorf("user-unfollowed: %w", err)
+	}
+
+	return nil
+}
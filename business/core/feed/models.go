@@ -0,0 +1,43 @@
+package feed
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/dudakovict/social-network/business/core/feed/db"
+)
+
+// Post represents a post on a home timeline.
+type Post struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	UserID      string     `json:"user_id"`
+	DateCreated time.Time  `json:"date_created"`
+	DateUpdated time.Time  `json:"date_updated"`
+	Edited      bool       `json:"edited"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	Visibility  string     `json:"visibility"`
+}
+
+// Page is a slice of a home timeline. Cursor is empty on the last page,
+// otherwise it fetches the next one.
+type Page struct {
+	Posts  []Post `json:"posts"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// =============================================================================
+
+func toPost(dbP db.Post) Post {
+	pp := (*Post)(unsafe.Pointer(&dbP))
+	return *pp
+}
+
+func toPostSlice(dbPs []db.Post) []Post {
+	posts := make([]Post, len(dbPs))
+	for i, dbP := range dbPs {
+		posts[i] = toPost(dbP)
+	}
+	return posts
+}
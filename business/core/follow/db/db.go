@@ -0,0 +1,327 @@
+// Package db contains follow related CRUD functionality.
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of API's for follow access.
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+// NewStore constructs a data for api access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create inserts a new follow into the database. Following a user twice is
+// not an error.
+func (s Store) Create(ctx context.Context, f Follow) error {
+	const q = `
+	INSERT INTO follows
+		(follower_id, followee_id, date_created)
+	VALUES
+		(:follower_id, :followee_id, :date_created)
+	ON CONFLICT DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, f); err != nil {
+		return fmt.Errorf("inserting follow: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a follow from the database.
+func (s Store) Delete(ctx context.Context, followerID string, followeeID string) error {
+	data := struct {
+		FollowerID string `db:"follower_id"`
+		FolloweeID string `db:"followee_id"`
+	}{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	}
+
+	const q = `
+	DELETE FROM
+		follows
+	WHERE
+		follower_id = :follower_id AND
+		followee_id = :followee_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting followerID[%s] followeeID[%s]: %w", followerID, followeeID, err)
+	}
+
+	return nil
+}
+
+// QueryByID gets the follow between the two users from the database.
+func (s Store) QueryByID(ctx context.Context, followerID string, followeeID string) (Follow, error) {
+	data := struct {
+		FollowerID string `db:"follower_id"`
+		FolloweeID string `db:"followee_id"`
+	}{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		follows
+	WHERE
+		follower_id = :follower_id AND
+		followee_id = :followee_id`
+
+	var f Follow
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &f); err != nil {
+		return Follow{}, fmt.Errorf("selecting followerID[%q] followeeID[%q]: %w", followerID, followeeID, err)
+	}
+
+	return f, nil
+}
+
+// QueryFollowers retrieves the users following the specified user, most
+// recent first.
+func (s Store) QueryFollowers(ctx context.Context, userID string, pageNumber int, rowsPerPage int) ([]Follow, error) {
+	data := struct {
+		UserID      string `db:"user_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		UserID:      userID,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		follows
+	WHERE
+		followee_id = :user_id
+	ORDER BY
+		date_created DESC, follower_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var fs []Follow
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &fs); err != nil {
+		return nil, fmt.Errorf("selecting followers userID[%s]: %w", userID, err)
+	}
+
+	return fs, nil
+}
+
+// QueryFollowing retrieves the users the specified user follows, most recent
+// first.
+func (s Store) QueryFollowing(ctx context.Context, userID string, pageNumber int, rowsPerPage int) ([]Follow, error) {
+	data := struct {
+		UserID      string `db:"user_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		UserID:      userID,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		follows
+	WHERE
+		follower_id = :user_id
+	ORDER BY
+		date_created DESC, followee_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var fs []Follow
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &fs); err != nil {
+		return nil, fmt.Errorf("selecting following userID[%s]: %w", userID, err)
+	}
+
+	return fs, nil
+}
+
+// QueryCounts counts the followers of the specified user and the users they
+// follow.
+func (s Store) QueryCounts(ctx context.Context, userID string) (Counts, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		(SELECT COUNT(*) FROM follows WHERE followee_id = :user_id) AS followers,
+		(SELECT COUNT(*) FROM follows WHERE follower_id = :user_id) AS following`
+
+	var c Counts
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &c); err != nil {
+		return Counts{}, fmt.Errorf("counting follows userID[%s]: %w", userID, err)
+	}
+
+	return c, nil
+}
+
+// QueryPrivate reports whether the specified user has a private account.
+func (s Store) QueryPrivate(ctx context.Context, userID string) (bool, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		private
+	FROM
+		users
+	WHERE
+		user_id = :user_id`
+
+	var result struct {
+		Private bool `db:"private"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return false, fmt.Errorf("selecting userID[%q]: %w", userID, err)
+	}
+
+	return result.Private, nil
+}
+
+// CreateRequest inserts a new follow request into the database. Asking twice
+// is not an error.
+func (s Store) CreateRequest(ctx context.Context, r Request) error {
+	const q = `
+	INSERT INTO follow_requests
+		(requester_id, target_id, date_created)
+	VALUES
+		(:requester_id, :target_id, :date_created)
+	ON CONFLICT DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, r); err != nil {
+		return fmt.Errorf("inserting request: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRequest removes a follow request from the database.
+func (s Store) DeleteRequest(ctx context.Context, requesterID string, targetID string) error {
+	data := struct {
+		RequesterID string `db:"requester_id"`
+		TargetID    string `db:"target_id"`
+	}{
+		RequesterID: requesterID,
+		TargetID:    targetID,
+	}
+
+	const q = `
+	DELETE FROM
+		follow_requests
+	WHERE
+		requester_id = :requester_id AND
+		target_id = :target_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting request requesterID[%s] targetID[%s]: %w", requesterID, targetID, err)
+	}
+
+	return nil
+}
+
+// QueryRequestByID gets the follow request between the two users from the
+// database.
+func (s Store) QueryRequestByID(ctx context.Context, requesterID string, targetID string) (Request, error) {
+	data := struct {
+		RequesterID string `db:"requester_id"`
+		TargetID    string `db:"target_id"`
+	}{
+		RequesterID: requesterID,
+		TargetID:    targetID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		follow_requests
+	WHERE
+		requester_id = :requester_id AND
+		target_id = :target_id`
+
+	var r Request
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &r); err != nil {
+		return Request{}, fmt.Errorf("selecting request requesterID[%q] targetID[%q]: %w", requesterID, targetID, err)
+	}
+
+	return r, nil
+}
+
+// QueryRequests retrieves the pending requests to follow the specified user,
+// oldest first.
+func (s Store) QueryRequests(ctx context.Context, targetID string, pageNumber int, rowsPerPage int) ([]Request, error) {
+	data := struct {
+		TargetID    string `db:"target_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		TargetID:    targetID,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		follow_requests
+	WHERE
+		target_id = :target_id
+	ORDER BY
+		date_created, requester_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var rs []Request
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &rs); err != nil {
+		return nil, fmt.Errorf("selecting requests targetID[%s]: %w", targetID, err)
+	}
+
+	return rs, nil
+}
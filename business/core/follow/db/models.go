@@ -0,0 +1,26 @@
+package db
+
+import (
+	"time"
+)
+
+// Follow represents a user following another user.
+type Follow struct {
+	FollowerID  string    `db:"follower_id"`
+	FolloweeID  string    `db:"followee_id"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// Request represents a pending request to follow a private user.
+type Request struct {
+	RequesterID string    `db:"requester_id"`
+	TargetID    string    `db:"target_id"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// Counts represents how many followers a user has and how many users they
+// follow.
+type Counts struct {
+	Followers int `db:"followers"`
+	Following int `db:"following"`
+}
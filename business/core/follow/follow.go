@@ -0,0 +1,384 @@
+// Package follow provides the core business API for the follow graph between
+// users. Changes to the graph are published so other services can keep their
+// own copy of who follows whom.
+package follow
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/follow/db"
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound        = errors.New("user not found")
+	ErrInvalidID       = errors.New("ID is not in its proper form")
+	ErrSelfFollow      = errors.New("users can not follow themselves")
+	ErrRequestNotFound = errors.New("follow request not found")
+	ErrPrivate         = errors.New("user has a private account")
+)
+
+// Core manages the set of API's for follow access.
+type Core struct {
+	store db.Store
+	nats  *nats.NATS
+}
+
+// NewCore constructs a core for follow api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS) Core {
+	return Core{
+		store: db.NewStore(log, sqlxDB),
+		nats:  nats,
+	}
+}
+
+// Follow makes the follower follow the followee. Following a private user
+// creates a follow request the followee has to accept instead.
+func (c Core) Follow(ctx context.Context, followerID string, followeeID string, now time.Time) (Relationship, error) {
+	if err := validate.CheckID(followerID); err != nil {
+		return Relationship{}, ErrInvalidID
+	}
+	if err := validate.CheckID(followeeID); err != nil {
+		return Relationship{}, ErrInvalidID
+	}
+	if followerID == followeeID {
+		return Relationship{}, ErrSelfFollow
+	}
+
+	private, err := c.store.QueryPrivate(ctx, followeeID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Relationship{}, ErrNotFound
+		}
+		return Relationship{}, fmt.Errorf("query private: %w", err)
+	}
+
+	rel, err := c.Relationship(ctx, followerID, followeeID)
+	if err != nil {
+		return Relationship{}, err
+	}
+
+	if rel.Following || rel.Requested {
+		return rel, nil
+	}
+
+	if private {
+		dbR := db.Request{
+			RequesterID: followerID,
+			TargetID:    followeeID,
+			DateCreated: now,
+		}
+
+		if err := c.store.CreateRequest(ctx, dbR); err != nil {
+			return Relationship{}, fmt.Errorf("create request: %w", err)
+		}
+
+		if err := c.publish("user-follow-requested", dbR); err != nil {
+			return Relationship{}, fmt.Errorf("pub: %w", err)
+		}
+
+		rel.Requested = true
+		return rel, nil
+	}
+
+	if err := c.follow(ctx, followerID, followeeID, now); err != nil {
+		return Relationship{}, err
+	}
+
+	rel.Following = true
+	rel.Mutual = rel.FollowedBy
+	return rel, nil
+}
+
+// Unfollow stops the follower from following the followee. Any pending
+// request to follow them is withdrawn as well.
+func (c Core) Unfollow(ctx context.Context, followerID string, followeeID string) error {
+	if err := validate.CheckID(followerID); err != nil {
+		return ErrInvalidID
+	}
+	if err := validate.CheckID(followeeID); err != nil {
+		return ErrInvalidID
+	}
+
+	dbF, err := c.store.QueryByID(ctx, followerID, followeeID)
+	if err != nil {
+		if !errors.Is(err, database.ErrDBNotFound) {
+			return fmt.Errorf("query: %w", err)
+		}
+
+		if err := c.store.DeleteRequest(ctx, followerID, followeeID); err != nil {
+			return fmt.Errorf("delete request: %w", err)
+		}
+		return nil
+	}
+
+	if err := c.store.Delete(ctx, followerID, followeeID); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	if err := c.publish("user-unfollowed", dbF); err != nil {
+		return fmt.Errorf("pub: %w", err)
+	}
+
+	return nil
+}
+
+// AcceptRequest lets the requester follow the target of the request.
+func (c Core) AcceptRequest(ctx context.Context, targetID string, requesterID string, now time.Time) error {
+	if err := validate.CheckID(targetID); err != nil {
+		return ErrInvalidID
+	}
+	if err := validate.CheckID(requesterID); err != nil {
+		return ErrInvalidID
+	}
+
+	if _, err := c.store.QueryRequestByID(ctx, requesterID, targetID); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrRequestNotFound
+		}
+		return fmt.Errorf("query request: %w", err)
+	}
+
+	return c.follow(ctx, requesterID, targetID, now)
+}
+
+// RejectRequest turns down the request of the requester to follow the target.
+func (c Core) RejectRequest(ctx context.Context, targetID string, requesterID string) error {
+	if err := validate.CheckID(targetID); err != nil {
+		return ErrInvalidID
+	}
+	if err := validate.CheckID(requesterID); err != nil {
+		return ErrInvalidID
+	}
+
+	if _, err := c.store.QueryRequestByID(ctx, requesterID, targetID); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrRequestNotFound
+		}
+		return fmt.Errorf("query request: %w", err)
+	}
+
+	if err := c.store.DeleteRequest(ctx, requesterID, targetID); err != nil {
+		return fmt.Errorf("delete request: %w", err)
+	}
+
+	return nil
+}
+
+// Relationship describes how the viewer and the specified user are connected.
+func (c Core) Relationship(ctx context.Context, viewerID string, userID string) (Relationship, error) {
+	if err := validate.CheckID(viewerID); err != nil {
+		return Relationship{}, ErrInvalidID
+	}
+	if err := validate.CheckID(userID); err != nil {
+		return Relationship{}, ErrInvalidID
+	}
+
+	_, err := c.store.QueryByID(ctx, viewerID, userID)
+	following, err := found(err)
+	if err != nil {
+		return Relationship{}, fmt.Errorf("query following: %w", err)
+	}
+
+	_, err = c.store.QueryByID(ctx, userID, viewerID)
+	followedBy, err := found(err)
+	if err != nil {
+		return Relationship{}, fmt.Errorf("query followed by: %w", err)
+	}
+
+	_, err = c.store.QueryRequestByID(ctx, viewerID, userID)
+	requested, err := found(err)
+	if err != nil {
+		return Relationship{}, fmt.Errorf("query request: %w", err)
+	}
+
+	rel := Relationship{
+		UserID:     userID,
+		Following:  following,
+		FollowedBy: followedBy,
+		Mutual:     following && followedBy,
+		Requested:  requested,
+	}
+
+	return rel, nil
+}
+
+// IsMutual reports whether the two users follow each other.
+func (c Core) IsMutual(ctx context.Context, userID string, otherID string) (bool, error) {
+	rel, err := c.Relationship(ctx, userID, otherID)
+	if err != nil {
+		return false, err
+	}
+
+	return rel.Mutual, nil
+}
+
+// QueryFollowers retrieves a page of the users following the specified user. The
+// lists of private users are only shown to them and their followers.
+func (c Core) QueryFollowers(ctx context.Context, viewerID string, userID string, pageNumber int, rowsPerPage int) ([]Follow, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	if err := c.canView(ctx, viewerID, userID); err != nil {
+		return nil, err
+	}
+
+	dbFollows, err := c.store.QueryFollowers(ctx, userID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toFollowSlice(dbFollows), nil
+}
+
+// QueryFollowing retrieves a page of the users the specified user follows. The
+// lists of private users are only shown to them and their followers.
+func (c Core) QueryFollowing(ctx context.Context, viewerID string, userID string, pageNumber int, rowsPerPage int) ([]Follow, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	if err := c.canView(ctx, viewerID, userID); err != nil {
+		return nil, err
+	}
+
+	dbFollows, err := c.store.QueryFollowing(ctx, userID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toFollowSlice(dbFollows), nil
+}
+
+// QueryCounts counts the followers of the specified user and the users they
+// follow.
+func (c Core) QueryCounts(ctx context.Context, userID string) (Counts, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return Counts{}, ErrInvalidID
+	}
+
+	dbCounts, err := c.store.QueryCounts(ctx, userID)
+	if err != nil {
+		return Counts{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toCounts(dbCounts), nil
+}
+
+// QueryRequests retrieves a page of the pending requests to follow the
+// specified user.
+func (c Core) QueryRequests(ctx context.Context, userID string, pageNumber int, rowsPerPage int) ([]Request, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	dbRequests, err := c.store.QueryRequests(ctx, userID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toRequestSlice(dbRequests), nil
+}
+
+// =============================================================================
+
+// follow records the follow, clears any request that led to it and announces
+// it with a user-followed event.
+func (c Core) follow(ctx context.Context, followerID string, followeeID string, now time.Time) error {
+	dbF := db.Follow{
+		FollowerID:  followerID,
+		FolloweeID:  followeeID,
+		DateCreated: now,
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		if err := c.store.Tran(tx).DeleteRequest(ctx, followerID, followeeID); err != nil {
+			return fmt.Errorf("delete request: %w", err)
+		}
+		if err := c.store.Tran(tx).Create(ctx, dbF); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	if err := c.publish("user-followed", dbF); err != nil {
+		return fmt.Errorf("pub: %w", err)
+	}
+
+	return nil
+}
+
+// canView checks the viewer is allowed to see who the specified user follows
+// and is followed by. Everyone can see it for public users, only the user
+// and their followers for private users.
+func (c Core) canView(ctx context.Context, viewerID string, userID string) error {
+	if viewerID == userID {
+		return nil
+	}
+
+	private, err := c.store.QueryPrivate(ctx, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("query private: %w", err)
+	}
+
+	if !private {
+		return nil
+	}
+
+	_, err = c.store.QueryByID(ctx, viewerID, userID)
+	following, err := found(err)
+	if err != nil {
+		return fmt.Errorf("query following: %w", err)
+	}
+
+	if !following {
+		return ErrPrivate
+	}
+
+	return nil
+}
+
+// found reports whether the query that returned the error found a row.
+func found(err error) (bool, error) {
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, database.ErrDBNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (c Core) publish(subject string, v any) error {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+
+	if err := c.nats.Client.Publish(subject, buf.Bytes()); err != nil {
+		return fmt.Errorf("publishing %s: %w", subject, err)
+	}
+
+	return nil
+}
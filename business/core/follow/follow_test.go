@@ -0,0 +1,138 @@
+package follow_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/follow"
+	"github.com/dudakovict/social-network/business/data/user/dbtest"
+	"github.com/dudakovict/social-network/foundation/docker"
+)
+
+var nc *docker.Container
+var dbc *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	nc, err = dbtest.StartNATS()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	dbc, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	defer dbtest.StopNATS(nc)
+	defer dbtest.StopDB(dbc)
+
+	m.Run()
+}
+
+func TestFollow(t *testing.T) {
+	log, db, _, teardown := dbtest.NewUnit(t, dbc, "testfollow")
+	t.Cleanup(teardown)
+
+	n, teardownNATS := dbtest.NewNATS(t, nc)
+	t.Cleanup(teardownNATS)
+
+	core := follow.NewCore(log, db, n)
+
+	t.Log("Given the need to work with the follow graph.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen following public and private users.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+			adminID := "5cf37266-3473-4006-984f-9325122678b7"
+			userID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+			if _, err := core.Follow(ctx, userID, userID, now); !errors.Is(err, follow.ErrSelfFollow) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to follow yourself : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to follow yourself.", dbtest.Success, testID)
+
+			rel, err := core.Follow(ctx, userID, adminID, now)
+			if err != nil || !rel.Following || rel.Mutual {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to follow a public user : %+v %v.", dbtest.Failed, testID, rel, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to follow a public user.", dbtest.Success, testID)
+
+			if _, err := db.ExecContext(ctx, `UPDATE users SET private = TRUE WHERE user_id = $1`, userID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to make the user private : %s.", dbtest.Failed, testID, err)
+			}
+
+			rel, err = core.Follow(ctx, adminID, userID, now)
+			if err != nil || rel.Following || !rel.Requested {
+				t.Fatalf("\t%s\tTest %d:\tShould only request to follow a private user : %+v %v.", dbtest.Failed, testID, rel, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only request to follow a private user.", dbtest.Success, testID)
+
+			reqs, err := core.QueryRequests(ctx, userID, 1, 10)
+			if err != nil || len(reqs) != 1 || reqs[0].RequesterID != adminID {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to see the follow request : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to see the follow request.", dbtest.Success, testID)
+
+			if err := core.AcceptRequest(ctx, userID, adminID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to accept the follow request : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to accept the follow request.", dbtest.Success, testID)
+
+			mutual, err := core.IsMutual(ctx, userID, adminID)
+			if err != nil || !mutual {
+				t.Fatalf("\t%s\tTest %d:\tShould see the users following each other : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould see the users following each other.", dbtest.Success, testID)
+
+			counts, err := core.QueryCounts(ctx, userID)
+			if err != nil || counts.Followers != 1 || counts.Following != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to count follows : %+v %v.", dbtest.Failed, testID, counts, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to count follows.", dbtest.Success, testID)
+
+			followers, err := core.QueryFollowers(ctx, userID, adminID, 1, 10)
+			if err != nil || len(followers) != 1 || followers[0].FollowerID != userID {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to list followers : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to list followers.", dbtest.Success, testID)
+
+			if err := core.Unfollow(ctx, userID, adminID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unfollow : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to unfollow.", dbtest.Success, testID)
+
+			following, err := core.QueryFollowing(ctx, userID, userID, 1, 10)
+			if err != nil || len(following) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT follow anyone after unfollowing : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT follow anyone after unfollowing.", dbtest.Success, testID)
+
+			if err := core.RejectRequest(ctx, userID, adminID); !errors.Is(err, follow.ErrRequestNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT find an accepted request : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT find an accepted request.", dbtest.Success, testID)
+
+			if _, err := core.QueryFollowers(ctx, adminID, userID, 1, 10); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to list the followers of a followed private user : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to list the followers of a followed private user.", dbtest.Success, testID)
+
+			if err := core.Unfollow(ctx, adminID, userID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unfollow : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.QueryFollowing(ctx, adminID, userID, 1, 10); !errors.Is(err, follow.ErrPrivate) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to list the follows of a private user : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to list the follows of a private user.", dbtest.Success, testID)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package follow
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/dudakovict/social-network/business/core/follow/db"
+)
+
+// Follow represents a user following another user.
+type Follow struct {
+	FollowerID  string    `json:"follower_id"`
+	FolloweeID  string    `json:"followee_id"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+// Request represents a pending request to follow a private user.
+type Request struct {
+	RequesterID string    `json:"requester_id"`
+	TargetID    string    `json:"target_id"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+// Counts represents how many followers a user has and how many users they
+// follow.
+type Counts struct {
+	Followers int `json:"followers"`
+	Following int `json:"following"`
+}
+
+// Relationship describes how a viewer and another user are connected.
+// Following means the viewer follows the user, FollowedBy means the user
+// follows the viewer and Requested means the viewer is waiting for the user
+// to approve a follow request.
+type Relationship struct {
+	UserID     string `json:"user_id"`
+	Following  bool   `json:"following"`
+	FollowedBy bool   `json:"followed_by"`
+	Mutual     bool   `json:"mutual"`
+	Requested  bool   `json:"requested"`
+}
+
+// =============================================================================
+
+func toFollow(dbF db.Follow) Follow {
+	pf := (*Follow)(unsafe.Pointer(&dbF))
+	return *pf
+}
+
+func toFollowSlice(dbFs []db.Follow) []Follow {
+	follows := make([]Follow, len(dbFs))
+	for i, dbF := range dbFs {
+		follows[i] = toFollow(dbF)
+	}
+	return follows
+}
+
+func toRequest(dbR db.Request) Request {
+	pr := (*Request)(unsafe.Pointer(&dbR))
+	return *pr
+}
+
+func toRequestSlice(dbRs []db.Request) []Request {
+	requests := make([]Request, len(dbRs))
+	for i, dbR := range dbRs {
+		requests[i] = toRequest(dbR)
+	}
+	return requests
+}
+
+func toCounts(dbC db.Counts) Counts {
+	pc := (*Counts)(unsafe.Pointer(&dbC))
+	return *pc
+}
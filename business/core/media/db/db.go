@@ -0,0 +1,134 @@
+// Package db contains attachment related CRUD functionality.
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of API's for attachment access.
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+// NewStore constructs a data for api access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create inserts a new attachment into the database.
+func (s Store) Create(ctx context.Context, a Attachment) error {
+	const q = `
+	INSERT INTO attachments
+		(attachment_id, user_id, content_type, size, checksum, date_created, status, attempts, width, height, blurhash, variants)
+	VALUES
+		(:attachment_id, :user_id, :content_type, :size, :checksum, :date_created, :status, :attempts, :width, :height, :blurhash, :variants)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, a); err != nil {
+		return fmt.Errorf("inserting attachment: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces the processing results of an attachment in the database.
+func (s Store) Update(ctx context.Context, a Attachment) error {
+	const q = `
+	UPDATE
+		attachments
+	SET
+		"size" = :size,
+		"checksum" = :checksum,
+		"status" = :status,
+		"attempts" = :attempts,
+		"width" = :width,
+		"height" = :height,
+		"blurhash" = :blurhash,
+		"variants" = :variants
+	WHERE
+		attachment_id = :attachment_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, a); err != nil {
+		return fmt.Errorf("updating attachmentID[%s]: %w", a.ID, err)
+	}
+
+	return nil
+}
+
+// QueryByID gets the specified attachment from the database.
+func (s Store) QueryByID(ctx context.Context, attachmentID string) (Attachment, error) {
+	data := struct {
+		AttachmentID string `db:"attachment_id"`
+	}{
+		AttachmentID: attachmentID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		attachments
+	WHERE
+		attachment_id = :attachment_id`
+
+	var a Attachment
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &a); err != nil {
+		return Attachment{}, fmt.Errorf("selecting attachmentID[%q]: %w", attachmentID, err)
+	}
+
+	return a, nil
+}
+
+// QueryByIDs gets the specified attachments from the database.
+func (s Store) QueryByIDs(ctx context.Context, attachmentIDs []string) ([]Attachment, error) {
+	data := struct {
+		AttachmentIDs pq.StringArray `db:"attachment_ids"`
+	}{
+		AttachmentIDs: attachmentIDs,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		attachments
+	WHERE
+		CAST(attachment_id AS TEXT) = ANY(:attachment_ids)`
+
+	var as []Attachment
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &as); err != nil {
+		return nil, fmt.Errorf("selecting attachments: %w", err)
+	}
+
+	return as, nil
+}
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Attachment represent the structure we need for moving data
+// between the app and the database.
+type Attachment struct {
+	ID          string         `db:"attachment_id"`
+	UserID      string         `db:"user_id"`
+	ContentType string         `db:"content_type"`
+	Size        int64          `db:"size"`
+	Checksum    string         `db:"checksum"`
+	DateCreated time.Time      `db:"date_created"`
+	Status      string         `db:"status"`
+	Attempts    int            `db:"attempts"`
+	Width       int            `db:"width"`
+	Height      int            `db:"height"`
+	Blurhash    string         `db:"blurhash"`
+	Variants    pq.StringArray `db:"variants"`
+}
@@ -0,0 +1,63 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/dudakovict/social-network/business/core/media/db"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/foundation/storage"
+	"github.com/jmoiron/sqlx"
+	"github.com/nats-io/stan.go"
+	"go.uber.org/zap"
+)
+
+// Listener processes the attachments as they are uploaded.
+type Listener struct {
+	log  *zap.SugaredLogger
+	nats *nats.NATS
+	core Core
+}
+
+// NewListener constructs a listener for attachment events.
+func NewListener(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS, storage storage.Storage) Listener {
+	return Listener{
+		log:  log,
+		nats: nats,
+		core: NewCore(log, sqlxDB, nats, storage),
+	}
+}
+
+// Listen subscribes to all the attachment events.
+func (l Listener) Listen() error {
+	if err := l.AttachmentCreated(); err != nil {
+		return fmt.Errorf("attachment-created: %w", err)
+	}
+
+	return nil
+}
+
+// AttachmentCreated processes a new attachment. Failed attempts are not
+// acknowledged so NATS delivers the event again once the ack wait passes.
+func (l Listener) AttachmentCreated() error {
+	return l.nats.Subscribe("attachment-created", "media", func(m *stan.Msg) {
+		buf := bytes.NewReader(m.Data)
+		dec := gob.NewDecoder(buf)
+
+		var dbA db.Attachment
+
+		if err := dec.Decode(&dbA); err != nil {
+			l.log.Errorw("attachment-created", "ERROR", fmt.Errorf("decoding: %w", err))
+			return
+		}
+
+		if err := l.core.Process(context.Background(), dbA.ID); err != nil {
+			l.log.Errorw("attachment-created", "ERROR", err)
+			return
+		}
+
+		m.Ack()
+	})
+}
@@ -0,0 +1,525 @@
+// Package media provides the core business API for attachments. Uploads are
+// streamed to blob storage while they are checked against the allowed
+// content types, size limits and the checksum sent by the client. Images are
+// then processed in the background: their metadata is stripped and resized
+// variants and a blurhash placeholder are produced for the ones that can be
+// decoded.
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	// Register the GIF decoder, JPEG and PNG are registered by their
+	// encoders above.
+	_ "image/gif"
+
+	"github.com/dudakovict/social-network/business/core/media/db"
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/business/sys/validate"
+	"github.com/dudakovict/social-network/foundation/imaging"
+	"github.com/dudakovict/social-network/foundation/storage"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Set of error variables for attachment access.
+var (
+	ErrNotFound         = errors.New("attachment not found")
+	ErrInvalidID        = errors.New("ID is not in its proper form")
+	ErrUnsupportedType  = errors.New("content type is not allowed")
+	ErrTooLarge         = errors.New("attachment is too large")
+	ErrContentMismatch  = errors.New("content does not match the content type")
+	ErrSizeMismatch     = errors.New("content does not match the declared size")
+	ErrChecksumMismatch = errors.New("content does not match the checksum")
+	ErrNotReady         = errors.New("attachment is still being processed")
+	ErrVariantNotFound  = errors.New("variant not found")
+)
+
+// Set of size limits for attachments.
+const (
+	MaxImageSize = 10 << 20
+	MaxVideoSize = 100 << 20
+)
+
+// Set of dimension limits for images that are processed. The size limits only
+// cap the compressed bytes, an image that declares huge dimensions would
+// still need gigabytes of memory to be decoded.
+const (
+	MaxImageWidth  = 10000
+	MaxImageHeight = 10000
+	MaxImagePixels = 40_000_000
+)
+
+// AllowedTypes are the content types that can be uploaded along with the
+// maximum size for each.
+var AllowedTypes = map[string]int64{
+	"image/jpeg": MaxImageSize,
+	"image/png":  MaxImageSize,
+	"image/gif":  MaxImageSize,
+	"image/webp": MaxImageSize,
+	"video/mp4":  MaxVideoSize,
+	"video/webm": MaxVideoSize,
+}
+
+// Variants are the resized copies produced for images. Only the variants
+// smaller than the original image are produced.
+var Variants = []Variant{
+	{Name: "thumb", Size: 150},
+	{Name: "small", Size: 480},
+	{Name: "medium", Size: 1080},
+}
+
+// MaxAttempts is how many times processing an attachment is tried before it
+// is marked as failed.
+const MaxAttempts = 5
+
+// Set of settings for producing variants and placeholders.
+const (
+	jpegQuality    = 85
+	blurhashSize   = 32
+	blurhashXComps = 4
+	blurhashYComps = 3
+)
+
+// processableTypes are the content types of images that can be decoded.
+var processableTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// strippableTypes are the content types of images that can not be decoded
+// but still have their metadata stripped.
+var strippableTypes = map[string]bool{
+	"image/webp": true,
+}
+
+// errPermanent marks processing failures that retrying can not fix.
+var errPermanent = errors.New("permanent failure")
+
+// Core manages the set of API's for attachment access.
+type Core struct {
+	store   db.Store
+	nats    *nats.NATS
+	storage storage.Storage
+}
+
+// NewCore constructs a core for attachment api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS, storage storage.Storage) Core {
+	return Core{
+		store:   db.NewStore(log, sqlxDB),
+		nats:    nats,
+		storage: storage,
+	}
+}
+
+// Key returns the storage key of the content of an attachment.
+func Key(attachmentID string) string {
+	return "attachments/" + attachmentID
+}
+
+// VariantKey returns the storage key of a variant of an attachment.
+func VariantKey(attachmentID string, name string) string {
+	return Key(attachmentID) + "/" + name
+}
+
+// VariantType returns the content type of the variants of an image. JPEG
+// images stay JPEG, everything else becomes PNG to keep transparency.
+func VariantType(contentType string) string {
+	if contentType == "image/jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// Create streams the content read from r to storage and records the
+// attachment. Nothing is kept when the content fails any of the checks.
+func (c Core) Create(ctx context.Context, na NewAttachment, r io.Reader, now time.Time) (Attachment, error) {
+	if err := validate.Check(na); err != nil {
+		return Attachment{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	contentType, _, err := mime.ParseMediaType(na.ContentType)
+	if err != nil {
+		return Attachment{}, ErrUnsupportedType
+	}
+
+	max, ok := AllowedTypes[contentType]
+	if !ok {
+		return Attachment{}, ErrUnsupportedType
+	}
+	if na.Size > max {
+		return Attachment{}, ErrTooLarge
+	}
+
+	// Sniff the start of the content so clients can not label arbitrary
+	// files as images.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return Attachment{}, fmt.Errorf("reading content: %w", err)
+	}
+	head = head[:n]
+
+	if http.DetectContentType(head) != contentType {
+		return Attachment{}, ErrContentMismatch
+	}
+
+	hash := sha256.New()
+	body := io.TeeReader(io.MultiReader(bytes.NewReader(head), r), hash)
+
+	dbA := db.Attachment{
+		ID:          validate.GenerateID(),
+		UserID:      na.UserID,
+		ContentType: contentType,
+		Size:        na.Size,
+		DateCreated: now,
+		Status:      StatusPending,
+		Variants:    pq.StringArray{},
+	}
+	key := Key(dbA.ID)
+
+	if err := c.storage.Put(ctx, key, body, na.Size, contentType); err != nil {
+		if errors.Is(err, storage.ErrSizeMismatch) {
+			return Attachment{}, ErrSizeMismatch
+		}
+		return Attachment{}, fmt.Errorf("put: %w", err)
+	}
+
+	// From here on the content is in storage and has to be removed if the
+	// attachment is rejected.
+	reject := func(reason error) (Attachment, error) {
+		if err := c.storage.Delete(ctx, key); err != nil {
+			return Attachment{}, fmt.Errorf("delete: %w", err)
+		}
+		return Attachment{}, reason
+	}
+
+	// Storage only reads the declared size so anything left means the
+	// content is larger.
+	if n, _ := io.ReadFull(r, make([]byte, 1)); n != 0 {
+		return reject(ErrSizeMismatch)
+	}
+
+	dbA.Checksum = hex.EncodeToString(hash.Sum(nil))
+	if na.Checksum != "" && !strings.EqualFold(na.Checksum, dbA.Checksum) {
+		return reject(ErrChecksumMismatch)
+	}
+
+	// The event is published within the transaction so an attachment is
+	// never left waiting for processing that was not requested.
+	tran := func(tx sqlx.ExtContext) error {
+		if err := c.store.Tran(tx).Create(ctx, dbA); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		if err := c.publish("attachment-created", dbA); err != nil {
+			return fmt.Errorf("publish: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return reject(fmt.Errorf("tran: %w", err))
+	}
+
+	return toAttachment(dbA), nil
+}
+
+// QueryByID gets the specified attachment from the database.
+func (c Core) QueryByID(ctx context.Context, attachmentID string) (Attachment, error) {
+	if err := validate.CheckID(attachmentID); err != nil {
+		return Attachment{}, ErrInvalidID
+	}
+
+	dbA, err := c.store.QueryByID(ctx, attachmentID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Attachment{}, ErrNotFound
+		}
+		return Attachment{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toAttachment(dbA), nil
+}
+
+// QueryByIDs gets the specified attachments from the database.
+func (c Core) QueryByIDs(ctx context.Context, attachmentIDs []string) ([]Attachment, error) {
+	if len(attachmentIDs) == 0 {
+		return []Attachment{}, nil
+	}
+
+	dbAs, err := c.store.QueryByIDs(ctx, attachmentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toAttachmentSlice(dbAs), nil
+}
+
+// Open gets the specified attachment along with its content. The caller
+// must close the content. Content is only served once it is processed so
+// the metadata of images is never exposed.
+func (c Core) Open(ctx context.Context, attachmentID string) (Attachment, io.ReadCloser, error) {
+	a, err := c.QueryByID(ctx, attachmentID)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+
+	if a.Status != StatusReady {
+		return Attachment{}, nil, ErrNotReady
+	}
+
+	content, err := c.storage.Get(ctx, Key(a.ID))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return Attachment{}, nil, ErrNotFound
+		}
+		return Attachment{}, nil, fmt.Errorf("get: %w", err)
+	}
+
+	return a, content, nil
+}
+
+// OpenVariant gets the specified attachment along with the content of one
+// of its variants. The caller must close the content.
+func (c Core) OpenVariant(ctx context.Context, attachmentID string, name string) (Attachment, io.ReadCloser, error) {
+	a, err := c.QueryByID(ctx, attachmentID)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+
+	var found bool
+	for _, v := range a.Variants {
+		if v == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return Attachment{}, nil, ErrVariantNotFound
+	}
+
+	content, err := c.storage.Get(ctx, VariantKey(a.ID, name))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return Attachment{}, nil, ErrVariantNotFound
+		}
+		return Attachment{}, nil, fmt.Errorf("get: %w", err)
+	}
+
+	return a, content, nil
+}
+
+// Process strips the metadata of an image, records its dimensions and
+// produces its variants and placeholder. Attachments that are not images
+// are marked ready as they are. Processing is idempotent, a failure is
+// returned so it is retried until MaxAttempts is reached.
+func (c Core) Process(ctx context.Context, attachmentID string) error {
+	dbA, err := c.store.QueryByID(ctx, attachmentID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return nil
+		}
+		return fmt.Errorf("query: %w", err)
+	}
+
+	if dbA.Status != StatusPending {
+		return nil
+	}
+
+	process := c.processImage
+	switch {
+	case processableTypes[dbA.ContentType]:
+	case strippableTypes[dbA.ContentType]:
+		process = c.stripImage
+	default:
+		dbA.Status = StatusReady
+		if err := c.store.Update(ctx, dbA); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+		return nil
+	}
+
+	if err := process(ctx, &dbA); err != nil {
+		dbA.Attempts++
+		if errors.Is(err, errPermanent) || dbA.Attempts >= MaxAttempts {
+			dbA.Status = StatusFailed
+		}
+
+		if err := c.store.Update(ctx, dbA); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+		return fmt.Errorf("processing attempt %d: %w", dbA.Attempts, err)
+	}
+
+	dbA.Status = StatusReady
+	if err := c.store.Update(ctx, dbA); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	return nil
+}
+
+// processImage does the work of Process for images, the results are set on
+// the attachment.
+func (c Core) processImage(ctx context.Context, dbA *db.Attachment) error {
+	data, err := c.read(ctx, *dbA)
+	if err != nil {
+		return err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("decode config: %w: %s", errPermanent, err)
+	}
+	if cfg.Width > MaxImageWidth || cfg.Height > MaxImageHeight || cfg.Width*cfg.Height > MaxImagePixels {
+		return fmt.Errorf("dimensions %dx%d: %w: image is too large", cfg.Width, cfg.Height, errPermanent)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("decode: %w: %s", errPermanent, err)
+	}
+
+	stripped, err := imaging.Strip(data, dbA.ContentType)
+	if err != nil {
+		return fmt.Errorf("strip: %w: %s", errPermanent, err)
+	}
+
+	// The orientation is lost with the metadata so rotated photos are
+	// stored upright instead.
+	if dbA.ContentType == "image/jpeg" {
+		if o := imaging.Orientation(data); o != 1 {
+			img = imaging.Orient(img, o)
+
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+				return fmt.Errorf("encode: %w", err)
+			}
+			stripped = buf.Bytes()
+		}
+	}
+
+	if err := c.replace(ctx, dbA, data, stripped); err != nil {
+		return err
+	}
+
+	b := img.Bounds()
+	dbA.Width, dbA.Height = b.Dx(), b.Dy()
+
+	variantType := VariantType(dbA.ContentType)
+	dbA.Variants = pq.StringArray{}
+	for _, v := range Variants {
+		if dbA.Width <= v.Size && dbA.Height <= v.Size {
+			continue
+		}
+
+		var buf bytes.Buffer
+		resized := imaging.Fit(img, v.Size)
+		if variantType == "image/jpeg" {
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: jpegQuality})
+		} else {
+			err = png.Encode(&buf, resized)
+		}
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", v.Name, err)
+		}
+
+		if err := c.storage.Put(ctx, VariantKey(dbA.ID, v.Name), &buf, int64(buf.Len()), variantType); err != nil {
+			return fmt.Errorf("put %s: %w", v.Name, err)
+		}
+		dbA.Variants = append(dbA.Variants, v.Name)
+	}
+
+	dbA.Blurhash = imaging.Blurhash(imaging.Fit(img, blurhashSize), blurhashXComps, blurhashYComps)
+
+	return nil
+}
+
+// stripImage does the work of Process for images that can not be decoded,
+// only their metadata is stripped.
+func (c Core) stripImage(ctx context.Context, dbA *db.Attachment) error {
+	data, err := c.read(ctx, *dbA)
+	if err != nil {
+		return err
+	}
+
+	stripped, err := imaging.Strip(data, dbA.ContentType)
+	if err != nil {
+		return fmt.Errorf("strip: %w: %s", errPermanent, err)
+	}
+
+	return c.replace(ctx, dbA, data, stripped)
+}
+
+// read returns the original content of an image.
+func (c Core) read(ctx context.Context, dbA db.Attachment) ([]byte, error) {
+	rc, err := c.storage.Get(ctx, Key(dbA.ID))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("get: %w: %s", errPermanent, err)
+		}
+		return nil, fmt.Errorf("get: %w", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, MaxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	return data, nil
+}
+
+// replace stores the stripped content in place of the original when they
+// differ and records its size and checksum on the attachment.
+func (c Core) replace(ctx context.Context, dbA *db.Attachment, data []byte, stripped []byte) error {
+	if bytes.Equal(stripped, data) {
+		return nil
+	}
+
+	if err := c.storage.Put(ctx, Key(dbA.ID), bytes.NewReader(stripped), int64(len(stripped)), dbA.ContentType); err != nil {
+		return fmt.Errorf("put: %w", err)
+	}
+
+	sum := sha256.Sum256(stripped)
+	dbA.Size = int64(len(stripped))
+	dbA.Checksum = hex.EncodeToString(sum[:])
+
+	return nil
+}
+
+// publish sends the attachment to the services listening on the specified
+// subject.
+func (c Core) publish(subject string, dbA db.Attachment) error {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	if err := enc.Encode(&dbA); err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+
+	if err := c.nats.Client.Publish(subject, buf.Bytes()); err != nil {
+		return fmt.Errorf("publishing %s: %w", subject, err)
+	}
+
+	return nil
+}
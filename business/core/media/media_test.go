@@ -0,0 +1,306 @@
+package media_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/media"
+	"github.com/dudakovict/social-network/business/core/post"
+	"github.com/dudakovict/social-network/business/data/post/dbtest"
+	"github.com/dudakovict/social-network/foundation/docker"
+	"github.com/dudakovict/social-network/foundation/storage"
+	"github.com/google/go-cmp/cmp"
+)
+
+var nc *docker.Container
+var dbc *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	nc, err = dbtest.StartNATS()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	dbc, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	defer dbtest.StopNATS(nc)
+	defer dbtest.StopDB(dbc)
+
+	m.Run()
+}
+
+func TestAttachment(t *testing.T) {
+	log, db, n, teardown := dbtest.NewUnit(t, nc, dbc, "testattachment")
+	t.Cleanup(teardown)
+
+	store, err := storage.NewFS(t.TempDir())
+	if err != nil {
+		t.Fatalf("Should be able to construct the storage : %s.", err)
+	}
+
+	core := media.NewCore(log, db, n, store)
+	postCore := post.NewCore(log, db, n)
+
+	t.Log("Given the need to work with Attachment records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen uploading an image.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+			userID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 600, 300))); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to encode an image : %s.", dbtest.Failed, testID, err)
+			}
+			content := buf.Bytes()
+			sum := sha256.Sum256(content)
+			checksum := hex.EncodeToString(sum[:])
+
+			na := media.NewAttachment{
+				UserID:      userID,
+				ContentType: "image/png",
+				Size:        int64(len(content)),
+				Checksum:    checksum,
+			}
+
+			a, err := core.Create(ctx, na, bytes.NewReader(content), now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to upload attachment : %s.", dbtest.Failed, testID, err)
+			}
+			if a.Checksum != checksum {
+				t.Fatalf("\t%s\tTest %d:\tShould record the checksum : %s.", dbtest.Failed, testID, a.Checksum)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to upload attachment.", dbtest.Success, testID)
+
+			if _, _, err := core.Open(ctx, a.ID); !errors.Is(err, media.ErrNotReady) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT serve content before processing : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT serve content before processing.", dbtest.Success, testID)
+
+			if err := core.Process(ctx, a.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to process attachment : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to process attachment.", dbtest.Success, testID)
+
+			a, err = core.QueryByID(ctx, a.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve attachment by ID : %s.", dbtest.Failed, testID, err)
+			}
+			if a.Status != media.StatusReady || a.Width != 600 || a.Height != 300 || len(a.Blurhash) != 28 {
+				t.Fatalf("\t%s\tTest %d:\tShould record the image details : %+v.", dbtest.Failed, testID, a)
+			}
+			t.Logf("\t%s\tTest %d:\tShould record the image details.", dbtest.Success, testID)
+
+			if diff := cmp.Diff([]string{"thumb", "small"}, a.Variants); diff != "" {
+				t.Fatalf("\t%s\tTest %d:\tShould produce the smaller variants. Diff:\n%s", dbtest.Failed, testID, diff)
+			}
+			t.Logf("\t%s\tTest %d:\tShould produce the smaller variants.", dbtest.Success, testID)
+
+			_, vr, err := core.OpenVariant(ctx, a.ID, "thumb")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to open variant : %s.", dbtest.Failed, testID, err)
+			}
+			thumb, err := png.Decode(vr)
+			vr.Close()
+			if err != nil || thumb.Bounds().Dx() != 150 || thumb.Bounds().Dy() != 75 {
+				t.Fatalf("\t%s\tTest %d:\tShould get a 150x75 thumbnail : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get a 150x75 thumbnail.", dbtest.Success, testID)
+
+			if _, _, err := core.OpenVariant(ctx, a.ID, "medium"); !errors.Is(err, media.ErrVariantNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT upscale to larger variants : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT upscale to larger variants.", dbtest.Success, testID)
+
+			_, r, err := core.Open(ctx, a.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to open attachment : %s.", dbtest.Failed, testID, err)
+			}
+			got, err := io.ReadAll(r)
+			r.Close()
+			if err != nil || !bytes.Equal(got, content) {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the same content : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back the same content.", dbtest.Success, testID)
+
+			bad := na
+			bad.Checksum = hex.EncodeToString(make([]byte, 32))
+			if _, err := core.Create(ctx, bad, bytes.NewReader(content), now); !errors.Is(err, media.ErrChecksumMismatch) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT accept a wrong checksum : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT accept a wrong checksum.", dbtest.Success, testID)
+
+			bad = na
+			bad.ContentType = "application/pdf"
+			if _, err := core.Create(ctx, bad, bytes.NewReader(content), now); !errors.Is(err, media.ErrUnsupportedType) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT accept an unsupported type : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT accept an unsupported type.", dbtest.Success, testID)
+
+			bad = na
+			bad.ContentType = "image/jpeg"
+			if _, err := core.Create(ctx, bad, bytes.NewReader(content), now); !errors.Is(err, media.ErrContentMismatch) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT accept content of another type : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT accept content of another type.", dbtest.Success, testID)
+
+			bad = na
+			bad.Size = media.MaxImageSize + 1
+			if _, err := core.Create(ctx, bad, bytes.NewReader(content), now); !errors.Is(err, media.ErrTooLarge) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT accept images over the size limit : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT accept images over the size limit.", dbtest.Success, testID)
+
+			np := post.NewPost{
+				Title:         "New Song",
+				Description:   "Check out the cover!",
+				UserID:        userID,
+				AttachmentIDs: []string{a.ID},
+			}
+
+			corrupt := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 64)...)
+			na.Size = int64(len(corrupt))
+			na.Checksum = ""
+			c, err := core.Create(ctx, na, bytes.NewReader(corrupt), now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to upload attachment : %s.", dbtest.Failed, testID, err)
+			}
+			if err := core.Process(ctx, c.ID); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to process a corrupt image.", dbtest.Failed, testID)
+			}
+			if c, err = core.QueryByID(ctx, c.ID); err != nil || c.Status != media.StatusFailed {
+				t.Fatalf("\t%s\tTest %d:\tShould mark a corrupt image as failed without retries : %+v %v.", dbtest.Failed, testID, c, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould mark a corrupt image as failed without retries.", dbtest.Success, testID)
+
+			bomb := pngHeader(30000, 30000)
+			na.Size = int64(len(bomb))
+			b, err := core.Create(ctx, na, bytes.NewReader(bomb), now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to upload attachment : %s.", dbtest.Failed, testID, err)
+			}
+			if err := core.Process(ctx, b.ID); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to process an image with huge dimensions.", dbtest.Failed, testID)
+			}
+			if b, err = core.QueryByID(ctx, b.ID); err != nil || b.Status != media.StatusFailed || b.Width != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould mark an image with huge dimensions as failed without decoding it : %+v %v.", dbtest.Failed, testID, b, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould mark an image with huge dimensions as failed without decoding it.", dbtest.Success, testID)
+
+			gps := webpWithGPS()
+			na.ContentType = "image/webp"
+			na.Size = int64(len(gps))
+			w, err := core.Create(ctx, na, bytes.NewReader(gps), now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to upload a WebP image : %s.", dbtest.Failed, testID, err)
+			}
+			if err := core.Process(ctx, w.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to process a WebP image : %s.", dbtest.Failed, testID, err)
+			}
+			w, wr, err := core.Open(ctx, w.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to open a WebP image : %s.", dbtest.Failed, testID, err)
+			}
+			stripped, err := io.ReadAll(wr)
+			wr.Close()
+			if err != nil || bytes.Contains(stripped, []byte("GPS")) || w.Size != int64(len(stripped)) {
+				t.Fatalf("\t%s\tTest %d:\tShould strip the GPS data of a WebP image : %q %v.", dbtest.Failed, testID, stripped, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould strip the GPS data of a WebP image.", dbtest.Success, testID)
+			na.ContentType = "image/png"
+
+			p, err := postCore.Create(ctx, np, now)
+			if err != nil || len(p.AttachmentIDs) != 1 || p.AttachmentIDs[0] != a.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to attach to a post : %+v %v.", dbtest.Failed, testID, p, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to attach to a post.", dbtest.Success, testID)
+
+			np.UserID = "5cf37266-3473-4006-984f-9325122678b7"
+			if _, err := postCore.Create(ctx, np, now); !errors.Is(err, post.ErrInvalidAttachment) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to attach uploads of other users : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to attach uploads of other users.", dbtest.Success, testID)
+
+			const viewerID = "5cf37266-3473-4006-984f-9325122678b7"
+			if ok, err := postCore.CanViewAttachment(ctx, c.ID, viewerID); err != nil || ok {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to see an attachment that is not attached to a post : %v %v.", dbtest.Failed, testID, ok, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to see an attachment that is not attached to a post.", dbtest.Success, testID)
+
+			if ok, err := postCore.CanViewAttachment(ctx, a.ID, viewerID); err != nil || !ok {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to see an attachment of a public post : %v %v.", dbtest.Failed, testID, ok, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to see an attachment of a public post.", dbtest.Success, testID)
+
+			if _, err := db.ExecContext(ctx, `UPDATE posts SET visibility = 'private' WHERE post_id = $1`, p.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to make the post private : %s.", dbtest.Failed, testID, err)
+			}
+
+			if ok, err := postCore.CanViewAttachment(ctx, a.ID, viewerID); err != nil || ok {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to see an attachment of someone else's private post : %v %v.", dbtest.Failed, testID, ok, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to see an attachment of someone else's private post.", dbtest.Success, testID)
+		}
+	}
+}
+
+// pngHeader returns the start of a PNG image that declares the specified
+// dimensions without carrying the pixels for them.
+func pngHeader(width uint32, height uint32) []byte {
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], width)
+	binary.BigEndian.PutUint32(ihdr[8:], height)
+	ihdr[12] = 8 // bit depth
+	ihdr[13] = 2 // truecolor
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)-4))
+	buf.Write(ihdr)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+	return buf.Bytes()
+}
+
+// webpWithGPS returns a WebP image carrying GPS data in its EXIF chunk.
+func webpWithGPS() []byte {
+	chunk := func(typ string, data []byte) []byte {
+		c := make([]byte, 8, 9+len(data))
+		copy(c, typ)
+		binary.LittleEndian.PutUint32(c[4:], uint32(len(data)))
+		c = append(c, data...)
+		if len(data)%2 == 1 {
+			c = append(c, 0)
+		}
+		return c
+	}
+
+	body := []byte("WEBP")
+	body = append(body, chunk("VP8X", []byte{0x08, 0, 0, 0, 7, 0, 0, 7, 0, 0})...)
+	body = append(body, chunk("VP8L", []byte("VP8L image data!"))...)
+	body = append(body, chunk("EXIF", []byte("GPS 45.81,15.98"))...)
+
+	data := make([]byte, 8, 8+len(body))
+	copy(data, "RIFF")
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(body)))
+	return append(data, body...)
+}
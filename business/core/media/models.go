@@ -0,0 +1,64 @@
+package media
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/dudakovict/social-network/business/core/media/db"
+)
+
+// Set of statuses an attachment moves through while it is processed.
+const (
+	StatusPending = "pending"
+	StatusReady   = "ready"
+	StatusFailed  = "failed"
+)
+
+// Attachment represents an uploaded image or video. Checksum is the hex
+// encoded SHA-256 of the content. Width, Height, Blurhash and Variants are
+// filled in once an image is processed.
+type Attachment struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Checksum    string    `json:"checksum"`
+	DateCreated time.Time `json:"date_created"`
+	Status      string    `json:"status"`
+	Attempts    int       `json:"-"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Blurhash    string    `json:"blurhash,omitempty"`
+	Variants    []string  `json:"variants"`
+}
+
+// Variant describes a resized copy of an image. Images are scaled down so
+// their longest side is at most Size pixels.
+type Variant struct {
+	Name string
+	Size int
+}
+
+// NewAttachment contains information needed to upload an attachment. The
+// checksum is optional, when it is provided the content has to match it.
+type NewAttachment struct {
+	UserID      string `json:"user_id" validate:"required"`
+	ContentType string `json:"content_type" validate:"required"`
+	Size        int64  `json:"size" validate:"gt=0"`
+	Checksum    string `json:"checksum" validate:"omitempty,len=64,hexadecimal"`
+}
+
+// =============================================================================
+
+func toAttachment(dbA db.Attachment) Attachment {
+	pa := (*Attachment)(unsafe.Pointer(&dbA))
+	return *pa
+}
+
+func toAttachmentSlice(dbAs []db.Attachment) []Attachment {
+	as := make([]Attachment, len(dbAs))
+	for i, dbA := range dbAs {
+		as[i] = toAttachment(dbA)
+	}
+	return as
+}
@@ -0,0 +1,606 @@
+// Package db contains conversation and message related CRUD functionality.
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of API's for conversation access.
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+// NewStore constructs a data for api access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create inserts a new conversation into the database. Creating a direct
+// conversation that already exists is not an error, the existing one is
+// kept.
+func (s Store) Create(ctx context.Context, c Conversation) error {
+	const q = `
+	INSERT INTO conversations
+		(conversation_id, creator_id, title, direct_key, date_created, date_updated)
+	VALUES
+		(:conversation_id, :creator_id, :title, :direct_key, :date_created, :date_updated)
+	ON CONFLICT (direct_key) DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, c); err != nil {
+		return fmt.Errorf("inserting conversation: %w", err)
+	}
+
+	return nil
+}
+
+// Touch records that there was activity in the conversation.
+func (s Store) Touch(ctx context.Context, conversationID string, now time.Time) error {
+	data := struct {
+		ConversationID string    `db:"conversation_id"`
+		DateUpdated    time.Time `db:"date_updated"`
+	}{
+		ConversationID: conversationID,
+		DateUpdated:    now,
+	}
+
+	const q = `
+	UPDATE
+		conversations
+	SET
+		"date_updated" = :date_updated
+	WHERE
+		conversation_id = :conversation_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("touching conversationID[%s]: %w", conversationID, err)
+	}
+
+	return nil
+}
+
+// QueryByID gets the specified conversation from the database.
+func (s Store) QueryByID(ctx context.Context, conversationID string) (Conversation, error) {
+	data := struct {
+		ConversationID string `db:"conversation_id"`
+	}{
+		ConversationID: conversationID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		conversations
+	WHERE
+		conversation_id = :conversation_id`
+
+	var c Conversation
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &c); err != nil {
+		return Conversation{}, fmt.Errorf("selecting conversationID[%q]: %w", conversationID, err)
+	}
+
+	return c, nil
+}
+
+// QueryByDirectKey gets the direct conversation with the specified key from
+// the database.
+func (s Store) QueryByDirectKey(ctx context.Context, directKey string) (Conversation, error) {
+	data := struct {
+		DirectKey string `db:"direct_key"`
+	}{
+		DirectKey: directKey,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		conversations
+	WHERE
+		direct_key = :direct_key`
+
+	var c Conversation
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &c); err != nil {
+		return Conversation{}, fmt.Errorf("selecting directKey[%q]: %w", directKey, err)
+	}
+
+	return c, nil
+}
+
+// QuerySummaries retrieves a page of the conversations the user takes part
+// in, most recently active first.
+func (s Store) QuerySummaries(ctx context.Context, userID string, pageNumber int, rowsPerPage int) ([]Summary, error) {
+	data := struct {
+		UserID      string `db:"user_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		UserID:      userID,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		c.*,
+		m.muted,
+		(
+			SELECT COUNT(*) FROM messages msg
+			WHERE
+				msg.conversation_id = m.conversation_id AND
+				msg.user_id <> m.user_id AND
+				msg.date_created >= m.date_joined AND
+				(m.last_read_id IS NULL OR (msg.date_created, msg.message_id) > (m.last_read_date, m.last_read_id))
+		) AS unread
+	FROM
+		conversations AS c
+	JOIN
+		members AS m ON m.conversation_id = c.conversation_id
+	WHERE
+		m.user_id = :user_id AND
+		m.date_left IS NULL
+	ORDER BY
+		c.date_updated DESC, c.conversation_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var ss []Summary
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ss); err != nil {
+		return nil, fmt.Errorf("selecting conversations of userID[%s]: %w", userID, err)
+	}
+
+	return ss, nil
+}
+
+// QuerySummary gets the specified conversation as seen by the user. Users
+// who are not taking part in the conversation do not see it.
+func (s Store) QuerySummary(ctx context.Context, conversationID string, userID string) (Summary, error) {
+	data := struct {
+		ConversationID string `db:"conversation_id"`
+		UserID         string `db:"user_id"`
+	}{
+		ConversationID: conversationID,
+		UserID:         userID,
+	}
+
+	const q = `
+	SELECT
+		c.*,
+		m.muted,
+		(
+			SELECT COUNT(*) FROM messages msg
+			WHERE
+				msg.conversation_id = m.conversation_id AND
+				msg.user_id <> m.user_id AND
+				msg.date_created >= m.date_joined AND
+				(m.last_read_id IS NULL OR (msg.date_created, msg.message_id) > (m.last_read_date, m.last_read_id))
+		) AS unread
+	FROM
+		conversations AS c
+	JOIN
+		members AS m ON m.conversation_id = c.conversation_id
+	WHERE
+		c.conversation_id = :conversation_id AND
+		m.user_id = :user_id AND
+		m.date_left IS NULL`
+
+	var sm Summary
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &sm); err != nil {
+		return Summary{}, fmt.Errorf("selecting conversationID[%q]: %w", conversationID, err)
+	}
+
+	return sm, nil
+}
+
+// QueryUnread counts the messages the user has not read yet and the
+// conversations they are in. Muted conversations are not counted.
+func (s Store) QueryUnread(ctx context.Context, userID string) (Unread, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		COUNT(*) AS messages,
+		COUNT(DISTINCT msg.conversation_id) AS conversations
+	FROM
+		members AS m
+	JOIN
+		messages AS msg ON msg.conversation_id = m.conversation_id
+	WHERE
+		m.user_id = :user_id AND
+		m.date_left IS NULL AND
+		NOT m.muted AND
+		msg.user_id <> m.user_id AND
+		msg.date_created >= m.date_joined AND
+		(m.last_read_id IS NULL OR (msg.date_created, msg.message_id) > (m.last_read_date, m.last_read_id))`
+
+	var u Unread
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &u); err != nil {
+		return Unread{}, fmt.Errorf("counting unread userID[%s]: %w", userID, err)
+	}
+
+	return u, nil
+}
+
+// =============================================================================
+
+// SaveMember adds the user to the conversation. Users who left the
+// conversation join it again from now on, users who are still taking part
+// are left as they are.
+func (s Store) SaveMember(ctx context.Context, m Member) error {
+	const q = `
+	INSERT INTO members
+		(conversation_id, user_id, muted, last_read_id, last_read_date, date_read, date_joined, date_left)
+	VALUES
+		(:conversation_id, :user_id, :muted, :last_read_id, :last_read_date, :date_read, :date_joined, :date_left)
+	ON CONFLICT (conversation_id, user_id) DO UPDATE SET
+		date_joined = EXCLUDED.date_joined,
+		date_left = NULL
+	WHERE
+		members.date_left IS NOT NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, m); err != nil {
+		return fmt.Errorf("inserting member: %w", err)
+	}
+
+	return nil
+}
+
+// QueryMember gets the membership of the user in the conversation, whether
+// the user is still taking part or not.
+func (s Store) QueryMember(ctx context.Context, conversationID string, userID string) (Member, error) {
+	data := struct {
+		ConversationID string `db:"conversation_id"`
+		UserID         string `db:"user_id"`
+	}{
+		ConversationID: conversationID,
+		UserID:         userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		members
+	WHERE
+		conversation_id = :conversation_id AND
+		user_id = :user_id`
+
+	var m Member
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &m); err != nil {
+		return Member{}, fmt.Errorf("selecting member userID[%q]: %w", userID, err)
+	}
+
+	return m, nil
+}
+
+// QueryMembers retrieves the users taking part in the specified
+// conversations, in the order they joined.
+func (s Store) QueryMembers(ctx context.Context, conversationIDs []string) ([]Member, error) {
+	data := struct {
+		ConversationIDs pq.StringArray `db:"conversation_ids"`
+	}{
+		ConversationIDs: conversationIDs,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		members
+	WHERE
+		CAST(conversation_id AS TEXT) = ANY(:conversation_ids) AND
+		date_left IS NULL
+	ORDER BY
+		date_joined, user_id`
+
+	var ms []Member
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ms); err != nil {
+		return nil, fmt.Errorf("selecting members: %w", err)
+	}
+
+	return ms, nil
+}
+
+// Leave takes the user out of the conversation.
+func (s Store) Leave(ctx context.Context, conversationID string, userID string, now time.Time) error {
+	data := struct {
+		ConversationID string    `db:"conversation_id"`
+		UserID         string    `db:"user_id"`
+		DateLeft       time.Time `db:"date_left"`
+	}{
+		ConversationID: conversationID,
+		UserID:         userID,
+		DateLeft:       now,
+	}
+
+	const q = `
+	UPDATE
+		members
+	SET
+		"date_left" = :date_left
+	WHERE
+		conversation_id = :conversation_id AND
+		user_id = :user_id AND
+		date_left IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("leaving conversationID[%s]: %w", conversationID, err)
+	}
+
+	return nil
+}
+
+// Return brings every member who left the conversation back into it. The
+// messages sent while they were away are not hidden from them.
+func (s Store) Return(ctx context.Context, conversationID string) error {
+	data := struct {
+		ConversationID string `db:"conversation_id"`
+	}{
+		ConversationID: conversationID,
+	}
+
+	const q = `
+	UPDATE
+		members
+	SET
+		"date_left" = NULL
+	WHERE
+		conversation_id = :conversation_id AND
+		date_left IS NOT NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("returning to conversationID[%s]: %w", conversationID, err)
+	}
+
+	return nil
+}
+
+// SetMuted mutes or unmutes the conversation for the user.
+func (s Store) SetMuted(ctx context.Context, conversationID string, userID string, muted bool) error {
+	data := struct {
+		ConversationID string `db:"conversation_id"`
+		UserID         string `db:"user_id"`
+		Muted          bool   `db:"muted"`
+	}{
+		ConversationID: conversationID,
+		UserID:         userID,
+		Muted:          muted,
+	}
+
+	const q = `
+	UPDATE
+		members
+	SET
+		"muted" = :muted
+	WHERE
+		conversation_id = :conversation_id AND
+		user_id = :user_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("muting conversationID[%s]: %w", conversationID, err)
+	}
+
+	return nil
+}
+
+// MarkRead moves how far the user has read the conversation up to the
+// message and reports whether it moved. It never moves back to an older
+// message.
+func (s Store) MarkRead(ctx context.Context, userID string, m Message, now time.Time) (bool, error) {
+	data := struct {
+		ConversationID string    `db:"conversation_id"`
+		UserID         string    `db:"user_id"`
+		MessageID      string    `db:"message_id"`
+		MessageDate    time.Time `db:"message_date"`
+		DateRead       time.Time `db:"date_read"`
+	}{
+		ConversationID: m.ConversationID,
+		UserID:         userID,
+		MessageID:      m.ID,
+		MessageDate:    m.DateCreated,
+		DateRead:       now,
+	}
+
+	const q = `
+	UPDATE
+		members
+	SET
+		"last_read_id" = :message_id,
+		"last_read_date" = :message_date,
+		"date_read" = :date_read
+	WHERE
+		conversation_id = :conversation_id AND
+		user_id = :user_id AND
+		(last_read_id IS NULL OR (last_read_date, last_read_id) < (CAST(:message_date AS TIMESTAMP), CAST(:message_id AS UUID)))
+	RETURNING
+		user_id`
+
+	var result struct {
+		UserID string `db:"user_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("marking read conversationID[%s]: %w", m.ConversationID, err)
+	}
+
+	return true, nil
+}
+
+// QueryReceipts retrieves how far each user taking part in the conversation
+// has read it. Users who have not read anything yet are left out.
+func (s Store) QueryReceipts(ctx context.Context, conversationID string) ([]Receipt, error) {
+	data := struct {
+		ConversationID string `db:"conversation_id"`
+	}{
+		ConversationID: conversationID,
+	}
+
+	const q = `
+	SELECT
+		conversation_id, user_id, last_read_id, date_read
+	FROM
+		members
+	WHERE
+		conversation_id = :conversation_id AND
+		date_left IS NULL AND
+		last_read_id IS NOT NULL
+	ORDER BY
+		last_read_date DESC, user_id`
+
+	var rs []Receipt
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &rs); err != nil {
+		return nil, fmt.Errorf("selecting receipts of conversationID[%s]: %w", conversationID, err)
+	}
+
+	return rs, nil
+}
+
+// =============================================================================
+
+// CreateMessage inserts a new message into the database.
+func (s Store) CreateMessage(ctx context.Context, m Message) error {
+	const q = `
+	INSERT INTO messages
+		(message_id, conversation_id, user_id, body, date_created)
+	VALUES
+		(:message_id, :conversation_id, :user_id, :body, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, m); err != nil {
+		return fmt.Errorf("inserting message: %w", err)
+	}
+
+	return nil
+}
+
+// QueryMessageByID gets the specified message of the conversation from the
+// database.
+func (s Store) QueryMessageByID(ctx context.Context, conversationID string, messageID string) (Message, error) {
+	data := struct {
+		ConversationID string `db:"conversation_id"`
+		MessageID      string `db:"message_id"`
+	}{
+		ConversationID: conversationID,
+		MessageID:      messageID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		messages
+	WHERE
+		message_id = :message_id AND
+		conversation_id = :conversation_id`
+
+	var m Message
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &m); err != nil {
+		return Message{}, fmt.Errorf("selecting messageID[%q]: %w", messageID, err)
+	}
+
+	return m, nil
+}
+
+// QueryLatestMessage gets the most recent message of the conversation from
+// the database.
+func (s Store) QueryLatestMessage(ctx context.Context, conversationID string) (Message, error) {
+	data := struct {
+		ConversationID string `db:"conversation_id"`
+	}{
+		ConversationID: conversationID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		messages
+	WHERE
+		conversation_id = :conversation_id
+	ORDER BY
+		date_created DESC, message_id DESC
+	LIMIT 1`
+
+	var m Message
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &m); err != nil {
+		return Message{}, fmt.Errorf("selecting latest message of conversationID[%q]: %w", conversationID, err)
+	}
+
+	return m, nil
+}
+
+// QueryMessages retrieves the messages of the conversation sent since the
+// specified time, newest first. Only messages sent before the cursor are
+// returned.
+func (s Store) QueryMessages(ctx context.Context, conversationID string, since time.Time, before time.Time, beforeID string, limit int) ([]Message, error) {
+	data := struct {
+		ConversationID string    `db:"conversation_id"`
+		Since          time.Time `db:"since"`
+		Before         time.Time `db:"before"`
+		BeforeID       string    `db:"before_id"`
+		Limit          int       `db:"limit"`
+	}{
+		ConversationID: conversationID,
+		Since:          since,
+		Before:         before,
+		BeforeID:       beforeID,
+		Limit:          limit,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		messages
+	WHERE
+		conversation_id = :conversation_id AND
+		date_created >= :since AND
+		(date_created, message_id) < (CAST(:before AS TIMESTAMP), CAST(:before_id AS UUID))
+	ORDER BY
+		date_created DESC, message_id DESC
+	LIMIT :limit`
+
+	var ms []Message
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ms); err != nil {
+		return nil, fmt.Errorf("selecting messages of conversationID[%s]: %w", conversationID, err)
+	}
+
+	return ms, nil
+}
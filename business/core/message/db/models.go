@@ -0,0 +1,69 @@
+package db
+
+import (
+	"time"
+)
+
+// Conversation represent the structure we need for moving data
+// between the app and the database. Direct conversations have a key made of
+// their two members so there is only ever one between the same users.
+type Conversation struct {
+	ID          string    `db:"conversation_id"`
+	CreatorID   string    `db:"creator_id"`
+	Title       *string   `db:"title"`
+	DirectKey   *string   `db:"direct_key"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+// Summary is a conversation as seen by one of its members.
+type Summary struct {
+	Conversation
+	Muted  bool `db:"muted"`
+	Unread int  `db:"unread"`
+}
+
+// Member represents a user taking part in a conversation. The last read
+// message and its date mark how far the member has read. Members see the
+// messages sent since they joined until they leave.
+type Member struct {
+	ConversationID string     `db:"conversation_id"`
+	UserID         string     `db:"user_id"`
+	Muted          bool       `db:"muted"`
+	LastReadID     *string    `db:"last_read_id"`
+	LastReadDate   *time.Time `db:"last_read_date"`
+	DateRead       *time.Time `db:"date_read"`
+	DateJoined     time.Time  `db:"date_joined"`
+	DateLeft       *time.Time `db:"date_left"`
+}
+
+// Message represents a message sent to a conversation.
+type Message struct {
+	ID             string    `db:"message_id"`
+	ConversationID string    `db:"conversation_id"`
+	UserID         string    `db:"user_id"`
+	Body           string    `db:"body"`
+	DateCreated    time.Time `db:"date_created"`
+}
+
+// Unread represents how many messages a user has not read yet and in how
+// many conversations.
+type Unread struct {
+	Messages      int `db:"messages"`
+	Conversations int `db:"conversations"`
+}
+
+// Delivery is a message together with the members it is delivered to. The
+// sender and the members who muted the conversation are left out.
+type Delivery struct {
+	Message    Message
+	Recipients []string
+}
+
+// Receipt records how far a member has read a conversation.
+type Receipt struct {
+	ConversationID string    `db:"conversation_id"`
+	UserID         string    `db:"user_id"`
+	MessageID      string    `db:"last_read_id"`
+	DateRead       time.Time `db:"date_read"`
+}
@@ -0,0 +1,623 @@
+// Package message provides the core business API for direct messaging.
+// Users talk in conversations, either one to one or in small groups, and
+// every member keeps track of how far they have read.
+package message
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/message/db"
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound           = errors.New("conversation not found")
+	ErrMessageNotFound    = errors.New("message not found")
+	ErrInvalidID          = errors.New("ID is not in its proper form")
+	ErrInvalidCursor      = errors.New("cursor is not in its proper form")
+	ErrInvalidMembers     = errors.New("conversation needs at least one other member")
+	ErrTooManyMembers     = errors.New("conversation has too many members")
+	ErrDirectConversation = errors.New("members can not be added to a direct conversation")
+)
+
+// MaxMembers is how many users can take part in a conversation.
+const MaxMembers = 32
+
+// Core manages the set of API's for conversation access.
+type Core struct {
+	store db.Store
+	nats  *nats.NATS
+}
+
+// NewCore constructs a core for conversation api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS) Core {
+	return Core{
+		store: db.NewStore(log, sqlxDB),
+		nats:  nats,
+	}
+}
+
+// Create starts a conversation between the user and the specified users.
+// There is only ever one direct conversation between the same two users, so
+// starting it again brings both of them back into the existing one.
+func (c Core) Create(ctx context.Context, userID string, nc NewConversation, now time.Time) (Conversation, error) {
+	if err := validate.Check(nc); err != nil {
+		return Conversation{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	others := unique(nc.UserIDs, userID)
+	if len(others) == 0 {
+		return Conversation{}, ErrInvalidMembers
+	}
+	if len(others)+1 > MaxMembers {
+		return Conversation{}, ErrTooManyMembers
+	}
+
+	dbC := db.Conversation{
+		ID:          validate.GenerateID(),
+		CreatorID:   userID,
+		Title:       nc.Title,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if len(others) == 1 && nc.Title == nil {
+		key := directKey(userID, others[0])
+		dbC.DirectKey = &key
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		if err := store.Create(ctx, dbC); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		if dbC.DirectKey != nil {
+			var err error
+			if dbC, err = store.QueryByDirectKey(ctx, *dbC.DirectKey); err != nil {
+				return fmt.Errorf("query direct: %w", err)
+			}
+
+			if err := store.Return(ctx, dbC.ID); err != nil {
+				return fmt.Errorf("return: %w", err)
+			}
+		}
+
+		for _, memberID := range append([]string{userID}, others...) {
+			dbM := db.Member{
+				ConversationID: dbC.ID,
+				UserID:         memberID,
+				DateJoined:     now,
+			}
+			if err := store.SaveMember(ctx, dbM); err != nil {
+				return fmt.Errorf("save member: %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Conversation{}, fmt.Errorf("tran: %w", err)
+	}
+
+	return c.QueryByID(ctx, userID, dbC.ID)
+}
+
+// Query retrieves a page of the conversations the user takes part in, most
+// recently active first.
+func (c Core) Query(ctx context.Context, userID string, pageNumber int, rowsPerPage int) ([]Conversation, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	dbSummaries, err := c.store.QuerySummaries(ctx, userID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	if len(dbSummaries) == 0 {
+		return []Conversation{}, nil
+	}
+
+	conversationIDs := make([]string, len(dbSummaries))
+	for i, dbS := range dbSummaries {
+		conversationIDs[i] = dbS.ID
+	}
+
+	dbMembers, err := c.store.QueryMembers(ctx, conversationIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query members: %w", err)
+	}
+
+	members := make(map[string][]db.Member)
+	for _, dbM := range dbMembers {
+		members[dbM.ConversationID] = append(members[dbM.ConversationID], dbM)
+	}
+
+	conversations := make([]Conversation, len(dbSummaries))
+	for i, dbS := range dbSummaries {
+		conversations[i] = toConversation(dbS, members[dbS.ID])
+	}
+
+	return conversations, nil
+}
+
+// QueryByID gets the specified conversation as seen by the user.
+func (c Core) QueryByID(ctx context.Context, userID string, conversationID string) (Conversation, error) {
+	if err := validate.CheckID(conversationID); err != nil {
+		return Conversation{}, ErrInvalidID
+	}
+
+	dbS, err := c.store.QuerySummary(ctx, conversationID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Conversation{}, ErrNotFound
+		}
+		return Conversation{}, fmt.Errorf("query: conversationID[%s]: %w", conversationID, err)
+	}
+
+	dbMembers, err := c.store.QueryMembers(ctx, []string{conversationID})
+	if err != nil {
+		return Conversation{}, fmt.Errorf("query members: %w", err)
+	}
+
+	return toConversation(dbS, dbMembers), nil
+}
+
+// QueryUnread counts the messages the user has not read yet.
+func (c Core) QueryUnread(ctx context.Context, userID string) (Unread, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return Unread{}, ErrInvalidID
+	}
+
+	dbU, err := c.store.QueryUnread(ctx, userID)
+	if err != nil {
+		return Unread{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toUnread(dbU), nil
+}
+
+// AddMembers adds users to a group conversation the user takes part in. The
+// users added only see the messages sent from now on.
+func (c Core) AddMembers(ctx context.Context, userID string, conversationID string, nm NewMembers, now time.Time) error {
+	if err := validate.Check(nm); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	dbC, err := c.conversation(ctx, conversationID, userID)
+	if err != nil {
+		return err
+	}
+
+	if dbC.DirectKey != nil {
+		return ErrDirectConversation
+	}
+
+	dbMembers, err := c.store.QueryMembers(ctx, []string{conversationID})
+	if err != nil {
+		return fmt.Errorf("query members: %w", err)
+	}
+
+	var added []string
+	for _, memberID := range unique(nm.UserIDs, userID) {
+		if !isMember(dbMembers, memberID) {
+			added = append(added, memberID)
+		}
+	}
+
+	if len(dbMembers)+len(added) > MaxMembers {
+		return ErrTooManyMembers
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		for _, memberID := range added {
+			dbM := db.Member{
+				ConversationID: conversationID,
+				UserID:         memberID,
+				DateJoined:     now,
+			}
+			if err := c.store.Tran(tx).SaveMember(ctx, dbM); err != nil {
+				return fmt.Errorf("save member: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// Leave takes the user out of the conversation. A user who left a direct
+// conversation is brought back by the next message sent to it.
+func (c Core) Leave(ctx context.Context, userID string, conversationID string, now time.Time) error {
+	if _, err := c.conversation(ctx, conversationID, userID); err != nil {
+		return err
+	}
+
+	if err := c.store.Leave(ctx, conversationID, userID, now); err != nil {
+		return fmt.Errorf("leave: %w", err)
+	}
+
+	return nil
+}
+
+// Mute mutes or unmutes the conversation for the user. Members who muted a
+// conversation are not told about its new messages and its messages do not
+// count towards their unread total.
+func (c Core) Mute(ctx context.Context, userID string, conversationID string, muted bool) error {
+	if _, err := c.conversation(ctx, conversationID, userID); err != nil {
+		return err
+	}
+
+	if err := c.store.SetMuted(ctx, conversationID, userID, muted); err != nil {
+		return fmt.Errorf("mute: %w", err)
+	}
+
+	return nil
+}
+
+// CheckMember reports whether the user takes part in the conversation.
+func (c Core) CheckMember(ctx context.Context, userID string, conversationID string) error {
+	_, err := c.conversation(ctx, conversationID, userID)
+	return err
+}
+
+// =============================================================================
+
+// Send sends a message to a conversation the user takes part in. A direct
+// conversation the user left is still theirs to write to, and the message
+// brings back whoever left it. The message counts as read by its sender.
+func (c Core) Send(ctx context.Context, userID string, conversationID string, nm NewMessage, now time.Time) (Message, error) {
+	if err := validate.CheckID(conversationID); err != nil {
+		return Message{}, ErrInvalidID
+	}
+
+	if err := validate.Check(nm); err != nil {
+		return Message{}, fmt.Errorf("validating data: %w", err)
+	}
+
+	dbMem, err := c.store.QueryMember(ctx, conversationID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Message{}, ErrNotFound
+		}
+		return Message{}, fmt.Errorf("query member: %w", err)
+	}
+
+	dbC, err := c.store.QueryByID(ctx, conversationID)
+	if err != nil {
+		return Message{}, fmt.Errorf("query: conversationID[%s]: %w", conversationID, err)
+	}
+
+	if dbMem.DateLeft != nil && dbC.DirectKey == nil {
+		return Message{}, ErrNotFound
+	}
+
+	dbMsg := db.Message{
+		ID:             validate.GenerateID(),
+		ConversationID: conversationID,
+		UserID:         userID,
+		Body:           nm.Body,
+		DateCreated:    now,
+	}
+
+	tran := func(tx sqlx.ExtContext) error {
+		store := c.store.Tran(tx)
+
+		if err := store.CreateMessage(ctx, dbMsg); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		if err := store.Touch(ctx, conversationID, now); err != nil {
+			return fmt.Errorf("touch: %w", err)
+		}
+
+		if dbC.DirectKey != nil {
+			if err := store.Return(ctx, conversationID); err != nil {
+				return fmt.Errorf("return: %w", err)
+			}
+		}
+
+		if _, err := store.MarkRead(ctx, userID, dbMsg, now); err != nil {
+			return fmt.Errorf("mark read: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return Message{}, fmt.Errorf("tran: %w", err)
+	}
+
+	dbMembers, err := c.store.QueryMembers(ctx, []string{conversationID})
+	if err != nil {
+		return Message{}, fmt.Errorf("query members: %w", err)
+	}
+
+	dbD := db.Delivery{
+		Message: dbMsg,
+	}
+	for _, dbM := range dbMembers {
+		if dbM.UserID != userID && !dbM.Muted {
+			dbD.Recipients = append(dbD.Recipients, dbM.UserID)
+		}
+	}
+
+	if err := c.publish("message-created", dbD); err != nil {
+		return Message{}, fmt.Errorf("pub: %w", err)
+	}
+
+	return toMessage(dbMsg), nil
+}
+
+// QueryMessages retrieves a page of the history of the conversation, newest
+// first. Members only see the messages sent since they joined. An empty
+// cursor starts from the newest message.
+func (c Core) QueryMessages(ctx context.Context, userID string, conversationID string, cursor string, limit int) (Page, error) {
+	if err := validate.CheckID(conversationID); err != nil {
+		return Page{}, ErrInvalidID
+	}
+
+	before, beforeID, err := decodeCursor(cursor)
+	if err != nil {
+		return Page{}, err
+	}
+
+	dbMem, err := c.member(ctx, conversationID, userID)
+	if err != nil {
+		return Page{}, err
+	}
+
+	// Ask for one extra message to know if there is a next page.
+	dbMsgs, err := c.store.QueryMessages(ctx, conversationID, dbMem.DateJoined, before, beforeID, limit+1)
+	if err != nil {
+		return Page{}, fmt.Errorf("query: %w", err)
+	}
+
+	var page Page
+	if len(dbMsgs) > limit {
+		dbMsgs = dbMsgs[:limit]
+		last := dbMsgs[limit-1]
+		page.Cursor = encodeCursor(last.DateCreated, last.ID)
+	}
+	page.Messages = toMessageSlice(dbMsgs)
+
+	return page, nil
+}
+
+// MarkRead records that the user read the conversation up to the specified
+// message, or up to the newest message when none is specified. Reading an
+// older message than the one already read changes nothing.
+func (c Core) MarkRead(ctx context.Context, userID string, conversationID string, rm ReadMessages, now time.Time) error {
+	if err := validate.Check(rm); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	dbMem, err := c.member(ctx, conversationID, userID)
+	if err != nil {
+		return err
+	}
+
+	var dbMsg db.Message
+	switch rm.MessageID {
+	case nil:
+		dbMsg, err = c.store.QueryLatestMessage(ctx, conversationID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return nil
+			}
+			return fmt.Errorf("query latest: %w", err)
+		}
+
+	default:
+		dbMsg, err = c.store.QueryMessageByID(ctx, conversationID, *rm.MessageID)
+		if err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return ErrMessageNotFound
+			}
+			return fmt.Errorf("query message: %w", err)
+		}
+	}
+
+	if dbMsg.DateCreated.Before(dbMem.DateJoined) {
+		if rm.MessageID != nil {
+			return ErrMessageNotFound
+		}
+		return nil
+	}
+
+	moved, err := c.store.MarkRead(ctx, userID, dbMsg, now)
+	if err != nil {
+		return fmt.Errorf("mark read: %w", err)
+	}
+
+	if !moved {
+		return nil
+	}
+
+	dbR := db.Receipt{
+		ConversationID: conversationID,
+		UserID:         userID,
+		MessageID:      dbMsg.ID,
+		DateRead:       now,
+	}
+
+	if err := c.publishReceipt(dbR); err != nil {
+		return fmt.Errorf("pub: %w", err)
+	}
+
+	return nil
+}
+
+// QueryReceipts retrieves how far every member of the conversation has read
+// it, most recently read first.
+func (c Core) QueryReceipts(ctx context.Context, userID string, conversationID string) ([]Receipt, error) {
+	if _, err := c.conversation(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	dbRs, err := c.store.QueryReceipts(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toReceiptSlice(dbRs), nil
+}
+
+// =============================================================================
+
+// member returns the membership of a user who takes part in the
+// conversation. Conversations the user does not take part in are reported
+// as not found so their existence is not revealed.
+func (c Core) member(ctx context.Context, conversationID string, userID string) (db.Member, error) {
+	if err := validate.CheckID(conversationID); err != nil {
+		return db.Member{}, ErrInvalidID
+	}
+
+	dbMem, err := c.store.QueryMember(ctx, conversationID, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return db.Member{}, ErrNotFound
+		}
+		return db.Member{}, fmt.Errorf("query member: %w", err)
+	}
+
+	if dbMem.DateLeft != nil {
+		return db.Member{}, ErrNotFound
+	}
+
+	return dbMem, nil
+}
+
+// conversation returns a conversation the user takes part in.
+func (c Core) conversation(ctx context.Context, conversationID string, userID string) (db.Conversation, error) {
+	if _, err := c.member(ctx, conversationID, userID); err != nil {
+		return db.Conversation{}, err
+	}
+
+	dbC, err := c.store.QueryByID(ctx, conversationID)
+	if err != nil {
+		return db.Conversation{}, fmt.Errorf("query: conversationID[%s]: %w", conversationID, err)
+	}
+
+	return dbC, nil
+}
+
+// publish sends the delivery of a message to the services listening on the
+// specified subject.
+func (c Core) publish(subject string, dbD db.Delivery) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&dbD); err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+
+	if err := c.nats.Client.Publish(subject, buf.Bytes()); err != nil {
+		return fmt.Errorf("publishing %s: %w", subject, err)
+	}
+
+	return nil
+}
+
+// publishReceipt tells the services listening that a member read the
+// conversation further.
+func (c Core) publishReceipt(dbR db.Receipt) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&dbR); err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+
+	if err := c.nats.Client.Publish("conversation-read", buf.Bytes()); err != nil {
+		return fmt.Errorf("publishing conversation-read: %w", err)
+	}
+
+	return nil
+}
+
+// unique returns the specified users once each, leaving out the user. IDs
+// are compared in lower case since the database does not tell them apart.
+func unique(userIDs []string, userID string) []string {
+	seen := map[string]bool{strings.ToLower(userID): true}
+
+	var ids []string
+	for _, id := range userIDs {
+		id = strings.ToLower(id)
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// isMember reports whether the user is one of the members.
+func isMember(dbMembers []db.Member, userID string) bool {
+	for _, dbM := range dbMembers {
+		if dbM.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// directKey identifies the direct conversation between two users regardless
+// of which one started it or how their IDs are cased.
+func directKey(userID string, otherID string) string {
+	ids := []string{strings.ToLower(userID), strings.ToLower(otherID)}
+	sort.Strings(ids)
+	return strings.Join(ids, ":")
+}
+
+// encodeCursor makes an opaque cursor pointing past the specified message.
+func encodeCursor(date time.Time, messageID string) string {
+	s := date.UTC().Format(time.RFC3339Nano) + "|" + messageID
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// decodeCursor returns the position a cursor points to. The empty cursor
+// points past the newest possible message.
+func decodeCursor(cursor string) (time.Time, string, error) {
+	if cursor == "" {
+		return time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC), "ffffffff-ffff-ffff-ffff-ffffffffffff", nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	date, messageID, ok := strings.Cut(string(b), "|")
+	if !ok {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, date)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	if err := validate.CheckID(messageID); err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	return t, messageID, nil
+}
@@ -0,0 +1,206 @@
+package message_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/message"
+	"github.com/dudakovict/social-network/business/data/message/dbtest"
+	"github.com/dudakovict/social-network/foundation/docker"
+)
+
+var nc *docker.Container
+var dbc *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	nc, err = dbtest.StartNATS()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	dbc, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	defer dbtest.StopNATS(nc)
+	defer dbtest.StopDB(dbc)
+
+	m.Run()
+}
+
+func TestMessage(t *testing.T) {
+	log, sqlxDB, teardown := dbtest.NewUnit(t, dbc, "testmessage")
+	t.Cleanup(teardown)
+
+	n, teardownNATS := dbtest.NewNATS(t, nc)
+	t.Cleanup(teardownNATS)
+
+	core := message.NewCore(log, sqlxDB, n)
+
+	t.Log("Given the need to work with direct messages.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a direct conversation.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.April, 1, 0, 0, 0, 0, time.UTC)
+			adminID := "5cf37266-3473-4006-984f-9325122678b7"
+			userID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+			seedID := "9b1f6c3e-5a2d-4e8f-b7c1-3d9e2f4a6b80"
+
+			c, err := core.Create(ctx, userID, message.NewConversation{UserIDs: []string{adminID}}, now)
+			if err != nil || c.ID != seedID || !c.Direct || len(c.Members) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould get the existing direct conversation : %+v %v.", dbtest.Failed, testID, c, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the existing direct conversation.", dbtest.Success, testID)
+
+			if upper, err := core.Create(ctx, userID, message.NewConversation{UserIDs: []string{strings.ToUpper(adminID)}}, now); err == nil && upper.ID != seedID {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT start a second direct conversation with an upper case ID : %+v.", dbtest.Failed, testID, upper)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT start a second direct conversation with an upper case ID.", dbtest.Success, testID)
+
+			unread, err := core.QueryUnread(ctx, adminID)
+			if err != nil || unread.Messages != 1 || unread.Conversations != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould count the seeded unread message : %+v %v.", dbtest.Failed, testID, unread, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould count the seeded unread message.", dbtest.Success, testID)
+
+			for i := 0; i < 3; i++ {
+				nm := message.NewMessage{Body: fmt.Sprintf("Message %d", i)}
+				if _, err := core.Send(ctx, userID, seedID, nm, now.Add(time.Duration(i)*time.Minute)); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to send a message : %s.", dbtest.Failed, testID, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to send messages.", dbtest.Success, testID)
+
+			page, err := core.QueryMessages(ctx, adminID, seedID, "", 2)
+			if err != nil || len(page.Messages) != 2 || page.Messages[0].Body != "Message 2" || page.Cursor == "" {
+				t.Fatalf("\t%s\tTest %d:\tShould get the newest messages first : %+v %v.", dbtest.Failed, testID, page, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the newest messages first.", dbtest.Success, testID)
+
+			page, err = core.QueryMessages(ctx, adminID, seedID, page.Cursor, 2)
+			if err != nil || len(page.Messages) != 2 || page.Messages[0].Body != "Message 0" {
+				t.Fatalf("\t%s\tTest %d:\tShould get the next page from the cursor : %+v %v.", dbtest.Failed, testID, page, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the next page from the cursor.", dbtest.Success, testID)
+
+			if _, err := core.QueryMessages(ctx, adminID, seedID, "bad cursor", 2); !errors.Is(err, message.ErrInvalidCursor) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT accept a bad cursor : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT accept a bad cursor.", dbtest.Success, testID)
+
+			c, err = core.QueryByID(ctx, adminID, seedID)
+			if err != nil || c.Unread != 4 {
+				t.Fatalf("\t%s\tTest %d:\tShould count the unread messages of the conversation : %+v %v.", dbtest.Failed, testID, c, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould count the unread messages of the conversation.", dbtest.Success, testID)
+
+			if err := core.Mute(ctx, adminID, seedID, true); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to mute the conversation : %s.", dbtest.Failed, testID, err)
+			}
+
+			unread, err = core.QueryUnread(ctx, adminID)
+			if err != nil || unread.Messages != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT count muted conversations : %+v %v.", dbtest.Failed, testID, unread, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT count muted conversations.", dbtest.Success, testID)
+
+			if err := core.MarkRead(ctx, adminID, seedID, message.ReadMessages{}, now.Add(time.Hour)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to read the conversation : %s.", dbtest.Failed, testID, err)
+			}
+
+			receipts, err := core.QueryReceipts(ctx, userID, seedID)
+			if err != nil || len(receipts) != 2 || receipts[0].MessageID != receipts[1].MessageID {
+				t.Fatalf("\t%s\tTest %d:\tShould see both members read up to the same message : %+v %v.", dbtest.Failed, testID, receipts, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould see both members read up to the same message.", dbtest.Success, testID)
+
+			if err := core.Leave(ctx, adminID, seedID, now.Add(time.Hour)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to leave : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.QueryByID(ctx, adminID, seedID); !errors.Is(err, message.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT see a conversation after leaving : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT see a conversation after leaving.", dbtest.Success, testID)
+
+			if _, err := core.Send(ctx, userID, seedID, message.NewMessage{Body: "Are you there?"}, now.Add(2*time.Hour)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to send a message : %s.", dbtest.Failed, testID, err)
+			}
+
+			c, err = core.QueryByID(ctx, adminID, seedID)
+			if err != nil || c.Unread != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould be brought back by a new message : %+v %v.", dbtest.Failed, testID, c, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be brought back by a new message.", dbtest.Success, testID)
+		}
+
+		testID = 1
+		t.Logf("\tTest %d:\tWhen handling a group conversation.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.April, 2, 0, 0, 0, 0, time.UTC)
+			adminID := "5cf37266-3473-4006-984f-9325122678b7"
+			userID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+			otherID := "a1f6e7c2-3d4b-4c5a-9e8f-7b6a5d4c3b2a"
+			title := "Band"
+
+			if _, err := core.Create(ctx, adminID, message.NewConversation{UserIDs: []string{adminID}}, now); !errors.Is(err, message.ErrInvalidMembers) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to talk to yourself : %v.", dbtest.Failed, testID, err)
+			}
+			if _, err := core.Create(ctx, adminID, message.NewConversation{UserIDs: []string{strings.ToUpper(adminID)}}, now); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to talk to yourself with an upper case ID : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to talk to yourself.", dbtest.Success, testID)
+
+			c, err := core.Create(ctx, adminID, message.NewConversation{UserIDs: []string{userID}, Title: &title}, now)
+			if err != nil || c.Direct || len(c.Members) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a group : %+v %v.", dbtest.Failed, testID, c, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create a group.", dbtest.Success, testID)
+
+			if _, err := core.Send(ctx, adminID, c.ID, message.NewMessage{Body: "Before you joined"}, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to send a message : %s.", dbtest.Failed, testID, err)
+			}
+
+			if err := core.AddMembers(ctx, userID, c.ID, message.NewMembers{UserIDs: []string{otherID}}, now.Add(time.Minute)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add a member : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to add a member.", dbtest.Success, testID)
+
+			page, err := core.QueryMessages(ctx, otherID, c.ID, "", 10)
+			if err != nil || len(page.Messages) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT see the messages sent before joining : %+v %v.", dbtest.Failed, testID, page, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT see the messages sent before joining.", dbtest.Success, testID)
+
+			if err := core.Leave(ctx, userID, c.ID, now.Add(time.Hour)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to leave : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.Send(ctx, userID, c.ID, message.NewMessage{Body: "Still here?"}, now.Add(2*time.Hour)); !errors.Is(err, message.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to write to a group after leaving : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to write to a group after leaving.", dbtest.Success, testID)
+
+			direct, err := core.Create(ctx, adminID, message.NewConversation{UserIDs: []string{otherID}}, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a direct conversation : %s.", dbtest.Failed, testID, err)
+			}
+
+			if err := core.AddMembers(ctx, adminID, direct.ID, message.NewMembers{UserIDs: []string{userID}}, now); !errors.Is(err, message.ErrDirectConversation) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to add members to a direct conversation : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to add members to a direct conversation.", dbtest.Success, testID)
+		}
+	}
+}
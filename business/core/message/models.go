@@ -0,0 +1,139 @@
+package message
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/dudakovict/social-network/business/core/message/db"
+)
+
+// Conversation represents a conversation as seen by one of its members.
+// Direct conversations are between two users and have no title.
+type Conversation struct {
+	ID          string    `json:"id"`
+	CreatorID   string    `json:"creator_id"`
+	Title       *string   `json:"title,omitempty"`
+	Direct      bool      `json:"direct"`
+	Members     []Member  `json:"members"`
+	Muted       bool      `json:"muted"`
+	Unread      int       `json:"unread"`
+	DateCreated time.Time `json:"date_created"`
+	DateUpdated time.Time `json:"date_updated"`
+}
+
+// Member represents a user taking part in a conversation.
+type Member struct {
+	UserID     string    `json:"user_id"`
+	DateJoined time.Time `json:"date_joined"`
+}
+
+// Message represents an individual message.
+type Message struct {
+	ID             string    `json:"id"`
+	ConversationID string    `json:"conversation_id"`
+	UserID         string    `json:"user_id"`
+	Body           string    `json:"body"`
+	DateCreated    time.Time `json:"date_created"`
+}
+
+// Page is a slice of the history of a conversation. Cursor is empty on the
+// last page, otherwise it fetches the next one.
+type Page struct {
+	Messages []Message `json:"messages"`
+	Cursor   string    `json:"cursor,omitempty"`
+}
+
+// Receipt represents how far a member has read a conversation. Every message
+// up to and including the message was read.
+type Receipt struct {
+	ConversationID string    `json:"conversation_id"`
+	UserID         string    `json:"user_id"`
+	MessageID      string    `json:"message_id"`
+	DateRead       time.Time `json:"date_read"`
+}
+
+// Unread represents how many messages a user has not read yet and in how
+// many conversations. Muted conversations are not counted.
+type Unread struct {
+	Messages      int `json:"messages"`
+	Conversations int `json:"conversations"`
+}
+
+// NewConversation contains information needed to start a conversation with
+// other users. A conversation with a single other user and no title is a
+// direct conversation.
+type NewConversation struct {
+	UserIDs []string `json:"user_ids" validate:"required,min=1,dive,uuid"`
+	Title   *string  `json:"title" validate:"omitempty,max=100"`
+}
+
+// NewMembers contains the users to add to a conversation.
+type NewMembers struct {
+	UserIDs []string `json:"user_ids" validate:"required,min=1,dive,uuid"`
+}
+
+// NewMessage contains information needed to send a message.
+type NewMessage struct {
+	Body string `json:"body" validate:"required,max=4000"`
+}
+
+// ReadMessages contains how far the user has read a conversation. Without a
+// message the whole conversation is marked read.
+type ReadMessages struct {
+	MessageID *string `json:"message_id" validate:"omitempty,uuid"`
+}
+
+// =============================================================================
+
+func toConversation(dbS db.Summary, dbMembers []db.Member) Conversation {
+	members := make([]Member, len(dbMembers))
+	for i, dbM := range dbMembers {
+		members[i] = Member{
+			UserID:     dbM.UserID,
+			DateJoined: dbM.DateJoined,
+		}
+	}
+
+	return Conversation{
+		ID:          dbS.ID,
+		CreatorID:   dbS.CreatorID,
+		Title:       dbS.Title,
+		Direct:      dbS.DirectKey != nil,
+		Members:     members,
+		Muted:       dbS.Muted,
+		Unread:      dbS.Unread,
+		DateCreated: dbS.DateCreated,
+		DateUpdated: dbS.DateUpdated,
+	}
+}
+
+func toMessage(dbM db.Message) Message {
+	mu := (*Message)(unsafe.Pointer(&dbM))
+	return *mu
+}
+
+func toMessageSlice(dbMs []db.Message) []Message {
+	messages := make([]Message, len(dbMs))
+	for i, dbM := range dbMs {
+		messages[i] = toMessage(dbM)
+	}
+	return messages
+}
+
+func toReceipt(dbR db.Receipt) Receipt {
+	ru := (*Receipt)(unsafe.Pointer(&dbR))
+	return *ru
+}
+
+func toReceiptSlice(dbRs []db.Receipt) []Receipt {
+	receipts := make([]Receipt, len(dbRs))
+	for i, dbR := range dbRs {
+		receipts[i] = toReceipt(dbR)
+	}
+	return receipts
+}
+
+func toUnread(dbU db.Unread) Unread {
+	uu := (*Unread)(unsafe.Pointer(&dbU))
+	return *uu
+}
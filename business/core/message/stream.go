@@ -0,0 +1,41 @@
+package message
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/dudakovict/social-network/business/core/message/db"
+	"github.com/dudakovict/social-network/business/sys/stream"
+)
+
+// StreamCreated turns a message-created event into the message pushed to
+// the clients streaming the conversation.
+func StreamCreated(data []byte) (string, stream.Message, error) {
+	var dbD db.Delivery
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dbD); err != nil {
+		return "", stream.Message{}, err
+	}
+
+	m := stream.Message{
+		Type: "message",
+		Data: toMessage(dbD.Message),
+	}
+
+	return dbD.Message.ConversationID, m, nil
+}
+
+// StreamRead turns a conversation-read event into the receipt pushed to the
+// clients streaming the conversation.
+func StreamRead(data []byte) (string, stream.Message, error) {
+	var dbR db.Receipt
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dbR); err != nil {
+		return "", stream.Message{}, err
+	}
+
+	m := stream.Message{
+		Type: "receipt",
+		Data: toReceipt(dbR),
+	}
+
+	return dbR.ConversationID, m, nil
+}
@@ -0,0 +1,587 @@
+// Package db contains notification related CRUD functionality.
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of API's for notification access.
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+// NewStore constructs a data for api access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Create inserts a new notification into the database and reports whether
+// it was created. Notifying a user twice about the same actor and source is
+// not an error so redelivered events do not duplicate notifications.
+func (s Store) Create(ctx context.Context, n Notification) (bool, error) {
+	const q = `
+	INSERT INTO notifications
+		(notification_id, user_id, actor_id, type, target_id, source_id, post_id, comment_id, date_created, date_read)
+	VALUES
+		(:notification_id, :user_id, :actor_id, :type, :target_id, :source_id, :post_id, :comment_id, :date_created, :date_read)
+	ON CONFLICT DO NOTHING
+	RETURNING
+		notification_id`
+
+	var result struct {
+		ID string `db:"notification_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, n, &result); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("inserting notification: %w", err)
+	}
+
+	return true, nil
+}
+
+// DeleteBySource removes the notifications caused by the specified source.
+func (s Store) DeleteBySource(ctx context.Context, sourceID string) error {
+	data := struct {
+		SourceID string `db:"source_id"`
+	}{
+		SourceID: sourceID,
+	}
+
+	const q = `
+	DELETE FROM
+		notifications
+	WHERE
+		source_id = :source_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting sourceID[%s]: %w", sourceID, err)
+	}
+
+	return nil
+}
+
+// QueryByID gets the specified notification of the user from the database.
+func (s Store) QueryByID(ctx context.Context, userID string, notificationID string) (Notification, error) {
+	data := struct {
+		UserID         string `db:"user_id"`
+		NotificationID string `db:"notification_id"`
+	}{
+		UserID:         userID,
+		NotificationID: notificationID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		notifications
+	WHERE
+		notification_id = :notification_id AND
+		user_id = :user_id`
+
+	var n Notification
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &n); err != nil {
+		return Notification{}, fmt.Errorf("selecting notificationID[%q]: %w", notificationID, err)
+	}
+
+	return n, nil
+}
+
+// QueryGroups retrieves the notifications of the user folded into groups of
+// the same type and target, most recently active group first.
+func (s Store) QueryGroups(ctx context.Context, userID string, pageNumber int, rowsPerPage int) ([]Group, error) {
+	data := struct {
+		UserID      string `db:"user_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
+	}{
+		UserID:      userID,
+		Offset:      (pageNumber - 1) * rowsPerPage,
+		RowsPerPage: rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		(ARRAY_AGG(notification_id ORDER BY date_created DESC, notification_id))[1] AS notification_id,
+		type,
+		target_id,
+		(ARRAY_AGG(post_id ORDER BY date_created DESC, notification_id))[1] AS post_id,
+		(ARRAY_AGG(comment_id ORDER BY date_created DESC, notification_id))[1] AS comment_id,
+		ARRAY_AGG(CAST(actor_id AS TEXT) ORDER BY date_created DESC, notification_id) AS actor_ids,
+		COUNT(DISTINCT actor_id) AS actors,
+		COUNT(*) AS count,
+		COUNT(*) FILTER (WHERE date_read IS NULL) AS unread,
+		MAX(date_created) AS date_created
+	FROM
+		notifications
+	WHERE
+		user_id = :user_id
+	GROUP BY
+		type, target_id
+	ORDER BY
+		date_created DESC, type, target_id
+	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
+
+	var gs []Group
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &gs); err != nil {
+		return nil, fmt.Errorf("selecting groups userID[%s]: %w", userID, err)
+	}
+
+	return gs, nil
+}
+
+// QueryUnread counts the notifications and the groups of notifications the
+// user has not read yet.
+func (s Store) QueryUnread(ctx context.Context, userID string) (Unread, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		COUNT(*) AS notifications,
+		COUNT(DISTINCT type || ':' || CAST(target_id AS TEXT)) AS groups
+	FROM
+		notifications
+	WHERE
+		user_id = :user_id AND
+		date_read IS NULL`
+
+	var u Unread
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &u); err != nil {
+		return Unread{}, fmt.Errorf("counting unread userID[%s]: %w", userID, err)
+	}
+
+	return u, nil
+}
+
+// MarkGroupRead marks the unread notifications of the user with the same
+// type and target that were created up to the specified time as read.
+func (s Store) MarkGroupRead(ctx context.Context, n Notification, now time.Time) error {
+	data := struct {
+		UserID      string    `db:"user_id"`
+		Type        string    `db:"type"`
+		TargetID    string    `db:"target_id"`
+		DateCreated time.Time `db:"date_created"`
+		DateRead    time.Time `db:"date_read"`
+	}{
+		UserID:      n.UserID,
+		Type:        n.Type,
+		TargetID:    n.TargetID,
+		DateCreated: n.DateCreated,
+		DateRead:    now,
+	}
+
+	const q = `
+	UPDATE
+		notifications
+	SET
+		"date_read" = :date_read
+	WHERE
+		user_id = :user_id AND
+		type = :type AND
+		target_id = :target_id AND
+		date_created <= :date_created AND
+		date_read IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("marking read notificationID[%s]: %w", n.ID, err)
+	}
+
+	return nil
+}
+
+// MarkAllRead marks every unread notification of the user as read.
+func (s Store) MarkAllRead(ctx context.Context, userID string, now time.Time) error {
+	data := struct {
+		UserID   string    `db:"user_id"`
+		DateRead time.Time `db:"date_read"`
+	}{
+		UserID:   userID,
+		DateRead: now,
+	}
+
+	const q = `
+	UPDATE
+		notifications
+	SET
+		"date_read" = :date_read
+	WHERE
+		user_id = :user_id AND
+		date_read IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("marking all read userID[%s]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// SavePost stores or replaces the copy of a post.
+func (s Store) SavePost(ctx context.Context, p Post) error {
+	const q = `
+	INSERT INTO posts
+		(post_id, user_id)
+	VALUES
+		(:post_id, :user_id)
+	ON CONFLICT (post_id) DO UPDATE SET
+		user_id = EXCLUDED.user_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, p); err != nil {
+		return fmt.Errorf("inserting post: %w", err)
+	}
+
+	return nil
+}
+
+// QueryPostByID gets the copy of the specified post from the database.
+func (s Store) QueryPostByID(ctx context.Context, postID string) (Post, error) {
+	data := struct {
+		PostID string `db:"post_id"`
+	}{
+		PostID: postID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		posts
+	WHERE
+		post_id = :post_id`
+
+	var p Post
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &p); err != nil {
+		return Post{}, fmt.Errorf("selecting postID[%q]: %w", postID, err)
+	}
+
+	return p, nil
+}
+
+// SaveComment stores the copy of a comment. Saving a comment twice is not an
+// error.
+func (s Store) SaveComment(ctx context.Context, c Comment) error {
+	data := struct {
+		ID     string `db:"comment_id"`
+		UserID string `db:"user_id"`
+		PostID string `db:"post_id"`
+	}{
+		ID:     c.ID,
+		UserID: c.UserID,
+		PostID: c.PostID,
+	}
+
+	const q = `
+	INSERT INTO comments
+		(comment_id, user_id, post_id)
+	VALUES
+		(:comment_id, :user_id, :post_id)
+	ON CONFLICT DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("inserting comment: %w", err)
+	}
+
+	return nil
+}
+
+// QueryCommentAuthor gets the author of the copy of the specified comment
+// from the database.
+func (s Store) QueryCommentAuthor(ctx context.Context, commentID string) (string, error) {
+	data := struct {
+		CommentID string `db:"comment_id"`
+	}{
+		CommentID: commentID,
+	}
+
+	const q = `
+	SELECT
+		user_id
+	FROM
+		comments
+	WHERE
+		comment_id = :comment_id`
+
+	var result struct {
+		UserID string `db:"user_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return "", fmt.Errorf("selecting commentID[%q]: %w", commentID, err)
+	}
+
+	return result.UserID, nil
+}
+
+// SaveHandle stores the copy of the handle of a user, replacing the previous
+// handle of the user and taking the handle over from any other user.
+func (s Store) SaveHandle(ctx context.Context, h Handle) error {
+	const q = `
+	INSERT INTO handles
+		(user_id, handle)
+	VALUES
+		(:user_id, :handle)`
+
+	tran := func(tx sqlx.ExtContext) error {
+		if err := s.Tran(tx).DeleteHandle(ctx, h); err != nil {
+			return err
+		}
+
+		if err := database.NamedExecContext(ctx, s.log, tx, q, h); err != nil {
+			return fmt.Errorf("inserting handle: %w", err)
+		}
+
+		return nil
+	}
+
+	return s.WithinTran(ctx, tran)
+}
+
+// DeleteHandle removes the copy of the handle of the user along with any
+// other user holding the same handle.
+func (s Store) DeleteHandle(ctx context.Context, h Handle) error {
+	const q = `
+	DELETE FROM
+		handles
+	WHERE
+		user_id = :user_id OR
+		handle = :handle`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, h); err != nil {
+		return fmt.Errorf("deleting handle of userID[%s]: %w", h.UserID, err)
+	}
+
+	return nil
+}
+
+// QueryHandles gets the handles of the specified users.
+func (s Store) QueryHandles(ctx context.Context, userIDs []string) ([]Handle, error) {
+	data := struct {
+		UserIDs pq.StringArray `db:"user_ids"`
+	}{
+		UserIDs: userIDs,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		handles
+	WHERE
+		CAST(user_id AS TEXT) = ANY(:user_ids)`
+
+	var hs []Handle
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &hs); err != nil {
+		return nil, fmt.Errorf("selecting handles: %w", err)
+	}
+
+	return hs, nil
+}
+
+// =============================================================================
+
+// SaveContact stores or replaces the copy of the contact of a user.
+func (s Store) SaveContact(ctx context.Context, c Contact) error {
+	const q = `
+	INSERT INTO contacts
+		(user_id, email, name, locale)
+	VALUES
+		(:user_id, :email, :name, :locale)
+	ON CONFLICT (user_id) DO UPDATE SET
+		email = EXCLUDED.email,
+		name = EXCLUDED.name,
+		locale = EXCLUDED.locale`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, c); err != nil {
+		return fmt.Errorf("inserting contact: %w", err)
+	}
+
+	return nil
+}
+
+// QueryContact gets the copy of the contact of the specified user.
+func (s Store) QueryContact(ctx context.Context, userID string) (Contact, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		contacts
+	WHERE
+		user_id = :user_id`
+
+	var c Contact
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &c); err != nil {
+		return Contact{}, fmt.Errorf("selecting contact userID[%q]: %w", userID, err)
+	}
+
+	return c, nil
+}
+
+// SavePreferences stores or replaces the specified preferences.
+func (s Store) SavePreferences(ctx context.Context, ps []Preference) error {
+	const q = `
+	INSERT INTO preferences
+		(user_id, type, delivery, date_updated)
+	VALUES
+		(:user_id, :type, :delivery, :date_updated)
+	ON CONFLICT (user_id, type) DO UPDATE SET
+		delivery = EXCLUDED.delivery,
+		date_updated = EXCLUDED.date_updated`
+
+	tran := func(tx sqlx.ExtContext) error {
+		for _, p := range ps {
+			if err := database.NamedExecContext(ctx, s.log, tx, q, p); err != nil {
+				return fmt.Errorf("inserting preference: %w", err)
+			}
+		}
+		return nil
+	}
+
+	return s.WithinTran(ctx, tran)
+}
+
+// QueryPreferences gets the preferences the specified user has set.
+func (s Store) QueryPreferences(ctx context.Context, userID string) ([]Preference, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		preferences
+	WHERE
+		user_id = :user_id
+	ORDER BY
+		type`
+
+	var ps []Preference
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ps); err != nil {
+		return nil, fmt.Errorf("selecting preferences userID[%s]: %w", userID, err)
+	}
+
+	return ps, nil
+}
+
+// QueryDigestGroups retrieves the unread notifications that belong in the
+// digests of the specified frequency for the period, folded into groups of
+// the same type and target. Only users with a contact are included, and for
+// every user only the notifications created since the previous digest, or
+// since the specified time when there was none. Types the user has not set a
+// preference for are delivered the specified default way.
+func (s Store) QueryDigestGroups(ctx context.Context, frequency string, defaultDelivery string, since time.Time, period time.Time) ([]DigestGroup, error) {
+	data := struct {
+		Frequency       string    `db:"frequency"`
+		DefaultDelivery string    `db:"default_delivery"`
+		Since           time.Time `db:"since"`
+		Period          time.Time `db:"period"`
+	}{
+		Frequency:       frequency,
+		DefaultDelivery: defaultDelivery,
+		Since:           since,
+		Period:          period,
+	}
+
+	const q = `
+	SELECT
+		n.user_id,
+		c.email,
+		c.name,
+		c.locale,
+		n.type,
+		n.target_id,
+		ARRAY_AGG(CAST(n.actor_id AS TEXT) ORDER BY n.date_created DESC, n.notification_id) AS actor_ids,
+		COUNT(DISTINCT n.actor_id) AS actors,
+		COUNT(*) AS count,
+		MAX(n.date_created) AS date_created
+	FROM
+		notifications AS n
+	JOIN
+		contacts AS c ON c.user_id = n.user_id
+	LEFT JOIN
+		preferences AS p ON p.user_id = n.user_id AND p.type = n.type
+	LEFT JOIN
+		digests AS d ON d.user_id = n.user_id AND d.frequency = :frequency
+	WHERE
+		COALESCE(p.delivery, :default_delivery) = :frequency AND
+		n.date_read IS NULL AND
+		n.date_created >= COALESCE(d.period, :since) AND
+		n.date_created < :period
+	GROUP BY
+		n.user_id, c.email, c.name, c.locale, n.type, n.target_id
+	ORDER BY
+		n.user_id, date_created DESC, n.type, n.target_id`
+
+	var gs []DigestGroup
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &gs); err != nil {
+		return nil, fmt.Errorf("selecting digest groups frequency[%s]: %w", frequency, err)
+	}
+
+	return gs, nil
+}
+
+// SaveDigest records the digest sent to a user, replacing the previous one
+// of the same frequency.
+func (s Store) SaveDigest(ctx context.Context, d Digest) error {
+	const q = `
+	INSERT INTO digests
+		(user_id, frequency, period, date_sent)
+	VALUES
+		(:user_id, :frequency, :period, :date_sent)
+	ON CONFLICT (user_id, frequency) DO UPDATE SET
+		period = EXCLUDED.period,
+		date_sent = EXCLUDED.date_sent`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, d); err != nil {
+		return fmt.Errorf("inserting digest: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Notification represent the structure we need for moving data
+// between the app and the database.
+type Notification struct {
+	ID          string     `db:"notification_id"`
+	UserID      string     `db:"user_id"`
+	ActorID     string     `db:"actor_id"`
+	Type        string     `db:"type"`
+	TargetID    string     `db:"target_id"`
+	SourceID    string     `db:"source_id"`
+	PostID      *string    `db:"post_id"`
+	CommentID   *string    `db:"comment_id"`
+	DateCreated time.Time  `db:"date_created"`
+	DateRead    *time.Time `db:"date_read"`
+}
+
+// Group represents the notifications of a user with the same type and
+// target folded into one. ID, PostID and CommentID belong to the most
+// recent notification and ActorIDs are ordered most recent first.
+type Group struct {
+	ID          string         `db:"notification_id"`
+	Type        string         `db:"type"`
+	TargetID    string         `db:"target_id"`
+	PostID      *string        `db:"post_id"`
+	CommentID   *string        `db:"comment_id"`
+	ActorIDs    pq.StringArray `db:"actor_ids"`
+	Actors      int            `db:"actors"`
+	Count       int            `db:"count"`
+	Unread      int            `db:"unread"`
+	DateCreated time.Time      `db:"date_created"`
+}
+
+// Unread represents how many notifications and groups of notifications a
+// user has not read yet.
+type Unread struct {
+	Notifications int `db:"notifications"`
+	Groups        int `db:"groups"`
+}
+
+// Post represents the part of the post events needed to know who to notify
+// about comments on a post.
+type Post struct {
+	ID     string `db:"post_id"`
+	UserID string `db:"user_id"`
+}
+
+// Comment represents the part of the comment events needed to know who to
+// notify about a comment and about replies to it.
+type Comment struct {
+	ID          string    `db:"comment_id"`
+	UserID      string    `db:"user_id"`
+	PostID      string    `db:"post_id"`
+	ParentID    *string   `db:"parent_id"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// Follow represents the part of the user-followed events needed to notify
+// the followee.
+type Follow struct {
+	FollowerID  string    `db:"follower_id"`
+	FolloweeID  string    `db:"followee_id"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// Request represents the part of the user-follow-requested events needed to
+// notify the target of the request.
+type Request struct {
+	RequesterID string    `db:"requester_id"`
+	TargetID    string    `db:"target_id"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// Mention represents the part of the user-mentioned events needed to notify
+// the mentioned user. CommentID is empty for mentions in posts.
+type Mention struct {
+	PostID      string    `db:"post_id"`
+	CommentID   string    `db:"comment_id"`
+	UserID      string    `db:"user_id"`
+	AuthorID    string    `db:"author_id"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// Handle is the copy of the handle a user goes by, kept so notifications can
+// name the users behind them.
+type Handle struct {
+	UserID string `db:"user_id"`
+	Handle string `db:"handle"`
+}
+
+// Contact is the copy of how to reach a user by e-mail, and in which
+// language.
+type Contact struct {
+	UserID string `db:"user_id"`
+	Email  string `db:"email"`
+	Name   string `db:"name"`
+	Locale string `db:"locale"`
+}
+
+// Preference is how a user wants to be e-mailed about a type of
+// notification.
+type Preference struct {
+	UserID      string    `db:"user_id"`
+	Type        string    `db:"type"`
+	Delivery    string    `db:"delivery"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+// Digest records the most recent period a digest was sent to a user for.
+type Digest struct {
+	UserID    string    `db:"user_id"`
+	Frequency string    `db:"frequency"`
+	Period    time.Time `db:"period"`
+	DateSent  time.Time `db:"date_sent"`
+}
+
+// DigestGroup represents the unread notifications of a user with the same
+// type and target that belong in a digest, along with how to reach the user.
+// ActorIDs are ordered most recent first.
+type DigestGroup struct {
+	UserID      string         `db:"user_id"`
+	Email       string         `db:"email"`
+	Name        string         `db:"name"`
+	Locale      string         `db:"locale"`
+	Type        string         `db:"type"`
+	TargetID    string         `db:"target_id"`
+	ActorIDs    pq.StringArray `db:"actor_ids"`
+	Actors      int            `db:"actors"`
+	Count       int            `db:"count"`
+	DateCreated time.Time      `db:"date_created"`
+}
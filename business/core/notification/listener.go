@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"fmt"
+
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Listener turns the post, comment, follow, mention and handle events
+// published by the other services into notifications, and keeps the
+// contacts used to e-mail them.
+type Listener struct {
+	log  *zap.SugaredLogger
+	nats *nats.NATS
+	core Core
+}
+
+// NewListener constructs a listener for notification events.
+func NewListener(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS, mailer Mailer) Listener {
+	return Listener{
+		log:  log,
+		nats: nats,
+		core: NewCore(log, sqlxDB, nats, mailer),
+	}
+}
+
+// Listen subscribes to all the notification events. Events that fail are not
+// acknowledged so they are delivered again, which covers events that arrive
+// before the post or comment they refer to.
+func (l Listener) Listen() error {
+	if err := nats.Handle(l.nats, l.log, "post-created", "notifications", l.core.SavePost); err != nil {
+		return fmt.Errorf("post-created: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "comment-created", "notifications", l.core.AddComment); err != nil {
+		return fmt.Errorf("comment-created: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "comment-restored", "notifications", l.core.AddComment); err != nil {
+		return fmt.Errorf("comment-restored: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "comment-deleted", "notifications", l.core.RemoveComment); err != nil {
+		return fmt.Errorf("comment-deleted: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "user-followed", "notifications", l.core.AddFollow); err != nil {
+		return fmt.Errorf("user-followed: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "user-follow-requested", "notifications", l.core.AddFollowRequest); err != nil {
+		return fmt.Errorf("user-follow-requested: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "user-mentioned", "notifications", l.core.AddMention); err != nil {
+		return fmt.Errorf("user-mentioned: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "user-handle-changed", "notifications", l.core.SaveHandle); err != nil {
+		return fmt.Errorf("user-handle-changed: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "user-contact-changed", "notifications", l.core.SaveContact); err != nil {
+		return fmt.Errorf("user-contact-changed: %w", err)
+	}
+
+	return nil
+}
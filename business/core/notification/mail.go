@@ -0,0 +1,415 @@
+package notification
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/notification/db"
+	"github.com/dudakovict/social-network/business/data/email"
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/validate"
+)
+
+// ErrInvalidToken is returned when an unsubscribe token is malformed or was
+// not signed by this service.
+var ErrInvalidToken = errors.New("unsubscribe token is not valid")
+
+// Mailer configures the e-mails sent about notifications. No e-mails are sent
+// without a client.
+type Mailer struct {
+	Client email.EmailClient
+
+	// Secret signs the unsubscribe links so they work without logging in.
+	Secret []byte
+
+	// AppURL is where the e-mails link to and UnsubscribeURL is the
+	// endpoint the unsubscribe links post to.
+	AppURL         string
+	UnsubscribeURL string
+}
+
+// QueryPreferences retrieves how the user wants to be e-mailed about every
+// type of notification, including the types left at the default.
+func (c Core) QueryPreferences(ctx context.Context, userID string) ([]Preference, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	deliveries, err := c.deliveries(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := make([]Preference, len(types))
+	for i, typ := range types {
+		prefs[i] = Preference{
+			Type:     typ,
+			Delivery: deliveries[typ],
+		}
+	}
+
+	return prefs, nil
+}
+
+// UpdatePreferences replaces how the user wants to be e-mailed about the
+// specified types of notifications.
+func (c Core) UpdatePreferences(ctx context.Context, userID string, up UpdatePreferences, now time.Time) error {
+	if err := validate.CheckID(userID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := validate.Check(up); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	dbPrefs := make([]db.Preference, len(up.Preferences))
+	for i, p := range up.Preferences {
+		dbPrefs[i] = db.Preference{
+			UserID:      userID,
+			Type:        p.Type,
+			Delivery:    p.Delivery,
+			DateUpdated: now,
+		}
+	}
+
+	if err := c.store.SavePreferences(ctx, dbPrefs); err != nil {
+		return fmt.Errorf("save preferences: %w", err)
+	}
+
+	return nil
+}
+
+// Unsubscribe stops the e-mails the signed token was issued for without the
+// user logging in. A token from an e-mail about a single notification stops
+// the e-mails about that type of notification and a token from a digest
+// stops that digest.
+func (c Core) Unsubscribe(ctx context.Context, token string, now time.Time) error {
+	userID, scope, err := c.verify(token)
+	if err != nil {
+		return err
+	}
+
+	var up UpdatePreferences
+	switch scope {
+	case DeliveryDaily, DeliveryWeekly:
+		prefs, err := c.QueryPreferences(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, p := range prefs {
+			if p.Delivery == scope {
+				up.Preferences = append(up.Preferences, Preference{Type: p.Type, Delivery: DeliveryNone})
+			}
+		}
+		if len(up.Preferences) == 0 {
+			return nil
+		}
+
+	default:
+		up.Preferences = []Preference{{Type: scope, Delivery: DeliveryNone}}
+	}
+
+	return c.UpdatePreferences(ctx, userID, up, now)
+}
+
+// CheckUnsubscribe validates an unsubscribe token without acting on it.
+func (c Core) CheckUnsubscribe(token string) error {
+	_, _, err := c.verify(token)
+	return err
+}
+
+// SaveContact stores the copy of how to reach a user by e-mail.
+func (c Core) SaveContact(ctx context.Context, dbC db.Contact) error {
+	if err := c.store.SaveContact(ctx, dbC); err != nil {
+		return fmt.Errorf("save contact: %w", err)
+	}
+
+	return nil
+}
+
+// SendDigests e-mails every user a digest of the unread notifications they
+// want delivered with the specified frequency, once per period. Daily
+// periods start at midnight UTC and weekly ones on Monday. A digest covers
+// the notifications created since the previous one up to the start of the
+// current period. It returns how many digests were sent.
+func (c Core) SendDigests(ctx context.Context, frequency string, now time.Time) (int, error) {
+	if c.mailer.Client == nil {
+		return 0, nil
+	}
+
+	period, length, err := periodOf(frequency, now)
+	if err != nil {
+		return 0, err
+	}
+
+	dbGroups, err := c.store.QueryDigestGroups(ctx, frequency, DefaultDelivery, period.Add(-length), period)
+	if err != nil {
+		return 0, fmt.Errorf("query: %w", err)
+	}
+
+	if len(dbGroups) == 0 {
+		return 0, nil
+	}
+
+	var actorIDs []string
+	for i := range dbGroups {
+		dbGroups[i].ActorIDs = recent(dbGroups[i].ActorIDs, MaxActors)
+		actorIDs = append(actorIDs, dbGroups[i].ActorIDs...)
+	}
+
+	handles, err := c.handles(ctx, actorIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	var sent int
+	for len(dbGroups) > 0 {
+
+		// Groups are ordered by user so each user takes the next run.
+		n := 1
+		for n < len(dbGroups) && dbGroups[n].UserID == dbGroups[0].UserID {
+			n++
+		}
+		userGroups := dbGroups[:n]
+		dbGroups = dbGroups[n:]
+
+		if err := c.sendDigest(ctx, frequency, period, userGroups, handles, now); err != nil {
+			c.log.Errorw("digest", "userID", userGroups[0].UserID, "frequency", frequency, "ERROR", err)
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// =============================================================================
+
+// mail e-mails the user about the notification right away when the user
+// wants to hear about its type that way. Failing to send it does not fail
+// the notification, it stays in the inbox.
+func (c Core) mail(ctx context.Context, dbN db.Notification) {
+	if c.mailer.Client == nil {
+		return
+	}
+
+	if err := c.mailNotification(ctx, dbN); err != nil {
+		c.log.Errorw("mail", "notificationID", dbN.ID, "ERROR", err)
+	}
+}
+
+// mailNotification sends the e-mail about a single notification.
+func (c Core) mailNotification(ctx context.Context, dbN db.Notification) error {
+	deliveries, err := c.deliveries(ctx, dbN.UserID)
+	if err != nil {
+		return err
+	}
+	if deliveries[dbN.Type] != DeliveryImmediate {
+		return nil
+	}
+
+	dbC, err := c.store.QueryContact(ctx, dbN.UserID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return nil
+		}
+		return fmt.Errorf("query contact: %w", err)
+	}
+
+	handles, err := c.handles(ctx, []string{dbN.ActorID})
+	if err != nil {
+		return err
+	}
+
+	g := Group{
+		Type:     dbN.Type,
+		ActorIDs: []string{dbN.ActorID},
+		Actors:   1,
+	}
+
+	in := email.EmailRequest{
+		RequestId: "notification:" + dbN.ID,
+		Email:     dbC.Email,
+		Template:  "notification",
+		Locale:    dbC.Locale,
+		Vars: map[string]string{
+			"name":        dbC.Name,
+			"summary":     summary(g, handles),
+			"link":        c.mailer.AppURL,
+			"unsubscribe": c.unsubscribeURL(dbN.UserID, dbN.Type),
+		},
+	}
+
+	if _, err := c.mailer.Client.Send(ctx, &in); err != nil {
+		if email.IsRejected(err) {
+			c.log.Infow("mail", "notificationID", dbN.ID, "rejected", err)
+			return nil
+		}
+		return fmt.Errorf("send: %w", err)
+	}
+
+	return nil
+}
+
+// sendDigest sends the digest of the groups of a single user and records
+// it. The request ID makes sending the digest of a period again harmless. A
+// digest to a suppressed address is recorded too, so it is not tried again
+// before the next period. One over the rate limit is left for the scheduler
+// to try again.
+func (c Core) sendDigest(ctx context.Context, frequency string, period time.Time, dbGroups []db.DigestGroup, handles map[string]string, now time.Time) error {
+	dbG := dbGroups[0]
+
+	var count int
+	items := make([]string, len(dbGroups))
+	for i, g := range dbGroups {
+		count += g.Count
+		items[i] = summary(Group{Type: g.Type, ActorIDs: g.ActorIDs, Actors: g.Actors}, handles)
+	}
+
+	in := email.EmailRequest{
+		RequestId: fmt.Sprintf("digest:%s:%s:%s", dbG.UserID, frequency, period.Format("2006-01-02")),
+		Email:     dbG.Email,
+		Template:  "digest",
+		Locale:    dbG.Locale,
+		Vars: map[string]string{
+			"name":        dbG.Name,
+			"count":       strconv.Itoa(count),
+			"items":       strings.Join(items, "\n"),
+			"link":        c.mailer.AppURL,
+			"unsubscribe": c.unsubscribeURL(dbG.UserID, frequency),
+		},
+	}
+
+	if _, err := c.mailer.Client.Send(ctx, &in); err != nil {
+		if !email.IsSuppressed(err) {
+			return fmt.Errorf("send: %w", err)
+		}
+		c.log.Infow("digest", "userID", dbG.UserID, "frequency", frequency, "rejected", err)
+	}
+
+	dbD := db.Digest{
+		UserID:    dbG.UserID,
+		Frequency: frequency,
+		Period:    period,
+		DateSent:  now,
+	}
+
+	if err := c.store.SaveDigest(ctx, dbD); err != nil {
+		return fmt.Errorf("save digest: %w", err)
+	}
+
+	return nil
+}
+
+// deliveries returns how the user wants to be e-mailed about every type of
+// notification.
+func (c Core) deliveries(ctx context.Context, userID string) (map[string]string, error) {
+	dbPrefs, err := c.store.QueryPreferences(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query preferences: %w", err)
+	}
+
+	deliveries := make(map[string]string, len(types))
+	for _, typ := range types {
+		deliveries[typ] = DefaultDelivery
+	}
+	for _, dbP := range dbPrefs {
+		deliveries[dbP.Type] = dbP.Delivery
+	}
+
+	return deliveries, nil
+}
+
+// handles maps the specified users to the handles they go by.
+func (c Core) handles(ctx context.Context, userIDs []string) (map[string]string, error) {
+	dbHandles, err := c.store.QueryHandles(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query handles: %w", err)
+	}
+
+	handles := make(map[string]string, len(dbHandles))
+	for _, dbH := range dbHandles {
+		handles[dbH.UserID] = dbH.Handle
+	}
+
+	return handles, nil
+}
+
+// unsubscribeURL returns the link that stops the e-mails of the scope, a
+// type of notification or a digest frequency, for the user.
+func (c Core) unsubscribeURL(userID string, scope string) string {
+	return c.mailer.UnsubscribeURL + "?token=" + url.QueryEscape(c.sign(userID, scope))
+}
+
+// sign issues the unsubscribe token for the scope of the user. The token is
+// the base64 encoded user and scope followed by their HMAC-SHA256.
+func (c Core) sign(userID string, scope string) string {
+	payload := userID + ":" + scope
+
+	mac := hmac.New(sha256.New, c.mailer.Secret)
+	mac.Write([]byte(payload))
+
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(payload)) + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks the signature of the unsubscribe token and returns the user
+// and the scope it was issued for.
+func (c Core) verify(token string) (string, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return "", "", ErrInvalidToken
+	}
+
+	enc := base64.RawURLEncoding
+	payload, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return "", "", ErrInvalidToken
+	}
+	sig, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return "", "", ErrInvalidToken
+	}
+
+	mac := hmac.New(sha256.New, c.mailer.Secret)
+	mac.Write(payload)
+	if len(c.mailer.Secret) == 0 || !hmac.Equal(sig, mac.Sum(nil)) {
+		return "", "", ErrInvalidToken
+	}
+
+	userID, scope, ok := strings.Cut(string(payload), ":")
+	if !ok || validate.CheckID(userID) != nil {
+		return "", "", ErrInvalidToken
+	}
+
+	if _, ok := verbs[scope]; !ok && scope != DeliveryDaily && scope != DeliveryWeekly {
+		return "", "", ErrInvalidToken
+	}
+
+	return userID, scope, nil
+}
+
+// periodOf returns the start and the length of the digest period of the
+// frequency that the specified time falls in.
+func periodOf(frequency string, now time.Time) (time.Time, time.Duration, error) {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch frequency {
+	case DeliveryDaily:
+		return day, 24 * time.Hour, nil
+	case DeliveryWeekly:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset), 7 * 24 * time.Hour, nil
+	}
+
+	return time.Time{}, 0, fmt.Errorf("unknown digest frequency %q", frequency)
+}
@@ -0,0 +1,98 @@
+package notification
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/dudakovict/social-network/business/core/notification/db"
+)
+
+// Set of types of notifications.
+const (
+	TypeComment       = "comment"
+	TypeReply         = "reply"
+	TypeReaction      = "reaction"
+	TypeMention       = "mention"
+	TypeFollow        = "follow"
+	TypeFollowRequest = "follow_request"
+)
+
+// types lists every type of notification in the order preferences are
+// shown.
+var types = []string{TypeComment, TypeReply, TypeReaction, TypeMention, TypeFollow, TypeFollowRequest}
+
+// Set of ways a user can be e-mailed about a type of notification.
+const (
+	DeliveryImmediate = "immediate"
+	DeliveryDaily     = "daily"
+	DeliveryWeekly    = "weekly"
+	DeliveryNone      = "none"
+)
+
+// DefaultDelivery is used for the types of notifications a user has not set
+// a preference for.
+const DefaultDelivery = DeliveryDaily
+
+// Notification represents an individual notification.
+type Notification struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	ActorID     string     `json:"actor_id"`
+	Type        string     `json:"type"`
+	TargetID    string     `json:"target_id"`
+	SourceID    string     `json:"source_id"`
+	PostID      *string    `json:"post_id"`
+	CommentID   *string    `json:"comment_id"`
+	DateCreated time.Time  `json:"date_created"`
+	DateRead    *time.Time `json:"date_read"`
+}
+
+// Group represents similar notifications folded into one entry of the inbox,
+// such as everyone who commented on the same post. ID is the most recent
+// notification in the group and marking it read marks the whole group read.
+// ActorIDs holds the most recent distinct actors and Actors counts them all.
+type Group struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	TargetID    string    `json:"target_id"`
+	PostID      *string   `json:"post_id"`
+	CommentID   *string   `json:"comment_id"`
+	ActorIDs    []string  `json:"actor_ids"`
+	Actors      int       `json:"actors"`
+	Count       int       `json:"count"`
+	Unread      int       `json:"unread"`
+	Summary     string    `json:"summary"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+// Unread represents how many notifications and groups of notifications a
+// user has not read yet.
+type Unread struct {
+	Notifications int `json:"notifications"`
+	Groups        int `json:"groups"`
+}
+
+// Preference is how a user wants to be e-mailed about a type of
+// notification: right away, in a daily or weekly digest or not at all.
+type Preference struct {
+	Type     string `json:"type" validate:"required,oneof=comment reply reaction mention follow follow_request"`
+	Delivery string `json:"delivery" validate:"required,oneof=immediate daily weekly none"`
+}
+
+// UpdatePreferences contains the preferences a user changes. Types that are
+// left out keep their current preference.
+type UpdatePreferences struct {
+	Preferences []Preference `json:"preferences" validate:"required,min=1,dive"`
+}
+
+// =============================================================================
+
+func toNotification(dbN db.Notification) Notification {
+	pn := (*Notification)(unsafe.Pointer(&dbN))
+	return *pn
+}
+
+func toUnread(dbU db.Unread) Unread {
+	pu := (*Unread)(unsafe.Pointer(&dbU))
+	return *pu
+}
@@ -0,0 +1,384 @@
+// Package notification provides the core business API for the in-app inbox
+// of a user. Notifications are created from the events published by the
+// other services and similar notifications are grouped in the inbox. Users
+// are also e-mailed about notifications, right away or in daily and weekly
+// digests, depending on their preferences for each type.
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/notification/db"
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/business/sys/validate"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound  = errors.New("notification not found")
+	ErrInvalidID = errors.New("ID is not in its proper form")
+)
+
+// MaxActors is how many of the most recent actors a group lists.
+const MaxActors = 3
+
+// verbs describe what the actors of each type of notification did.
+var verbs = map[string]string{
+	TypeComment:       "commented on your post",
+	TypeReply:         "replied to your comment",
+	TypeReaction:      "liked your post",
+	TypeMention:       "mentioned you",
+	TypeFollow:        "started following you",
+	TypeFollowRequest: "requested to follow you",
+}
+
+// Core manages the set of API's for notification access.
+type Core struct {
+	log    *zap.SugaredLogger
+	store  db.Store
+	nats   *nats.NATS
+	mailer Mailer
+}
+
+// NewCore constructs a core for notification api access. The mailer sends
+// the e-mails about notifications.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS, mailer Mailer) Core {
+	return Core{
+		log:    log,
+		store:  db.NewStore(log, sqlxDB),
+		nats:   nats,
+		mailer: mailer,
+	}
+}
+
+// QueryInbox retrieves a page of the notifications of the user grouped by
+// type and target, most recently active group first.
+func (c Core) QueryInbox(ctx context.Context, userID string, pageNumber int, rowsPerPage int) ([]Group, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	dbGroups, err := c.store.QueryGroups(ctx, userID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	groups := make([]Group, len(dbGroups))
+	var actorIDs []string
+	for i, dbG := range dbGroups {
+		groups[i] = Group{
+			ID:          dbG.ID,
+			Type:        dbG.Type,
+			TargetID:    dbG.TargetID,
+			PostID:      dbG.PostID,
+			CommentID:   dbG.CommentID,
+			ActorIDs:    recent(dbG.ActorIDs, MaxActors),
+			Actors:      dbG.Actors,
+			Count:       dbG.Count,
+			Unread:      dbG.Unread,
+			DateCreated: dbG.DateCreated,
+		}
+		actorIDs = append(actorIDs, groups[i].ActorIDs...)
+	}
+
+	if len(actorIDs) == 0 {
+		return groups, nil
+	}
+
+	handles, err := c.handles(ctx, actorIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range groups {
+		groups[i].Summary = summary(groups[i], handles)
+	}
+
+	return groups, nil
+}
+
+// QueryUnread counts the notifications and the groups of notifications the
+// user has not read yet.
+func (c Core) QueryUnread(ctx context.Context, userID string) (Unread, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return Unread{}, ErrInvalidID
+	}
+
+	dbUnread, err := c.store.QueryUnread(ctx, userID)
+	if err != nil {
+		return Unread{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toUnread(dbUnread), nil
+}
+
+// MarkRead marks the specified notification of the user as read along with
+// the older notifications in its group.
+func (c Core) MarkRead(ctx context.Context, userID string, notificationID string, now time.Time) error {
+	if err := validate.CheckID(userID); err != nil {
+		return ErrInvalidID
+	}
+	if err := validate.CheckID(notificationID); err != nil {
+		return ErrInvalidID
+	}
+
+	dbN, err := c.store.QueryByID(ctx, userID, notificationID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("query: %w", err)
+	}
+
+	if err := c.store.MarkGroupRead(ctx, dbN, now); err != nil {
+		return fmt.Errorf("mark read: %w", err)
+	}
+
+	return nil
+}
+
+// MarkAllRead marks every notification of the user as read.
+func (c Core) MarkAllRead(ctx context.Context, userID string, now time.Time) error {
+	if err := validate.CheckID(userID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := c.store.MarkAllRead(ctx, userID, now); err != nil {
+		return fmt.Errorf("mark all read: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AddComment notifies the author of the post about a new comment and, for
+// replies, the author of the parent comment. Authors who are also the
+// author of the parent comment only get the reply.
+func (c Core) AddComment(ctx context.Context, dbC db.Comment) error {
+	if err := c.store.SaveComment(ctx, dbC); err != nil {
+		return fmt.Errorf("save comment: %w", err)
+	}
+
+	dbP, err := c.store.QueryPostByID(ctx, dbC.PostID)
+	if err != nil {
+		return fmt.Errorf("query post: %w", err)
+	}
+
+	commentID := dbC.ID
+	postID := dbC.PostID
+
+	var parentAuthorID string
+	if dbC.ParentID != nil {
+		if parentAuthorID, err = c.store.QueryCommentAuthor(ctx, *dbC.ParentID); err != nil {
+			return fmt.Errorf("query parent: %w", err)
+		}
+
+		dbN := db.Notification{
+			UserID:      parentAuthorID,
+			ActorID:     dbC.UserID,
+			Type:        TypeReply,
+			TargetID:    *dbC.ParentID,
+			SourceID:    dbC.ID,
+			PostID:      &postID,
+			CommentID:   &commentID,
+			DateCreated: dbC.DateCreated,
+		}
+
+		if err := c.notify(ctx, dbN); err != nil {
+			return err
+		}
+	}
+
+	if dbP.UserID == parentAuthorID {
+		return nil
+	}
+
+	dbN := db.Notification{
+		UserID:      dbP.UserID,
+		ActorID:     dbC.UserID,
+		Type:        TypeComment,
+		TargetID:    dbC.PostID,
+		SourceID:    dbC.ID,
+		PostID:      &postID,
+		CommentID:   &commentID,
+		DateCreated: dbC.DateCreated,
+	}
+
+	return c.notify(ctx, dbN)
+}
+
+// RemoveComment takes back the notifications about a deleted comment,
+// including the mentions in it.
+func (c Core) RemoveComment(ctx context.Context, dbC db.Comment) error {
+	if err := c.store.DeleteBySource(ctx, dbC.ID); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// AddFollow notifies a user about a new follower.
+func (c Core) AddFollow(ctx context.Context, dbF db.Follow) error {
+	dbN := db.Notification{
+		UserID:      dbF.FolloweeID,
+		ActorID:     dbF.FollowerID,
+		Type:        TypeFollow,
+		TargetID:    dbF.FolloweeID,
+		SourceID:    dbF.FollowerID,
+		DateCreated: dbF.DateCreated,
+	}
+
+	return c.notify(ctx, dbN)
+}
+
+// AddFollowRequest notifies a private user about a request to follow them.
+func (c Core) AddFollowRequest(ctx context.Context, dbR db.Request) error {
+	dbN := db.Notification{
+		UserID:      dbR.TargetID,
+		ActorID:     dbR.RequesterID,
+		Type:        TypeFollowRequest,
+		TargetID:    dbR.TargetID,
+		SourceID:    dbR.RequesterID,
+		DateCreated: dbR.DateCreated,
+	}
+
+	return c.notify(ctx, dbN)
+}
+
+// AddMention notifies a user about being mentioned in a post or a comment.
+func (c Core) AddMention(ctx context.Context, dbM db.Mention) error {
+	postID := dbM.PostID
+	sourceID := dbM.PostID
+
+	var commentID *string
+	if dbM.CommentID != "" {
+		commentID = &dbM.CommentID
+		sourceID = dbM.CommentID
+	}
+
+	dbN := db.Notification{
+		UserID:      dbM.UserID,
+		ActorID:     dbM.AuthorID,
+		Type:        TypeMention,
+		TargetID:    sourceID,
+		SourceID:    sourceID,
+		PostID:      &postID,
+		CommentID:   commentID,
+		DateCreated: dbM.DateCreated,
+	}
+
+	return c.notify(ctx, dbN)
+}
+
+// SavePost stores the copy of a post so comments on it can be routed to its
+// author.
+func (c Core) SavePost(ctx context.Context, dbP db.Post) error {
+	if err := c.store.SavePost(ctx, dbP); err != nil {
+		return fmt.Errorf("save post: %w", err)
+	}
+
+	return nil
+}
+
+// SaveHandle stores the copy of the handle a user goes by. An empty handle
+// removes the copy.
+func (c Core) SaveHandle(ctx context.Context, dbH db.Handle) error {
+	save := c.store.SaveHandle
+	if dbH.Handle == "" {
+		save = c.store.DeleteHandle
+	}
+
+	if err := save(ctx, dbH); err != nil {
+		return fmt.Errorf("save handle: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// notify stores the notification unless users would be notified about
+// their own actions. New notifications are announced with a
+// notification-created event so they can be pushed to the user, and are
+// e-mailed right away to users who want that.
+func (c Core) notify(ctx context.Context, dbN db.Notification) error {
+	if dbN.UserID == dbN.ActorID {
+		return nil
+	}
+
+	dbN.ID = validate.GenerateID()
+
+	created, err := c.store.Create(ctx, dbN)
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+	if !created {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(dbN); err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+
+	if err := c.nats.Client.Publish("notification-created", buf.Bytes()); err != nil {
+		return fmt.Errorf("publishing notification-created: %w", err)
+	}
+
+	c.mail(ctx, dbN)
+
+	return nil
+}
+
+// recent returns up to n distinct IDs in the order they first appear.
+func recent(ids []string, n int) []string {
+	seen := make(map[string]bool, n)
+	out := make([]string, 0, n)
+	for _, id := range ids {
+		if len(out) == n {
+			break
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	return out
+}
+
+// summary describes the group the way the inbox shows it, naming the most
+// recent actor and counting the rest, such as "@gopher and 2 others
+// commented on your post".
+func summary(g Group, handles map[string]string) string {
+	name := func(i int) string {
+		if h, ok := handles[g.ActorIDs[i]]; ok {
+			return "@" + h
+		}
+		if i == 0 {
+			return "Someone"
+		}
+		return "someone"
+	}
+
+	var who string
+	switch {
+	case g.Actors == 1:
+		who = name(0)
+	case g.Actors == 2:
+		who = name(0) + " and " + name(1)
+	default:
+		who = fmt.Sprintf("%s and %d others", name(0), g.Actors-1)
+	}
+
+	return who + " " + verbs[g.Type]
+}
@@ -0,0 +1,328 @@
+package notification_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/notification"
+	"github.com/dudakovict/social-network/business/core/notification/db"
+	"github.com/dudakovict/social-network/business/data/email"
+	"github.com/dudakovict/social-network/business/data/notification/dbtest"
+	"github.com/dudakovict/social-network/foundation/docker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var nc *docker.Container
+var dbc *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	nc, err = dbtest.StartNATS()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	dbc, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	defer dbtest.StopNATS(nc)
+	defer dbtest.StopDB(dbc)
+
+	m.Run()
+}
+
+func TestNotification(t *testing.T) {
+	log, sqlxDB, teardown := dbtest.NewUnit(t, dbc, "testnotification")
+	t.Cleanup(teardown)
+
+	n, teardownNATS := dbtest.NewNATS(t, nc)
+	t.Cleanup(teardownNATS)
+
+	core := notification.NewCore(log, sqlxDB, n, notification.Mailer{})
+
+	t.Log("Given the need to work with the inbox.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling comment and follow events.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.April, 1, 0, 0, 0, 0, time.UTC)
+			adminID := "5cf37266-3473-4006-984f-9325122678b7"
+			userID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+			otherID := "a1f6e7c2-3d4b-4c5a-9e8f-7b6a5d4c3b2a"
+			postID := "3dc0a440-2e05-11ed-a261-0242ac120002"
+			parentID := "7f6edd62-2e05-11ed-a261-0242ac120002"
+
+			if err := core.SaveHandle(ctx, db.Handle{UserID: otherID, Handle: "other_gopher"}); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to save a handle : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to save a handle.", dbtest.Success, testID)
+
+			dbC := db.Comment{
+				ID:          "0b2e8f5a-6c1d-4e3b-8a7f-9d6c5b4a3e21",
+				UserID:      otherID,
+				PostID:      postID,
+				DateCreated: now,
+			}
+			for i := 0; i < 2; i++ {
+				if err := core.AddComment(ctx, dbC); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to add a comment : %s.", dbtest.Failed, testID, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to add a comment twice.", dbtest.Success, testID)
+
+			reply := db.Comment{
+				ID:          "5e4d3c2b-1a09-4f8e-b7d6-c5b4a3928170",
+				UserID:      adminID,
+				PostID:      postID,
+				ParentID:    &parentID,
+				DateCreated: now,
+			}
+			if err := core.AddComment(ctx, reply); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add a reply : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to add a reply.", dbtest.Success, testID)
+
+			if err := core.AddFollow(ctx, db.Follow{FollowerID: otherID, FolloweeID: adminID, DateCreated: now.Add(time.Hour)}); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add a follow : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to add a follow.", dbtest.Success, testID)
+
+			groups, err := core.QueryInbox(ctx, adminID, 1, 10)
+			if err != nil || len(groups) != 3 {
+				t.Fatalf("\t%s\tTest %d:\tShould get three groups for the post author : %+v %v.", dbtest.Failed, testID, groups, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get three groups for the post author.", dbtest.Success, testID)
+
+			if groups[0].Type != notification.TypeFollow || groups[0].Summary != "@other_gopher started following you" {
+				t.Fatalf("\t%s\tTest %d:\tShould get the follow first : %+v.", dbtest.Failed, testID, groups[0])
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the follow first.", dbtest.Success, testID)
+
+			g := groups[1]
+			if g.TargetID != postID || g.Count != 2 || g.Unread != 2 || g.Summary != "@other_gopher and @user_gopher commented on your post" {
+				t.Fatalf("\t%s\tTest %d:\tShould group the comments on the post : %+v.", dbtest.Failed, testID, g)
+			}
+			t.Logf("\t%s\tTest %d:\tShould group the comments on the post.", dbtest.Success, testID)
+
+			replies, err := core.QueryInbox(ctx, userID, 1, 10)
+			if err != nil || len(replies) != 1 || replies[0].Type != notification.TypeReply {
+				t.Fatalf("\t%s\tTest %d:\tShould only notify the parent author about a reply : %+v %v.", dbtest.Failed, testID, replies, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only notify the parent author about a reply.", dbtest.Success, testID)
+
+			if err := core.MarkRead(ctx, userID, g.ID, now); !errors.Is(err, notification.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to mark the notifications of others read : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to mark the notifications of others read.", dbtest.Success, testID)
+
+			if err := core.MarkRead(ctx, adminID, g.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to mark a group read : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to mark a group read.", dbtest.Success, testID)
+
+			unread, err := core.QueryUnread(ctx, adminID)
+			if err != nil || unread.Notifications != 2 || unread.Groups != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould count the unread notifications : %+v %v.", dbtest.Failed, testID, unread, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould count the unread notifications.", dbtest.Success, testID)
+
+			if err := core.RemoveComment(ctx, dbC); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to remove a comment : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to remove a comment.", dbtest.Success, testID)
+
+			if err := core.MarkAllRead(ctx, adminID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to mark everything read : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to mark everything read.", dbtest.Success, testID)
+
+			groups, err = core.QueryInbox(ctx, adminID, 1, 10)
+			if err != nil || len(groups) != 3 || groups[1].Count != 1 || groups[0].Unread+groups[1].Unread+groups[2].Unread != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould see the inbox read without the removed comment : %+v %v.", dbtest.Failed, testID, groups, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould see the inbox read without the removed comment.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestMail(t *testing.T) {
+	log, sqlxDB, teardown := dbtest.NewUnit(t, dbc, "testmail")
+	t.Cleanup(teardown)
+
+	n, teardownNATS := dbtest.NewNATS(t, nc)
+	t.Cleanup(teardownNATS)
+
+	client := &emailClient{}
+	mailer := notification.Mailer{
+		Client:         client,
+		Secret:         []byte("secret"),
+		AppURL:         "http://localhost:3000",
+		UnsubscribeURL: "http://localhost:3003/v1/notifications/unsubscribe",
+	}
+
+	core := notification.NewCore(log, sqlxDB, n, mailer)
+
+	t.Log("Given the need to e-mail notifications.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen users want e-mails right away or in digests.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.April, 2, 10, 0, 0, 0, time.UTC)
+			adminID := "5cf37266-3473-4006-984f-9325122678b7"
+			userID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+			otherID := "a1f6e7c2-3d4b-4c5a-9e8f-7b6a5d4c3b2a"
+			postID := "3dc0a440-2e05-11ed-a261-0242ac120002"
+
+			if err := core.SaveHandle(ctx, db.Handle{UserID: otherID, Handle: "other_gopher"}); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to save a handle : %s.", dbtest.Failed, testID, err)
+			}
+
+			up := notification.UpdatePreferences{
+				Preferences: []notification.Preference{
+					{Type: notification.TypeComment, Delivery: notification.DeliveryImmediate},
+					{Type: notification.TypeFollow, Delivery: notification.DeliveryWeekly},
+				},
+			}
+			if err := core.UpdatePreferences(ctx, adminID, up, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update preferences : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to update preferences.", dbtest.Success, testID)
+
+			bad := notification.UpdatePreferences{Preferences: []notification.Preference{{Type: "poke", Delivery: "hourly"}}}
+			if err := core.UpdatePreferences(ctx, adminID, bad, now); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to set an unknown preference.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to set an unknown preference.", dbtest.Success, testID)
+
+			dbC := db.Comment{
+				ID:          "0b2e8f5a-6c1d-4e3b-8a7f-9d6c5b4a3e21",
+				UserID:      otherID,
+				PostID:      postID,
+				DateCreated: now,
+			}
+			if err := core.AddComment(ctx, dbC); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add a comment : %s.", dbtest.Failed, testID, err)
+			}
+			if err := core.AddFollow(ctx, db.Follow{FollowerID: otherID, FolloweeID: adminID, DateCreated: now}); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add a follow : %s.", dbtest.Failed, testID, err)
+			}
+			if err := core.AddFollow(ctx, db.Follow{FollowerID: otherID, FolloweeID: userID, DateCreated: now}); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add a follow : %s.", dbtest.Failed, testID, err)
+			}
+
+			if len(client.requests) != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould only e-mail the comment right away : %+v.", dbtest.Failed, testID, client.requests)
+			}
+			req := client.requests[0]
+			if req.Email != "admin@example.com" || req.Template != "notification" || req.Vars["summary"] != "@other_gopher commented on your post" {
+				t.Fatalf("\t%s\tTest %d:\tShould e-mail the post author about the comment : %+v.", dbtest.Failed, testID, req)
+			}
+			t.Logf("\t%s\tTest %d:\tShould e-mail the post author about the comment right away.", dbtest.Success, testID)
+
+			client.err = status.Error(email.CodeRateLimited, "address is over its rate limit")
+			if sent, err := core.SendDigests(ctx, notification.DeliveryDaily, now.Add(24*time.Hour)); err != nil || sent != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT send a digest over the rate limit : %d %v.", dbtest.Failed, testID, sent, err)
+			}
+			client.err = nil
+			t.Logf("\t%s\tTest %d:\tShould NOT send a digest over the rate limit.", dbtest.Success, testID)
+
+			sent, err := core.SendDigests(ctx, notification.DeliveryDaily, now.Add(24*time.Hour))
+			if err != nil || sent != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould send one daily digest : %d %v.", dbtest.Failed, testID, sent, err)
+			}
+			req = client.requests[1]
+			if req.Email != "user@example.com" || req.Template != "digest" || req.Vars["count"] != "1" || req.Vars["items"] != "@other_gopher started following you" {
+				t.Fatalf("\t%s\tTest %d:\tShould send the digest to the followee : %+v.", dbtest.Failed, testID, req)
+			}
+			t.Logf("\t%s\tTest %d:\tShould send one daily digest.", dbtest.Success, testID)
+
+			if sent, err := core.SendDigests(ctx, notification.DeliveryDaily, now.Add(25*time.Hour)); err != nil || sent != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT send the daily digest twice : %d %v.", dbtest.Failed, testID, sent, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT send the daily digest twice.", dbtest.Success, testID)
+
+			if sent, err := core.SendDigests(ctx, notification.DeliveryWeekly, now.Add(7*24*time.Hour)); err != nil || sent != 1 || client.requests[2].Email != "admin@example.com" {
+				t.Fatalf("\t%s\tTest %d:\tShould send one weekly digest : %d %v.", dbtest.Failed, testID, sent, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould send one weekly digest.", dbtest.Success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen users unsubscribe from an e-mail.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2019, time.April, 10, 0, 0, 0, 0, time.UTC)
+			adminID := "5cf37266-3473-4006-984f-9325122678b7"
+
+			u, err := url.Parse(client.requests[0].Vars["unsubscribe"])
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould get an unsubscribe link : %s.", dbtest.Failed, testID, err)
+			}
+			token := u.Query().Get("token")
+
+			if err := core.Unsubscribe(ctx, token+"x", now); !errors.Is(err, notification.ErrInvalidToken) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT accept a tampered token : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT accept a tampered token.", dbtest.Success, testID)
+
+			if err := core.CheckUnsubscribe(token); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to check the token before unsubscribing : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to check the token before unsubscribing.", dbtest.Success, testID)
+
+			if err := core.Unsubscribe(ctx, token, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to unsubscribe : %s.", dbtest.Failed, testID, err)
+			}
+
+			prefs, err := core.QueryPreferences(ctx, adminID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query preferences : %s.", dbtest.Failed, testID, err)
+			}
+			for _, p := range prefs {
+				want := notification.DefaultDelivery
+				switch p.Type {
+				case notification.TypeComment:
+					want = notification.DeliveryNone
+				case notification.TypeFollow:
+					want = notification.DeliveryWeekly
+				}
+				if p.Delivery != want {
+					t.Fatalf("\t%s\tTest %d:\tShould only stop the e-mails about comments : %+v.", dbtest.Failed, testID, prefs)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould only stop the e-mails about comments.", dbtest.Success, testID)
+		}
+	}
+}
+
+// =============================================================================
+
+// emailClient records the e-mails sent in place of the e-mail service.
+type emailClient struct {
+	email.EmailClient
+	requests []*email.EmailRequest
+	err      error
+}
+
+func (c *emailClient) Send(ctx context.Context, in *email.EmailRequest, opts ...grpc.CallOption) (*email.EmailResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.requests = append(c.requests, in)
+	return &email.EmailResponse{Id: in.RequestId, Status: "queued"}, nil
+}
+
+func (c *emailClient) Status(ctx context.Context, in *email.StatusRequest, opts ...grpc.CallOption) (*email.StatusResponse, error) {
+	return nil, errors.New("not implemented")
+}
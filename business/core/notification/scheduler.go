@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Scheduler sends the daily and weekly digests in the background. Digests
+// go out on the first tick of every period, and checking again within a
+// period does not send them twice.
+type Scheduler struct {
+	log      *zap.SugaredLogger
+	core     Core
+	interval time.Duration
+	shutdown chan struct{}
+	done     chan struct{}
+}
+
+// NewScheduler constructs a scheduler for sending the digests of the core.
+func NewScheduler(log *zap.SugaredLogger, core Core, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		log:      log,
+		core:     core,
+		interval: interval,
+		shutdown: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start begins sending the digests in a goroutine.
+func (s *Scheduler) Start() {
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.send()
+
+			select {
+			case <-s.shutdown:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown waits for the digests being sent to finish, or for the context
+// to be done.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	close(s.shutdown)
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// send sends the digests of every frequency that are due.
+func (s *Scheduler) send() {
+	for _, frequency := range []string{DeliveryDaily, DeliveryWeekly} {
+		n, err := s.core.SendDigests(context.Background(), frequency, time.Now())
+		if err != nil {
+			s.log.Errorw("scheduler", "frequency", frequency, "ERROR", err)
+			continue
+		}
+
+		if n > 0 {
+			s.log.Infow("scheduler", "frequency", frequency, "digests", n)
+		}
+	}
+}
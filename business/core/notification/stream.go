@@ -0,0 +1,25 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/dudakovict/social-network/business/core/notification/db"
+	"github.com/dudakovict/social-network/business/sys/stream"
+)
+
+// StreamCreated turns a notification-created event into the message pushed
+// to the clients streaming the notifications of the user.
+func StreamCreated(data []byte) (string, stream.Message, error) {
+	var dbN db.Notification
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&dbN); err != nil {
+		return "", stream.Message{}, err
+	}
+
+	m := stream.Message{
+		Type: "notification",
+		Data: toNotification(dbN),
+	}
+
+	return dbN.UserID, m, nil
+}
@@ -4,12 +4,25 @@ package db
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/dudakovict/social-network/business/sys/database"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
+// visible restricts a query on posts to the ones the viewer is allowed to
+// see: public posts, their own posts and the followers only posts of the
+// users they follow.
+const visible = `(
+		visibility = 'public' OR
+		user_id = :viewer_id OR
+		(visibility = 'followers' AND EXISTS (
+			SELECT 1 FROM followers f WHERE f.user_id = posts.user_id AND f.follower_id = :viewer_id
+		))
+	)`
+
 // Store manages the set of API's for post access.
 type Store struct {
 	log          *zap.SugaredLogger
@@ -49,9 +62,9 @@ func (s Store) Tran(tx sqlx.ExtContext) Store {
 func (s Store) Create(ctx context.Context, p Post) error {
 	const q = `
 	INSERT INTO posts
-		(post_id, title, description, user_id, date_created, date_updated)
+		(post_id, title, description, user_id, date_created, date_updated, status, publish_at, visibility, attachment_ids)
 	VALUES
-		(:post_id, :title, :description, :user_id, :date_created, :date_updated)`
+		(:post_id, :title, :description, :user_id, :date_created, :date_updated, :status, :publish_at, :visibility, :attachment_ids)`
 
 	if err := database.NamedExecContext(ctx, s.log, s.db, q, p); err != nil {
 		return fmt.Errorf("inserting post: %w", err)
@@ -68,7 +81,12 @@ func (s Store) Update(ctx context.Context, p Post) error {
 	SET 
 		"title" = :title,
 		"description" = :description,
-		"date_updated" = :date_updated
+		"date_updated" = :date_updated,
+		"edited" = :edited,
+		"status" = :status,
+		"publish_at" = :publish_at,
+		"visibility" = :visibility,
+		"attachment_ids" = :attachment_ids
 	WHERE
 		post_id = :post_id`
 
@@ -79,8 +97,27 @@ func (s Store) Update(ctx context.Context, p Post) error {
 	return nil
 }
 
-// Delete removes a post from the database.
-func (s Store) Delete(ctx context.Context, postID string) error {
+// Delete marks a post as deleted in the database. The post stays in the
+// trash until it is restored or purged.
+func (s Store) Delete(ctx context.Context, p Post) error {
+	const q = `
+	UPDATE
+		posts
+	SET
+		"deleted_at" = :deleted_at
+	WHERE
+		post_id = :post_id AND
+		deleted_at IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, p); err != nil {
+		return fmt.Errorf("deleting postID[%s]: %w", p.ID, err)
+	}
+
+	return nil
+}
+
+// Restore takes a post out of the trash.
+func (s Store) Restore(ctx context.Context, postID string) error {
 	data := struct {
 		PostID string `db:"post_id"`
 	}{
@@ -88,24 +125,51 @@ func (s Store) Delete(ctx context.Context, postID string) error {
 	}
 
 	const q = `
-	DELETE FROM
+	UPDATE
 		posts
+	SET
+		"deleted_at" = NULL
 	WHERE
 		post_id = :post_id`
 
 	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
-		return fmt.Errorf("deleting postID[%s]: %w", postID, err)
+		return fmt.Errorf("restoring postID[%s]: %w", postID, err)
+	}
+
+	return nil
+}
+
+// Purge removes the posts that were deleted before the specified time from
+// the database for good.
+func (s Store) Purge(ctx context.Context, before time.Time) error {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before,
+	}
+
+	const q = `
+	DELETE FROM
+		posts
+	WHERE
+		deleted_at < :before`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("purging posts: %w", err)
 	}
 
 	return nil
 }
 
-// Query retrieves a list of existing posts from the database.
-func (s Store) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Post, error) {
+// Query retrieves a list of existing posts the viewer is allowed to see from
+// the database.
+func (s Store) Query(ctx context.Context, viewerID string, pageNumber int, rowsPerPage int) ([]Post, error) {
 	data := struct {
-		Offset      int `db:"offset"`
-		RowsPerPage int `db:"rows_per_page"`
+		ViewerID    string `db:"viewer_id"`
+		Offset      int    `db:"offset"`
+		RowsPerPage int    `db:"rows_per_page"`
 	}{
+		ViewerID:    viewerID,
 		Offset:      (pageNumber - 1) * rowsPerPage,
 		RowsPerPage: rowsPerPage,
 	}
@@ -115,6 +179,10 @@ func (s Store) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Po
 		*
 	FROM
 		posts
+	WHERE
+		status = 'published' AND
+		deleted_at IS NULL AND
+		` + visible + `
 	ORDER BY
 		post_id
 	OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY`
@@ -141,7 +209,8 @@ func (s Store) QueryByID(ctx context.Context, postID string) (Post, error) {
 	FROM
 		posts
 	WHERE 
-		post_id = :post_id`
+		post_id = :post_id AND
+		deleted_at IS NULL`
 
 	var p Post
 	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &p); err != nil {
@@ -151,7 +220,65 @@ func (s Store) QueryByID(ctx context.Context, postID string) (Post, error) {
 	return p, nil
 }
 
-func (s Store) QueryByUserID(ctx context.Context, userID string) ([]Post, error) {
+// QueryByUserID retrieves the published posts of the specified user that the
+// viewer is allowed to see.
+func (s Store) QueryByUserID(ctx context.Context, userID string, viewerID string) ([]Post, error) {
+	data := struct {
+		UserID   string `db:"user_id"`
+		ViewerID string `db:"viewer_id"`
+	}{
+		UserID:   userID,
+		ViewerID: viewerID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		posts
+	WHERE
+		user_id = :user_id AND
+		status = 'published' AND
+		deleted_at IS NULL AND
+		` + visible
+
+	var ps []Post
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ps); err != nil {
+		return nil, fmt.Errorf("selecting posts userID[%s]: %w", userID, err)
+	}
+
+	return ps, nil
+}
+
+// QueryByAttachmentID retrieves the posts the specified attachment is
+// attached to.
+func (s Store) QueryByAttachmentID(ctx context.Context, attachmentID string) ([]Post, error) {
+	data := struct {
+		AttachmentID string `db:"attachment_id"`
+	}{
+		AttachmentID: attachmentID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		posts
+	WHERE
+		:attachment_id = ANY(attachment_ids) AND
+		deleted_at IS NULL`
+
+	var ps []Post
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ps); err != nil {
+		return nil, fmt.Errorf("selecting posts attachmentID[%s]: %w", attachmentID, err)
+	}
+
+	return ps, nil
+}
+
+// QueryUnpublishedByUserID retrieves the drafts and scheduled posts of the
+// specified user, most recently updated first.
+func (s Store) QueryUnpublishedByUserID(ctx context.Context, userID string) ([]Post, error) {
 	data := struct {
 		UserID string `db:"user_id"`
 	}{
@@ -164,12 +291,399 @@ func (s Store) QueryByUserID(ctx context.Context, userID string) ([]Post, error)
 	FROM
 		posts
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND
+		status <> 'published' AND
+		deleted_at IS NULL
+	ORDER BY
+		date_updated DESC`
 
 	var ps []Post
 	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ps); err != nil {
-		return nil, fmt.Errorf("selecting posts userID[%s]: %w", userID, err)
+		return nil, fmt.Errorf("selecting unpublished posts userID[%s]: %w", userID, err)
 	}
 
 	return ps, nil
 }
+
+// PublishDue marks up to limit scheduled posts whose publish time has come as
+// published and returns them. Rows claimed by a concurrent transaction are
+// skipped, so running it from several replicas never publishes a post twice.
+func (s Store) PublishDue(ctx context.Context, now time.Time, limit int) ([]Post, error) {
+	data := struct {
+		Now   time.Time `db:"now"`
+		Limit int       `db:"limit"`
+	}{
+		Now:   now,
+		Limit: limit,
+	}
+
+	const q = `
+	UPDATE
+		posts
+	SET
+		"status" = 'published',
+		"date_updated" = :now
+	WHERE
+		post_id IN (
+			SELECT
+				post_id
+			FROM
+				posts
+			WHERE
+				status = 'scheduled' AND
+				publish_at <= :now AND
+				deleted_at IS NULL
+			ORDER BY
+				publish_at
+			LIMIT :limit
+			FOR UPDATE SKIP LOCKED
+		)
+	RETURNING
+		*`
+
+	var ps []Post
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ps); err != nil {
+		return nil, fmt.Errorf("publishing due posts: %w", err)
+	}
+
+	return ps, nil
+}
+
+// QueryDeletedByID gets the specified post from the trash.
+func (s Store) QueryDeletedByID(ctx context.Context, postID string) (Post, error) {
+	data := struct {
+		PostID string `db:"post_id"`
+	}{
+		PostID: postID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		posts
+	WHERE
+		post_id = :post_id AND
+		deleted_at IS NOT NULL`
+
+	var p Post
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &p); err != nil {
+		return Post{}, fmt.Errorf("selecting deleted postID[%q]: %w", postID, err)
+	}
+
+	return p, nil
+}
+
+// QueryDeletedByUserID retrieves the posts of the specified user that are in
+// the trash, most recently deleted first.
+func (s Store) QueryDeletedByUserID(ctx context.Context, userID string) ([]Post, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		posts
+	WHERE
+		user_id = :user_id AND
+		deleted_at IS NOT NULL
+	ORDER BY
+		deleted_at DESC`
+
+	var ps []Post
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ps); err != nil {
+		return nil, fmt.Errorf("selecting deleted posts userID[%s]: %w", userID, err)
+	}
+
+	return ps, nil
+}
+
+// CreateFollower inserts a copy of a follow into the database. Receiving the
+// same follow twice is not an error.
+func (s Store) CreateFollower(ctx context.Context, f Follower) error {
+	const q = `
+	INSERT INTO followers
+		(user_id, follower_id, date_created)
+	VALUES
+		(:user_id, :follower_id, :date_created)
+	ON CONFLICT DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, f); err != nil {
+		return fmt.Errorf("inserting follower: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteFollower removes the copy of a follow from the database.
+func (s Store) DeleteFollower(ctx context.Context, f Follower) error {
+	const q = `
+	DELETE FROM
+		followers
+	WHERE
+		user_id = :user_id AND
+		follower_id = :follower_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, f); err != nil {
+		return fmt.Errorf("deleting follower userID[%s] followerID[%s]: %w", f.FolloweeID, f.FollowerID, err)
+	}
+
+	return nil
+}
+
+// IsFollower reports whether the follower follows the specified user.
+func (s Store) IsFollower(ctx context.Context, userID string, followerID string) (bool, error) {
+	data := struct {
+		UserID     string `db:"user_id"`
+		FollowerID string `db:"follower_id"`
+	}{
+		UserID:     userID,
+		FollowerID: followerID,
+	}
+
+	const q = `
+	SELECT
+		EXISTS (
+			SELECT 1 FROM followers WHERE user_id = :user_id AND follower_id = :follower_id
+		) AS following`
+
+	var result struct {
+		Following bool `db:"following"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return false, fmt.Errorf("selecting follower userID[%s] followerID[%s]: %w", userID, followerID, err)
+	}
+
+	return result.Following, nil
+}
+
+// CreateRevision inserts a new post revision into the database.
+func (s Store) CreateRevision(ctx context.Context, rev Revision) error {
+	const q = `
+	INSERT INTO post_revisions
+		(revision_id, post_id, title, description, editor_id, date_created)
+	VALUES
+		(:revision_id, :post_id, :title, :description, :editor_id, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, rev); err != nil {
+		return fmt.Errorf("inserting revision: %w", err)
+	}
+
+	return nil
+}
+
+// QueryRevisions retrieves the revisions of the specified post from the
+// newest to the oldest.
+func (s Store) QueryRevisions(ctx context.Context, postID string) ([]Revision, error) {
+	data := struct {
+		PostID string `db:"post_id"`
+	}{
+		PostID: postID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		post_revisions
+	WHERE
+		post_id = :post_id
+	ORDER BY
+		date_created DESC`
+
+	var revs []Revision
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &revs); err != nil {
+		return nil, fmt.Errorf("selecting revisions postID[%s]: %w", postID, err)
+	}
+
+	return revs, nil
+}
+
+// QueryRevisionByID gets the specified revision of a post from the database.
+func (s Store) QueryRevisionByID(ctx context.Context, postID string, revisionID string) (Revision, error) {
+	data := struct {
+		PostID     string `db:"post_id"`
+		RevisionID string `db:"revision_id"`
+	}{
+		PostID:     postID,
+		RevisionID: revisionID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		post_revisions
+	WHERE
+		post_id = :post_id AND
+		revision_id = :revision_id`
+
+	var rev Revision
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &rev); err != nil {
+		return Revision{}, fmt.Errorf("selecting revisionID[%q]: %w", revisionID, err)
+	}
+
+	return rev, nil
+}
+
+// CountAttachments returns how many of the specified attachments were
+// uploaded by the user.
+func (s Store) CountAttachments(ctx context.Context, userID string, attachmentIDs []string) (int, error) {
+	data := struct {
+		UserID        string         `db:"user_id"`
+		AttachmentIDs pq.StringArray `db:"attachment_ids"`
+	}{
+		UserID:        userID,
+		AttachmentIDs: attachmentIDs,
+	}
+
+	const q = `
+	SELECT
+		COUNT(*) AS count
+	FROM
+		attachments
+	WHERE
+		user_id = :user_id AND
+		CAST(attachment_id AS TEXT) = ANY(:attachment_ids)`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("counting attachments of userID[%s]: %w", userID, err)
+	}
+
+	return count.Count, nil
+}
+
+// SaveHandle stores the copy of the handle a user goes by. Any other user
+// holding the handle has released it by now.
+func (s Store) SaveHandle(ctx context.Context, h Handle) error {
+	const q = `
+	INSERT INTO handles
+		(user_id, handle)
+	VALUES
+		(:user_id, :handle)`
+
+	tran := func(tx sqlx.ExtContext) error {
+		if err := s.Tran(tx).DeleteHandle(ctx, h); err != nil {
+			return err
+		}
+
+		if err := database.NamedExecContext(ctx, s.log, tx, q, h); err != nil {
+			return fmt.Errorf("inserting handle: %w", err)
+		}
+
+		return nil
+	}
+
+	return s.WithinTran(ctx, tran)
+}
+
+// DeleteHandle removes the copy of the handle of the user along with any
+// other user holding the same handle.
+func (s Store) DeleteHandle(ctx context.Context, h Handle) error {
+	const q = `
+	DELETE FROM
+		handles
+	WHERE
+		user_id = :user_id OR
+		handle = :handle`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, h); err != nil {
+		return fmt.Errorf("deleting handle of userID[%s]: %w", h.UserID, err)
+	}
+
+	return nil
+}
+
+// QueryHandles gets the users going by the specified handles.
+func (s Store) QueryHandles(ctx context.Context, handles []string) ([]Handle, error) {
+	data := struct {
+		Handles pq.StringArray `db:"handles"`
+	}{
+		Handles: handles,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		handles
+	WHERE
+		handle = ANY(:handles)`
+
+	var hs []Handle
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &hs); err != nil {
+		return nil, fmt.Errorf("selecting handles: %w", err)
+	}
+
+	return hs, nil
+}
+
+// CreateMention inserts a new mention into the database.
+func (s Store) CreateMention(ctx context.Context, m Mention) error {
+	const q = `
+	INSERT INTO post_mentions
+		(post_id, user_id, author_id, handle, start_offset, end_offset, date_created)
+	VALUES
+		(:post_id, :user_id, :author_id, :handle, :start_offset, :end_offset, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, m); err != nil {
+		return fmt.Errorf("inserting mention: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteMentions removes the mentions of the specified post.
+func (s Store) DeleteMentions(ctx context.Context, postID string) error {
+	data := struct {
+		PostID string `db:"post_id"`
+	}{
+		PostID: postID,
+	}
+
+	const q = `
+	DELETE FROM
+		post_mentions
+	WHERE
+		post_id = :post_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("deleting mentions of postID[%s]: %w", postID, err)
+	}
+
+	return nil
+}
+
+// QueryMentions gets the mentions of the specified posts in the order they
+// appear.
+func (s Store) QueryMentions(ctx context.Context, postIDs []string) ([]Mention, error) {
+	data := struct {
+		PostIDs pq.StringArray `db:"post_ids"`
+	}{
+		PostIDs: postIDs,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		post_mentions
+	WHERE
+		CAST(post_id AS TEXT) = ANY(:post_ids)
+	ORDER BY
+		post_id, start_offset`
+
+	var ms []Mention
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &ms); err != nil {
+		return nil, fmt.Errorf("selecting mentions: %w", err)
+	}
+
+	return ms, nil
+}
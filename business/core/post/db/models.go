@@ -2,15 +2,62 @@ package db
 
 import (
 	"time"
+
+	"github.com/lib/pq"
 )
 
 // Post represent the structure we need for moving data
 // between the app and the database.
 type Post struct {
-	ID          string    `db:"post_id"`
+	ID            string         `db:"post_id"`
+	Title         string         `db:"title"`
+	Description   string         `db:"description"`
+	UserID        string         `db:"user_id"`
+	DateCreated   time.Time      `db:"date_created"`
+	DateUpdated   time.Time      `db:"date_updated"`
+	Edited        bool           `db:"edited"`
+	DeletedAt     *time.Time     `db:"deleted_at"`
+	Status        string         `db:"status"`
+	PublishAt     *time.Time     `db:"publish_at"`
+	Visibility    string         `db:"visibility"`
+	AttachmentIDs pq.StringArray `db:"attachment_ids"`
+}
+
+// Revision represents a previous version of a post that was replaced by
+// an edit.
+type Revision struct {
+	ID          string    `db:"revision_id"`
+	PostID      string    `db:"post_id"`
 	Title       string    `db:"title"`
 	Description string    `db:"description"`
+	EditorID    string    `db:"editor_id"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// Follower represents a user following the author of posts. It mirrors the
+// follow graph owned by the users service so the field names match the
+// user-followed events.
+type Follower struct {
+	FollowerID  string    `db:"follower_id"`
+	FolloweeID  string    `db:"user_id"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// Handle is the copy of the handle a user goes by, kept so mentions can be
+// resolved locally.
+type Handle struct {
+	UserID string `db:"user_id"`
+	Handle string `db:"handle"`
+}
+
+// Mention records that a post mentions a user. Start and End are the offsets
+// of the mention in the description of the post.
+type Mention struct {
+	PostID      string    `db:"post_id"`
 	UserID      string    `db:"user_id"`
+	AuthorID    string    `db:"author_id"`
+	Handle      string    `db:"handle"`
+	Start       int       `db:"start_offset"`
+	End         int       `db:"end_offset"`
 	DateCreated time.Time `db:"date_created"`
-	DateUpdated time.Time `db:"date_updated"`
 }
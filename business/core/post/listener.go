@@ -0,0 +1,119 @@
+package post
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/dudakovict/social-network/business/core/post/db"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/jmoiron/sqlx"
+	"github.com/nats-io/stan.go"
+	"go.uber.org/zap"
+)
+
+// Listener keeps the local copies of the follow graph and of the user
+// handles in sync with the events published by the users service.
+type Listener struct {
+	log   *zap.SugaredLogger
+	nats  *nats.NATS
+	store db.Store
+}
+
+// NewListener constructs a listener for follow events.
+func NewListener(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS) Listener {
+	return Listener{
+		log:   log,
+		nats:  nats,
+		store: db.NewStore(log, sqlxDB),
+	}
+}
+
+// Listen subscribes to all the follow and handle events.
+func (l Listener) Listen() error {
+	if err := l.UserFollowed(); err != nil {
+		return fmt.Errorf("user-followed: %w", err)
+	}
+	if err := l.UserUnfollowed(); err != nil {
+		return fmt.Errorf("user-unfollowed: %w", err)
+	}
+	if err := l.UserHandleChanged(); err != nil {
+		return fmt.Errorf("user-handle-changed: %w", err)
+	}
+
+	return nil
+}
+
+// UserFollowed stores a copy of a new follow so followers only posts can be
+// checked locally.
+func (l Listener) UserFollowed() error {
+	return l.nats.Subscribe("user-followed", "posts", func(m *stan.Msg) {
+		buf := bytes.NewReader(m.Data)
+		dec := gob.NewDecoder(buf)
+
+		var dbF db.Follower
+
+		if err := dec.Decode(&dbF); err != nil {
+			l.log.Errorw("user-followed", "ERROR", fmt.Errorf("decoding: %w", err))
+			return
+		}
+
+		if err := l.store.CreateFollower(context.Background(), dbF); err != nil {
+			l.log.Errorw("user-followed", "ERROR", fmt.Errorf("create: %w", err))
+			return
+		}
+
+		m.Ack()
+	})
+}
+
+// UserUnfollowed removes the copy of a follow.
+func (l Listener) UserUnfollowed() error {
+	return l.nats.Subscribe("user-unfollowed", "posts", func(m *stan.Msg) {
+		buf := bytes.NewReader(m.Data)
+		dec := gob.NewDecoder(buf)
+
+		var dbF db.Follower
+
+		if err := dec.Decode(&dbF); err != nil {
+			l.log.Errorw("user-unfollowed", "ERROR", fmt.Errorf("decoding: %w", err))
+			return
+		}
+
+		if err := l.store.DeleteFollower(context.Background(), dbF); err != nil {
+			l.log.Errorw("user-unfollowed", "ERROR", fmt.Errorf("delete: %w", err))
+			return
+		}
+
+		m.Ack()
+	})
+}
+
+// UserHandleChanged stores a copy of the handle a user goes by so mentions
+// can be resolved locally. An empty handle means it was released.
+func (l Listener) UserHandleChanged() error {
+	return l.nats.Subscribe("user-handle-changed", "posts", func(m *stan.Msg) {
+		buf := bytes.NewReader(m.Data)
+		dec := gob.NewDecoder(buf)
+
+		var dbH db.Handle
+
+		if err := dec.Decode(&dbH); err != nil {
+			l.log.Errorw("user-handle-changed", "ERROR", fmt.Errorf("decoding: %w", err))
+			return
+		}
+
+		save := l.store.SaveHandle
+		if dbH.Handle == "" {
+			save = l.store.DeleteHandle
+		}
+
+		if err := save(context.Background(), dbH); err != nil {
+			l.log.Errorw("user-handle-changed", "ERROR", fmt.Errorf("save: %w", err))
+			return
+		}
+
+		m.Ack()
+	})
+}
@@ -5,23 +5,84 @@ import (
 	"unsafe"
 
 	"github.com/dudakovict/social-network/business/core/post/db"
+	"github.com/dudakovict/social-network/foundation/diff"
 )
 
-// Post represents an individual post.
+// Set of statuses a post moves through on its way to being published.
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+)
+
+// Set of audiences a post can be shared with.
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityPrivate   = "private"
+)
+
+// Post represents an individual post. PublishAt is when the post went, or is
+// scheduled to go, live.
 type Post struct {
+	ID            string     `json:"id"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	UserID        string     `json:"user_id"`
+	DateCreated   time.Time  `json:"date_created"`
+	DateUpdated   time.Time  `json:"date_updated"`
+	Edited        bool       `json:"edited"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	Status        string     `json:"status"`
+	PublishAt     *time.Time `json:"publish_at,omitempty"`
+	Visibility    string     `json:"visibility"`
+	AttachmentIDs []string   `json:"attachment_ids"`
+}
+
+// Revision represents a previous version of a post. The editor is the user
+// whose edit replaced this version.
+type Revision struct {
 	ID          string    `json:"id"`
+	PostID      string    `json:"post_id"`
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
-	UserID      string    `json:"user_id"`
+	EditorID    string    `json:"editor_id"`
 	DateCreated time.Time `json:"date_created"`
-	DateUpdated time.Time `json:"date_updated"`
 }
 
-// NewPost contains information needed to create a new Post.
+// Mention represents a user mentioned in the description of a post. Start
+// and End are offsets in Unicode code points, End is exclusive, covering the
+// @ sign and the handle.
+type Mention struct {
+	PostID      string    `json:"-"`
+	UserID      string    `json:"user_id"`
+	AuthorID    string    `json:"-"`
+	Handle      string    `json:"handle"`
+	Start       int       `json:"start"`
+	End         int       `json:"end"`
+	DateCreated time.Time `json:"-"`
+}
+
+// RevisionDiff represents the line by line differences between two
+// revisions of a post.
+type RevisionDiff struct {
+	From        string      `json:"from"`
+	To          string      `json:"to"`
+	Title       []diff.Line `json:"title"`
+	Description []diff.Line `json:"description"`
+}
+
+// NewPost contains information needed to create a new Post. A post without
+// a status is published right away, a scheduled post needs a PublishAt. Posts
+// are public unless a narrower visibility is requested.
 type NewPost struct {
-	Title       string `json:"title" validate:"required"`
-	Description string `json:"description" validate:"required"`
-	UserID      string `json:"user_id" validate:"required"`
+	Title         string     `json:"title" validate:"required,max=200"`
+	Description   string     `json:"description" validate:"required,max=10000"`
+	UserID        string     `json:"-" validate:"required"`
+	Status        string     `json:"status" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt     *time.Time `json:"publish_at" validate:"required_if=Status scheduled"`
+	Visibility    string     `json:"visibility" validate:"omitempty,oneof=public followers private"`
+	AttachmentIDs []string   `json:"attachment_ids" validate:"max=10,dive,uuid"`
 }
 
 // UpdatePost defines what information may be provided to modify an existing
@@ -31,8 +92,12 @@ type NewPost struct {
 // we do not want to use pointers to basic types but we make exceptions around
 // marshalling/unmarshalling.
 type UpdatePost struct {
-	Title       *string `json:"title"`
-	Description *string `json:"description"`
+	Title         *string    `json:"title" validate:"omitempty,max=200"`
+	Description   *string    `json:"description" validate:"omitempty,max=10000"`
+	Status        *string    `json:"status" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt     *time.Time `json:"publish_at"`
+	Visibility    *string    `json:"visibility" validate:"omitempty,oneof=public followers private"`
+	AttachmentIDs []string   `json:"attachment_ids" validate:"omitempty,max=10,dive,uuid"`
 }
 
 // =============================================================================
@@ -49,3 +114,21 @@ func toPostSlice(dbPs []db.Post) []Post {
 	}
 	return posts
 }
+
+func toRevision(dbRev db.Revision) Revision {
+	ru := (*Revision)(unsafe.Pointer(&dbRev))
+	return *ru
+}
+
+func toRevisionSlice(dbRevs []db.Revision) []Revision {
+	revs := make([]Revision, len(dbRevs))
+	for i, dbRev := range dbRevs {
+		revs[i] = toRevision(dbRev)
+	}
+	return revs
+}
+
+func toMention(dbM db.Mention) Mention {
+	mu := (*Mention)(unsafe.Pointer(&dbM))
+	return *mu
+}
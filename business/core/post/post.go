@@ -9,13 +9,17 @@ import (
 	"encoding/gob"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/dudakovict/social-network/business/core/post/db"
 	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/mention"
 	"github.com/dudakovict/social-network/business/sys/nats"
 	"github.com/dudakovict/social-network/business/sys/validate"
+	"github.com/dudakovict/social-network/foundation/diff"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -24,8 +28,23 @@ var (
 	ErrNotFound              = errors.New("post not found")
 	ErrInvalidID             = errors.New("ID is not in its proper form")
 	ErrAuthenticationFailure = errors.New("authentication failed")
+	ErrRevisionNotFound      = errors.New("revision not found")
+	ErrRetentionExpired      = errors.New("post can no longer be restored")
+	ErrInvalidSchedule       = errors.New("post must be scheduled to publish in the future")
+	ErrAlreadyPublished      = errors.New("post is already published")
+	ErrInvalidAttachment     = errors.New("attachment does not exist or belongs to another user")
 )
 
+// TrashRetention is how long a deleted post is kept in the trash before it
+// is purged for good.
+const TrashRetention = 30 * 24 * time.Hour
+
+// CurrentRevision stands for the post as it is now when comparing revisions.
+const CurrentRevision = "current"
+
+// publishBatch caps how many scheduled posts are published in one go.
+const publishBatch = 100
+
 // Core manages the set of API's for post access.
 type Core struct {
 	store db.Store
@@ -55,10 +74,34 @@ func (c Core) Create(ctx context.Context, np NewPost, now time.Time) (Post, erro
 		DateUpdated: now,
 	}
 
+	dbP.Visibility = np.Visibility
+	if dbP.Visibility == "" {
+		dbP.Visibility = VisibilityPublic
+	}
+
+	if err := c.attach(ctx, &dbP, np.AttachmentIDs); err != nil {
+		return Post{}, err
+	}
+
+	status := np.Status
+	if status == "" {
+		status = StatusPublished
+	}
+
+	if err := schedule(&dbP, status, np.PublishAt, now); err != nil {
+		return Post{}, err
+	}
+
+	var mentions []db.Mention
 	tran := func(tx sqlx.ExtContext) error {
 		if err := c.store.Tran(tx).Create(ctx, dbP); err != nil {
 			return fmt.Errorf("create: %w", err)
 		}
+
+		var err error
+		if mentions, _, err = c.mention(ctx, c.store.Tran(tx), dbP, now); err != nil {
+			return fmt.Errorf("mention: %w", err)
+		}
 		return nil
 	}
 
@@ -66,22 +109,23 @@ func (c Core) Create(ctx context.Context, np NewPost, now time.Time) (Post, erro
 		return Post{}, fmt.Errorf("tran: %w", err)
 	}
 
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-
-	if err := enc.Encode(&dbP); err != nil {
-		return Post{}, fmt.Errorf("encoding: %w", err)
-	}
-
-	if err := c.nats.Client.Publish("post-created", buf.Bytes()); err != nil {
-		return Post{}, fmt.Errorf("pub: %w", err)
+	if dbP.Status == StatusPublished {
+		if err := c.publish("post-created", dbP); err != nil {
+			return Post{}, fmt.Errorf("pub: %w", err)
+		}
+		if err := c.notify(ctx, dbP, mentions); err != nil {
+			return Post{}, fmt.Errorf("notify: %w", err)
+		}
 	}
 
 	return toPost(dbP), nil
 }
 
-// Update replaces a post document in the database.
-func (c Core) Update(ctx context.Context, postID string, up UpdatePost, now time.Time) error {
+// Update replaces a post document in the database. When the title or the
+// description change, the version being replaced is kept as a revision
+// attributed to the editor and the post is marked as edited. Drafts and scheduled posts
+// can be rescheduled or published, published posts stay published.
+func (c Core) Update(ctx context.Context, postID string, editorID string, up UpdatePost, now time.Time) error {
 	if err := validate.CheckID(postID); err != nil {
 		return ErrInvalidID
 	}
@@ -98,48 +142,236 @@ func (c Core) Update(ctx context.Context, postID string, up UpdatePost, now time
 		return fmt.Errorf("updating post postID[%s]: %w", postID, err)
 	}
 
+	dbRev := db.Revision{
+		ID:          validate.GenerateID(),
+		PostID:      dbP.ID,
+		Title:       dbP.Title,
+		Description: dbP.Description,
+		EditorID:    editorID,
+		DateCreated: now,
+	}
+
 	if up.Title != nil {
 		dbP.Title = *up.Title
 	}
 	if up.Description != nil {
 		dbP.Description = *up.Description
 	}
+	if up.Visibility != nil {
+		dbP.Visibility = *up.Visibility
+	}
+	if up.AttachmentIDs != nil {
+		if err := c.attach(ctx, &dbP, up.AttachmentIDs); err != nil {
+			return err
+		}
+	}
 	dbP.DateUpdated = now
 
-	if err := c.store.Update(ctx, dbP); err != nil {
-		return fmt.Errorf("udpate: %w", err)
+	changed := dbP.Title != dbRev.Title || dbP.Description != dbRev.Description
+	if changed {
+		dbP.Edited = true
 	}
 
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
+	wasPublished := dbP.Status == StatusPublished
+	if up.Status != nil || up.PublishAt != nil {
+		status := dbP.Status
+		if up.Status != nil {
+			status = *up.Status
+		}
 
-	if err := enc.Encode(&dbP); err != nil {
-		return fmt.Errorf("encoding: %w", err)
+		if wasPublished && status != StatusPublished {
+			return ErrAlreadyPublished
+		}
+
+		if !wasPublished {
+			publishAt := dbP.PublishAt
+			if up.PublishAt != nil {
+				publishAt = up.PublishAt
+			}
+
+			if err := schedule(&dbP, status, publishAt, now); err != nil {
+				return err
+			}
+		}
 	}
 
-	if err := c.nats.Client.Publish("post-updated", buf.Bytes()); err != nil {
-		return fmt.Errorf("pub: %w", err)
+	var mentions, added []db.Mention
+	tran := func(tx sqlx.ExtContext) error {
+		if changed {
+			if err := c.store.Tran(tx).CreateRevision(ctx, dbRev); err != nil {
+				return fmt.Errorf("create revision: %w", err)
+			}
+		}
+		if err := c.store.Tran(tx).Update(ctx, dbP); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+
+		var err error
+		if mentions, added, err = c.mention(ctx, c.store.Tran(tx), dbP, now); err != nil {
+			return fmt.Errorf("mention: %w", err)
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	// Users mentioned in a published post were told already, the others
+	// are told once the post is published.
+	switch {
+	case wasPublished:
+		if err := c.publish("post-updated", dbP); err != nil {
+			return fmt.Errorf("pub: %w", err)
+		}
+		if err := c.notify(ctx, dbP, added); err != nil {
+			return fmt.Errorf("notify: %w", err)
+		}
+	case dbP.Status == StatusPublished:
+		if err := c.publish("post-created", dbP); err != nil {
+			return fmt.Errorf("pub: %w", err)
+		}
+		if err := c.notify(ctx, dbP, mentions); err != nil {
+			return fmt.Errorf("notify: %w", err)
+		}
 	}
 
 	return nil
 }
 
-// Delete removes a post from the database.
-func (c Core) Delete(ctx context.Context, postID string) error {
+// Delete moves a post into the trash. It can be restored until it is purged
+// once the TrashRetention period is over.
+func (c Core) Delete(ctx context.Context, postID string, now time.Time) error {
 	if err := validate.CheckID(postID); err != nil {
 		return ErrInvalidID
 	}
 
-	if err := c.store.Delete(ctx, postID); err != nil {
+	dbP, err := c.store.QueryByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("deleting post postID[%s]: %w", postID, err)
+	}
+
+	dbP.DeletedAt = &now
+
+	if err := c.store.Delete(ctx, dbP); err != nil {
 		return fmt.Errorf("delete: %w", err)
 	}
 
+	if dbP.Status == StatusPublished {
+		if err := c.publish("post-deleted", dbP); err != nil {
+			return fmt.Errorf("pub: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Restore takes a post out of the trash as long as it was deleted within the
+// TrashRetention period.
+func (c Core) Restore(ctx context.Context, postID string, now time.Time) error {
+	if err := validate.CheckID(postID); err != nil {
+		return ErrInvalidID
+	}
+
+	dbP, err := c.store.QueryDeletedByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("restoring post postID[%s]: %w", postID, err)
+	}
+
+	if dbP.DeletedAt.Before(now.Add(-TrashRetention)) {
+		return ErrRetentionExpired
+	}
+
+	if err := c.store.Restore(ctx, postID); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+
+	if dbP.Status == StatusPublished {
+		if err := c.publish("post-restored", dbP); err != nil {
+			return fmt.Errorf("pub: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Purge permanently removes the posts that have been in the trash for longer
+// than the TrashRetention period.
+func (c Core) Purge(ctx context.Context, now time.Time) error {
+	if err := c.store.Purge(ctx, now.Add(-TrashRetention)); err != nil {
+		return fmt.Errorf("purge: %w", err)
+	}
+
+	return nil
+}
+
+// PublishDue publishes the scheduled posts whose publish time has come and
+// announces each of them with a post-created event. The posts are claimed in
+// a transaction of their own so concurrent runs never claim the same post,
+// and they are only announced once the claim is committed. A failed
+// announcement is not retried, so the post is never announced twice.
+func (c Core) PublishDue(ctx context.Context, now time.Time) ([]Post, error) {
+	dbPosts, err := c.store.PublishDue(ctx, now, publishBatch)
+	if err != nil {
+		return nil, fmt.Errorf("publish due: %w", err)
+	}
+
+	var errs []string
+	for _, dbP := range dbPosts {
+		if err := c.announce(ctx, dbP); err != nil {
+			errs = append(errs, fmt.Sprintf("postID[%s]: %s", dbP.ID, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return toPostSlice(dbPosts), fmt.Errorf("announce: %s", strings.Join(errs, "; "))
+	}
+
+	return toPostSlice(dbPosts), nil
+}
+
+// announce tells the followers and the mentioned users about a post that
+// went live.
+func (c Core) announce(ctx context.Context, dbP db.Post) error {
+	if err := c.publish("post-created", dbP); err != nil {
+		return fmt.Errorf("pub: %w", err)
+	}
+
+	mentions, err := c.store.QueryMentions(ctx, []string{dbP.ID})
+	if err != nil {
+		return fmt.Errorf("query mentions: %w", err)
+	}
+	if err := c.notify(ctx, dbP, mentions); err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+
 	return nil
 }
 
-// Query retrieves a list of existing posts from the database.
-func (c Core) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Post, error) {
-	dbPosts, err := c.store.Query(ctx, pageNumber, rowsPerPage)
+// QueryDrafts retrieves the drafts and scheduled posts of the specified user.
+func (c Core) QueryDrafts(ctx context.Context, userID string) ([]Post, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
+
+	dbPosts, err := c.store.QueryUnpublishedByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toPostSlice(dbPosts), nil
+}
+
+// Query retrieves a list of existing posts the viewer is allowed to see from
+// the database.
+func (c Core) Query(ctx context.Context, viewerID string, pageNumber int, rowsPerPage int) ([]Post, error) {
+	dbPosts, err := c.store.Query(ctx, viewerID, pageNumber, rowsPerPage)
 	if err != nil {
 		if errors.Is(err, database.ErrDBNotFound) {
 			return nil, ErrNotFound
@@ -150,6 +382,25 @@ func (c Core) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]Pos
 	return toPostSlice(dbPosts), nil
 }
 
+// QueryMentions gets the mentions of the specified posts keyed by post ID.
+func (c Core) QueryMentions(ctx context.Context, postIDs ...string) (map[string][]Mention, error) {
+	mentions := make(map[string][]Mention)
+	if len(postIDs) == 0 {
+		return mentions, nil
+	}
+
+	dbMentions, err := c.store.QueryMentions(ctx, postIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	for _, dbM := range dbMentions {
+		mentions[dbM.PostID] = append(mentions[dbM.PostID], toMention(dbM))
+	}
+
+	return mentions, nil
+}
+
 // QueryByID gets the specified post from the database.
 func (c Core) QueryByID(ctx context.Context, postID string) (Post, error) {
 	if err := validate.CheckID(postID); err != nil {
@@ -167,16 +418,361 @@ func (c Core) QueryByID(ctx context.Context, postID string) (Post, error) {
 	return toPost(dbP), nil
 }
 
-func (c Core) QueryByUserID(ctx context.Context, userID string) ([]Post, error) {
+// QueryVisibleByID gets the specified post from the database as long as the
+// viewer is allowed to see it. Posts the viewer can not see are reported as
+// not found so their existence is not revealed.
+func (c Core) QueryVisibleByID(ctx context.Context, postID string, viewerID string) (Post, error) {
+	p, err := c.QueryByID(ctx, postID)
+	if err != nil {
+		return Post{}, err
+	}
+
+	ok, err := c.canView(ctx, p, viewerID)
+	if err != nil {
+		return Post{}, fmt.Errorf("can view: %w", err)
+	}
+	if !ok {
+		return Post{}, ErrNotFound
+	}
+
+	return p, nil
+}
+
+// CanViewAttachment reports whether the viewer is allowed to see a post the
+// specified attachment is attached to.
+func (c Core) CanViewAttachment(ctx context.Context, attachmentID string, viewerID string) (bool, error) {
+	if err := validate.CheckID(attachmentID); err != nil {
+		return false, ErrInvalidID
+	}
+
+	dbPosts, err := c.store.QueryByAttachmentID(ctx, strings.ToLower(attachmentID))
+	if err != nil {
+		return false, fmt.Errorf("query: %w", err)
+	}
+
+	for _, p := range toPostSlice(dbPosts) {
+		ok, err := c.canView(ctx, p, viewerID)
+		if err != nil {
+			return false, fmt.Errorf("can view: %w", err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// QueryByUserID retrieves the published posts of the specified user that the
+// viewer is allowed to see.
+func (c Core) QueryByUserID(ctx context.Context, userID string, viewerID string) ([]Post, error) {
 	if err := validate.CheckID(userID); err != nil {
 		return nil, ErrInvalidID
 	}
 
-	dbPosts, err := c.store.QueryByUserID(ctx, userID)
+	dbPosts, err := c.store.QueryByUserID(ctx, userID, viewerID)
+
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toPostSlice(dbPosts), nil
+}
+
+// QueryDeletedByID gets the specified post from the trash.
+func (c Core) QueryDeletedByID(ctx context.Context, postID string) (Post, error) {
+	if err := validate.CheckID(postID); err != nil {
+		return Post{}, ErrInvalidID
+	}
+
+	dbP, err := c.store.QueryDeletedByID(ctx, postID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Post{}, ErrNotFound
+		}
+		return Post{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toPost(dbP), nil
+}
+
+// QueryTrash retrieves the posts of the specified user that are in the trash.
+func (c Core) QueryTrash(ctx context.Context, userID string) ([]Post, error) {
+	if err := validate.CheckID(userID); err != nil {
+		return nil, ErrInvalidID
+	}
 
+	dbPosts, err := c.store.QueryDeletedByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("query: %w", err)
 	}
 
 	return toPostSlice(dbPosts), nil
 }
+
+// QueryRevisions retrieves the previous versions of the specified post from
+// the newest to the oldest.
+func (c Core) QueryRevisions(ctx context.Context, postID string) ([]Revision, error) {
+	if _, err := c.QueryByID(ctx, postID); err != nil {
+		return nil, err
+	}
+
+	dbRevs, err := c.store.QueryRevisions(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toRevisionSlice(dbRevs), nil
+}
+
+// QueryRevisionByID gets the specified revision of a post from the database.
+func (c Core) QueryRevisionByID(ctx context.Context, postID string, revisionID string) (Revision, error) {
+	if err := validate.CheckID(postID); err != nil {
+		return Revision{}, ErrInvalidID
+	}
+	if err := validate.CheckID(revisionID); err != nil {
+		return Revision{}, ErrInvalidID
+	}
+
+	dbRev, err := c.store.QueryRevisionByID(ctx, postID, revisionID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Revision{}, ErrRevisionNotFound
+		}
+		return Revision{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toRevision(dbRev), nil
+}
+
+// DiffRevisions computes the differences between two revisions of a post.
+// Either revision can be CurrentRevision to compare with the post as it is
+// now.
+func (c Core) DiffRevisions(ctx context.Context, postID string, fromID string, toID string) (RevisionDiff, error) {
+	from, err := c.revision(ctx, postID, fromID)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+
+	to, err := c.revision(ctx, postID, toID)
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+
+	rd := RevisionDiff{
+		From:        from.ID,
+		To:          to.ID,
+		Title:       diff.Lines(from.Title, to.Title),
+		Description: diff.Lines(from.Description, to.Description),
+	}
+
+	return rd, nil
+}
+
+// revision gets the specified revision of a post, or the post as it is now
+// for CurrentRevision.
+func (c Core) revision(ctx context.Context, postID string, revisionID string) (Revision, error) {
+	if revisionID != CurrentRevision {
+		return c.QueryRevisionByID(ctx, postID, revisionID)
+	}
+
+	p, err := c.QueryByID(ctx, postID)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	rev := Revision{
+		ID:          CurrentRevision,
+		PostID:      p.ID,
+		Title:       p.Title,
+		Description: p.Description,
+		EditorID:    p.UserID,
+		DateCreated: p.DateUpdated,
+	}
+
+	return rev, nil
+}
+
+// =============================================================================
+
+// canView reports whether the viewer is allowed to see the post. Authors see
+// all of their posts, everyone else only sees published posts that are public
+// or followers only posts of users they follow.
+func (c Core) canView(ctx context.Context, p Post, viewerID string) (bool, error) {
+	if p.UserID == viewerID {
+		return true, nil
+	}
+
+	if p.Status != StatusPublished {
+		return false, nil
+	}
+
+	switch p.Visibility {
+	case VisibilityPublic:
+		return true, nil
+	case VisibilityFollowers:
+		return c.store.IsFollower(ctx, p.UserID, viewerID)
+	}
+
+	return false, nil
+}
+
+// schedule applies the publishing status to the post. Published posts go
+// live now and scheduled posts must go live in the future.
+func schedule(dbP *db.Post, status string, publishAt *time.Time, now time.Time) error {
+	switch status {
+	case StatusDraft:
+		dbP.PublishAt = nil
+	case StatusScheduled:
+		if publishAt == nil || !publishAt.After(now) {
+			return ErrInvalidSchedule
+		}
+		at := publishAt.UTC()
+		dbP.PublishAt = &at
+	case StatusPublished:
+		dbP.PublishAt = &now
+	}
+	dbP.Status = status
+
+	return nil
+}
+
+// attach sets the attachments of the post. Authors can only attach what
+// they uploaded themselves.
+func (c Core) attach(ctx context.Context, dbP *db.Post, attachmentIDs []string) error {
+	ids := pq.StringArray{}
+	seen := make(map[string]bool)
+	for _, id := range attachmentIDs {
+		id = strings.ToLower(id)
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) > 0 {
+		count, err := c.store.CountAttachments(ctx, dbP.UserID, ids)
+		if err != nil {
+			return fmt.Errorf("count attachments: %w", err)
+		}
+		if count != len(ids) {
+			return ErrInvalidAttachment
+		}
+	}
+
+	dbP.AttachmentIDs = ids
+	return nil
+}
+
+// mention resolves the handles mentioned in the description of the post and
+// stores the mentions, replacing the previous ones. Authors mentioning
+// themselves and handles nobody goes by are ignored. It returns all the
+// mentions along with those of users the post did not mention before.
+func (c Core) mention(ctx context.Context, store db.Store, dbP db.Post, now time.Time) ([]db.Mention, []db.Mention, error) {
+	previous, err := store.QueryMentions(ctx, []string{dbP.ID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("query mentions: %w", err)
+	}
+
+	mentioned := make(map[string]bool)
+	for _, dbM := range previous {
+		mentioned[dbM.UserID] = true
+	}
+
+	if err := store.DeleteMentions(ctx, dbP.ID); err != nil {
+		return nil, nil, fmt.Errorf("delete mentions: %w", err)
+	}
+
+	parsed := mention.Parse(dbP.Description)
+	if len(parsed) == 0 {
+		return nil, nil, nil
+	}
+
+	dbHandles, err := store.QueryHandles(ctx, mention.Handles(parsed))
+	if err != nil {
+		return nil, nil, fmt.Errorf("query handles: %w", err)
+	}
+
+	userIDs := make(map[string]string)
+	for _, dbH := range dbHandles {
+		userIDs[dbH.Handle] = dbH.UserID
+	}
+
+	var mentions, added []db.Mention
+	notified := make(map[string]bool)
+	for _, m := range parsed {
+		userID, exists := userIDs[m.Handle]
+		if !exists || userID == dbP.UserID {
+			continue
+		}
+
+		dbM := db.Mention{
+			PostID:      dbP.ID,
+			UserID:      userID,
+			AuthorID:    dbP.UserID,
+			Handle:      m.Handle,
+			Start:       m.Start,
+			End:         m.End,
+			DateCreated: now,
+		}
+		if err := store.CreateMention(ctx, dbM); err != nil {
+			return nil, nil, fmt.Errorf("create mention: %w", err)
+		}
+
+		mentions = append(mentions, dbM)
+		if !mentioned[userID] && !notified[userID] {
+			notified[userID] = true
+			added = append(added, dbM)
+		}
+	}
+
+	return mentions, added, nil
+}
+
+// notify publishes a user-mentioned event for every user mentioned in the
+// post that is allowed to see it. Users mentioned more than once are only
+// told once.
+func (c Core) notify(ctx context.Context, dbP db.Post, mentions []db.Mention) error {
+	seen := make(map[string]bool)
+	for _, dbM := range mentions {
+		if seen[dbM.UserID] {
+			continue
+		}
+		seen[dbM.UserID] = true
+
+		ok, err := c.canView(ctx, toPost(dbP), dbM.UserID)
+		if err != nil {
+			return fmt.Errorf("can view: %w", err)
+		}
+		if !ok {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(&dbM); err != nil {
+			return fmt.Errorf("encoding: %w", err)
+		}
+
+		if err := c.nats.Client.Publish("user-mentioned", buf.Bytes()); err != nil {
+			return fmt.Errorf("publishing user-mentioned: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// publish sends the post to the services listening on the specified subject.
+func (c Core) publish(subject string, dbP db.Post) error {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	if err := enc.Encode(&dbP); err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+
+	if err := c.nats.Client.Publish(subject, buf.Bytes()); err != nil {
+		return fmt.Errorf("publishing %s: %w", subject, err)
+	}
+
+	return nil
+}
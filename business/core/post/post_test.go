@@ -85,7 +85,7 @@ func TestPost(t *testing.T) {
 				Description: dbtest.StringPointer("I've just released a new album!"),
 			}
 
-			if err := core.Update(ctx, p.ID, upd, now); err != nil {
+			if err := core.Update(ctx, p.ID, p.UserID, upd, now); err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to update post : %s.", dbtest.Failed, testID, err)
 			}
 			t.Logf("\t%s\tTest %d:\tShould be able to update post.", dbtest.Success, testID)
@@ -112,7 +112,58 @@ func TestPost(t *testing.T) {
 				t.Logf("\t%s\tTest %d:\tShould be able to see updates to Description.", dbtest.Success, testID)
 			}
 
-			if err := core.Delete(ctx, p.ID); err != nil {
+			if !saved.Edited {
+				t.Errorf("\t%s\tTest %d:\tShould be able to see the post marked as edited.", dbtest.Failed, testID)
+			} else {
+				t.Logf("\t%s\tTest %d:\tShould be able to see the post marked as edited.", dbtest.Success, testID)
+			}
+
+			revs, err := core.QueryRevisions(ctx, p.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve post revisions : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve post revisions.", dbtest.Success, testID)
+
+			if len(revs) != 1 || revs[0].Title != p.Title || revs[0].Description != p.Description || revs[0].EditorID != p.UserID {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the original post as a revision : %+v.", dbtest.Failed, testID, revs)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back the original post as a revision.", dbtest.Success, testID)
+
+			upd = post.UpdatePost{
+				Description: dbtest.StringPointer("I've just released a new album!\nListen to it now."),
+			}
+
+			if err := core.Update(ctx, p.ID, p.UserID, upd, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update post again : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to update post again.", dbtest.Success, testID)
+
+			revs, err = core.QueryRevisions(ctx, p.ID)
+			if err != nil || len(revs) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve 2 post revisions : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve 2 post revisions.", dbtest.Success, testID)
+
+			if _, err := core.DiffRevisions(ctx, p.ID, revs[1].ID, revs[0].ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to diff post revisions : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to diff post revisions.", dbtest.Success, testID)
+
+			rd, err := core.DiffRevisions(ctx, p.ID, revs[0].ID, post.CurrentRevision)
+			if err != nil || rd.To != post.CurrentRevision || len(rd.Description) == 0 || rd.Description[len(rd.Description)-1].Text != "Listen to it now." {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to diff a revision against the current post : %+v %v.", dbtest.Failed, testID, rd, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to diff a revision against the current post.", dbtest.Success, testID)
+
+			if err := core.Update(ctx, p.ID, p.UserID, upd, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to save the post unchanged : %s.", dbtest.Failed, testID, err)
+			}
+			if revs, err := core.QueryRevisions(ctx, p.ID); err != nil || len(revs) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT keep a revision when nothing changed : %d %v.", dbtest.Failed, testID, len(revs), err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT keep a revision when nothing changed.", dbtest.Success, testID)
+
+			if err := core.Delete(ctx, p.ID, now); err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to delete post : %s.", dbtest.Failed, testID, err)
 			}
 			t.Logf("\t%s\tTest %d:\tShould be able to delete post.", dbtest.Success, testID)
@@ -123,7 +174,44 @@ func TestPost(t *testing.T) {
 			}
 			t.Logf("\t%s\tTest %d:\tShould NOT be able to retrieve post.", dbtest.Success, testID)
 
-			_, err = core.QueryByUserID(ctx, p.UserID)
+			trash, err := core.QueryTrash(ctx, p.UserID)
+			if err != nil || len(trash) != 1 || trash[0].ID != p.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to find the post in the trash : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to find the post in the trash.", dbtest.Success, testID)
+
+			late := now.Add(post.TrashRetention + time.Hour)
+			if err := core.Restore(ctx, p.ID, late); !errors.Is(err, post.ErrRetentionExpired) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to restore post after retention : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to restore post after retention.", dbtest.Success, testID)
+
+			if err := core.Restore(ctx, p.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to restore post : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to restore post.", dbtest.Success, testID)
+
+			if _, err := core.QueryByID(ctx, p.ID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve restored post : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve restored post.", dbtest.Success, testID)
+
+			if err := core.Delete(ctx, p.ID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete post again : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to delete post again.", dbtest.Success, testID)
+
+			if err := core.Purge(ctx, late); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to purge the trash : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to purge the trash.", dbtest.Success, testID)
+
+			if _, err := core.QueryDeletedByID(ctx, p.ID); !errors.Is(err, post.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to find purged post : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to find purged post.", dbtest.Success, testID)
+
+			_, err = core.QueryByUserID(ctx, p.UserID, p.UserID)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve posts by UserID : %s.", dbtest.Failed, testID, err)
 			}
@@ -132,6 +220,230 @@ func TestPost(t *testing.T) {
 	}
 }
 
+func TestSchedulePost(t *testing.T) {
+	log, db, n, teardown := dbtest.NewUnit(t, nc, dbc, "testschedule")
+	t.Cleanup(teardown)
+
+	core := post.NewCore(log, db, n)
+
+	t.Log("Given the need to publish Post records later.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling drafts and scheduled Posts.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+			userID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+			np := post.NewPost{
+				Title:       "New Song",
+				Description: "Check out my new song!",
+				UserID:      userID,
+				Status:      post.StatusDraft,
+			}
+
+			draft, err := core.Create(ctx, np, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create draft : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create draft.", dbtest.Success, testID)
+
+			np.Status = post.StatusScheduled
+			if _, err := core.Create(ctx, np, now); !errors.Is(err, post.ErrInvalidSchedule) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to schedule a post without a publish time : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to schedule a post without a publish time.", dbtest.Success, testID)
+
+			publishAt := now.Add(time.Hour)
+			np.PublishAt = &publishAt
+
+			scheduled, err := core.Create(ctx, np, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to schedule post : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to schedule post.", dbtest.Success, testID)
+
+			posts, err := core.QueryByUserID(ctx, userID, userID)
+			if err != nil || len(posts) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to see unpublished posts : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to see unpublished posts.", dbtest.Success, testID)
+
+			drafts, err := core.QueryDrafts(ctx, userID)
+			if err != nil || len(drafts) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to see drafts and scheduled posts : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to see drafts and scheduled posts.", dbtest.Success, testID)
+
+			published, err := core.PublishDue(ctx, now)
+			if err != nil || len(published) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT publish posts before their time : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT publish posts before their time.", dbtest.Success, testID)
+
+			published, err = core.PublishDue(ctx, publishAt)
+			if err != nil || len(published) != 1 || published[0].ID != scheduled.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould publish the scheduled post once due : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould publish the scheduled post once due.", dbtest.Success, testID)
+
+			published, err = core.PublishDue(ctx, publishAt)
+			if err != nil || len(published) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT publish the scheduled post twice : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT publish the scheduled post twice.", dbtest.Success, testID)
+
+			status := post.StatusPublished
+			if err := core.Update(ctx, draft.ID, userID, post.UpdatePost{Status: &status}, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to publish draft : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to publish draft.", dbtest.Success, testID)
+
+			status = post.StatusDraft
+			if err := core.Update(ctx, draft.ID, userID, post.UpdatePost{Status: &status}, now); !errors.Is(err, post.ErrAlreadyPublished) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to unpublish post : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to unpublish post.", dbtest.Success, testID)
+
+			posts, err = core.QueryByUserID(ctx, userID, userID)
+			if err != nil || len(posts) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to see published posts : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to see published posts.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestVisibilityPost(t *testing.T) {
+	log, db, n, teardown := dbtest.NewUnit(t, nc, dbc, "testvisibility")
+	t.Cleanup(teardown)
+
+	core := post.NewCore(log, db, n)
+
+	t.Log("Given the need to restrict who can see Post records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling followers only and private Posts.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+			authorID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+			viewerID := "5cf37266-3473-4006-984f-9325122678b7"
+
+			np := post.NewPost{
+				Title:       "New Song",
+				Description: "Check out my new song!",
+				UserID:      authorID,
+				Visibility:  post.VisibilityFollowers,
+			}
+
+			followers, err := core.Create(ctx, np, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create followers only post : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create followers only post.", dbtest.Success, testID)
+
+			np.Visibility = post.VisibilityPrivate
+			private, err := core.Create(ctx, np, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create private post : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create private post.", dbtest.Success, testID)
+
+			for _, p := range []post.Post{followers, private} {
+				if _, err := core.QueryVisibleByID(ctx, p.ID, authorID); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to see own %s post : %s.", dbtest.Failed, testID, p.Visibility, err)
+				}
+				if _, err := core.QueryVisibleByID(ctx, p.ID, viewerID); !errors.Is(err, post.ErrNotFound) {
+					t.Fatalf("\t%s\tTest %d:\tShould NOT be able to see someone else's %s post : %v.", dbtest.Failed, testID, p.Visibility, err)
+				}
+			}
+			t.Logf("\t%s\tTest %d:\tShould only be able to see restricted posts as the author.", dbtest.Success, testID)
+
+			const q = `INSERT INTO followers (user_id, follower_id, date_created) VALUES ($1, $2, $3)`
+			if _, err := db.ExecContext(ctx, q, authorID, viewerID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to follow the author : %s.", dbtest.Failed, testID, err)
+			}
+
+			if _, err := core.QueryVisibleByID(ctx, followers.ID, viewerID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to see followers only post as a follower : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to see followers only post as a follower.", dbtest.Success, testID)
+
+			if _, err := core.QueryVisibleByID(ctx, private.ID, viewerID); !errors.Is(err, post.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to see private post as a follower : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to see private post as a follower.", dbtest.Success, testID)
+
+			posts, err := core.QueryByUserID(ctx, authorID, viewerID)
+			if err != nil || len(posts) != 1 || posts[0].ID != followers.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould only list the posts a follower can see : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only list the posts a follower can see.", dbtest.Success, testID)
+		}
+	}
+}
+
+func TestMentionPost(t *testing.T) {
+	log, db, n, teardown := dbtest.NewUnit(t, nc, dbc, "testmention")
+	t.Cleanup(teardown)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dbschema.Seed(ctx, db)
+
+	core := post.NewCore(log, db, n)
+
+	t.Log("Given the need to mention users in Post records.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen mentioning users by handle.", testID)
+		{
+			now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+			adminID := "5cf37266-3473-4006-984f-9325122678b7"
+			userID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+			np := post.NewPost{
+				Title:       "New Song",
+				Description: "Thanks @User_Gopher and @admin_gopher, hi @nobody_here",
+				UserID:      adminID,
+			}
+
+			p, err := core.Create(ctx, np, now)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create post : %s.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to create post.", dbtest.Success, testID)
+
+			mentions, err := core.QueryMentions(ctx, p.ID)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to query mentions : %s.", dbtest.Failed, testID, err)
+			}
+
+			got := mentions[p.ID]
+			if len(got) != 1 || got[0].UserID != userID || got[0].Handle != "user_gopher" || got[0].Start != 7 || got[0].End != 19 {
+				t.Fatalf("\t%s\tTest %d:\tShould only mention other known users : %+v.", dbtest.Failed, testID, got)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only mention other known users.", dbtest.Success, testID)
+
+			upd := post.UpdatePost{
+				Description: dbtest.StringPointer("Never mind"),
+			}
+
+			if err := core.Update(ctx, p.ID, adminID, upd, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update post : %s.", dbtest.Failed, testID, err)
+			}
+
+			mentions, err = core.QueryMentions(ctx, p.ID)
+			if err != nil || len(mentions[p.ID]) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould drop the mentions removed by an edit : %+v %v.", dbtest.Failed, testID, mentions, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould drop the mentions removed by an edit.", dbtest.Success, testID)
+		}
+	}
+}
+
 func TestPagingPost(t *testing.T) {
 	log, db, n, teardown := dbtest.NewUnit(t, nc, dbc, "testpaging")
 	t.Cleanup(teardown)
@@ -149,8 +461,9 @@ func TestPagingPost(t *testing.T) {
 		t.Logf("\tTest %d:\tWhen paging through 2 posts.", testID)
 		{
 			ctx := context.Background()
+			viewerID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
 
-			posts1, err := post.Query(ctx, 1, 1)
+			posts1, err := post.Query(ctx, viewerID, 1, 1)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve posts for page 1 : %s.", dbtest.Failed, testID, err)
 			}
@@ -161,7 +474,7 @@ func TestPagingPost(t *testing.T) {
 			}
 			t.Logf("\t%s\tTest %d:\tShould have a single post.", dbtest.Success, testID)
 
-			posts2, err := post.Query(ctx, 2, 1)
+			posts2, err := post.Query(ctx, viewerID, 2, 1)
 			if err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve posts for page 2 : %s.", dbtest.Failed, testID, err)
 			}
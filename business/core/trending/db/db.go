@@ -0,0 +1,187 @@
+// Package db contains trending post related CRUD functionality.
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of API's for post score access.
+type Store struct {
+	log          *zap.SugaredLogger
+	tr           database.Transactor
+	db           sqlx.ExtContext
+	isWithinTran bool
+}
+
+// NewStore constructs a data for api access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) Store {
+	return Store{
+		log: log,
+		tr:  db,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and do commit/rollback at the end.
+func (s Store) WithinTran(ctx context.Context, fn func(sqlx.ExtContext) error) error {
+	if s.isWithinTran {
+		return fn(s.db)
+	}
+	return database.WithinTran(ctx, s.log, s.tr, fn)
+}
+
+// Tran return new Store with transaction in it.
+func (s Store) Tran(tx sqlx.ExtContext) Store {
+	return Store{
+		log:          s.log,
+		tr:           s.tr,
+		db:           tx,
+		isWithinTran: true,
+	}
+}
+
+// Add adds to the score of a post. Scores are kept in log space so adding
+// is done with log-sum-exp. Posts that no longer exist are ignored.
+func (s Store) Add(ctx context.Context, sc Score) error {
+	const q = `
+	INSERT INTO post_scores
+		(post_id, score, comments)
+	SELECT
+		post_id,
+		CAST(:score AS DOUBLE PRECISION),
+		GREATEST(CAST(:comments AS INT), 0)
+	FROM
+		posts
+	WHERE
+		post_id = :post_id
+	ON CONFLICT (post_id) DO UPDATE SET
+		score = GREATEST(post_scores.score, EXCLUDED.score) + LN(1 + EXP(-ABS(post_scores.score - EXCLUDED.score))),
+		comments = GREATEST(post_scores.comments + :comments, 0)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, sc); err != nil {
+		return fmt.Errorf("adding score of postID[%s]: %w", sc.PostID, err)
+	}
+
+	return nil
+}
+
+// Subtract takes a previously added amount off the score of a post.
+func (s Store) Subtract(ctx context.Context, sc Score) error {
+	const q = `
+	UPDATE
+		post_scores
+	SET
+		score = score + LN(GREATEST(1 - EXP(LEAST(:score - score, 0)), 1e-12)),
+		comments = GREATEST(comments - :comments, 0)
+	WHERE
+		post_id = :post_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, sc); err != nil {
+		return fmt.Errorf("subtracting score of postID[%s]: %w", sc.PostID, err)
+	}
+
+	return nil
+}
+
+// Count marks the post or comment as counted in the score of its post. It
+// reports false when it is counted already, or the post no longer exists,
+// so the score is not changed twice for an event delivered again.
+func (s Store) Count(ctx context.Context, ev Event) (bool, error) {
+	const q = `
+	INSERT INTO post_score_events
+		(event_id, post_id, counted)
+	SELECT
+		:event_id, post_id, TRUE
+	FROM
+		posts
+	WHERE
+		post_id = :post_id
+	ON CONFLICT (event_id) DO UPDATE SET
+		counted = TRUE
+	WHERE
+		post_score_events.counted = FALSE
+	RETURNING
+		event_id`
+
+	var result struct {
+		ID string `db:"event_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, ev, &result); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("counting eventID[%s]: %w", ev.ID, err)
+	}
+
+	return true, nil
+}
+
+// Uncount marks the comment as no longer counted in the score of its post.
+// It reports false when it is not counted.
+func (s Store) Uncount(ctx context.Context, ev Event) (bool, error) {
+	const q = `
+	UPDATE
+		post_score_events
+	SET
+		counted = FALSE
+	WHERE
+		event_id = :event_id AND
+		counted = TRUE
+	RETURNING
+		event_id`
+
+	var result struct {
+		ID string `db:"event_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, ev, &result); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("uncounting eventID[%s]: %w", ev.ID, err)
+	}
+
+	return true, nil
+}
+
+// QueryTop retrieves the public posts published since the specified time
+// with the highest scores.
+func (s Store) QueryTop(ctx context.Context, since time.Time, limit int) ([]Ranked, error) {
+	data := struct {
+		Since time.Time `db:"since"`
+		Limit int       `db:"limit"`
+	}{
+		Since: since,
+		Limit: limit,
+	}
+
+	const q = `
+	SELECT
+		p.post_id, p.title, p.description, p.user_id, p.date_created, p.date_updated,
+		p.edited, p.publish_at, p.visibility, s.comments, s.score
+	FROM
+		post_scores s
+	JOIN
+		posts p ON p.post_id = s.post_id
+	WHERE
+		p.status = 'published' AND
+		p.visibility = 'public' AND
+		p.deleted_at IS NULL AND
+		COALESCE(p.publish_at, p.date_created) >= :since
+	ORDER BY
+		s.score DESC, p.post_id
+	LIMIT :limit`
+
+	var rs []Ranked
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &rs); err != nil {
+		return nil, fmt.Errorf("selecting top posts: %w", err)
+	}
+
+	return rs, nil
+}
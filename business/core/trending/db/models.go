@@ -0,0 +1,49 @@
+package db
+
+import (
+	"time"
+)
+
+// Post represents the part of the post events needed to rank posts.
+type Post struct {
+	ID          string     `db:"post_id"`
+	DateCreated time.Time  `db:"date_created"`
+	PublishAt   *time.Time `db:"publish_at"`
+}
+
+// Ranked represent the structure we need for moving data
+// between the app and the database.
+type Ranked struct {
+	ID          string     `db:"post_id"`
+	Title       string     `db:"title"`
+	Description string     `db:"description"`
+	UserID      string     `db:"user_id"`
+	DateCreated time.Time  `db:"date_created"`
+	DateUpdated time.Time  `db:"date_updated"`
+	Edited      bool       `db:"edited"`
+	PublishAt   *time.Time `db:"publish_at"`
+	Visibility  string     `db:"visibility"`
+	Comments    int        `db:"comments"`
+	Score       float64    `db:"score"`
+}
+
+// Comment represents the part of the comment events needed to rank posts.
+type Comment struct {
+	ID          string    `db:"comment_id"`
+	PostID      string    `db:"post_id"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+// Score is a change to the score of a post. Score is in log space and
+// Comments is the change to the number of comments.
+type Score struct {
+	PostID   string  `db:"post_id"`
+	Score    float64 `db:"score"`
+	Comments int     `db:"comments"`
+}
+
+// Event is a post or comment whose weight is counted in the score of a post.
+type Event struct {
+	ID     string `db:"event_id"`
+	PostID string `db:"post_id"`
+}
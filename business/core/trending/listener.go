@@ -0,0 +1,43 @@
+package trending
+
+import (
+	"fmt"
+
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Listener keeps the post scores up to date with the post and comment events.
+type Listener struct {
+	log  *zap.SugaredLogger
+	nats *nats.NATS
+	core Core
+}
+
+// NewListener constructs a listener for ranking events.
+func NewListener(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS) Listener {
+	return Listener{
+		log:  log,
+		nats: nats,
+		core: NewCore(log, sqlxDB),
+	}
+}
+
+// Listen subscribes to all the ranking events.
+func (l Listener) Listen() error {
+	if err := nats.Handle(l.nats, l.log, "post-created", "trending", l.core.AddPost); err != nil {
+		return fmt.Errorf("post-created: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "comment-created", "trending", l.core.AddComment); err != nil {
+		return fmt.Errorf("comment-created: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "comment-restored", "trending", l.core.AddComment); err != nil {
+		return fmt.Errorf("comment-restored: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "comment-deleted", "trending", l.core.RemoveComment); err != nil {
+		return fmt.Errorf("comment-deleted: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package trending
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/dudakovict/social-network/business/core/trending/db"
+)
+
+// Post represents a trending post. Score is only meaningful compared to the
+// scores of other posts.
+type Post struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	UserID      string     `json:"user_id"`
+	DateCreated time.Time  `json:"date_created"`
+	DateUpdated time.Time  `json:"date_updated"`
+	Edited      bool       `json:"edited"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	Visibility  string     `json:"visibility"`
+	Comments    int        `json:"comments"`
+	Score       float64    `json:"score"`
+}
+
+// =============================================================================
+
+func toPost(dbR db.Ranked) Post {
+	pp := (*Post)(unsafe.Pointer(&dbR))
+	return *pp
+}
+
+func toPostSlice(dbRs []db.Ranked) []Post {
+	posts := make([]Post, len(dbRs))
+	for i, dbR := range dbRs {
+		posts[i] = toPost(dbR)
+	}
+	return posts
+}
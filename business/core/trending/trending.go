@@ -0,0 +1,178 @@
+// Package trending provides the core business API for ranking posts. Every
+// post and comment adds weight to the score of a post that decays
+// exponentially with its age. Decay is applied by making newer weight worth
+// exponentially more instead of shrinking older weight, so a score never
+// has to be recomputed as time passes and every event updates it in place.
+package trending
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/trending/db"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidWindow is returned for windows that are not supported.
+var ErrInvalidWindow = errors.New("window must be one of 1h, 24h or 7d")
+
+// Windows are the periods posts can trend over.
+var Windows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// Set of weights events add to the score of a post.
+const (
+	postWeight    = 1.0
+	commentWeight = 2.0
+)
+
+// HalfLife is how long it takes for the weight of an event to halve.
+const HalfLife = 12 * time.Hour
+
+// epoch is the point in time scores are measured from.
+var epoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Core manages the set of API's for trending access.
+type Core struct {
+	store db.Store
+}
+
+// NewCore constructs a core for trending api access.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB) Core {
+	return Core{
+		store: db.NewStore(log, sqlxDB),
+	}
+}
+
+// Query retrieves the highest ranked public posts published within the
+// specified window.
+func (c Core) Query(ctx context.Context, window string, limit int, now time.Time) ([]Post, error) {
+	d, ok := Windows[window]
+	if !ok {
+		return nil, ErrInvalidWindow
+	}
+
+	dbRs, err := c.store.QueryTop(ctx, now.Add(-d), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return toPostSlice(dbRs), nil
+}
+
+// AddPost gives a newly published post its initial score.
+func (c Core) AddPost(ctx context.Context, dbP db.Post) error {
+	at := dbP.DateCreated
+	if dbP.PublishAt != nil {
+		at = *dbP.PublishAt
+	}
+
+	ev := db.Event{
+		ID:     dbP.ID,
+		PostID: dbP.ID,
+	}
+
+	sc := db.Score{
+		PostID: dbP.ID,
+		Score:  weight(postWeight, at),
+	}
+
+	return c.add(ctx, ev, sc)
+}
+
+// AddComment adds the weight of a comment to the score of its post.
+func (c Core) AddComment(ctx context.Context, dbC db.Comment) error {
+	ev := db.Event{
+		ID:     dbC.ID,
+		PostID: dbC.PostID,
+	}
+
+	sc := db.Score{
+		PostID:   dbC.PostID,
+		Score:    weight(commentWeight, dbC.DateCreated),
+		Comments: 1,
+	}
+
+	return c.add(ctx, ev, sc)
+}
+
+// RemoveComment takes the weight of a deleted comment off the score of its
+// post.
+func (c Core) RemoveComment(ctx context.Context, dbC db.Comment) error {
+	ev := db.Event{
+		ID:     dbC.ID,
+		PostID: dbC.PostID,
+	}
+
+	sc := db.Score{
+		PostID:   dbC.PostID,
+		Score:    weight(commentWeight, dbC.DateCreated),
+		Comments: 1,
+	}
+
+	return c.subtract(ctx, ev, sc)
+}
+
+// =============================================================================
+
+// weight returns, in log space, what an event at the specified time adds to
+// a score. Every HalfLife after the epoch doubles the weight.
+func weight(w float64, at time.Time) float64 {
+	return math.Log(w) + at.Sub(epoch).Hours()/HalfLife.Hours()*math.Ln2
+}
+
+// add adds the score unless the post or comment is counted already. Events
+// are delivered at least once, so the same one may be handled twice.
+func (c Core) add(ctx context.Context, ev db.Event, sc db.Score) error {
+	tran := func(tx sqlx.ExtContext) error {
+		counted, err := c.store.Tran(tx).Count(ctx, ev)
+		if err != nil {
+			return fmt.Errorf("count: %w", err)
+		}
+		if !counted {
+			return nil
+		}
+
+		if err := c.store.Tran(tx).Add(ctx, sc); err != nil {
+			return fmt.Errorf("add: %w", err)
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
+
+// subtract takes the score off unless the comment is no longer counted.
+func (c Core) subtract(ctx context.Context, ev db.Event, sc db.Score) error {
+	tran := func(tx sqlx.ExtContext) error {
+		uncounted, err := c.store.Tran(tx).Uncount(ctx, ev)
+		if err != nil {
+			return fmt.Errorf("uncount: %w", err)
+		}
+		if !uncounted {
+			return nil
+		}
+
+		if err := c.store.Tran(tx).Subtract(ctx, sc); err != nil {
+			return fmt.Errorf("subtract: %w", err)
+		}
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return fmt.Errorf("tran: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package trending_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/social-network/business/core/post"
+	"github.com/dudakovict/social-network/business/core/trending"
+	trendingDB "github.com/dudakovict/social-network/business/core/trending/db"
+	"github.com/dudakovict/social-network/business/data/post/dbtest"
+	"github.com/dudakovict/social-network/foundation/docker"
+)
+
+var nc *docker.Container
+var dbc *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	nc, err = dbtest.StartNATS()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	dbc, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	defer dbtest.StopNATS(nc)
+	defer dbtest.StopDB(dbc)
+
+	m.Run()
+}
+
+func TestTrending(t *testing.T) {
+	log, db, n, teardown := dbtest.NewUnit(t, nc, dbc, "testtrending")
+	t.Cleanup(teardown)
+
+	postCore := post.NewCore(log, db, n)
+	core := trending.NewCore(log, db)
+
+	t.Log("Given the need to rank posts.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen posts get comments as they age.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2022, time.October, 1, 0, 0, 0, 0, time.UTC)
+			userID := "5cf37266-3473-4006-984f-9325122678b7"
+
+			create := func(at time.Time) post.Post {
+				np := post.NewPost{
+					Title:       "New Song",
+					Description: "Check out my new song!",
+					UserID:      userID,
+				}
+
+				p, err := postCore.Create(ctx, np, at)
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to create post : %s.", dbtest.Failed, testID, err)
+				}
+
+				if err := core.AddPost(ctx, trendingDB.Post{ID: p.ID, DateCreated: p.DateCreated, PublishAt: p.PublishAt}); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to score post : %s.", dbtest.Failed, testID, err)
+				}
+
+				return p
+			}
+
+			old := create(now.Add(-48 * time.Hour))
+			recent := create(now.Add(-30 * time.Minute))
+			t.Logf("\t%s\tTest %d:\tShould be able to score posts.", dbtest.Success, testID)
+
+			c := trendingDB.Comment{ID: "a1b5c5b6-2e05-11ed-a261-0242ac120002", PostID: old.ID, DateCreated: now}
+			// Events are delivered at least once, the second one must not count.
+			for i := 0; i < 2; i++ {
+				if err := core.AddComment(ctx, c); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to score comment : %s.", dbtest.Failed, testID, err)
+				}
+			}
+
+			posts, err := core.Query(ctx, "7d", 10, now)
+			if err != nil || len(posts) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould get the trending posts : %+v %v.", dbtest.Failed, testID, posts, err)
+			}
+			if posts[0].ID != old.ID || posts[0].Comments != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould rank the commented post first : %+v.", dbtest.Failed, testID, posts)
+			}
+			t.Logf("\t%s\tTest %d:\tShould rank the commented post first.", dbtest.Success, testID)
+
+			for i := 0; i < 2; i++ {
+				if err := core.RemoveComment(ctx, c); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to remove comment : %s.", dbtest.Failed, testID, err)
+				}
+			}
+
+			posts, err = core.Query(ctx, "7d", 10, now)
+			if err != nil || len(posts) != 2 || posts[0].ID != recent.ID || posts[1].Comments != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould rank the newer post first : %+v %v.", dbtest.Failed, testID, posts, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould rank the newer post first.", dbtest.Success, testID)
+
+			posts, err = core.Query(ctx, "1h", 10, now)
+			if err != nil || len(posts) != 1 || posts[0].ID != recent.ID {
+				t.Fatalf("\t%s\tTest %d:\tShould only get posts within the window : %+v %v.", dbtest.Failed, testID, posts, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould only get posts within the window.", dbtest.Success, testID)
+
+			if _, err := core.Query(ctx, "2w", 10, now); !errors.Is(err, trending.ErrInvalidWindow) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT accept an unknown window : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT accept an unknown window.", dbtest.Success, testID)
+		}
+	}
+}
@@ -10,6 +10,10 @@ import (
 	"go.uber.org/zap"
 )
 
+// handleKey is the unique constraint keeping two users from going by the
+// same handle.
+const handleKey = "users_handle_key"
+
 // Store manages the set of API's for user access.
 type Store struct {
 	log          *zap.SugaredLogger
@@ -45,22 +49,27 @@ func (s Store) Tran(tx sqlx.ExtContext) Store {
 	}
 }
 
-// Create inserts a new user into the database.
+// Create inserts a new user into the database. It returns
+// ErrDBDuplicatedEntry when another user has the handle.
 func (s Store) Create(ctx context.Context, usr User) error {
 	const q = `
 	INSERT INTO users
-		(user_id, name, email, password_hash, roles, date_created, date_updated)
+		(user_id, name, email, password_hash, roles, date_created, date_updated, private, handle, display_name, email_verified, locale)
 	VALUES
-		(:user_id, :name, :email, :password_hash, :roles, :date_created, :date_updated)`
+		(:user_id, :name, :email, :password_hash, :roles, :date_created, :date_updated, :private, :handle, :display_name, :email_verified, :locale)`
 
 	if err := database.NamedExecContext(ctx, s.log, s.db, q, usr); err != nil {
+		if database.IsUniqueViolation(err, handleKey) {
+			return fmt.Errorf("inserting user: %w", database.ErrDBDuplicatedEntry)
+		}
 		return fmt.Errorf("inserting user: %w", err)
 	}
 
 	return nil
 }
 
-// Update replaces a user document in the database.
+// Update replaces a user document in the database. It returns
+// ErrDBDuplicatedEntry when another user has the handle.
 func (s Store) Update(ctx context.Context, usr User) error {
 	const q = `
 	UPDATE
@@ -70,11 +79,23 @@ func (s Store) Update(ctx context.Context, usr User) error {
 		"email" = :email,
 		"roles" = :roles,
 		"password_hash" = :password_hash,
-		"date_updated" = :date_updated
+		"date_updated" = :date_updated,
+		"private" = :private,
+		"handle" = :handle,
+		"display_name" = :display_name,
+		"bio" = :bio,
+		"avatar_url" = :avatar_url,
+		"location" = :location,
+		"website" = :website,
+		"email_verified" = :email_verified,
+		"locale" = :locale
 	WHERE
 		user_id = :user_id`
 
 	if err := database.NamedExecContext(ctx, s.log, s.db, q, usr); err != nil {
+		if database.IsUniqueViolation(err, handleKey) {
+			return fmt.Errorf("updating userID[%s]: %w", usr.ID, database.ErrDBDuplicatedEntry)
+		}
 		return fmt.Errorf("updating userID[%s]: %w", usr.ID, err)
 	}
 
@@ -102,6 +123,24 @@ func (s Store) Delete(ctx context.Context, userID string) error {
 	return nil
 }
 
+// MarkEmailUnverified stops treating the e-mail as verified for the users
+// with that address.
+func (s Store) MarkEmailUnverified(ctx context.Context, u Undeliverable) error {
+	const q = `
+	UPDATE
+		users
+	SET
+		"email_verified" = false
+	WHERE
+		LOWER(email) = LOWER(:email)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, u); err != nil {
+		return fmt.Errorf("marking email unverified email[%s]: %w", u.Email, err)
+	}
+
+	return nil
+}
+
 // Query retrieves a list of existing users from the database.
 func (s Store) Query(ctx context.Context, pageNumber int, rowsPerPage int) ([]User, error) {
 	data := struct {
@@ -176,3 +215,91 @@ func (s Store) QueryByEmail(ctx context.Context, email string) (User, error) {
 
 	return usr, nil
 }
+
+// QueryByHandle gets the specified user from the database by handle.
+func (s Store) QueryByHandle(ctx context.Context, handle string) (User, error) {
+	data := struct {
+		Handle string `db:"handle"`
+	}{
+		Handle: handle,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		users
+	WHERE
+		handle = :handle`
+
+	var usr User
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &usr); err != nil {
+		return User{}, fmt.Errorf("selecting handle[%q]: %w", handle, err)
+	}
+
+	return usr, nil
+}
+
+// QueryProfileByHandle gets the public profile of the specified user from
+// the database along with their post and follow counts.
+func (s Store) QueryProfileByHandle(ctx context.Context, handle string) (Profile, error) {
+	data := struct {
+		Handle string `db:"handle"`
+	}{
+		Handle: handle,
+	}
+
+	const q = `
+	SELECT
+		u.user_id, u.handle, u.display_name, u.bio, u.avatar_url, u.location, u.website, u.private, u.date_created,
+		(SELECT COUNT(*) FROM user_posts WHERE user_id = u.user_id) AS posts,
+		(SELECT COUNT(*) FROM follows WHERE followee_id = u.user_id) AS followers,
+		(SELECT COUNT(*) FROM follows WHERE follower_id = u.user_id) AS following
+	FROM
+		users u
+	WHERE
+		u.handle = :handle`
+
+	var prf Profile
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &prf); err != nil {
+		return Profile{}, fmt.Errorf("selecting profile handle[%q]: %w", handle, err)
+	}
+
+	return prf, nil
+}
+
+// CreateUserPost stores a copy of the authorship of a post. Posts whose
+// author no longer exists are ignored.
+func (s Store) CreateUserPost(ctx context.Context, up UserPost) error {
+	const q = `
+	INSERT INTO user_posts
+		(post_id, user_id)
+	SELECT
+		CAST(:post_id AS UUID), user_id
+	FROM
+		users
+	WHERE
+		user_id = :user_id
+	ON CONFLICT DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, up); err != nil {
+		return fmt.Errorf("inserting user post: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteUserPost removes the copy of the authorship of a post.
+func (s Store) DeleteUserPost(ctx context.Context, up UserPost) error {
+	const q = `
+	DELETE FROM
+		user_posts
+	WHERE
+		post_id = :post_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, up); err != nil {
+		return fmt.Errorf("deleting postID[%s]: %w", up.ID, err)
+	}
+
+	return nil
+}
@@ -9,11 +9,67 @@ import (
 // User represent the structure we need for moving data
 // between the app and the database.
 type User struct {
-	ID           string         `db:"user_id"`
-	Name         string         `db:"name"`
-	Email        string         `db:"email"`
-	Roles        pq.StringArray `db:"roles"`
-	PasswordHash []byte         `db:"password_hash"`
-	DateCreated  time.Time      `db:"date_created"`
-	DateUpdated  time.Time      `db:"date_updated"`
+	ID            string         `db:"user_id"`
+	Name          string         `db:"name"`
+	Email         string         `db:"email"`
+	Roles         pq.StringArray `db:"roles"`
+	PasswordHash  []byte         `db:"password_hash"`
+	DateCreated   time.Time      `db:"date_created"`
+	DateUpdated   time.Time      `db:"date_updated"`
+	Private       bool           `db:"private"`
+	Handle        *string        `db:"handle"`
+	DisplayName   string         `db:"display_name"`
+	Bio           string         `db:"bio"`
+	AvatarURL     string         `db:"avatar_url"`
+	Location      string         `db:"location"`
+	Website       string         `db:"website"`
+	EmailVerified bool           `db:"email_verified"`
+	Locale        string         `db:"locale"`
+}
+
+// Profile represents the public view of a user along with their counts.
+type Profile struct {
+	ID          string    `db:"user_id"`
+	Handle      string    `db:"handle"`
+	DisplayName string    `db:"display_name"`
+	Bio         string    `db:"bio"`
+	AvatarURL   string    `db:"avatar_url"`
+	Location    string    `db:"location"`
+	Website     string    `db:"website"`
+	Private     bool      `db:"private"`
+	DateCreated time.Time `db:"date_created"`
+	Posts       int       `db:"posts"`
+	Followers   int       `db:"followers"`
+	Following   int       `db:"following"`
+}
+
+// UserPost records that a user authored a post. It mirrors the posts owned
+// by the posts service so the field names match the post events.
+type UserPost struct {
+	ID     string `db:"post_id"`
+	UserID string `db:"user_id"`
+}
+
+// Handle announces the handle a user goes by to the services that resolve
+// mentions. An empty handle means the user no longer has one.
+type Handle struct {
+	UserID string `db:"user_id"`
+	Handle string `db:"handle"`
+}
+
+// Undeliverable announces an address the e-mail service can no longer
+// deliver to. It mirrors the events published by the e-mail service.
+type Undeliverable struct {
+	Email  string `db:"email"`
+	Reason string `db:"reason"`
+	Detail string `db:"detail"`
+}
+
+// Contact announces how to reach a user by e-mail to the services that send
+// them e-mails.
+type Contact struct {
+	UserID string `db:"user_id"`
+	Email  string `db:"email"`
+	Name   string `db:"name"`
+	Locale string `db:"locale"`
 }
@@ -0,0 +1,47 @@
+package user
+
+import (
+	"fmt"
+
+	"github.com/dudakovict/social-network/business/core/user/db"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Listener keeps the local copy of who authored which post in sync with the
+// events published by the posts service so profiles can count posts. It also
+// stops treating the e-mails the e-mail service can not deliver to as
+// verified.
+type Listener struct {
+	log   *zap.SugaredLogger
+	nats  *nats.NATS
+	store db.Store
+}
+
+// NewListener constructs a listener for post and e-mail events.
+func NewListener(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS) Listener {
+	return Listener{
+		log:   log,
+		nats:  nats,
+		store: db.NewStore(log, sqlxDB),
+	}
+}
+
+// Listen subscribes to all the post and e-mail events.
+func (l Listener) Listen() error {
+	if err := nats.Handle(l.nats, l.log, "post-created", "users", l.store.CreateUserPost); err != nil {
+		return fmt.Errorf("post-created: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "post-restored", "users", l.store.CreateUserPost); err != nil {
+		return fmt.Errorf("post-restored: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "post-deleted", "users", l.store.DeleteUserPost); err != nil {
+		return fmt.Errorf("post-deleted: %w", err)
+	}
+	if err := nats.Handle(l.nats, l.log, "email-undeliverable", "users", l.store.MarkEmailUnverified); err != nil {
+		return fmt.Errorf("email-undeliverable: %w", err)
+	}
+
+	return nil
+}
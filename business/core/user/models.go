@@ -7,15 +7,28 @@ import (
 	"github.com/dudakovict/social-network/business/core/user/db"
 )
 
-// User represents an individual user.
+// User represents an individual user. Private users approve who follows them.
+// The handle, display name, bio, avatar, location and website make up the
+// public profile of the user. The e-mail stops being verified once mail to it
+// bounces or the user complains about it. The locale is the language the user
+// is e-mailed in.
 type User struct {
-	ID           string    `json:"id"`
-	Name         string    `json:"name"`
-	Email        string    `json:"email"`
-	Roles        []string  `json:"roles"`
-	PasswordHash []byte    `json:"-"`
-	DateCreated  time.Time `json:"date_created"`
-	DateUpdated  time.Time `json:"date_updated"`
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	Roles         []string  `json:"roles"`
+	PasswordHash  []byte    `json:"-"`
+	DateCreated   time.Time `json:"date_created"`
+	DateUpdated   time.Time `json:"date_updated"`
+	Private       bool      `json:"private"`
+	Handle        *string   `json:"handle,omitempty"`
+	DisplayName   string    `json:"display_name"`
+	Bio           string    `json:"bio"`
+	AvatarURL     string    `json:"avatar_url"`
+	Location      string    `json:"location"`
+	Website       string    `json:"website"`
+	EmailVerified bool      `json:"email_verified"`
+	Locale        string    `json:"locale"`
 }
 
 // NewUser contains information needed to create a new User.
@@ -25,6 +38,10 @@ type NewUser struct {
 	Roles           []string `json:"roles" validate:"required"`
 	Password        string   `json:"password" validate:"required"`
 	PasswordConfirm string   `json:"password_confirm" validate:"eqfield=Password"`
+	Private         bool     `json:"private"`
+	Handle          *string  `json:"handle" validate:"omitempty,handle"`
+	DisplayName     string   `json:"display_name" validate:"max=50"`
+	Locale          string   `json:"locale" validate:"omitempty,bcp47_language_tag"`
 }
 
 // UpdateUser defines what information may be provided to modify an existing
@@ -39,6 +56,36 @@ type UpdateUser struct {
 	Roles           []string `json:"roles"`
 	Password        *string  `json:"password"`
 	PasswordConfirm *string  `json:"password_confirm" validate:"omitempty,eqfield=Password"`
+	Private         *bool    `json:"private"`
+	Locale          *string  `json:"locale" validate:"omitempty,bcp47_language_tag"`
+}
+
+// UpdateProfile defines what information users may provide to modify their
+// own profile. All fields are optional.
+type UpdateProfile struct {
+	Handle      *string `json:"handle" validate:"omitempty,handle"`
+	DisplayName *string `json:"display_name" validate:"omitempty,max=50"`
+	Bio         *string `json:"bio" validate:"omitempty,max=160"`
+	AvatarURL   *string `json:"avatar_url" validate:"omitempty,url"`
+	Location    *string `json:"location" validate:"omitempty,max=30"`
+	Website     *string `json:"website" validate:"omitempty,url"`
+}
+
+// Profile represents the public view of a user. It never includes the email
+// or roles of the user.
+type Profile struct {
+	ID          string    `json:"id"`
+	Handle      string    `json:"handle"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   string    `json:"avatar_url"`
+	Location    string    `json:"location"`
+	Website     string    `json:"website"`
+	Private     bool      `json:"private"`
+	DateCreated time.Time `json:"date_created"`
+	Posts       int       `json:"posts"`
+	Followers   int       `json:"followers"`
+	Following   int       `json:"following"`
 }
 
 // =============================================================================
@@ -55,3 +102,8 @@ func toUserSlice(dbUsrs []db.User) []User {
 	}
 	return users
 }
+
+func toProfile(dbPrf db.Profile) Profile {
+	pp := (*Profile)(unsafe.Pointer(&dbPrf))
+	return *pp
+}
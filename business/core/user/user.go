@@ -4,7 +4,9 @@
 package user
 
 import (
+	"bytes"
 	"context"
+	"encoding/gob"
 	"errors"
 	"fmt"
 	"time"
@@ -13,6 +15,8 @@ import (
 	"github.com/dudakovict/social-network/business/data/email"
 	"github.com/dudakovict/social-network/business/sys/auth"
 	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/locale"
+	"github.com/dudakovict/social-network/business/sys/nats"
 	"github.com/dudakovict/social-network/business/sys/validate"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/jmoiron/sqlx"
@@ -25,19 +29,25 @@ var (
 	ErrNotFound              = errors.New("user not found")
 	ErrInvalidID             = errors.New("ID is not in its proper form")
 	ErrAuthenticationFailure = errors.New("authentication failed")
+	ErrHandleTaken           = errors.New("handle is already taken")
+	ErrInvalidHandle         = errors.New("handle is not in its proper form")
 )
 
 // Core manages the set of API's for user access.
 type Core struct {
 	store db.Store
+	nats  *nats.NATS
 	ec    email.EmailClient
 	log   *zap.SugaredLogger
 }
 
-// NewCore constructs a core for user api access.
-func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB, client email.EmailClient) Core {
+// NewCore constructs a core for user api access. Contact and handle changes
+// are published to NATS, a nil NATS keeps them to the database so tools and
+// tests can pass nil.
+func NewCore(log *zap.SugaredLogger, sqlxDB *sqlx.DB, nats *nats.NATS, client email.EmailClient) Core {
 	return Core{
 		store: db.NewStore(log, sqlxDB),
+		nats:  nats,
 		ec:    client,
 		log:   log,
 	}
@@ -62,19 +72,62 @@ func (c Core) Create(ctx context.Context, nu NewUser, now time.Time) (User, erro
 		Roles:        nu.Roles,
 		DateCreated:  now,
 		DateUpdated:  now,
+		Private:      nu.Private,
+		DisplayName:  nu.DisplayName,
+
+		EmailVerified: true,
+		Locale:        nu.Locale,
+	}
+
+	if dbUsr.Locale == "" {
+		dbUsr.Locale = locale.Default
+	}
+
+	if dbUsr.DisplayName == "" {
+		dbUsr.DisplayName = nu.Name
+	}
+
+	if nu.Handle != nil {
+		if err := c.claimHandle(ctx, &dbUsr, *nu.Handle); err != nil {
+			return User{}, err
+		}
 	}
 
 	if err := c.store.Create(ctx, dbUsr); err != nil {
+		if errors.Is(err, database.ErrDBDuplicatedEntry) {
+			return User{}, ErrHandleTaken
+		}
 		return User{}, fmt.Errorf("create: %w", err)
 	}
 
+	if dbUsr.Handle != nil {
+		if err := c.publishHandle(dbUsr.ID, *dbUsr.Handle); err != nil {
+			return User{}, fmt.Errorf("pub: %w", err)
+		}
+	}
+
+	if err := c.publishContact(dbUsr); err != nil {
+		return User{}, fmt.Errorf("pub: %w", err)
+	}
+
 	in := email.EmailRequest{
-		Email: dbUsr.Email,
+		RequestId: "welcome:" + dbUsr.ID,
+		Email:     dbUsr.Email,
+		Template:  "welcome",
+		Locale:    dbUsr.Locale,
+		Vars: map[string]string{
+			"name": dbUsr.Name,
+		},
 	}
 
-	_, err = c.ec.Send(ctx, &in)
-	if err != nil {
-		c.log.Info(err)
+	// The user is created even when the welcome e-mail can not be sent. A
+	// rejected address is expected, anything else is worth a look.
+	if _, err := c.ec.Send(ctx, &in); err != nil {
+		if email.IsRejected(err) {
+			c.log.Infow("welcome", "userID", dbUsr.ID, "rejected", err)
+		} else {
+			c.log.Errorw("welcome", "userID", dbUsr.ID, "ERROR", err)
+		}
 	}
 
 	return toUser(dbUsr), nil
@@ -98,11 +151,13 @@ func (c Core) Update(ctx context.Context, userID string, uu UpdateUser, now time
 		return fmt.Errorf("updating user userID[%s]: %w", userID, err)
 	}
 
+	name, address, loc := dbUsr.Name, dbUsr.Email, dbUsr.Locale
 	if uu.Name != nil {
 		dbUsr.Name = *uu.Name
 	}
-	if uu.Email != nil {
+	if uu.Email != nil && *uu.Email != dbUsr.Email {
 		dbUsr.Email = *uu.Email
+		dbUsr.EmailVerified = true
 	}
 	if uu.Roles != nil {
 		dbUsr.Roles = uu.Roles
@@ -114,25 +169,109 @@ func (c Core) Update(ctx context.Context, userID string, uu UpdateUser, now time
 		}
 		dbUsr.PasswordHash = pw
 	}
+	if uu.Private != nil {
+		dbUsr.Private = *uu.Private
+	}
+	if uu.Locale != nil {
+		dbUsr.Locale = *uu.Locale
+	}
 	dbUsr.DateUpdated = now
 
 	if err := c.store.Update(ctx, dbUsr); err != nil {
 		return fmt.Errorf("udpate: %w", err)
 	}
 
+	if dbUsr.Name != name || dbUsr.Email != address || dbUsr.Locale != loc {
+		if err := c.publishContact(dbUsr); err != nil {
+			return fmt.Errorf("pub: %w", err)
+		}
+	}
+
 	return nil
 }
 
-// Delete removes a user from the database.
+// UpdateProfile replaces the profile of a user in the database.
+func (c Core) UpdateProfile(ctx context.Context, userID string, up UpdateProfile, now time.Time) error {
+	if err := validate.CheckID(userID); err != nil {
+		return ErrInvalidID
+	}
+
+	if err := validate.Check(up); err != nil {
+		return fmt.Errorf("validating data: %w", err)
+	}
+
+	dbUsr, err := c.store.QueryByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("updating profile userID[%s]: %w", userID, err)
+	}
+
+	previous := dbUsr.Handle
+	if up.Handle != nil {
+		if err := c.claimHandle(ctx, &dbUsr, *up.Handle); err != nil {
+			return err
+		}
+	}
+	if up.DisplayName != nil {
+		dbUsr.DisplayName = *up.DisplayName
+	}
+	if up.Bio != nil {
+		dbUsr.Bio = *up.Bio
+	}
+	if up.AvatarURL != nil {
+		dbUsr.AvatarURL = *up.AvatarURL
+	}
+	if up.Location != nil {
+		dbUsr.Location = *up.Location
+	}
+	if up.Website != nil {
+		dbUsr.Website = *up.Website
+	}
+	dbUsr.DateUpdated = now
+
+	if err := c.store.Update(ctx, dbUsr); err != nil {
+		if errors.Is(err, database.ErrDBDuplicatedEntry) {
+			return ErrHandleTaken
+		}
+		return fmt.Errorf("update: %w", err)
+	}
+
+	if dbUsr.Handle != nil && (previous == nil || *previous != *dbUsr.Handle) {
+		if err := c.publishHandle(dbUsr.ID, *dbUsr.Handle); err != nil {
+			return fmt.Errorf("pub: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Delete removes a user from the database. The handle of the user is
+// released so it no longer resolves in mentions.
 func (c Core) Delete(ctx context.Context, userID string) error {
 	if err := validate.CheckID(userID); err != nil {
 		return ErrInvalidID
 	}
 
+	dbUsr, err := c.store.QueryByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return nil
+		}
+		return fmt.Errorf("deleting userID[%s]: %w", userID, err)
+	}
+
 	if err := c.store.Delete(ctx, userID); err != nil {
 		return fmt.Errorf("delete: %w", err)
 	}
 
+	if dbUsr.Handle != nil {
+		if err := c.publishHandle(dbUsr.ID, ""); err != nil {
+			return fmt.Errorf("pub: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -185,6 +324,25 @@ func (c Core) QueryByEmail(ctx context.Context, email string) (User, error) {
 	return toUser(dbUsr), nil
 }
 
+// QueryProfileByHandle gets the public profile of the user with the
+// specified handle.
+func (c Core) QueryProfileByHandle(ctx context.Context, handle string) (Profile, error) {
+	handle = validate.NormalizeHandle(handle)
+	if err := validate.CheckHandle(handle); errors.Is(err, validate.ErrInvalidHandle) {
+		return Profile{}, ErrInvalidHandle
+	}
+
+	dbPrf, err := c.store.QueryProfileByHandle(ctx, handle)
+	if err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return Profile{}, ErrNotFound
+		}
+		return Profile{}, fmt.Errorf("query: %w", err)
+	}
+
+	return toProfile(dbPrf), nil
+}
+
 // Authenticate finds a user by their email and verifies their password. On
 // success it returns a Claims User representing this user. The claims can be
 // used to generate a token for future authentication.
@@ -217,3 +375,68 @@ func (c Core) Authenticate(ctx context.Context, now time.Time, email, password s
 
 	return claims, nil
 }
+
+// claimHandle gives the user the specified handle as long as no other user
+// has it. Two users claiming the same handle at once both pass, the unique
+// constraint turns the second write into ErrHandleTaken.
+func (c Core) claimHandle(ctx context.Context, dbUsr *db.User, handle string) error {
+	handle = validate.NormalizeHandle(handle)
+
+	other, err := c.store.QueryByHandle(ctx, handle)
+	switch {
+	case err == nil:
+		if other.ID != dbUsr.ID {
+			return ErrHandleTaken
+		}
+	case !errors.Is(err, database.ErrDBNotFound):
+		return fmt.Errorf("query handle: %w", err)
+	}
+
+	dbUsr.Handle = &handle
+	return nil
+}
+
+// publishContact announces the name, the e-mail address and the locale of
+// the user on the user-contact-changed subject.
+func (c Core) publishContact(dbUsr db.User) error {
+	dbC := db.Contact{
+		UserID: dbUsr.ID,
+		Email:  dbUsr.Email,
+		Name:   dbUsr.Name,
+		Locale: dbUsr.Locale,
+	}
+
+	return c.publish("user-contact-changed", dbC)
+}
+
+// publishHandle announces the handle the user goes by on the
+// user-handle-changed subject.
+func (c Core) publishHandle(userID string, handle string) error {
+	dbH := db.Handle{
+		UserID: userID,
+		Handle: handle,
+	}
+
+	return c.publish("user-handle-changed", dbH)
+}
+
+// publish sends the event to the services listening on the specified
+// subject. Without NATS there is no one to send it to.
+func (c Core) publish(subject string, v interface{}) error {
+	if c.nats == nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+
+	if err := c.nats.Client.Publish(subject, buf.Bytes()); err != nil {
+		return fmt.Errorf("publishing %s: %w", subject, err)
+	}
+
+	return nil
+}
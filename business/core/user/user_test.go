@@ -16,6 +16,7 @@ import (
 )
 
 var esc *docker.Container
+var nc *docker.Container
 var dbc *docker.Container
 
 func TestMain(m *testing.M) {
@@ -26,6 +27,12 @@ func TestMain(m *testing.M) {
 		return
 	}
 
+	nc, err = dbtest.StartNATS()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
 	dbc, err = dbtest.StartDB()
 	if err != nil {
 		fmt.Println(err)
@@ -33,6 +40,7 @@ func TestMain(m *testing.M) {
 	}
 
 	defer dbtest.StopDB(esc)
+	defer dbtest.StopNATS(nc)
 	defer dbtest.StopDB(dbc)
 
 	m.Run()
@@ -42,7 +50,7 @@ func TestUser(t *testing.T) {
 	log, db, ec, teardown := dbtest.NewUnit(t, dbc, "testuser")
 	t.Cleanup(teardown)
 
-	core := user.NewCore(log, db, ec)
+	core := user.NewCore(log, db, nil, ec)
 
 	t.Log("Given the need to work with User records.")
 	{
@@ -77,9 +85,15 @@ func TestUser(t *testing.T) {
 			}
 			t.Logf("\t%s\tTest %d:\tShould get back the same user.", dbtest.Success, testID)
 
+			if saved.Locale != "en" {
+				t.Fatalf("\t%s\tTest %d:\tShould default to the english locale : got %q.", dbtest.Failed, testID, saved.Locale)
+			}
+			t.Logf("\t%s\tTest %d:\tShould default to the english locale.", dbtest.Success, testID)
+
 			upd := user.UpdateUser{
-				Name:  dbtest.StringPointer("Jacob Walker"),
-				Email: dbtest.StringPointer("jacob@ardanlabs.com"),
+				Name:   dbtest.StringPointer("Jacob Walker"),
+				Email:  dbtest.StringPointer("jacob@ardanlabs.com"),
+				Locale: dbtest.StringPointer("hr-HR"),
 			}
 
 			if err := core.Update(ctx, usr.ID, upd, now); err != nil {
@@ -109,6 +123,14 @@ func TestUser(t *testing.T) {
 				t.Logf("\t%s\tTest %d:\tShould be able to see updates to Email.", dbtest.Success, testID)
 			}
 
+			if saved.Locale != *upd.Locale {
+				t.Errorf("\t%s\tTest %d:\tShould be able to see updates to Locale.", dbtest.Failed, testID)
+				t.Logf("\t\tTest %d:\tGot: %v", testID, saved.Locale)
+				t.Logf("\t\tTest %d:\tExp: %v", testID, *upd.Locale)
+			} else {
+				t.Logf("\t%s\tTest %d:\tShould be able to see updates to Locale.", dbtest.Success, testID)
+			}
+
 			if err := core.Delete(ctx, usr.ID); err != nil {
 				t.Fatalf("\t%s\tTest %d:\tShould be able to delete user : %s.", dbtest.Failed, testID, err)
 			}
@@ -132,7 +154,7 @@ func TestPagingUser(t *testing.T) {
 
 	dbschema.Seed(ctx, db)
 
-	user := user.NewCore(log, db, ec)
+	user := user.NewCore(log, db, nil, ec)
 
 	t.Log("Given the need to page through User records.")
 	{
@@ -172,3 +194,79 @@ func TestPagingUser(t *testing.T) {
 		}
 	}
 }
+
+func TestProfile(t *testing.T) {
+	log, db, ec, teardown := dbtest.NewUnit(t, dbc, "testprofile")
+	t.Cleanup(teardown)
+
+	n, teardownNATS := dbtest.NewNATS(t, nc)
+	t.Cleanup(teardownNATS)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dbschema.Seed(ctx, db)
+
+	core := user.NewCore(log, db, n, ec)
+
+	t.Log("Given the need to work with public profiles.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling the profile of a seeded user.", testID)
+		{
+			ctx := context.Background()
+			now := time.Date(2018, time.October, 1, 0, 0, 0, 0, time.UTC)
+			adminID := "5cf37266-3473-4006-984f-9325122678b7"
+			userID := "45b5fbd3-755f-4379-8f07-a58d4a30fa2f"
+
+			if _, err := db.ExecContext(ctx, `INSERT INTO follows (follower_id, followee_id, date_created) VALUES ($1, $2, $3)`, userID, adminID, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add a follower : %s.", dbtest.Failed, testID, err)
+			}
+			if _, err := db.ExecContext(ctx, `INSERT INTO user_posts (post_id, user_id) VALUES ($1, $2)`, "3dc0a440-2e05-11ed-a261-0242ac120002", adminID); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to add a post : %s.", dbtest.Failed, testID, err)
+			}
+
+			prf, err := core.QueryProfileByHandle(ctx, "@Admin_Gopher")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to retrieve profile by handle : %s.", dbtest.Failed, testID, err)
+			}
+			if prf.ID != adminID || prf.Posts != 1 || prf.Followers != 1 || prf.Following != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould get the profile counts : %+v.", dbtest.Failed, testID, prf)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to retrieve profile by handle.", dbtest.Success, testID)
+
+			up := user.UpdateProfile{
+				Handle: dbtest.StringPointer("user_gopher"),
+			}
+			if err := core.UpdateProfile(ctx, adminID, up, now); !errors.Is(err, user.ErrHandleTaken) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to take a used handle : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to take a used handle.", dbtest.Success, testID)
+
+			up.Handle = dbtest.StringPointer("admin")
+			if err := core.UpdateProfile(ctx, adminID, up, now); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to take a reserved handle.", dbtest.Failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to take a reserved handle.", dbtest.Success, testID)
+
+			up = user.UpdateProfile{
+				Handle: dbtest.StringPointer("Gopher"),
+				Bio:    dbtest.StringPointer("I like Go."),
+			}
+			if err := core.UpdateProfile(ctx, adminID, up, now); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to update profile : %s.", dbtest.Failed, testID, err)
+			}
+
+			prf, err = core.QueryProfileByHandle(ctx, "gopher")
+			if err != nil || prf.Bio != *up.Bio {
+				t.Fatalf("\t%s\tTest %d:\tShould see the profile updates : %+v %v.", dbtest.Failed, testID, prf, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould see the profile updates.", dbtest.Success, testID)
+
+			if _, err := core.QueryProfileByHandle(ctx, "admin_gopher"); !errors.Is(err, user.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT find the old handle : %v.", dbtest.Failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT find the old handle.", dbtest.Success, testID)
+		}
+	}
+}
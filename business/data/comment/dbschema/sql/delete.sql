@@ -1,2 +1,6 @@
+DELETE FROM comment_mentions;
+DELETE FROM comment_revisions;
 DELETE FROM comments;
-DELETE FROM posts;
\ No newline at end of file
+DELETE FROM posts;
+DELETE FROM followers;
+DELETE FROM handles;
\ No newline at end of file
@@ -23,4 +23,71 @@ CREATE TABLE comments (
 
 	PRIMARY KEY (comment_id),
 	FOREIGN KEY (post_id) REFERENCES posts(post_id) ON DELETE CASCADE
-);
\ No newline at end of file
+);
+
+-- Version: 1.3
+-- Description: Add parent comment to comments for threaded replies
+ALTER TABLE comments
+	ADD COLUMN parent_id UUID NULL,
+	ADD FOREIGN KEY (parent_id) REFERENCES comments(comment_id) ON DELETE CASCADE;
+
+-- Version: 1.4
+-- Description: Create table comment_revisions
+CREATE TABLE comment_revisions (
+	revision_id    UUID,
+	comment_id     UUID,
+	description    TEXT,
+	editor_id      UUID,
+	date_created   TIMESTAMP,
+
+	PRIMARY KEY (revision_id),
+	FOREIGN KEY (comment_id) REFERENCES comments(comment_id) ON DELETE CASCADE
+);
+
+-- Version: 1.5
+-- Description: Add edited indicator to comments
+ALTER TABLE comments ADD COLUMN edited BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Version: 1.6
+-- Description: Add soft deletion to posts and comments
+ALTER TABLE posts ADD COLUMN deleted_at TIMESTAMP NULL;
+ALTER TABLE comments ADD COLUMN deleted_at TIMESTAMP NULL;
+
+-- Version: 1.7
+-- Description: Add visibility to posts
+ALTER TABLE posts ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
+
+-- Version: 1.8
+-- Description: Create table followers
+CREATE TABLE followers (
+	user_id        UUID,
+	follower_id    UUID,
+	date_created   TIMESTAMP,
+
+	PRIMARY KEY (user_id, follower_id)
+);
+
+-- Version: 1.9
+-- Description: Create table handles
+CREATE TABLE handles (
+	user_id UUID,
+	handle  TEXT UNIQUE NOT NULL,
+
+	PRIMARY KEY (user_id)
+);
+
+-- Version: 1.10
+-- Description: Create table comment_mentions
+CREATE TABLE comment_mentions (
+	comment_id   UUID,
+	post_id      UUID,
+	user_id      UUID,
+	author_id    UUID,
+	handle       TEXT,
+	start_offset INT,
+	end_offset   INT,
+	date_created TIMESTAMP,
+
+	PRIMARY KEY (comment_id, start_offset),
+	FOREIGN KEY (comment_id) REFERENCES comments(comment_id) ON DELETE CASCADE
+);
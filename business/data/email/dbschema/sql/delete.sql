@@ -0,0 +1,3 @@
+DELETE FROM bounces;
+DELETE FROM suppressions;
+DELETE FROM emails;
\ No newline at end of file
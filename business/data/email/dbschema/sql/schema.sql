@@ -0,0 +1,54 @@
+-- Version: 1.1
+-- Description: Create table emails
+CREATE TABLE emails (
+	email_id       TEXT,
+	address        TEXT NOT NULL,
+	template       TEXT NOT NULL,
+	locale         TEXT NOT NULL,
+	vars           TEXT NOT NULL,
+	status         TEXT NOT NULL,
+	attempts       INT NOT NULL DEFAULT 0,
+	last_error     TEXT NULL,
+	next_attempt   TIMESTAMP NOT NULL,
+	date_created   TIMESTAMP NOT NULL,
+	date_updated   TIMESTAMP NOT NULL,
+	date_sent      TIMESTAMP NULL,
+
+	PRIMARY KEY (email_id)
+);
+CREATE INDEX emails_due_idx ON emails (next_attempt) WHERE status IN ('queued', 'retrying', 'sending');
+
+-- Version: 1.2
+-- Description: Create table suppressions
+CREATE TABLE suppressions (
+	address        TEXT,
+	reason         TEXT NOT NULL,
+	detail         TEXT NOT NULL,
+	date_created   TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (address)
+);
+
+-- Version: 1.3
+-- Description: Index emails for rate limiting
+CREATE INDEX emails_address_idx ON emails (LOWER(address), date_created);
+CREATE INDEX emails_sent_idx ON emails (date_sent) WHERE date_sent IS NOT NULL;
+
+-- Version: 1.4
+-- Description: Create table bounces
+CREATE TABLE bounces (
+	bounce_id      UUID,
+	address        TEXT NOT NULL,
+	kind           TEXT NOT NULL,
+	status         TEXT NOT NULL,
+	detail         TEXT NOT NULL,
+	date_created   TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (bounce_id)
+);
+CREATE INDEX bounces_address_idx ON bounces (address, date_created);
+
+-- Version: 1.5
+-- Description: Record the report of every bounce so reports are processed once
+ALTER TABLE bounces ADD COLUMN report_id TEXT NULL;
+CREATE UNIQUE INDEX bounces_report_idx ON bounces (report_id, address);
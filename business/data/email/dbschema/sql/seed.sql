@@ -0,0 +1,4 @@
+INSERT INTO emails (email_id, address, template, locale, vars, status, attempts, last_error, next_attempt, date_created, date_updated, date_sent) VALUES
+	('welcome:5cf37266-3473-4006-984f-9325122678b7', 'admin@example.com', 'welcome', 'en', '{"name":"Admin Gopher"}', 'sent', 1, NULL, '2019-03-24 00:00:00', '2019-03-24 00:00:00', '2019-03-24 00:00:01', '2019-03-24 00:00:01'),
+	('welcome:45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'user@example.com', 'welcome', 'en', '{"name":"User Gopher"}', 'sent', 2, NULL, '2019-03-24 00:00:00', '2019-03-24 00:00:00', '2019-03-24 00:00:31', '2019-03-24 00:00:31')
+	ON CONFLICT DO NOTHING;
@@ -0,0 +1,30 @@
+package email
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Set of codes the service rejects a Send with when the address must not
+// get the e-mail. Retrying later can not help a suppressed address, it can
+// help an address that went over its rate limit.
+const (
+	CodeSuppressed  = codes.FailedPrecondition
+	CodeRateLimited = codes.ResourceExhausted
+)
+
+// IsRejected reports whether the service refused to send the e-mail because
+// of the address, rather than failing to queue it.
+func IsRejected(err error) bool {
+	switch status.Code(err) {
+	case CodeSuppressed, CodeRateLimited:
+		return true
+	}
+	return false
+}
+
+// IsSuppressed reports whether the service refused to send the e-mail
+// because the address is suppressed, which retrying can not change.
+func IsSuppressed(err error) bool {
+	return status.Code(err) == CodeSuppressed
+}
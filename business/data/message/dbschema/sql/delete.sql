@@ -0,0 +1,3 @@
+DELETE FROM messages;
+DELETE FROM members;
+DELETE FROM conversations;
\ No newline at end of file
@@ -0,0 +1,44 @@
+-- Version: 1.1
+-- Description: Create table conversations
+CREATE TABLE conversations (
+	conversation_id   UUID,
+	creator_id        UUID,
+	title             TEXT NULL,
+	direct_key        TEXT NULL,
+	date_created      TIMESTAMP,
+	date_updated      TIMESTAMP,
+
+	PRIMARY KEY (conversation_id),
+	UNIQUE (direct_key)
+);
+
+-- Version: 1.2
+-- Description: Create table members
+CREATE TABLE members (
+	conversation_id   UUID,
+	user_id           UUID,
+	muted             BOOLEAN NOT NULL DEFAULT FALSE,
+	last_read_id      UUID NULL,
+	last_read_date    TIMESTAMP NULL,
+	date_read         TIMESTAMP NULL,
+	date_joined       TIMESTAMP,
+	date_left         TIMESTAMP NULL,
+
+	PRIMARY KEY (conversation_id, user_id),
+	FOREIGN KEY (conversation_id) REFERENCES conversations(conversation_id) ON DELETE CASCADE
+);
+CREATE INDEX members_user_idx ON members (user_id);
+
+-- Version: 1.3
+-- Description: Create table messages
+CREATE TABLE messages (
+	message_id        UUID,
+	conversation_id   UUID,
+	user_id           UUID,
+	body              TEXT,
+	date_created      TIMESTAMP,
+
+	PRIMARY KEY (message_id),
+	FOREIGN KEY (conversation_id) REFERENCES conversations(conversation_id) ON DELETE CASCADE
+);
+CREATE INDEX messages_history_idx ON messages (conversation_id, date_created, message_id);
@@ -0,0 +1,13 @@
+INSERT INTO conversations (conversation_id, creator_id, title, direct_key, date_created, date_updated) VALUES
+	('9b1f6c3e-5a2d-4e8f-b7c1-3d9e2f4a6b80', '5cf37266-3473-4006-984f-9325122678b7', NULL, '45b5fbd3-755f-4379-8f07-a58d4a30fa2f:5cf37266-3473-4006-984f-9325122678b7', '2019-03-24 00:00:00', '2019-03-24 00:01:00')
+	ON CONFLICT DO NOTHING;
+
+INSERT INTO members (conversation_id, user_id, last_read_id, last_read_date, date_read, date_joined) VALUES
+	('9b1f6c3e-5a2d-4e8f-b7c1-3d9e2f4a6b80', '5cf37266-3473-4006-984f-9325122678b7', 'e3c7a1d9-2b4f-4a6e-8c0d-5f7b9a1c3e52', '2019-03-24 00:00:00', '2019-03-24 00:00:00', '2019-03-24 00:00:00'),
+	('9b1f6c3e-5a2d-4e8f-b7c1-3d9e2f4a6b80', '45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'f4d8b2e0-3c5a-4b7f-9d1e-6a8c0b2d4f63', '2019-03-24 00:01:00', '2019-03-24 00:01:00', '2019-03-24 00:00:00')
+	ON CONFLICT DO NOTHING;
+
+INSERT INTO messages (message_id, conversation_id, user_id, body, date_created) VALUES
+	('e3c7a1d9-2b4f-4a6e-8c0d-5f7b9a1c3e52', '9b1f6c3e-5a2d-4e8f-b7c1-3d9e2f4a6b80', '5cf37266-3473-4006-984f-9325122678b7', 'Thanks for listening to the new song!', '2019-03-24 00:00:00'),
+	('f4d8b2e0-3c5a-4b7f-9d1e-6a8c0b2d4f63', '9b1f6c3e-5a2d-4e8f-b7c1-3d9e2f4a6b80', '45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'It is great, when is the album out?', '2019-03-24 00:01:00')
+	ON CONFLICT DO NOTHING;
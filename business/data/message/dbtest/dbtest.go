@@ -0,0 +1,160 @@
+// Package dbtest contains supporting code for running tests that hit the DB.
+package dbtest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/social-network/business/data/message/dbschema"
+	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/foundation/docker"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Success and failure markers.
+const (
+	Success = "✓"
+	Failed  = "✗"
+)
+
+// StartDB starts a database instance.
+func StartDB() (*docker.Container, error) {
+	image := "postgres:13-alpine"
+	port := "5432"
+	args := []string{"-e", "POSTGRES_PASSWORD=postgres"}
+
+	return docker.StartContainer(image, port, args...)
+}
+
+// StopDB stops a running database instance.
+func StopDB(c *docker.Container) {
+	docker.StopContainer(c.ID)
+}
+
+// StartNATS starts a NATS streaming instance.
+func StartNATS() (*docker.Container, error) {
+	image := "nats-streaming:0.17.0"
+	port := "4222"
+	args := []string{"-p", "4222", "-m", "8222", "-hbi", "5s", "-hbt", "5s", "-hbf", "2", "-SD", "-cid", "social-network"}
+
+	return docker.StartContainer(image, port, args...)
+}
+
+// StopNATS stops a running NATS streaming instance.
+func StopNATS(c *docker.Container) {
+	docker.StopContainer(c.ID)
+}
+
+// NewNATS opens a connection to the NATS streaming instance for tests that
+// publish events. It returns the connection as well as a function to call at
+// the end of the test.
+func NewNATS(t *testing.T, c *docker.Container) (*nats.NATS, func()) {
+	t.Log("Opening NATS connection ...")
+
+	n, err := nats.Connect(nats.Config{
+		ClusterID: "social-network",
+		ClientID:  "messages",
+		Host:      c.Host,
+	})
+	if err != nil {
+		t.Fatalf("Connecting to NATS: %v", err)
+	}
+
+	t.Log("NATS ready ...")
+
+	teardown := func() {
+		t.Helper()
+		n.Client.Close()
+	}
+
+	return n, teardown
+}
+
+// NewUnit creates a test database inside a Docker container. It creates the
+// required table structure but the database is otherwise empty. It returns
+// the database to use as well as a function to call at the end of the test.
+func NewUnit(t *testing.T, c *docker.Container, dbName string) (*zap.SugaredLogger, *sqlx.DB, func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	dbM, err := database.Open(database.Config{
+		User:       "postgres",
+		Password:   "postgres",
+		Host:       c.Host,
+		Name:       "postgres",
+		DisableTLS: true,
+	})
+	if err != nil {
+		t.Fatalf("Opening database connection: %v", err)
+	}
+
+	t.Log("Waiting for database to be ready ...")
+
+	if err := database.StatusCheck(ctx, dbM); err != nil {
+		t.Fatalf("status check database: %v", err)
+	}
+
+	t.Log("Database ready")
+
+	if _, err := dbM.ExecContext(context.Background(), "CREATE DATABASE "+dbName); err != nil {
+		t.Fatalf("creating database %s: %v", dbName, err)
+	}
+	dbM.Close()
+
+	// =========================================================================
+
+	db, err := database.Open(database.Config{
+		User:       "postgres",
+		Password:   "postgres",
+		Host:       c.Host,
+		Name:       dbName,
+		DisableTLS: true,
+	})
+	if err != nil {
+		t.Fatalf("Opening database connection: %v", err)
+	}
+
+	t.Log("Migrate and seed database ...")
+
+	if err := dbschema.Migrate(ctx, db); err != nil {
+		docker.DumpContainerLogs(t, c.ID)
+		t.Fatalf("Migrating error: %s", err)
+	}
+
+	if err := dbschema.Seed(ctx, db); err != nil {
+		docker.DumpContainerLogs(t, c.ID)
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	t.Log("Ready for testing ...")
+
+	var buf bytes.Buffer
+	encoder := zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	writer := bufio.NewWriter(&buf)
+	log := zap.New(
+		zapcore.NewCore(encoder, zapcore.AddSync(writer), zapcore.DebugLevel)).
+		Sugar()
+
+	// teardown is the function that should be invoked when the caller is done
+	// with the database.
+	teardown := func() {
+		t.Helper()
+		db.Close()
+
+		log.Sync()
+
+		writer.Flush()
+		fmt.Println("******************** LOGS ********************")
+		fmt.Print(buf.String())
+		fmt.Println("******************** LOGS ********************")
+	}
+
+	return log, db, teardown
+}
@@ -0,0 +1,7 @@
+DELETE FROM notifications;
+DELETE FROM comments;
+DELETE FROM posts;
+DELETE FROM handles;
+DELETE FROM contacts;
+DELETE FROM preferences;
+DELETE FROM digests;
\ No newline at end of file
@@ -0,0 +1,84 @@
+-- Version: 1.1
+-- Description: Create table posts
+CREATE TABLE posts (
+	post_id        UUID,
+	user_id        UUID,
+
+	PRIMARY KEY (post_id)
+);
+
+-- Version: 1.2
+-- Description: Create table comments
+CREATE TABLE comments (
+	comment_id     UUID,
+	user_id        UUID,
+	post_id        UUID,
+
+	PRIMARY KEY (comment_id)
+);
+
+-- Version: 1.3
+-- Description: Create table handles
+CREATE TABLE handles (
+	user_id        UUID,
+	handle         TEXT,
+
+	PRIMARY KEY (user_id),
+	UNIQUE (handle)
+);
+
+-- Version: 1.4
+-- Description: Create table notifications
+CREATE TABLE notifications (
+	notification_id   UUID,
+	user_id           UUID,
+	actor_id          UUID,
+	type              TEXT,
+	target_id         UUID,
+	source_id         UUID,
+	post_id           UUID NULL,
+	comment_id        UUID NULL,
+	date_created      TIMESTAMP,
+	date_read         TIMESTAMP NULL,
+
+	PRIMARY KEY (notification_id),
+	UNIQUE (user_id, type, actor_id, source_id)
+);
+CREATE INDEX notifications_inbox_idx ON notifications (user_id, type, target_id, date_created);
+
+-- Version: 1.5
+-- Description: Create table contacts
+CREATE TABLE contacts (
+	user_id        UUID,
+	email          TEXT,
+	name           TEXT,
+
+	PRIMARY KEY (user_id)
+);
+
+-- Version: 1.6
+-- Description: Create table preferences
+CREATE TABLE preferences (
+	user_id        UUID,
+	type           TEXT,
+	delivery       TEXT,
+	date_updated   TIMESTAMP,
+
+	PRIMARY KEY (user_id, type)
+);
+
+-- Version: 1.7
+-- Description: Create table digests
+CREATE TABLE digests (
+	user_id        UUID,
+	frequency      TEXT,
+	period         TIMESTAMP,
+	date_sent      TIMESTAMP,
+
+	PRIMARY KEY (user_id, frequency)
+);
+
+-- Version: 1.8
+-- Description: Add the locale users are e-mailed in to contacts
+ALTER TABLE contacts
+	ADD COLUMN locale TEXT NOT NULL DEFAULT 'en';
@@ -0,0 +1,24 @@
+INSERT INTO posts (post_id, user_id) VALUES
+	('3dc0a440-2e05-11ed-a261-0242ac120002', '5cf37266-3473-4006-984f-9325122678b7'),
+	('47d0e86e-2e05-11ed-a261-0242ac120002', '5cf37266-3473-4006-984f-9325122678b7')
+	ON CONFLICT DO NOTHING;
+
+INSERT INTO comments (comment_id, user_id, post_id) VALUES
+	('7f6edd62-2e05-11ed-a261-0242ac120002', '45b5fbd3-755f-4379-8f07-a58d4a30fa2f', '3dc0a440-2e05-11ed-a261-0242ac120002'),
+	('a855e52c-2e05-11ed-a261-0242ac120002', '45b5fbd3-755f-4379-8f07-a58d4a30fa2f', '47d0e86e-2e05-11ed-a261-0242ac120002')
+	ON CONFLICT DO NOTHING;
+
+INSERT INTO handles (user_id, handle) VALUES
+	('5cf37266-3473-4006-984f-9325122678b7', 'admin_gopher'),
+	('45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'user_gopher')
+	ON CONFLICT DO NOTHING;
+
+INSERT INTO notifications (notification_id, user_id, actor_id, type, target_id, source_id, post_id, comment_id, date_created) VALUES
+	('c5f3b2b4-4f9e-4c1b-9d3a-2b7f1e6d8a01', '5cf37266-3473-4006-984f-9325122678b7', '45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'comment', '3dc0a440-2e05-11ed-a261-0242ac120002', '7f6edd62-2e05-11ed-a261-0242ac120002', '3dc0a440-2e05-11ed-a261-0242ac120002', '7f6edd62-2e05-11ed-a261-0242ac120002', '2019-03-24 00:00:00'),
+	('d2a7e9c0-8b1f-4e55-a0c6-9f4d3b2e7c12', '5cf37266-3473-4006-984f-9325122678b7', '45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'comment', '47d0e86e-2e05-11ed-a261-0242ac120002', 'a855e52c-2e05-11ed-a261-0242ac120002', '47d0e86e-2e05-11ed-a261-0242ac120002', 'a855e52c-2e05-11ed-a261-0242ac120002', '2019-03-24 00:00:00')
+	ON CONFLICT DO NOTHING;
+
+INSERT INTO contacts (user_id, email, name) VALUES
+	('5cf37266-3473-4006-984f-9325122678b7', 'admin@example.com', 'Admin Gopher'),
+	('45b5fbd3-755f-4379-8f07-a58d4a30fa2f', 'user@example.com', 'User Gopher')
+	ON CONFLICT DO NOTHING;
@@ -1 +1,9 @@
-DELETE FROM posts;
\ No newline at end of file
+DELETE FROM post_mentions;
+DELETE FROM attachments;
+DELETE FROM post_score_events;
+DELETE FROM post_scores;
+DELETE FROM timelines;
+DELETE FROM post_revisions;
+DELETE FROM posts;
+DELETE FROM followers;
+DELETE FROM handles;
\ No newline at end of file
@@ -9,4 +9,166 @@ CREATE TABLE posts (
 	date_updated   TIMESTAMP,
 
 	PRIMARY KEY (post_id)
-);
\ No newline at end of file
+);
+
+-- Version: 1.2
+-- Description: Create table post_revisions
+CREATE TABLE post_revisions (
+	revision_id    UUID,
+	post_id        UUID,
+	title          TEXT,
+	description    TEXT,
+	editor_id      UUID,
+	date_created   TIMESTAMP,
+
+	PRIMARY KEY (revision_id),
+	FOREIGN KEY (post_id) REFERENCES posts(post_id) ON DELETE CASCADE
+);
+
+-- Version: 1.3
+-- Description: Add edited indicator to posts
+ALTER TABLE posts ADD COLUMN edited BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Version: 1.4
+-- Description: Add soft deletion to posts
+ALTER TABLE posts ADD COLUMN deleted_at TIMESTAMP NULL;
+
+-- Version: 1.5
+-- Description: Add publishing status to posts
+ALTER TABLE posts
+	ADD COLUMN status TEXT NOT NULL DEFAULT 'published',
+	ADD COLUMN publish_at TIMESTAMP NULL;
+CREATE INDEX posts_scheduled_idx ON posts (publish_at) WHERE status = 'scheduled';
+
+-- Version: 1.6
+-- Description: Add visibility to posts
+ALTER TABLE posts ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
+
+-- Version: 1.7
+-- Description: Create table followers
+CREATE TABLE followers (
+	user_id        UUID,
+	follower_id    UUID,
+	date_created   TIMESTAMP,
+
+	PRIMARY KEY (user_id, follower_id)
+);
+
+-- Version: 1.8
+-- Description: Create table timelines
+CREATE TABLE timelines (
+	user_id        UUID,
+	post_id        UUID,
+	author_id      UUID,
+	date_published TIMESTAMP,
+
+	PRIMARY KEY (user_id, post_id),
+	FOREIGN KEY (post_id) REFERENCES posts(post_id) ON DELETE CASCADE
+);
+CREATE INDEX timelines_feed_idx ON timelines (user_id, date_published DESC, post_id DESC);
+CREATE INDEX followers_follower_idx ON followers (follower_id);
+
+-- Version: 1.9
+-- Description: Create table post_scores
+CREATE TABLE post_scores (
+	post_id  UUID,
+	score    DOUBLE PRECISION NOT NULL,
+	comments INT NOT NULL DEFAULT 0,
+
+	PRIMARY KEY (post_id),
+	FOREIGN KEY (post_id) REFERENCES posts(post_id) ON DELETE CASCADE
+);
+CREATE INDEX post_scores_score_idx ON post_scores (score DESC);
+
+-- Version: 1.10
+-- Description: Create table attachments
+CREATE TABLE attachments (
+	attachment_id UUID,
+	user_id       UUID,
+	content_type  TEXT,
+	size          BIGINT,
+	checksum      TEXT,
+	date_created  TIMESTAMP,
+
+	PRIMARY KEY (attachment_id)
+);
+CREATE INDEX attachments_user_idx ON attachments (user_id);
+
+-- Version: 1.11
+-- Description: Add attachments to posts
+ALTER TABLE posts ADD COLUMN attachment_ids TEXT[] NOT NULL DEFAULT '{}';
+
+-- Version: 1.12
+-- Description: Add image processing results to attachments
+ALTER TABLE attachments ADD COLUMN status TEXT NOT NULL DEFAULT 'pending';
+ALTER TABLE attachments ADD COLUMN attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE attachments ADD COLUMN width INT NOT NULL DEFAULT 0;
+ALTER TABLE attachments ADD COLUMN height INT NOT NULL DEFAULT 0;
+ALTER TABLE attachments ADD COLUMN blurhash TEXT NOT NULL DEFAULT '';
+ALTER TABLE attachments ADD COLUMN variants TEXT[] NOT NULL DEFAULT '{}';
+
+-- Version: 1.13
+-- Description: Create table handles
+CREATE TABLE handles (
+	user_id UUID,
+	handle  TEXT UNIQUE NOT NULL,
+
+	PRIMARY KEY (user_id)
+);
+
+-- Version: 1.14
+-- Description: Create table post_mentions
+CREATE TABLE post_mentions (
+	post_id      UUID,
+	user_id      UUID,
+	author_id    UUID,
+	handle       TEXT,
+	start_offset INT,
+	end_offset   INT,
+	date_created TIMESTAMP,
+
+	PRIMARY KEY (post_id, start_offset),
+	FOREIGN KEY (post_id) REFERENCES posts(post_id) ON DELETE CASCADE
+);
+
+-- Version: 1.15
+-- Description: Create table post_score_events
+CREATE TABLE post_score_events (
+	event_id UUID,
+	post_id  UUID,
+	counted  BOOLEAN NOT NULL,
+
+	PRIMARY KEY (event_id),
+	FOREIGN KEY (post_id) REFERENCES posts(post_id) ON DELETE CASCADE
+);
+
+-- Version: 1.16
+-- Description: Keep the posts on the timelines so timelines are read on their own
+ALTER TABLE timelines
+	ADD COLUMN title           TEXT NOT NULL DEFAULT '',
+	ADD COLUMN description     TEXT NOT NULL DEFAULT '',
+	ADD COLUMN date_created    TIMESTAMP,
+	ADD COLUMN date_updated    TIMESTAMP,
+	ADD COLUMN edited          BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD COLUMN publish_at      TIMESTAMP NULL,
+	ADD COLUMN visibility      TEXT NOT NULL DEFAULT 'public',
+	ADD COLUMN fan_out_on_read BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE timelines t SET
+	title = p.title,
+	description = p.description,
+	date_created = p.date_created,
+	date_updated = p.date_updated,
+	edited = p.edited,
+	publish_at = p.publish_at,
+	visibility = p.visibility
+FROM
+	posts p
+WHERE
+	p.post_id = t.post_id;
+UPDATE timelines t SET
+	fan_out_on_read = TRUE
+WHERE
+	t.user_id = t.author_id AND
+	t.visibility != 'private' AND
+	NOT EXISTS (SELECT 1 FROM timelines o WHERE o.post_id = t.post_id AND o.user_id != t.user_id);
+CREATE INDEX timelines_fan_out_idx ON timelines (user_id, date_published DESC, post_id DESC) WHERE fan_out_on_read;
@@ -1,3 +1,6 @@
+DELETE FROM user_posts;
+DELETE FROM follow_requests;
+DELETE FROM follows;
 DELETE FROM sales;
 DELETE FROM products;
 DELETE FROM users;
\ No newline at end of file
@@ -40,4 +40,64 @@ CREATE TABLE sales (
 	PRIMARY KEY (sale_id),
 	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
 	FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE
-);
\ No newline at end of file
+);
+
+-- Version: 1.4
+-- Description: Add private accounts to users
+ALTER TABLE users ADD COLUMN private BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Version: 1.5
+-- Description: Create table follows
+CREATE TABLE follows (
+	follower_id  UUID,
+	followee_id  UUID,
+	date_created TIMESTAMP,
+
+	PRIMARY KEY (follower_id, followee_id),
+	FOREIGN KEY (follower_id) REFERENCES users(user_id) ON DELETE CASCADE,
+	FOREIGN KEY (followee_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+CREATE INDEX follows_followee_idx ON follows (followee_id, date_created);
+
+-- Version: 1.6
+-- Description: Create table follow_requests
+CREATE TABLE follow_requests (
+	requester_id UUID,
+	target_id    UUID,
+	date_created TIMESTAMP,
+
+	PRIMARY KEY (requester_id, target_id),
+	FOREIGN KEY (requester_id) REFERENCES users(user_id) ON DELETE CASCADE,
+	FOREIGN KEY (target_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- Version: 1.7
+-- Description: Add profiles to users
+ALTER TABLE users
+	ADD COLUMN handle       TEXT UNIQUE,
+	ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+	ADD COLUMN bio          TEXT NOT NULL DEFAULT '',
+	ADD COLUMN avatar_url   TEXT NOT NULL DEFAULT '',
+	ADD COLUMN location     TEXT NOT NULL DEFAULT '',
+	ADD COLUMN website      TEXT NOT NULL DEFAULT '';
+
+-- Version: 1.8
+-- Description: Create table user_posts
+CREATE TABLE user_posts (
+	post_id UUID,
+	user_id UUID,
+
+	PRIMARY KEY (post_id),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+CREATE INDEX user_posts_user_idx ON user_posts (user_id);
+
+-- Version: 1.9
+-- Description: Add e-mail verification to users
+ALTER TABLE users
+	ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Version: 1.10
+-- Description: Add the locale users are e-mailed in
+ALTER TABLE users
+	ADD COLUMN locale TEXT NOT NULL DEFAULT 'en';
@@ -16,6 +16,7 @@ import (
 	"github.com/dudakovict/social-network/business/data/user/dbschema"
 	"github.com/dudakovict/social-network/business/sys/auth"
 	"github.com/dudakovict/social-network/business/sys/database"
+	"github.com/dudakovict/social-network/business/sys/nats"
 	"github.com/dudakovict/social-network/foundation/docker"
 	"github.com/dudakovict/social-network/foundation/keystore"
 	"github.com/golang-jwt/jwt/v4"
@@ -59,6 +60,45 @@ func StopGRPC(c *docker.Container) {
 	docker.StopContainer(c.ID)
 }
 
+// StartNATS starts a NATS streaming instance.
+func StartNATS() (*docker.Container, error) {
+	image := "nats-streaming:0.17.0"
+	port := "4222"
+	args := []string{"-p", "4222", "-m", "8222", "-hbi", "5s", "-hbt", "5s", "-hbf", "2", "-SD", "-cid", "social-network"}
+
+	return docker.StartContainer(image, port, args...)
+}
+
+// StopNATS stops a running NATS streaming instance.
+func StopNATS(c *docker.Container) {
+	docker.StopContainer(c.ID)
+}
+
+// NewNATS opens a connection to the NATS streaming instance for tests that
+// publish events. It returns the connection as well as a function to call at
+// the end of the test.
+func NewNATS(t *testing.T, c *docker.Container) (*nats.NATS, func()) {
+	t.Log("Opening NATS connection ...")
+
+	n, err := nats.Connect(nats.Config{
+		ClusterID: "social-network",
+		ClientID:  "users",
+		Host:      c.Host,
+	})
+	if err != nil {
+		t.Fatalf("Connecting to NATS: %v", err)
+	}
+
+	t.Log("NATS ready ...")
+
+	teardown := func() {
+		t.Helper()
+		n.Client.Close()
+	}
+
+	return n, teardown
+}
+
 // NewUnit creates a test database inside a Docker container. It creates the
 // required table structure but the database is otherwise empty. It returns
 // the database to use as well as a function to call at the end of the test.
@@ -12,7 +12,7 @@ import (
 
 	"github.com/dudakovict/social-network/foundation/web"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // Calls init function.
+	"github.com/lib/pq"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
@@ -20,9 +20,14 @@ import (
 
 // Set of error variables for CRUD operations.
 var (
-	ErrDBNotFound = errors.New("not found")
+	ErrDBNotFound        = errors.New("not found")
+	ErrDBDuplicatedEntry = errors.New("duplicated entry")
 )
 
+// uniqueViolation is the code postgres reports a violated unique
+// constraint with.
+const uniqueViolation = "23505"
+
 // Config is the required properties to use the database.
 type Config struct {
 	User         string
@@ -158,6 +163,13 @@ func NamedExecContext(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtCo
 	return nil
 }
 
+// IsUniqueViolation reports whether the error is a violation of the named
+// unique constraint.
+func IsUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && pqErr.Constraint == constraint
+}
+
 // NamedQuerySlice is a helper function for executing queries that return a
 // collection of data to be unmarshaled into a slice.
 func NamedQuerySlice(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtContext, query string, data interface{}, dest interface{}) error {
@@ -177,6 +189,7 @@ func NamedQuerySlice(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtCon
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
 	slice := val.Elem()
 	for rows.Next() {
@@ -204,6 +217,7 @@ func NamedQueryStruct(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtCo
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 	if !rows.Next() {
 		return ErrDBNotFound
 	}
@@ -0,0 +1,76 @@
+// Package locale contains the support for picking the language users are
+// spoken to in.
+package locale
+
+import (
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/hr"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// Default is the locale used when none of the requested locales is
+// supported.
+const Default = "en"
+
+// Supported returns the locales messages are translated to, the default
+// locale first.
+func Supported() []locales.Translator {
+	return []locales.Translator{
+		en.New(),
+		hr.New(),
+	}
+}
+
+// New constructs a universal translator for the supported locales that
+// falls back to the default locale.
+func New() *ut.UniversalTranslator {
+	supported := Supported()
+	return ut.New(supported[0], supported...)
+}
+
+// Chain returns the fallback chain for the requested locales in order of
+// preference: every locale followed by its base language, and the default
+// locale last. Locales are named in lower case with underscores, the way
+// the translators are, so "hr-HR" becomes "hr_hr" followed by "hr".
+func Chain(requested ...string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+
+	add := func(l string) {
+		if l != "" && !seen[l] {
+			seen[l] = true
+			chain = append(chain, l)
+		}
+	}
+
+	for _, l := range requested {
+		l = Normalize(l)
+		add(l)
+		if i := strings.Index(l, "_"); i != -1 {
+			add(l[:i])
+		}
+	}
+	add(Default)
+
+	return chain
+}
+
+// Match returns the first supported locale of the fallback chain for the
+// requested locales.
+func Match(requested ...string) string {
+	uni := New()
+	for _, l := range Chain(requested...) {
+		if _, ok := uni.GetTranslator(l); ok {
+			return l
+		}
+	}
+	return Default
+}
+
+// Normalize names the locale in lower case with underscores.
+func Normalize(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "-", "_"))
+}
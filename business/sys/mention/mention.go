@@ -0,0 +1,77 @@
+// Package mention provides support for finding @handle mentions in text.
+package mention
+
+import (
+	"github.com/dudakovict/social-network/business/sys/validate"
+)
+
+// MaxHandles caps how many different users a single text can mention.
+// Further handles are not treated as mentions.
+const MaxHandles = 20
+
+// Mention is a reference to a user by handle. Start and End are offsets in
+// Unicode code points into the text, End is exclusive, and cover the @ sign
+// along with the handle.
+type Mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// Parse returns the mentions in the text in the order they appear. Handles
+// are normalized and those that are not valid handles are skipped. An @ that
+// follows a letter, number or underscore, as in an email address, does not
+// start a mention.
+func Parse(text string) []Mention {
+	runes := []rune(text)
+
+	var mentions []Mention
+	seen := make(map[string]bool)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && (isHandleRune(runes[i-1]) || runes[i-1] == '@')) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isHandleRune(runes[end]) {
+			end++
+		}
+
+		handle := validate.NormalizeHandle(string(runes[i+1 : end]))
+		if validate.CheckHandle(handle) == nil {
+			if !seen[handle] && len(seen) == MaxHandles {
+				break
+			}
+			seen[handle] = true
+
+			mentions = append(mentions, Mention{
+				Handle: handle,
+				Start:  i,
+				End:    end,
+			})
+		}
+
+		i = end - 1
+	}
+
+	return mentions
+}
+
+// Handles returns the distinct handles of the mentions.
+func Handles(mentions []Mention) []string {
+	var handles []string
+	seen := make(map[string]bool)
+	for _, m := range mentions {
+		if !seen[m.Handle] {
+			seen[m.Handle] = true
+			handles = append(handles, m.Handle)
+		}
+	}
+	return handles
+}
+
+// isHandleRune reports whether the rune can be part of a handle.
+func isHandleRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
@@ -0,0 +1,68 @@
+package mention_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dudakovict/social-network/business/sys/mention"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	tt := []struct {
+		name string
+		text string
+		exp  []mention.Mention
+	}{
+		{
+			name: "start and middle",
+			text: "@gopher meet @Ferris_42.",
+			exp: []mention.Mention{
+				{Handle: "gopher", Start: 0, End: 7},
+				{Handle: "ferris_42", Start: 13, End: 23},
+			},
+		},
+		{
+			name: "code point offsets",
+			text: "Čestitke @gopher!",
+			exp: []mention.Mention{
+				{Handle: "gopher", Start: 9, End: 16},
+			},
+		},
+		{
+			name: "repeated",
+			text: "@gopher @gopher",
+			exp: []mention.Mention{
+				{Handle: "gopher", Start: 0, End: 7},
+				{Handle: "gopher", Start: 8, End: 15},
+			},
+		},
+		{name: "email address", text: "mail gopher@example.com"},
+		{name: "too short", text: "hi @go"},
+		{name: "reserved", text: "ask @admin"},
+		{name: "double at", text: "@@gopher"},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			got := mention.Parse(tst.text)
+			if diff := cmp.Diff(tst.exp, got); diff != "" {
+				t.Fatalf("Should get the expected mentions. Diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseMaxHandles(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < mention.MaxHandles+5; i++ {
+		b.WriteString("@user_")
+		b.WriteByte(byte('a' + i))
+		b.WriteString(" ")
+	}
+
+	got := mention.Handles(mention.Parse(b.String()))
+	if len(got) != mention.MaxHandles {
+		t.Fatalf("Should cap the number of handles at %d : got %d.", mention.MaxHandles, len(got))
+	}
+}
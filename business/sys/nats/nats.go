@@ -2,10 +2,15 @@
 package nats
 
 import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
 	"time"
 
 	nats "github.com/nats-io/nats.go"
 	stan "github.com/nats-io/stan.go"
+	"go.uber.org/zap"
 )
 
 type Config struct {
@@ -54,3 +59,27 @@ func (n NATS) Subscribe(subject string, queueGroupName string, cb stan.MsgHandle
 
 	return nil
 }
+
+// Handle subscribes fn to the gob encoded events of type T on the subject.
+// Events fn fails on are logged and not acknowledged, so they are delivered
+// again once the ack wait passes.
+func Handle[T any](n *NATS, log *zap.SugaredLogger, subject string, queueGroupName string, fn func(context.Context, T) error) error {
+	return n.Subscribe(subject, queueGroupName, func(m *stan.Msg) {
+		buf := bytes.NewReader(m.Data)
+		dec := gob.NewDecoder(buf)
+
+		var v T
+
+		if err := dec.Decode(&v); err != nil {
+			log.Errorw(subject, "ERROR", fmt.Errorf("decoding: %w", err))
+			return
+		}
+
+		if err := fn(context.Background(), v); err != nil {
+			log.Errorw(subject, "ERROR", err)
+			return
+		}
+
+		m.Ack()
+	})
+}
@@ -0,0 +1,235 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// healthService is the prefix of the methods of the standard health service,
+// which load balancers call without credentials.
+const healthService = "/grpc.health.v1.Health/"
+
+// Trace starts a span for the call, continuing the trace of the caller when
+// the metadata carries one.
+func Trace() Interceptor {
+	tracer := otel.Tracer("rpc")
+
+	i := func(ctx context.Context, method string, next func(ctx context.Context) error) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+		ctx, span := tracer.Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("rpc.system", "grpc")),
+		)
+		defer span.End()
+
+		err := next(ctx)
+
+		code := status.Code(err)
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+		if err != nil {
+			span.SetStatus(otelcodes.Error, code.String())
+		}
+
+		return err
+	}
+
+	return i
+}
+
+// Logger writes some information about the call to the logs in the
+// format: TraceID : (OK) /email.Email/Send -> IP ADDR (latency)
+func Logger(log *zap.SugaredLogger) Interceptor {
+	i := func(ctx context.Context, method string, next func(ctx context.Context) error) error {
+		now := time.Now()
+		traceID := trace.SpanFromContext(ctx).SpanContext().TraceID().String()
+
+		var remoteAddr string
+		if p, ok := peer.FromContext(ctx); ok {
+			remoteAddr = p.Addr.String()
+		}
+
+		log.Infow("request started", "traceid", traceID, "method", method, "remoteaddr", remoteAddr)
+
+		err := next(ctx)
+
+		log.Infow("request completed", "traceid", traceID, "method", method, "remoteaddr", remoteAddr,
+			"code", status.Code(err).String(), "since", time.Since(now))
+
+		if err != nil && status.Code(err) == codes.Internal {
+			log.Errorw("ERROR", "traceid", traceID, "ERROR", err)
+		}
+
+		return err
+	}
+
+	return i
+}
+
+// Metrics updates program counters.
+func Metrics() Interceptor {
+	i := func(ctx context.Context, method string, next func(ctx context.Context) error) error {
+
+		// Add the metrics into the context for metric gathering.
+		ctx = metrics.Set(ctx)
+
+		err := next(ctx)
+
+		// Increment the request and goroutines counter.
+		metrics.AddRequests(ctx)
+		metrics.AddGoroutines(ctx)
+
+		// Increment if there is an error flowing through the call.
+		if err != nil {
+			metrics.AddErrors(ctx)
+		}
+
+		return err
+	}
+
+	return i
+}
+
+// Panics recovers from panics and converts the panic to an Internal error so
+// it is reported in Metrics and logged with its stack trace. The caller only
+// gets the code.
+func Panics(log *zap.SugaredLogger) Interceptor {
+	i := func(ctx context.Context, method string, next func(ctx context.Context) error) (err error) {
+
+		// Defer a function to recover from a panic and set the err return
+		// variable after the fact.
+		defer func() {
+			if rec := recover(); rec != nil {
+				metrics.AddPanics(ctx)
+
+				traceID := trace.SpanFromContext(ctx).SpanContext().TraceID().String()
+				log.Errorw("PANIC", "traceid", traceID, "method", method, "panic", rec, "TRACE", string(debug.Stack()))
+
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+
+		return next(ctx)
+	}
+
+	return i
+}
+
+// Authenticate requires every call but health checks to come from a client
+// with one of the specified roles. Clients that presented a certificate
+// verified by the server (mTLS) are services named after the common name of
+// the certificate. Other clients need a JWT in the authorization metadata.
+func Authenticate(a *auth.Auth, roles ...string) Interceptor {
+	i := func(ctx context.Context, method string, next func(ctx context.Context) error) error {
+		if strings.HasPrefix(method, healthService) {
+			return next(ctx)
+		}
+
+		claims, err := authenticate(ctx, a)
+		if err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		if !claims.Authorized(roles...) {
+			return status.Errorf(codes.PermissionDenied, "you are not authorized for that action, claims[%v] roles[%v]", claims.Roles, roles)
+		}
+
+		// Add claims to the context so they can be retrieved later.
+		return next(auth.SetClaims(ctx, claims))
+	}
+
+	return i
+}
+
+// ClientTrace propagates the trace of the caller to the server.
+func ClientTrace() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if !ok {
+			md = metadata.MD{}
+		} else {
+			md = md.Copy()
+		}
+
+		otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+
+		return invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+	}
+}
+
+// =============================================================================
+
+// authenticate builds the claims of the client from its certificate or its
+// token.
+func authenticate(ctx context.Context, a *auth.Auth) (auth.Claims, error) {
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+			claims := auth.Claims{
+				Roles: []string{auth.RoleService},
+			}
+			claims.Subject = info.State.VerifiedChains[0][0].Subject.CommonName
+			return claims, nil
+		}
+	}
+
+	// Expecting: bearer <token>
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) != 1 {
+		return auth.Claims{}, errors.New("expected authorization metadata format: bearer <token>")
+	}
+
+	parts := strings.Split(values[0], " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return auth.Claims{}, errors.New("expected authorization metadata format: bearer <token>")
+	}
+
+	// Validate the token is signed by us.
+	return a.ValidateToken(parts[1])
+}
+
+// metadataCarrier adapts gRPC metadata to carry the trace context.
+type metadataCarrier metadata.MD
+
+var _ propagation.TextMapCarrier = metadataCarrier{}
+
+// Get returns the value associated with the passed key.
+func (mc metadataCarrier) Get(key string) string {
+	if values := metadata.MD(mc).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Set stores the key-value pair.
+func (mc metadataCarrier) Set(key string, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+// Keys lists the keys stored in this carrier.
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for k := range mc {
+		keys = append(keys, k)
+	}
+	return keys
+}
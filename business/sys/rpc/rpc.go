@@ -0,0 +1,162 @@
+// Package rpc provides support for securing and observing gRPC services and
+// the clients calling them.
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Interceptor runs code before and after the handling of a call, unary and
+// streaming alike. It calls next with the context the rest of the chain
+// should see.
+type Interceptor func(ctx context.Context, method string, next func(ctx context.Context) error) error
+
+// Unary chains the interceptors into a unary server interceptor. The first
+// interceptor runs first.
+func Unary(is ...Interceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var resp interface{}
+
+		call := func(ctx context.Context) error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		}
+
+		err := chain(is, info.FullMethod, call)(ctx)
+		return resp, err
+	}
+}
+
+// Stream chains the interceptors into a stream server interceptor. The first
+// interceptor runs first.
+func Stream(is ...Interceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		call := func(ctx context.Context) error {
+			return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		}
+
+		return chain(is, info.FullMethod, call)(ss.Context())
+	}
+}
+
+// TLSConfig holds the files the transport credentials are loaded from.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+// ServerCredentials returns the transport credentials of a server. Without a
+// certificate the server accepts plain text connections. With a CA the
+// server requires clients to present a certificate signed by it (mTLS).
+func ServerCredentials(cfg TLSConfig) (credentials.TransportCredentials, error) {
+	if cfg.CertFile == "" {
+		if cfg.CAFile != "" {
+			return nil, errors.New("client certificates need a server certificate")
+		}
+		return insecure.NewCredentials(), nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading certificate: %w", err)
+	}
+
+	tlsConfig := tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.CAFile != "" {
+		pool, err := loadPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return credentials.NewTLS(&tlsConfig), nil
+}
+
+// ClientCredentials returns the transport credentials of a client. Without
+// a CA the client connects in plain text. With a certificate the client
+// presents it to the server (mTLS).
+func ClientCredentials(cfg TLSConfig) (credentials.TransportCredentials, error) {
+	if cfg.CAFile == "" {
+		if cfg.CertFile != "" {
+			return nil, errors.New("client certificates need a CA to verify the server")
+		}
+		return insecure.NewCredentials(), nil
+	}
+
+	pool, err := loadPool(cfg.CAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := tls.Config{
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(&tlsConfig), nil
+}
+
+// =============================================================================
+
+// chain wraps the call in the interceptors so the first one runs first.
+func chain(is []Interceptor, method string, call func(ctx context.Context) error) func(ctx context.Context) error {
+	for i := len(is) - 1; i >= 0; i-- {
+		i, next := is[i], call
+		call = func(ctx context.Context) error {
+			return i(ctx, method, next)
+		}
+	}
+	return call
+}
+
+// serverStream replaces the context of a stream with the one built by the
+// interceptors.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of the stream.
+func (ss *serverStream) Context() context.Context {
+	return ss.ctx
+}
+
+// loadPool reads the PEM encoded certificates of the CA file.
+func loadPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in %s", file)
+	}
+
+	return pool, nil
+}
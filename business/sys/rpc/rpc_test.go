@@ -0,0 +1,131 @@
+package rpc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/rpc"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestInterceptors(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Should be able to create a private key : %s.", err)
+	}
+
+	a, err := auth.New("54bb2165-71e1-41a6-af3e-7da4a0e1e2c1", &keyStore{pk: privateKey})
+	if err != nil {
+		t.Fatalf("Should be able to create an authenticator : %s.", err)
+	}
+
+	log := zap.NewNop().Sugar()
+	unary := rpc.Unary(rpc.Logger(log), rpc.Metrics(), rpc.Panics(log), rpc.Authenticate(a, auth.RoleService))
+
+	call := func(ctx context.Context, method string, handler grpc.UnaryHandler) (interface{}, error) {
+		return unary(ctx, "request", &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	}
+
+	// incoming builds the context of a call carrying the metadata.
+	incoming := func(md map[string]string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.New(md))
+	}
+
+	t.Log("Given the need to secure gRPC calls.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen a service calls with its token.", testID)
+		{
+			md, err := a.ServiceToken("users-api", time.Hour, false).GetRequestMetadata(context.Background())
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to get a token : %s.", failed, testID, err)
+			}
+
+			resp, err := call(incoming(md), "/email.Email/Send", func(ctx context.Context, req interface{}) (interface{}, error) {
+				claims, err := auth.GetClaims(ctx)
+				if err != nil || claims.Subject != "users-api" {
+					t.Fatalf("\t%s\tTest %d:\tShould see the claims of the service : %+v %v.", failed, testID, claims, err)
+				}
+				return "response", nil
+			})
+			if err != nil || resp != "response" {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to call : %v %v.", failed, testID, resp, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to call.", success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen a client calls without the right credentials.", testID)
+		{
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "response", nil
+			}
+
+			if _, err := call(context.Background(), "/email.Email/Send", handler); status.Code(err) != codes.Unauthenticated {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to call without a token : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to call without a token.", success, testID)
+
+			if _, err := call(incoming(map[string]string{"authorization": "bearer abc"}), "/email.Email/Send", handler); status.Code(err) != codes.Unauthenticated {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to call with a bad token : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to call with a bad token.", success, testID)
+
+			claims := auth.Claims{Roles: []string{auth.RoleUser}}
+			token, err := a.GenerateToken(claims)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to generate a token : %s.", failed, testID, err)
+			}
+			if _, err := call(incoming(map[string]string{"authorization": "bearer " + token}), "/email.Email/Send", handler); status.Code(err) != codes.PermissionDenied {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT be able to call as a user : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT be able to call as a user.", success, testID)
+
+			if _, err := call(context.Background(), "/grpc.health.v1.Health/Check", handler); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to check health without a token : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to check health without a token.", success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen a handler panics.", testID)
+		{
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				panic("boom")
+			}
+
+			if _, err := call(context.Background(), "/grpc.health.v1.Health/Check", handler); status.Code(err) != codes.Internal {
+				t.Fatalf("\t%s\tTest %d:\tShould turn the panic into an Internal error : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould turn the panic into an Internal error.", success, testID)
+		}
+	}
+}
+
+// =============================================================================
+
+type keyStore struct {
+	pk *rsa.PrivateKey
+}
+
+func (ks *keyStore) PrivateKey(kid string) (*rsa.PrivateKey, error) {
+	return ks.pk, nil
+}
+
+func (ks *keyStore) PublicKey(kid string) (*rsa.PublicKey, error) {
+	return &ks.pk.PublicKey, nil
+}
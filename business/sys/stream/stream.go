@@ -0,0 +1,241 @@
+// Package stream pushes the events published over NATS to the clients that
+// stream them. Every instance of a service receives every event and hands it
+// to the streams of its own clients, keyed by what the clients stream such as
+// a post or a user.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/nats"
+	"github.com/dudakovict/social-network/foundation/web"
+	"github.com/nats-io/stan.go"
+	"go.uber.org/zap"
+)
+
+// Config represents how streams are served.
+//
+// Buffer is how many messages a stream can fall behind before it is dropped.
+// Dropping slow clients keeps them from holding up the events for everyone
+// else and clients are expected to reconnect and catch up over the API.
+//
+// CheckTTL is how long a passed check is trusted before it is run again, so
+// a busy stream does not query the database for every message.
+type Config struct {
+	Buffer       int
+	Heartbeat    time.Duration
+	WriteTimeout time.Duration
+	CheckTTL     time.Duration
+}
+
+// Message is what is pushed to the clients.
+type Message struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// Decoder turns the data of an event into the key of the streams it is pushed
+// to and the message they are sent.
+type Decoder func(data []byte) (key string, m Message, err error)
+
+// ErrDenied is wrapped by the errors of a check when the client is no longer
+// allowed to receive the messages of the stream.
+var ErrDenied = errors.New("stream access denied")
+
+// Check reports whether a client is still allowed to receive the messages of
+// the stream it opened. It is run before messages are sent so a client that
+// lost access stops receiving them. Only errors wrapping ErrDenied end the
+// stream, on any other error the message is skipped.
+type Check func(ctx context.Context) error
+
+// Hub keeps track of the streams served by this instance.
+type Hub struct {
+	log    *zap.SugaredLogger
+	cfg    Config
+	mu     sync.Mutex
+	subs   map[string]map[*subscriber]struct{}
+	closed bool
+}
+
+// NewHub constructs a hub for serving streams.
+func NewHub(log *zap.SugaredLogger, cfg Config) *Hub {
+	return &Hub{
+		log:  log,
+		cfg:  cfg,
+		subs: make(map[string]map[*subscriber]struct{}),
+	}
+}
+
+// Bridge pushes the events published on the subject to the streams with the
+// key returned by dec. The subscription is neither durable nor shared with
+// other instances, so every instance gets every event published from now on.
+func (h *Hub) Bridge(n *nats.NATS, subject string, dec Decoder) error {
+	_, err := n.Client.Subscribe(subject, func(m *stan.Msg) {
+		key, msg, err := dec(m.Data)
+		if err != nil {
+			h.log.Errorw(subject, "ERROR", fmt.Errorf("decoding: %w", err))
+			return
+		}
+
+		data, err := json.Marshal(msg)
+		if err != nil {
+			h.log.Errorw(subject, "ERROR", fmt.Errorf("encoding: %w", err))
+			return
+		}
+
+		h.publish(key, data)
+	})
+
+	return err
+}
+
+// Serve streams the messages with the specified key to the client until the
+// client goes away, falls behind, is denied by the check, its token expires
+// or the hub is closed. The check is optional. Errors are only returned when
+// the stream could not be opened.
+func (h *Hub) Serve(ctx context.Context, w http.ResponseWriter, r *http.Request, key string, check Check) error {
+	sub := h.subscribe(key)
+	defer h.unsubscribe(key, sub)
+
+	s, err := web.OpenStream(ctx, w, r, h.cfg.WriteTimeout)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	ticker := time.NewTicker(h.cfg.Heartbeat)
+	defer ticker.Stop()
+
+	// The token was only validated when the stream was opened.
+	var expired <-chan time.Time
+	if claims, err := auth.GetClaims(ctx); err == nil && claims.ExpiresAt != nil {
+		timer := time.NewTimer(time.Until(claims.ExpiresAt.Time))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	// The client was allowed to open the stream.
+	checked := time.Now()
+
+	for {
+		select {
+		case data := <-sub.ch:
+			if check != nil && time.Since(checked) >= h.cfg.CheckTTL {
+				if err := check(ctx); err != nil {
+					if errors.Is(err, ErrDenied) {
+						return nil
+					}
+					h.log.Errorw("stream", "key", key, "ERROR", fmt.Errorf("check: %w", err))
+					continue
+				}
+				checked = time.Now()
+			}
+			if err := s.Send(data); err != nil {
+				return nil
+			}
+
+		case <-ticker.C:
+			if err := s.Heartbeat(); err != nil {
+				return nil
+			}
+
+		case <-expired:
+			return nil
+
+		case <-sub.done:
+			return nil
+
+		case <-s.Done():
+			return nil
+		}
+	}
+}
+
+// Close ends every stream served by the hub.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for key, subs := range h.subs {
+		for sub := range subs {
+			sub.stop()
+		}
+		delete(h.subs, key)
+	}
+}
+
+// =============================================================================
+
+// subscriber is a stream waiting for messages. Done is closed when the stream
+// has to end.
+type subscriber struct {
+	ch   chan []byte
+	done chan struct{}
+	once sync.Once
+}
+
+func (s *subscriber) stop() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+func (h *Hub) subscribe(key string) *subscriber {
+	sub := subscriber{
+		ch:   make(chan []byte, h.cfg.Buffer),
+		done: make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		sub.stop()
+		return &sub
+	}
+
+	if h.subs[key] == nil {
+		h.subs[key] = make(map[*subscriber]struct{})
+	}
+	h.subs[key][&sub] = struct{}{}
+
+	return &sub
+}
+
+func (h *Hub) unsubscribe(key string, sub *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subs[key], sub)
+	if len(h.subs[key]) == 0 {
+		delete(h.subs, key)
+	}
+}
+
+// publish hands the message to the streams with the key. Streams that have
+// fallen too far behind are dropped instead of blocking the others.
+func (h *Hub) publish(key string, data []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs[key] {
+		select {
+		case sub.ch <- data:
+		default:
+			sub.stop()
+			delete(h.subs[key], sub)
+		}
+	}
+
+	if len(h.subs[key]) == 0 {
+		delete(h.subs, key)
+	}
+}
@@ -0,0 +1,156 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/foundation/web"
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestCheck(t *testing.T) {
+	hub := NewHub(zap.NewNop().Sugar(), Config{Buffer: 8, Heartbeat: time.Minute, WriteTimeout: time.Second})
+
+	// Set of states the check reports.
+	const (
+		allowed int32 = iota
+		failing
+		denied
+	)
+
+	var state int32
+	check := func(ctx context.Context) error {
+		switch atomic.LoadInt32(&state) {
+		case failing:
+			atomic.StoreInt32(&state, allowed)
+			return errors.New("database unavailable")
+		case denied:
+			return fmt.Errorf("%w: access lost", ErrDenied)
+		}
+		return nil
+	}
+
+	app := web.NewApp(make(chan os.Signal, 1))
+	app.Handle(http.MethodGet, "", "/stream", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return hub.Serve(ctx, w, r, "key", check)
+	})
+	app.Handle(http.MethodGet, "", "/expiring", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		claims := auth.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(100 * time.Millisecond)),
+			},
+		}
+		return hub.Serve(auth.SetClaims(ctx, claims), w, r, "expiring", nil)
+	})
+
+	srv := httptest.NewServer(app)
+	t.Cleanup(srv.Close)
+
+	t.Log("Given the need to stop streaming to clients that lost access.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen the check of a stream fails.", testID)
+		{
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"/stream", nil)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a request : %s.", failed, testID, err)
+			}
+			req.Header.Set("Accept", "text/event-stream")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to open the stream : %s.", failed, testID, err)
+			}
+			defer resp.Body.Close()
+
+			for !hub.subscribed("key") {
+				time.Sleep(time.Millisecond)
+			}
+
+			hub.publish("key", []byte("first"))
+
+			br := bufio.NewReader(resp.Body)
+			line, err := readData(br)
+			if err != nil || !strings.Contains(line, "first") {
+				t.Fatalf("\t%s\tTest %d:\tShould receive messages while allowed : %q %v.", failed, testID, line, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive messages while allowed.", success, testID)
+
+			atomic.StoreInt32(&state, failing)
+			hub.publish("key", []byte("skipped"))
+			hub.publish("key", []byte("second"))
+
+			line, err = readData(br)
+			if err != nil || strings.Contains(line, "skipped") || !strings.Contains(line, "second") {
+				t.Fatalf("\t%s\tTest %d:\tShould skip the message and keep the stream when the check fails : %q %v.", failed, testID, line, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould skip the message and keep the stream when the check fails.", success, testID)
+
+			atomic.StoreInt32(&state, denied)
+			hub.publish("key", []byte("third"))
+
+			rest, err := io.ReadAll(br)
+			if err != nil || strings.Contains(string(rest), "third") {
+				t.Fatalf("\t%s\tTest %d:\tShould end the stream without sending the message : %q %v.", failed, testID, rest, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould end the stream without sending the message.", success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen the token of a stream expires.", testID)
+		{
+			req, err := http.NewRequest(http.MethodGet, srv.URL+"/expiring", nil)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a request : %s.", failed, testID, err)
+			}
+			req.Header.Set("Accept", "text/event-stream")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to open the stream : %s.", failed, testID, err)
+			}
+			defer resp.Body.Close()
+
+			if _, err := io.ReadAll(resp.Body); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould end the stream once the token expires : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould end the stream once the token expires.", success, testID)
+		}
+	}
+}
+
+// subscribed reports whether a stream with the key is being served.
+func (h *Hub) subscribed(key string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subs[key]) > 0
+}
+
+// readData reads the next line of the stream carrying a message.
+func readData(br *bufio.Reader) (string, error) {
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil || strings.TrimSpace(line) != "" {
+			return line, err
+		}
+	}
+}
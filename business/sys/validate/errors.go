@@ -3,12 +3,18 @@ package validate
 import (
 	"encoding/json"
 	"errors"
+
+	"github.com/dudakovict/social-network/business/sys/locale"
+	"github.com/go-playground/validator/v10"
 )
 
 // FieldError is used to indicate an error with a specific request field.
+// The error is in the default locale, the validation error it came from is
+// kept to translate it.
 type FieldError struct {
 	Field string `json:"field"`
 	Error string `json:"error"`
+	fe    validator.FieldError
 }
 
 // FieldErrors represents a collection of field errors.
@@ -32,6 +38,22 @@ func (fe FieldErrors) Fields() map[string]string {
 	return m
 }
 
+// Translate returns a copy of the field errors in the first of the locales,
+// in order of preference, that has a message for the error. Locales fall back
+// to their base language and then to the default locale.
+func (fe FieldErrors) Translate(locales ...string) FieldErrors {
+	chain := locale.Chain(locales...)
+
+	fields := make(FieldErrors, len(fe))
+	for i, fld := range fe {
+		if fld.fe != nil {
+			fld.Error = translate(fld.fe, chain)
+		}
+		fields[i] = fld
+	}
+	return fields
+}
+
 // IsFieldErrors checks if an error of type FieldErrors exists.
 func IsFieldErrors(err error) bool {
 	var fe FieldErrors
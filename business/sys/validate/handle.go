@@ -0,0 +1,72 @@
+package validate
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Set of error variables for handle validation.
+var (
+	ErrInvalidHandle  = errors.New("handle must be 3 to 30 letters, numbers or underscores")
+	ErrReservedHandle = errors.New("handle is reserved")
+)
+
+// handleRE matches the characters a handle can be made of.
+var handleRE = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// reserved are the handles users can not take since they clash with routes
+// or could be used to impersonate the service.
+var reserved = map[string]bool{
+	"about":         true,
+	"account":       true,
+	"admin":         true,
+	"administrator": true,
+	"api":           true,
+	"feed":          true,
+	"help":          true,
+	"login":         true,
+	"logout":        true,
+	"me":            true,
+	"moderator":     true,
+	"null":          true,
+	"official":      true,
+	"profile":       true,
+	"profiles":      true,
+	"root":          true,
+	"security":      true,
+	"settings":      true,
+	"signup":        true,
+	"social":        true,
+	"support":       true,
+	"system":        true,
+	"undefined":     true,
+	"users":         true,
+}
+
+// NormalizeHandle returns the canonical form of a handle. Handles are case
+// insensitive and may be written with a leading @.
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(handle, "@"))
+}
+
+// CheckHandle validates that a handle is well formed and not reserved.
+func CheckHandle(handle string) error {
+	handle = NormalizeHandle(handle)
+
+	if !handleRE.MatchString(handle) {
+		return ErrInvalidHandle
+	}
+	if reserved[handle] {
+		return ErrReservedHandle
+	}
+
+	return nil
+}
+
+// validHandle is the validator for the handle tag.
+func validHandle(fl validator.FieldLevel) bool {
+	return CheckHandle(fl.Field().String()) == nil
+}
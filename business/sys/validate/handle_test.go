@@ -0,0 +1,31 @@
+package validate_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dudakovict/social-network/business/sys/validate"
+)
+
+func TestCheckHandle(t *testing.T) {
+	tt := []struct {
+		name   string
+		handle string
+		err    error
+	}{
+		{name: "valid", handle: "gopher_42"},
+		{name: "at sign and case", handle: "@Gopher"},
+		{name: "too short", handle: "go", err: validate.ErrInvalidHandle},
+		{name: "too long", handle: "gopher_gopher_gopher_gopher_gop", err: validate.ErrInvalidHandle},
+		{name: "bad characters", handle: "go-pher", err: validate.ErrInvalidHandle},
+		{name: "reserved", handle: "Admin", err: validate.ErrReservedHandle},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			if err := validate.CheckHandle(tst.handle); !errors.Is(err, tst.err) {
+				t.Fatalf("Should get %v for handle %q : got %v.", tst.err, tst.handle, err)
+			}
+		})
+	}
+}
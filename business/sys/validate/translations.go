@@ -0,0 +1,166 @@
+package validate
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// messages holds the error messages of the tags without a default
+// translation, by locale. Custom validations belong here for every locale.
+var messages = map[string]map[string]string{
+	"en": {
+		"handle":             "{0} must be 3 to 30 letters, numbers or underscores and not reserved",
+		"bcp47_language_tag": "{0} must be a language tag like en or hr-HR",
+	},
+	"hr": {
+		"handle":             "{0} mora imati 3 do 30 slova, brojki ili podvlaka i ne smije biti rezervirano",
+		"bcp47_language_tag": "{0} mora biti oznaka jezika poput en ili hr-HR",
+		"required":           "{0} je obavezno polje",
+		"required_if":        "{0} je obavezno polje",
+		"email":              "{0} mora biti ispravna adresa e-pošte",
+		"url":                "{0} mora biti ispravan URL",
+		"uuid":               "{0} mora biti ispravan UUID",
+		"hexadecimal":        "{0} mora biti heksadecimalni broj",
+		"oneof":              "{0} mora biti jedno od [{1}]",
+		"eqfield":            "{0} mora biti jednako polju {1}",
+	},
+}
+
+// sizes holds the error messages of the tags comparing sizes in locales
+// without a default translation, by kind of field. The size of strings and
+// collections is counted in characters and items.
+var sizes = map[string]map[string]sizeMessages{
+	"hr": {
+		"min": {str: "{0} mora imati najmanje {1}", items: "{0} mora sadržavati najmanje {1}", number: "{0} mora biti {1} ili više"},
+		"max": {str: "{0} može imati najviše {1}", items: "{0} može sadržavati najviše {1}", number: "{0} mora biti {1} ili manje"},
+		"len": {str: "{0} mora imati točno {1}", items: "{0} mora sadržavati točno {1}", number: "{0} mora biti jednako {1}"},
+		"gt":  {str: "{0} mora imati više od {1}", items: "{0} mora sadržavati više od {1}", number: "{0} mora biti veće od {1}"},
+	},
+}
+
+// counts holds the plural forms of characters and items, by locale.
+var counts = map[string]map[string]map[locales.PluralRule]string{
+	"hr": {
+		"character": {
+			locales.PluralRuleOne:   "{0} znak",
+			locales.PluralRuleFew:   "{0} znaka",
+			locales.PluralRuleOther: "{0} znakova",
+		},
+		"item": {
+			locales.PluralRuleOne:   "{0} stavku",
+			locales.PluralRuleFew:   "{0} stavke",
+			locales.PluralRuleOther: "{0} stavki",
+		},
+	},
+}
+
+// sizeMessages are the messages of a tag comparing sizes for every kind of
+// field.
+type sizeMessages struct {
+	str    string
+	items  string
+	number string
+}
+
+// registerTranslations registers the error messages of the locale that the
+// default translations of the validator do not provide.
+func registerTranslations(v *validator.Validate, trans ut.Translator) error {
+	l := trans.Locale()
+
+	for key, forms := range counts[l] {
+		for rule, text := range forms {
+			if err := trans.AddCardinal(key, text, rule, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	for tag, text := range messages[l] {
+		if err := v.RegisterTranslation(tag, trans, addMessage(tag, text), translateMessage); err != nil {
+			return err
+		}
+	}
+
+	for tag, m := range sizes[l] {
+		if err := v.RegisterTranslation(tag, trans, addSize(tag, m), translateSize); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addMessage adds the message of the tag.
+func addMessage(tag string, text string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, text, true)
+	}
+}
+
+// translateMessage translates the message of the tag with the name of the
+// field and the parameter of the tag.
+func translateMessage(trans ut.Translator, fe validator.FieldError) string {
+	t, err := trans.T(fe.Tag(), fe.Field(), fe.Param())
+	if err != nil {
+		return fe.(error).Error()
+	}
+	return t
+}
+
+// addSize adds the messages of the tag for every kind of field.
+func addSize(tag string, m sizeMessages) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		if err := trans.Add(tag+"-string", m.str, true); err != nil {
+			return err
+		}
+		if err := trans.Add(tag+"-items", m.items, true); err != nil {
+			return err
+		}
+		return trans.Add(tag+"-number", m.number, true)
+	}
+}
+
+// translateSize translates the message of the tag for the kind of the field,
+// counting the characters or items in the plural form of the locale.
+func translateSize(trans ut.Translator, fe validator.FieldError) string {
+	f64, err := strconv.ParseFloat(fe.Param(), 64)
+	if err != nil {
+		return fe.(error).Error()
+	}
+
+	var digits uint64
+	if i := strings.Index(fe.Param(), "."); i != -1 {
+		digits = uint64(len(fe.Param()[i+1:]))
+	}
+
+	kind := fe.Kind()
+	if kind == reflect.Ptr {
+		kind = fe.Type().Elem().Kind()
+	}
+
+	var t string
+	switch kind {
+	case reflect.String:
+		var c string
+		if c, err = trans.C("character", f64, digits, trans.FmtNumber(f64, digits)); err == nil {
+			t, err = trans.T(fe.Tag()+"-string", fe.Field(), c)
+		}
+	case reflect.Slice, reflect.Map, reflect.Array:
+		var c string
+		if c, err = trans.C("item", f64, digits, trans.FmtNumber(f64, digits)); err == nil {
+			t, err = trans.T(fe.Tag()+"-items", fe.Field(), c)
+		}
+	default:
+		t, err = trans.T(fe.Tag()+"-number", fe.Field(), trans.FmtNumber(f64, digits))
+	}
+
+	if err != nil {
+		return fe.(error).Error()
+	}
+	return t
+}
@@ -6,7 +6,7 @@ import (
 	"reflect"
 	"strings"
 
-	"github.com/go-playground/locales/en"
+	"github.com/dudakovict/social-network/business/sys/locale"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	en_translations "github.com/go-playground/validator/v10/translations/en"
@@ -16,20 +16,32 @@ import (
 // validate holds the settings and caches for validating request struct values.
 var validate *validator.Validate
 
-// translator is a cache of locale and translation information.
-var translator ut.Translator
+// translators is a cache of locale and translation information for every
+// supported locale.
+var translators *ut.UniversalTranslator
 
 func init() {
 
 	// Instantiate a validator.
 	validate = validator.New()
 
-	// Create a translator for english so the error messages are
-	// more human readable than technical.
-	translator, _ = ut.New(en.New(), en.New()).GetTranslator("en")
+	// Create a translator for every supported locale so the error messages
+	// are more human readable than technical.
+	translators = locale.New()
 
 	// Register the english error messages for use.
-	en_translations.RegisterDefaultTranslations(validate, translator)
+	en, _ := translators.GetTranslator("en")
+	en_translations.RegisterDefaultTranslations(validate, en)
+
+	// Register the custom validations.
+	validate.RegisterValidation("handle", validHandle)
+
+	// Register the error messages of the custom validations, and of every
+	// validation for the locales without default translations.
+	for _, l := range locale.Supported() {
+		trans, _ := translators.GetTranslator(l.Locale())
+		registerTranslations(validate, trans)
+	}
 
 	// Use JSON tag names for errors instead of Go struct names.
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
@@ -55,7 +67,8 @@ func Check(val interface{}) error {
 		for _, verror := range verrors {
 			field := FieldError{
 				Field: verror.Field(),
-				Error: verror.Translate(translator),
+				Error: translate(verror, locale.Chain()),
+				fe:    verror,
 			}
 			fields = append(fields, field)
 		}
@@ -66,6 +79,21 @@ func Check(val interface{}) error {
 	return nil
 }
 
+// translate translates the error with the first locale of the fallback chain
+// that has a message for the tag.
+func translate(verror validator.FieldError, chain []string) string {
+	for _, l := range chain {
+		trans, ok := translators.GetTranslator(l)
+		if !ok {
+			continue
+		}
+		if msg := verror.Translate(trans); msg != verror.Error() {
+			return msg
+		}
+	}
+	return verror.Error()
+}
+
 // GenerateID generate a unique id for entities.
 func GenerateID() string {
 	return uuid.NewString()
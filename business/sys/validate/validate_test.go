@@ -0,0 +1,82 @@
+package validate_test
+
+import (
+	"testing"
+
+	"github.com/dudakovict/social-network/business/sys/validate"
+)
+
+type signup struct {
+	Name   string   `json:"name" validate:"required"`
+	Handle string   `json:"handle" validate:"omitempty,handle"`
+	Bio    string   `json:"bio" validate:"max=3"`
+	Tags   []string `json:"tags" validate:"min=5"`
+	Locale string   `json:"locale" validate:"omitempty,bcp47_language_tag"`
+}
+
+func TestCheckTranslate(t *testing.T) {
+	bad := signup{Handle: "go", Bio: "gopher", Tags: []string{"a"}, Locale: "!!"}
+
+	tt := []struct {
+		name    string
+		locales []string
+		fields  map[string]string
+	}{
+		{
+			name: "default",
+			fields: map[string]string{
+				"name":   "name is a required field",
+				"handle": "handle must be 3 to 30 letters, numbers or underscores and not reserved",
+				"bio":    "bio must be a maximum of 3 characters in length",
+				"tags":   "tags must contain at least 5 items",
+				"locale": "locale must be a language tag like en or hr-HR",
+			},
+		},
+		{
+			name:    "croatian",
+			locales: []string{"hr"},
+			fields: map[string]string{
+				"name":   "name je obavezno polje",
+				"handle": "handle mora imati 3 do 30 slova, brojki ili podvlaka i ne smije biti rezervirano",
+				"bio":    "bio može imati najviše 3 znaka",
+				"tags":   "tags mora sadržavati najmanje 5 stavki",
+				"locale": "locale mora biti oznaka jezika poput en ili hr-HR",
+			},
+		},
+		{
+			name:    "region falls back to language",
+			locales: []string{"hr-HR", "en"},
+			fields: map[string]string{
+				"name": "name je obavezno polje",
+			},
+		},
+		{
+			name:    "unsupported falls back to default",
+			locales: []string{"de", "fr-CA"},
+			fields: map[string]string{
+				"name": "name is a required field",
+			},
+		},
+	}
+
+	err := validate.Check(bad)
+	if !validate.IsFieldErrors(err) {
+		t.Fatalf("Should get field errors : %v.", err)
+	}
+	fe := validate.GetFieldErrors(err)
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			got := fe.Translate(tst.locales...).Fields()
+			for field, want := range tst.fields {
+				if got[field] != want {
+					t.Errorf("Should translate %q : got %q, want %q.", field, got[field], want)
+				}
+			}
+		})
+	}
+
+	if got := fe.Fields()["name"]; got != "name is a required field" {
+		t.Fatalf("Should NOT change the checked errors : got %q.", got)
+	}
+}
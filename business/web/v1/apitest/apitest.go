@@ -0,0 +1,78 @@
+// Package apitest contains supporting code for testing the APIs of the
+// services.
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dudakovict/social-network/foundation/diff"
+)
+
+// update rewrites the golden files with the current output instead of
+// comparing against them.
+var update = flag.Bool("update", false, "update the golden files")
+
+// Success and failure markers.
+const (
+	Success = "✓"
+	Failed  = "✗"
+)
+
+// CheckOpenAPI compares the OpenAPI document the app serves at
+// /v1/openapi.json with the golden file, so a change to the routes or the
+// types they take and return fails until the golden file is updated by
+// running the test with the -update flag.
+func CheckOpenAPI(t *testing.T, app http.Handler, golden string) {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("\t%s\tShould get the OpenAPI document : %d %s", Failed, w.Code, w.Body.String())
+	}
+	t.Logf("\t%s\tShould get the OpenAPI document.", Success)
+
+	var got bytes.Buffer
+	if err := json.Indent(&got, w.Body.Bytes(), "", "  "); err != nil {
+		t.Fatalf("\t%s\tShould get a JSON document : %s", Failed, err)
+	}
+	got.WriteString("\n")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, got.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to read %s : %s", Failed, golden, err)
+	}
+
+	if !bytes.Equal(got.Bytes(), want) {
+		var b strings.Builder
+		for _, l := range diff.Lines(string(want), got.String()) {
+			switch l.Op {
+			case diff.OpInsert:
+				b.WriteString("+ " + l.Text + "\n")
+			case diff.OpDelete:
+				b.WriteString("- " + l.Text + "\n")
+			}
+		}
+		t.Fatalf("\t%s\tShould match %s, run the test with -update when the change is intended. Diff:\n%s", Failed, golden, b.String())
+	}
+	t.Logf("\t%s\tShould match %s.", Success, golden)
+}
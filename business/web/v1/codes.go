@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/foundation/web"
+)
+
+// ProblemContentType is the media type of the problems the API responds with.
+const ProblemContentType = "application/problem+json"
+
+// ProblemType is the prefix of the type URI of every problem the API responds
+// with. The code of the problem completes it.
+const ProblemType = "urn:social-network:problem:"
+
+// InstancePrefix is the prefix of the instance URI of every problem the API
+// responds with. The trace ID of the failed request completes it.
+const InstancePrefix = "urn:trace:"
+
+// Set of codes of the problems that are not caused by a registered error.
+const (
+	CodeValidation = "validation_failed"
+	CodeInternal   = "internal"
+)
+
+// Code is a registered error along with the machine-readable code and the
+// status it is responded with. The message of the error is the title.
+type Code struct {
+	Err    error
+	Code   string
+	Status int
+}
+
+// Registry holds the codes of the errors a service responds with.
+type Registry []Code
+
+// common holds the codes of the errors every service responds with.
+var common = Registry{
+	{Err: auth.ErrForbidden, Code: "forbidden", Status: http.StatusForbidden},
+	{Err: web.ErrBadHandshake, Code: "bad_handshake", Status: http.StatusBadRequest},
+}
+
+// Lookup returns the code of the first registered error the error wraps.
+func (r Registry) Lookup(err error) (Code, bool) {
+	for _, reg := range [...]Registry{r, common} {
+		for _, c := range reg {
+			if errors.Is(err, c.Err) {
+				return c, true
+			}
+		}
+	}
+	return Code{}, false
+}
+
+// StatusCode returns the code of errors that are not registered, derived from
+// the status they are responded with, like not_found for 404.
+func StatusCode(status int) string {
+	text := strings.ToLower(http.StatusText(status))
+	text = strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text)
+	if text == "" {
+		return CodeInternal
+	}
+	return text
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/dudakovict/social-network/foundation/web"
 )
 
-// Authenticate validates a JWT from the `Authorization` header.
+// Authenticate validates a JWT from the `Authorization` header. Browsers can
+// not set headers when opening a stream, so streams can pass the token in the
+// `access_token` query parameter instead.
 func Authenticate(a *auth.Auth) web.Middleware {
 
 	// This is the actual middleware function to be executed.
@@ -23,6 +25,9 @@ func Authenticate(a *auth.Auth) web.Middleware {
 
 			// Expecting: bearer <token>
 			authStr := r.Header.Get("authorization")
+			if token := r.URL.Query().Get("access_token"); authStr == "" && token != "" && web.IsStream(r) {
+				authStr = "bearer " + token
+			}
 
 			// Parse the authorization header.
 			parts := strings.Split(authStr, " ")
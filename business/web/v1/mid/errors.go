@@ -11,9 +11,11 @@ import (
 )
 
 // Errors handles errors coming out of the call chain. It detects normal
-// application errors which are used to respond to the client in a uniform way.
-// Unexpected errors (status >= 500) are logged.
-func Errors(log *zap.SugaredLogger) web.Middleware {
+// application errors which are used to respond to the client in a uniform way,
+// as problem details with the code the error is registered with. Unexpected
+// errors (status >= 500) are logged. Field errors are translated to the
+// languages the client accepts.
+func Errors(log *zap.SugaredLogger, registry v1Web.Registry) web.Middleware {
 
 	// This is the actual middleware function to be executed.
 	m := func(handler web.Handler) web.Handler {
@@ -36,32 +38,51 @@ func Errors(log *zap.SugaredLogger) web.Middleware {
 
 				// Build out the error response.
 				var er v1Web.ErrorResponse
-				var status int
+				code, registered := registry.Lookup(err)
 				switch {
 				case validate.IsFieldErrors(err):
-					fieldErrors := validate.GetFieldErrors(err)
+					fieldErrors := validate.GetFieldErrors(err).Translate(web.Languages(r)...)
 					er = v1Web.ErrorResponse{
-						Error:  "data validation error",
+						Code:   v1Web.CodeValidation,
+						Title:  "data validation error",
+						Status: http.StatusBadRequest,
 						Fields: fieldErrors.Fields(),
 					}
-					status = http.StatusBadRequest
+
+				case registered:
+					er = v1Web.ErrorResponse{
+						Code:   code.Code,
+						Title:  code.Err.Error(),
+						Status: code.Status,
+					}
+
+					// A handler can respond with a registered error in a
+					// status of its own.
+					if reqErr := v1Web.GetRequestError(err); reqErr != nil {
+						er.Status = reqErr.Status
+					}
 
 				case v1Web.IsRequestError(err):
 					reqErr := v1Web.GetRequestError(err)
 					er = v1Web.ErrorResponse{
-						Error: reqErr.Error(),
+						Code:   v1Web.StatusCode(reqErr.Status),
+						Title:  http.StatusText(reqErr.Status),
+						Status: reqErr.Status,
+						Detail: reqErr.Error(),
 					}
-					status = reqErr.Status
 
 				default:
 					er = v1Web.ErrorResponse{
-						Error: http.StatusText(http.StatusInternalServerError),
+						Code:   v1Web.CodeInternal,
+						Title:  http.StatusText(http.StatusInternalServerError),
+						Status: http.StatusInternalServerError,
 					}
-					status = http.StatusInternalServerError
 				}
+				er.Type = v1Web.ProblemType + er.Code
+				er.Instance = v1Web.InstancePrefix + v.TraceID
 
 				// Respond with the error back to the client.
-				if err := web.Respond(ctx, w, er, status); err != nil {
+				if err := web.RespondJSON(ctx, w, er, v1Web.ProblemContentType, er.Status); err != nil {
 					return err
 				}
 
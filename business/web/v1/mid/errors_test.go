@@ -0,0 +1,96 @@
+package mid_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dudakovict/social-network/business/sys/auth"
+	"github.com/dudakovict/social-network/business/sys/validate"
+	v1Web "github.com/dudakovict/social-network/business/web/v1"
+	"github.com/dudakovict/social-network/business/web/v1/mid"
+	"github.com/dudakovict/social-network/foundation/web"
+	"go.uber.org/zap"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+var errGopherNotFound = errors.New("gopher not found")
+
+type newGopher struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func TestErrors(t *testing.T) {
+	registry := v1Web.Registry{
+		{Err: errGopherNotFound, Code: "gopher_not_found", Status: http.StatusNotFound},
+	}
+
+	app := web.NewApp(make(chan os.Signal, 1), mid.Errors(zap.NewNop().Sugar(), registry))
+	handle := func(path string, err error) {
+		app.Handle(http.MethodGet, "v1", path, func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			return err
+		})
+	}
+	handle("/registered", fmt.Errorf("ID[1]: %w", errGopherNotFound))
+	handle("/status", v1Web.NewRequestError(errGopherNotFound, http.StatusGone))
+	handle("/request", v1Web.NewRequestError(errors.New("invalid page format [a]"), http.StatusBadRequest))
+	handle("/forbidden", v1Web.NewRequestError(auth.ErrForbidden, http.StatusForbidden))
+	handle("/validation", validate.Check(newGopher{}))
+	handle("/unexpected", errors.New("connection refused"))
+
+	tt := []struct {
+		path string
+		exp  v1Web.ErrorResponse
+	}{
+		{"/registered", v1Web.ErrorResponse{Code: "gopher_not_found", Title: "gopher not found", Status: http.StatusNotFound}},
+		{"/status", v1Web.ErrorResponse{Code: "gopher_not_found", Title: "gopher not found", Status: http.StatusGone}},
+		{"/request", v1Web.ErrorResponse{Code: "bad_request", Title: "Bad Request", Status: http.StatusBadRequest, Detail: "invalid page format [a]"}},
+		{"/forbidden", v1Web.ErrorResponse{Code: "forbidden", Title: auth.ErrForbidden.Error(), Status: http.StatusForbidden}},
+		{"/validation", v1Web.ErrorResponse{Code: v1Web.CodeValidation, Title: "data validation error", Status: http.StatusBadRequest}},
+		{"/unexpected", v1Web.ErrorResponse{Code: v1Web.CodeInternal, Title: "Internal Server Error", Status: http.StatusInternalServerError}},
+	}
+
+	t.Log("Given the need to respond with problem details.")
+	{
+		for testID, tst := range tt {
+			t.Logf("\tTest %d:\tWhen the handler of %s fails.", testID, tst.path)
+			{
+				r := httptest.NewRequest(http.MethodGet, "/v1"+tst.path, nil)
+				w := httptest.NewRecorder()
+				app.ServeHTTP(w, r)
+
+				if ct := w.Header().Get("Content-Type"); w.Code != tst.exp.Status || ct != v1Web.ProblemContentType {
+					t.Fatalf("\t%s\tTest %d:\tShould respond with the status and the media type : %d %s", failed, testID, w.Code, ct)
+				}
+				t.Logf("\t%s\tTest %d:\tShould respond with the status and the media type.", success, testID)
+
+				var got v1Web.ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to unmarshal the problem : %v", failed, testID, err)
+				}
+
+				if got.Type != v1Web.ProblemType+tst.exp.Code || got.Code != tst.exp.Code || got.Title != tst.exp.Title || got.Status != tst.exp.Status || got.Detail != tst.exp.Detail {
+					t.Fatalf("\t%s\tTest %d:\tShould get the problem : %+v", failed, testID, got)
+				}
+				if !strings.HasPrefix(got.Instance, v1Web.InstancePrefix) || got.Instance == v1Web.InstancePrefix {
+					t.Fatalf("\t%s\tTest %d:\tShould get the trace URI as the instance.", failed, testID)
+				}
+				if tst.path == "/validation" && got.Fields["name"] != "name is a required field" {
+					t.Fatalf("\t%s\tTest %d:\tShould get the field errors : %v", failed, testID, got.Fields)
+				}
+				t.Logf("\t%s\tTest %d:\tShould get the problem.", success, testID)
+			}
+		}
+	}
+}
@@ -1,12 +1,19 @@
-//  Package v1 represents types used by the web application for v1.
+// Package v1 represents types used by the web application for v1.
 package v1
 
 import "errors"
 
 // ErrorResponse is the form used for API responses from failures in the API.
+// It is a problem details document (RFC 9457) sent as application/problem+json.
+// Clients match on the code, which never changes, rather than on the title.
 type ErrorResponse struct {
-	Error  string            `json:"error"`
-	Fields map[string]string `json:"fields,omitempty"`
+	Type     string            `json:"type"`
+	Code     string            `json:"code"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
 }
 
 // RequestError is used to pass an error during the request through the
@@ -28,6 +35,11 @@ func (err *RequestError) Error() string {
 	return err.Err.Error()
 }
 
+// Unwrap returns the wrapped error so the registered code of it is found.
+func (err *RequestError) Unwrap() error {
+	return err.Err
+}
+
 // IsRequestError checks if an error of type RequestError exists.
 func IsRequestError(err error) bool {
 	var re *RequestError
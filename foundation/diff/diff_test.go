@@ -0,0 +1,98 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dudakovict/social-network/foundation/diff"
+	"github.com/google/go-cmp/cmp"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestLines(t *testing.T) {
+	tt := []struct {
+		name string
+		old  string
+		new  string
+		exp  []diff.Line
+	}{
+		{
+			name: "equal",
+			old:  "a\nb",
+			new:  "a\nb",
+			exp: []diff.Line{
+				{Op: diff.OpEqual, Text: "a"},
+				{Op: diff.OpEqual, Text: "b"},
+			},
+		},
+		{
+			name: "changed",
+			old:  "a\nb\nc",
+			new:  "a\nx\nc",
+			exp: []diff.Line{
+				{Op: diff.OpEqual, Text: "a"},
+				{Op: diff.OpDelete, Text: "b"},
+				{Op: diff.OpInsert, Text: "x"},
+				{Op: diff.OpEqual, Text: "c"},
+			},
+		},
+		{
+			name: "appended",
+			old:  "a",
+			new:  "a\nb",
+			exp: []diff.Line{
+				{Op: diff.OpEqual, Text: "a"},
+				{Op: diff.OpInsert, Text: "b"},
+			},
+		},
+		{
+			name: "emptied",
+			old:  "a",
+			new:  "",
+			exp: []diff.Line{
+				{Op: diff.OpDelete, Text: "a"},
+			},
+		},
+	}
+
+	t.Log("Given the need to compute line differences between texts.")
+	{
+		for testID, tst := range tt {
+			t.Logf("\tTest %d:\tWhen handling the %q texts.", testID, tst.name)
+			{
+				got := diff.Lines(tst.old, tst.new)
+				if d := cmp.Diff(tst.exp, got); d != "" {
+					t.Fatalf("\t%s\tTest %d:\tShould get back the expected lines. Diff:\n%s", failed, testID, d)
+				}
+				t.Logf("\t%s\tTest %d:\tShould get back the expected lines.", success, testID)
+			}
+		}
+	}
+}
+
+func TestLinesLimit(t *testing.T) {
+	n := diff.MaxLines + 1
+	old := "head\n" + strings.Repeat("old\n", n) + "tail"
+	new := "head\n" + strings.Repeat("new\n", n) + "tail"
+
+	t.Log("Given the need to bound the cost of comparing large texts.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen more than %d lines changed.", testID, diff.MaxLines)
+		{
+			got := diff.Lines(old, new)
+			if len(got) != 2*n+2 {
+				t.Fatalf("\t%s\tTest %d:\tShould get back every line once : %d.", failed, testID, len(got))
+			}
+			if got[0].Op != diff.OpEqual || got[1].Op != diff.OpDelete || got[n+1].Op != diff.OpInsert || got[2*n+1].Op != diff.OpEqual {
+				t.Fatalf("\t%s\tTest %d:\tShould report the changed lines as deleted and inserted : %+v %+v %+v %+v.", failed, testID, got[0], got[1], got[n+1], got[2*n+1])
+			}
+			t.Logf("\t%s\tTest %d:\tShould report the changed lines as deleted and inserted.", success, testID)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package imaging_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/dudakovict/social-network/foundation/imaging"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestFit(t *testing.T) {
+	tt := []struct {
+		name string
+		w, h int
+		size int
+		expW int
+		expH int
+	}{
+		{"landscape", 400, 200, 100, 100, 50},
+		{"portrait", 200, 400, 100, 50, 100},
+		{"small", 80, 60, 100, 80, 60},
+		{"sliver", 1000, 1, 10, 10, 1},
+	}
+
+	t.Log("Given the need to scale images down.")
+	{
+		for testID, tst := range tt {
+			t.Logf("\tTest %d:\tWhen fitting a %dx%d image in %d.", testID, tst.w, tst.h, tst.size)
+			{
+				img := image.NewNRGBA(image.Rect(0, 0, tst.w, tst.h))
+				got := imaging.Fit(img, tst.size).Bounds()
+				if got.Dx() != tst.expW || got.Dy() != tst.expH {
+					t.Fatalf("\t%s\tTest %d:\tShould get a %dx%d image : got %dx%d.", failed, testID, tst.expW, tst.expH, got.Dx(), got.Dy())
+				}
+				t.Logf("\t%s\tTest %d:\tShould get a %dx%d image.", success, testID, tst.expW, tst.expH)
+			}
+		}
+	}
+}
+
+func TestOrient(t *testing.T) {
+	t.Log("Given the need to display images upright.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen a JPEG records it was taken rotated.", testID)
+		{
+			// A 2x1 image with a red pixel on the left.
+			img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+			img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+			img.Set(1, 0, color.NRGBA{B: 255, A: 255})
+
+			data := withExif(t, encodeJPEG(t, img), 6)
+
+			o := imaging.Orientation(data)
+			if o != 6 {
+				t.Fatalf("\t%s\tTest %d:\tShould read the orientation : got %d.", failed, testID, o)
+			}
+			t.Logf("\t%s\tTest %d:\tShould read the orientation.", success, testID)
+
+			got := imaging.Orient(img, o)
+			if got.Bounds().Dx() != 1 || got.Bounds().Dy() != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould swap the dimensions : got %v.", failed, testID, got.Bounds())
+			}
+			if got.NRGBAAt(0, 0).R != 255 || got.NRGBAAt(0, 1).B != 255 {
+				t.Fatalf("\t%s\tTest %d:\tShould rotate clockwise : got %v %v.", failed, testID, got.NRGBAAt(0, 0), got.NRGBAAt(0, 1))
+			}
+			t.Logf("\t%s\tTest %d:\tShould rotate clockwise.", success, testID)
+
+			if o := imaging.Orientation(encodeJPEG(t, img)); o != 1 {
+				t.Fatalf("\t%s\tTest %d:\tShould default to upright : got %d.", failed, testID, o)
+			}
+			t.Logf("\t%s\tTest %d:\tShould default to upright.", success, testID)
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+
+	t.Log("Given the need to remove metadata from images.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen a JPEG carries EXIF data.", testID)
+		{
+			data := withExif(t, encodeJPEG(t, img), 6)
+
+			got, err := imaging.Strip(data, "image/jpeg")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to strip the image : %s.", failed, testID, err)
+			}
+			if bytes.Contains(got, []byte("Exif")) {
+				t.Fatalf("\t%s\tTest %d:\tShould remove the EXIF data.", failed, testID)
+			}
+			if _, err := jpeg.Decode(bytes.NewReader(got)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould still decode : %s.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould remove the EXIF data.", success, testID)
+		}
+
+		testID = 1
+		t.Logf("\tTest %d:\tWhen a PNG carries text chunks.", testID)
+		{
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, img); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to encode : %s.", failed, testID, err)
+			}
+
+			// Insert a text chunk right after the header chunk.
+			data := buf.Bytes()
+			ihdr := 8 + 12 + 13
+			text := chunk("tEXt", []byte("GPS\x0045.81,15.98"))
+			data = append(append(append([]byte{}, data[:ihdr]...), text...), data[ihdr:]...)
+
+			got, err := imaging.Strip(data, "image/png")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to strip the image : %s.", failed, testID, err)
+			}
+			if bytes.Contains(got, []byte("tEXt")) || !bytes.Equal(got, buf.Bytes()) {
+				t.Fatalf("\t%s\tTest %d:\tShould remove the text chunk.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould remove the text chunk.", success, testID)
+		}
+
+		testID = 2
+		t.Logf("\tTest %d:\tWhen a WebP carries EXIF and XMP chunks.", testID)
+		{
+			vp8x := []byte{webpFlagExif | webpFlagXMP, 0, 0, 0, 7, 0, 0, 7, 0, 0}
+			frame := []byte("VP8L image data!")
+			clean := webp(riffChunk("VP8X", []byte{0, 0, 0, 0, 7, 0, 0, 7, 0, 0}), riffChunk("VP8L", frame))
+			data := webp(riffChunk("VP8X", vp8x), riffChunk("VP8L", frame), riffChunk("EXIF", []byte("GPS 45.81,15.98")), riffChunk("XMP ", []byte("<x:xmpmeta/>")))
+
+			got, err := imaging.Strip(data, "image/webp")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to strip the image : %s.", failed, testID, err)
+			}
+			if bytes.Contains(got, []byte("GPS")) || !bytes.Equal(got, clean) {
+				t.Fatalf("\t%s\tTest %d:\tShould remove the EXIF and XMP chunks : %q.", failed, testID, got)
+			}
+			t.Logf("\t%s\tTest %d:\tShould remove the EXIF and XMP chunks.", success, testID)
+		}
+
+		testID = 3
+		t.Logf("\tTest %d:\tWhen the image is malformed.", testID)
+		{
+			if _, err := imaging.Strip([]byte("not an image"), "image/jpeg"); err != imaging.ErrMalformed {
+				t.Fatalf("\t%s\tTest %d:\tShould get ErrMalformed : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get ErrMalformed.", success, testID)
+		}
+	}
+}
+
+func TestBlurhash(t *testing.T) {
+	t.Log("Given the need to compute image placeholders.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen encoding a plain white image.", testID)
+		{
+			img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+			for i := range img.Pix {
+				img.Pix[i] = 255
+			}
+
+			got := imaging.Blurhash(img, 4, 3)
+			if len(got) != 28 {
+				t.Fatalf("\t%s\tTest %d:\tShould get 28 characters : got %q.", failed, testID, got)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get 28 characters.", success, testID)
+
+			// The size flag encodes 4x3 components and the average color
+			// is white.
+			if got[0] != 'L' || got[2:6] != "TSUA" {
+				t.Fatalf("\t%s\tTest %d:\tShould encode the components and average color : got %q.", failed, testID, got)
+			}
+			t.Logf("\t%s\tTest %d:\tShould encode the components and average color.", success, testID)
+		}
+	}
+}
+
+// =============================================================================
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("encoding jpeg: %s", err)
+	}
+	return buf.Bytes()
+}
+
+// withExif inserts an APP1 segment recording the orientation right after
+// the start of image marker.
+func withExif(t *testing.T, data []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	seg = append(seg, payload...)
+
+	return append(append(append([]byte{}, data[:2]...), seg...), data[2:]...)
+}
+
+// Set of VP8X flags announcing the WebP metadata chunks.
+const (
+	webpFlagExif = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// webp builds a WebP image out of the chunks.
+func webp(chunks ...[]byte) []byte {
+	body := append([]byte("WEBP"), bytes.Join(chunks, nil)...)
+	data := make([]byte, 8, 8+len(body))
+	copy(data, "RIFF")
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(body)))
+	return append(data, body...)
+}
+
+// riffChunk builds a RIFF chunk padded to an even length.
+func riffChunk(typ string, data []byte) []byte {
+	c := make([]byte, 8, 9+len(data))
+	copy(c, typ)
+	binary.LittleEndian.PutUint32(c[4:], uint32(len(data)))
+	c = append(c, data...)
+	if len(data)%2 == 1 {
+		c = append(c, 0)
+	}
+	return c
+}
+
+// chunk builds a PNG chunk.
+func chunk(typ string, data []byte) []byte {
+	c := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(c, uint32(len(data)))
+	copy(c[4:], typ)
+	c = append(c, data...)
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.ChecksumIEEE(c[4:]))
+	return append(c, crc...)
+}
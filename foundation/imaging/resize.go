@@ -0,0 +1,88 @@
+// Package imaging provides pure Go support for preparing uploaded images:
+// resizing, applying and stripping metadata and computing placeholders.
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Fit scales the image down so its longest side is at most size pixels,
+// keeping the aspect ratio. Images that already fit are only copied. Each
+// pixel of the result is the average of the pixels it covers.
+func Fit(img image.Image, size int) *image.NRGBA {
+	src := toNRGBA(img)
+
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	if sw <= size && sh <= size {
+		return src
+	}
+
+	dw, dh := size, sh*size/sw
+	if sh > sw {
+		dw, dh = sw*size/sh, size
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := span(y, sh, dh)
+		for x := 0; x < dw; x++ {
+			x0, x1 := span(x, sw, dw)
+
+			// Colors are weighted by alpha so transparent pixels do not
+			// bleed into their neighbours.
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					pa := uint64(src.Pix[i+3])
+					r += uint64(src.Pix[i]) * pa
+					g += uint64(src.Pix[i+1]) * pa
+					b += uint64(src.Pix[i+2]) * pa
+					a += pa
+					n++
+					i += 4
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			if a > 0 {
+				dst.Pix[i] = uint8(r / a)
+				dst.Pix[i+1] = uint8(g / a)
+				dst.Pix[i+2] = uint8(b / a)
+			}
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// span returns the range of source pixels covered by the destination pixel
+// at position i.
+func span(i int, src int, dst int) (int, int) {
+	from := i * src / dst
+	to := (i + 1) * src / dst
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
+
+// toNRGBA returns the image as a NRGBA image with its origin at zero.
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	if n, ok := img.(*image.NRGBA); ok && b.Min == (image.Point{}) {
+		return n
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Rect, img, b.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,195 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrMalformed is returned when the image structure can not be parsed.
+var ErrMalformed = errors.New("image is malformed")
+
+// Set of JPEG markers needed to walk the segments of an image.
+const (
+	soi   = 0xD8
+	sos   = 0xDA
+	app1  = 0xE1
+	app13 = 0xED
+	com   = 0xFE
+)
+
+// pngSignature starts every PNG image.
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// strippedChunks are the PNG chunks that carry metadata about the image
+// rather than the image itself.
+var strippedChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// Set of WebP chunks and the VP8X flags announcing the metadata chunks.
+const (
+	webpExif     = "EXIF"
+	webpXMP      = "XMP "
+	webpFlagExif = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// Strip removes the metadata, such as EXIF, GPS, XMP and IPTC data and
+// comments, from a JPEG, PNG or WebP image without re-encoding it. Other
+// content types are returned as is.
+func Strip(data []byte, contentType string) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	}
+	return data, nil
+}
+
+// stripJPEG drops the APP1 (EXIF and XMP), APP13 (IPTC) and comment
+// segments. The JFIF, ICC profile and Adobe segments are kept since they
+// affect how the image is decoded.
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != soi {
+		return nil, ErrMalformed
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+
+	pos := 2
+	done := walkJPEG(data, func(marker byte, segment []byte) bool {
+		end := pos + 4 + len(segment)
+		if marker != app1 && marker != app13 && marker != com {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+		return true
+	})
+	if !done {
+		return nil, ErrMalformed
+	}
+
+	// Everything from the start of scan on is image data.
+	return append(out, data[pos:]...), nil
+}
+
+// walkJPEG calls fn with every segment before the start of scan until fn
+// returns false. It reports whether the start of scan was reached.
+func walkJPEG(data []byte, fn func(marker byte, segment []byte) bool) bool {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != soi {
+		return false
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return false
+		}
+
+		marker := data[pos+1]
+		if marker == sos {
+			return true
+		}
+
+		n := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if n < 2 || pos+2+n > len(data) {
+			return false
+		}
+
+		if !fn(marker, data[pos+4:pos+2+n]) {
+			return false
+		}
+		pos += 2 + n
+	}
+
+	return false
+}
+
+// stripPNG drops the text, time and EXIF chunks.
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrMalformed
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, ErrMalformed
+		}
+
+		// Every chunk is a length, a type, the data and a CRC.
+		n := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + n
+		if n < 0 || end > len(data) {
+			return nil, ErrMalformed
+		}
+
+		typ := string(data[pos+4 : pos+8])
+		if !strippedChunks[typ] {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+
+		if typ == "IEND" {
+			break
+		}
+	}
+
+	return out, nil
+}
+
+// stripWebP drops the EXIF and XMP chunks of the RIFF container and clears
+// the flags announcing them in the VP8X header.
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrMalformed
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:12]...)
+
+	pos := 12
+	for pos < len(data) {
+		if pos+8 > len(data) {
+			return nil, ErrMalformed
+		}
+
+		// Every chunk is a type, a little endian length and the data padded
+		// to an even length.
+		n := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + n + n%2
+		if n < 0 || end > len(data) {
+			return nil, ErrMalformed
+		}
+
+		switch string(data[pos : pos+4]) {
+		case webpExif, webpXMP:
+		case "VP8X":
+			if n < 1 {
+				return nil, ErrMalformed
+			}
+			start := len(out)
+			out = append(out, data[pos:end]...)
+			out[start+8] &^= webpFlagExif | webpFlagXMP
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+
+	return out, nil
+}
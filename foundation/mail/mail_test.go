@@ -0,0 +1,113 @@
+package mail_test
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	fmail "github.com/dudakovict/social-network/foundation/mail"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func TestMessage(t *testing.T) {
+	t.Log("Given the need to compose e-mail messages.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen the message has an HTML alternative.", testID)
+		{
+			m := fmail.Message{
+				From:    "noreply@example.com",
+				To:      []string{"user@example.com"},
+				Subject: "Dobro došli",
+				Text:    "Hello,\nplain text.",
+				HTML:    "<p>Hello, <b>html</b>.</p>",
+				Date:    time.Date(2019, time.April, 1, 0, 0, 0, 0, time.UTC),
+
+				ListUnsubscribe: "https://example.com/unsubscribe?token=abc",
+			}
+
+			b, err := m.Bytes()
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to encode the message : %s.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to encode the message.", success, testID)
+
+			msg, err := mail.ReadMessage(bytes.NewReader(b))
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to parse the message : %s.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to parse the message.", success, testID)
+
+			subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+			if err != nil || subject != m.Subject {
+				t.Fatalf("\t%s\tTest %d:\tShould decode the subject : %q %v.", failed, testID, subject, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould decode the subject.", success, testID)
+
+			if msg.Header.Get("List-Unsubscribe") != "<"+m.ListUnsubscribe+">" || msg.Header.Get("List-Unsubscribe-Post") != "List-Unsubscribe=One-Click" {
+				t.Fatalf("\t%s\tTest %d:\tShould allow one-click unsubscribe : %v.", failed, testID, msg.Header)
+			}
+			t.Logf("\t%s\tTest %d:\tShould allow one-click unsubscribe.", success, testID)
+
+			mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+			if err != nil || mediaType != "multipart/alternative" {
+				t.Fatalf("\t%s\tTest %d:\tShould be multipart/alternative : %q %v.", failed, testID, mediaType, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be multipart/alternative.", success, testID)
+
+			var types, bodies []string
+			mr := multipart.NewReader(msg.Body, params["boundary"])
+			for {
+				p, err := mr.NextRawPart()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to read the parts : %s.", failed, testID, err)
+				}
+				body, err := io.ReadAll(quotedprintable.NewReader(p))
+				if err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to decode the part : %s.", failed, testID, err)
+				}
+				types = append(types, p.Header.Get("Content-Type"))
+				bodies = append(bodies, string(body))
+			}
+
+			if len(types) != 2 || !strings.HasPrefix(types[0], "text/plain") || !strings.HasPrefix(types[1], "text/html") {
+				t.Fatalf("\t%s\tTest %d:\tShould have the plain text part first : %v.", failed, testID, types)
+			}
+			t.Logf("\t%s\tTest %d:\tShould have the plain text part first.", success, testID)
+
+			if bodies[0] != "Hello,\r\nplain text." || bodies[1] != m.HTML {
+				t.Fatalf("\t%s\tTest %d:\tShould keep the bodies : %q.", failed, testID, bodies)
+			}
+			t.Logf("\t%s\tTest %d:\tShould keep the bodies.", success, testID)
+		}
+
+		testID = 1
+		t.Logf("\tTest %d:\tWhen an address tries to inject headers.", testID)
+		{
+			m := fmail.Message{
+				From: "noreply@example.com",
+				To:   []string{"user@example.com\r\nBcc: victim@example.com"},
+				Text: "Hello",
+			}
+
+			if _, err := m.Bytes(); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT encode the message.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT encode the message.", success, testID)
+		}
+	}
+}
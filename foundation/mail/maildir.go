@@ -0,0 +1,108 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Maildir is a transport that delivers the messages into a local Maildir,
+// one file per message, which mail clients and tooling can read directly.
+type Maildir struct {
+	dir     string
+	host    string
+	counter uint64
+}
+
+// NewMaildir constructs a transport that delivers into the Maildir at the
+// directory, creating it when needed.
+func NewMaildir(dir string) (*Maildir, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("creating maildir: %w", err)
+		}
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+
+	md := Maildir{
+		dir:  dir,
+		host: host,
+	}
+
+	return &md, nil
+}
+
+// Send writes the message into tmp and moves it into new once complete, so
+// readers never see a partial message.
+func (md *Maildir) Send(ctx context.Context, m Message) error {
+	b, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+
+	n := atomic.AddUint64(&md.counter, 1)
+	name := fmt.Sprintf("%d.P%dQ%d.%s", time.Now().Unix(), os.Getpid(), n, md.host)
+
+	tmp := filepath.Join(md.dir, "tmp", name)
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+
+	if err := os.Rename(tmp, filepath.Join(md.dir, "new", name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("delivering message: %w", err)
+	}
+
+	return nil
+}
+
+// Receive hands every new message in the Maildir to fn, oldest first, and
+// moves the messages fn accepts into cur so they are not received again. A
+// message fn fails on stays in new for the next call. It returns how many
+// messages were received.
+func (md *Maildir) Receive(fn func(r io.Reader) error) (int, error) {
+	entries, err := os.ReadDir(filepath.Join(md.dir, "new"))
+	if err != nil {
+		return 0, fmt.Errorf("reading maildir: %w", err)
+	}
+
+	var n int
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+
+		if err := md.receive(name, fn); err != nil {
+			return n, fmt.Errorf("receiving %s: %w", name, err)
+		}
+		n++
+	}
+
+	return n, nil
+}
+
+// receive hands a single message to fn and marks it seen.
+func (md *Maildir) receive(name string, fn func(r io.Reader) error) error {
+	path := filepath.Join(md.dir, "new", name)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = fn(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(path, filepath.Join(md.dir, "cur", name+":2,S"))
+}
@@ -0,0 +1,222 @@
+package mail
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// ErrNoReport is returned when a message is neither a delivery status
+// notification nor a feedback report.
+var ErrNoReport = errors.New("message is not a delivery status or feedback report")
+
+// Set of kinds of reports mail servers send back.
+const (
+	ReportDeliveryStatus = "delivery-status"
+	ReportFeedback       = "feedback-report"
+)
+
+// Report is what a mail server sent back about messages: a delivery status
+// notification (RFC 3464) about a message it could not deliver, or a
+// feedback report (RFC 5965) about a message the recipient complained about.
+// MessageID is the Message-ID of the report itself, so a report delivered
+// twice can be told apart from a new one.
+type Report struct {
+	MessageID    string
+	Type         string
+	FeedbackType string
+	Recipients   []ReportRecipient
+}
+
+// ReportRecipient is what the report says about a single recipient. Action
+// and Status are only set by delivery status notifications, Diagnostic holds
+// the reply of the remote server.
+type ReportRecipient struct {
+	Address    string
+	Action     string
+	Status     string
+	Diagnostic string
+}
+
+// ParseReport reads a multipart/report message. It returns ErrNoReport for
+// any other message, like an auto-reply sent to the bounce address.
+func ParseReport(r io.Reader) (Report, error) {
+	msg, err := netmail.ReadMessage(r)
+	if err != nil {
+		return Report{}, fmt.Errorf("reading message: %w", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/report" {
+		return Report{}, ErrNoReport
+	}
+
+	rep := Report{
+		MessageID: strings.Trim(msg.Header.Get("Message-Id"), "<> "),
+		Type:      strings.ToLower(params["report-type"]),
+	}
+	if rep.Type != ReportDeliveryStatus && rep.Type != ReportFeedback {
+		return Report{}, ErrNoReport
+	}
+
+	var original []string
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Report{}, fmt.Errorf("reading part: %w", err)
+		}
+
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		body := partBody(part)
+
+		switch partType {
+		case "message/delivery-status", "message/global-delivery-status":
+			if err := parseDeliveryStatus(body, &rep); err != nil {
+				return Report{}, err
+			}
+		case "message/feedback-report":
+			if err := parseFeedback(body, &rep); err != nil {
+				return Report{}, err
+			}
+		case "message/rfc822", "text/rfc822-headers", "message/global", "message/global-headers":
+			original = recipientsOf(body)
+		}
+	}
+
+	// Feedback reports may leave out who received the message, the headers
+	// of the original message still tell.
+	if rep.Type == ReportFeedback && len(rep.Recipients) == 0 {
+		for _, address := range original {
+			rep.Recipients = append(rep.Recipients, ReportRecipient{Address: address, Diagnostic: rep.FeedbackType})
+		}
+	}
+
+	if len(rep.Recipients) == 0 {
+		return Report{}, fmt.Errorf("report names no recipients")
+	}
+
+	return rep, nil
+}
+
+// =============================================================================
+
+// parseDeliveryStatus reads the per-message fields followed by a group of
+// fields for every recipient.
+func parseDeliveryStatus(r io.Reader, rep *Report) error {
+	groups, err := fieldGroups(r)
+	if err != nil {
+		return fmt.Errorf("reading delivery status: %w", err)
+	}
+
+	// The first group holds the per-message fields.
+	if len(groups) > 0 {
+		groups = groups[1:]
+	}
+
+	for _, h := range groups {
+		address := typedValue(h.Get("Final-Recipient"))
+		if address == "" {
+			address = typedValue(h.Get("Original-Recipient"))
+		}
+		if address == "" {
+			continue
+		}
+
+		rep.Recipients = append(rep.Recipients, ReportRecipient{
+			Address:    address,
+			Action:     strings.ToLower(h.Get("Action")),
+			Status:     strings.Fields(h.Get("Status") + " ")[0],
+			Diagnostic: typedValue(h.Get("Diagnostic-Code")),
+		})
+	}
+
+	return nil
+}
+
+// parseFeedback reads the fields of a feedback report.
+func parseFeedback(r io.Reader, rep *Report) error {
+	groups, err := fieldGroups(r)
+	if err != nil {
+		return fmt.Errorf("reading feedback report: %w", err)
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	h := groups[0]
+	rep.FeedbackType = strings.ToLower(h.Get("Feedback-Type"))
+	for _, address := range h.Values("Original-Rcpt-To") {
+		rep.Recipients = append(rep.Recipients, ReportRecipient{
+			Address:    strings.Trim(address, "<> "),
+			Diagnostic: rep.FeedbackType,
+		})
+	}
+
+	return nil
+}
+
+// fieldGroups reads groups of header fields separated by blank lines.
+func fieldGroups(r io.Reader) ([]textproto.MIMEHeader, error) {
+	tp := textproto.NewReader(bufio.NewReader(r))
+
+	var groups []textproto.MIMEHeader
+	for {
+		h, err := tp.ReadMIMEHeader()
+		if len(h) > 0 {
+			groups = append(groups, h)
+		}
+		if errors.Is(err, io.EOF) {
+			return groups, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// recipientsOf returns the addresses in the To header of the original
+// message, or its headers.
+func recipientsOf(r io.Reader) []string {
+	h, err := textproto.NewReader(bufio.NewReader(r)).ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	list, err := netmail.ParseAddressList(h.Get("To"))
+	if err != nil {
+		return nil
+	}
+
+	addresses := make([]string, len(list))
+	for i, a := range list {
+		addresses[i] = a.Address
+	}
+	return addresses
+}
+
+// partBody decodes a base64 part, quoted-printable is decoded by the reader.
+func partBody(part *multipart.Part) io.Reader {
+	if strings.EqualFold(part.Header.Get("Content-Transfer-Encoding"), "base64") {
+		return base64.NewDecoder(base64.StdEncoding, part)
+	}
+	return part
+}
+
+// typedValue drops the type from a field like "rfc822; user@example.com".
+func typedValue(v string) string {
+	if _, after, ok := strings.Cut(v, ";"); ok {
+		v = after
+	}
+	return strings.Trim(strings.TrimSpace(v), "<>")
+}
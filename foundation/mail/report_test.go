@@ -0,0 +1,126 @@
+package mail_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	fmail "github.com/dudakovict/social-network/foundation/mail"
+)
+
+const dsn = "From: MAILER-DAEMON@mx.example.com\r\n" +
+	"To: bounces@example.com\r\n" +
+	"Subject: Undelivered Mail Returned to Sender\r\n" +
+	"Message-ID: <20190401000000.1@mx.example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/report; report-type=delivery-status; boundary=\"b1\"\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"I'm sorry to have to inform you that your message could not be delivered.\r\n" +
+	"--b1\r\n" +
+	"Content-Type: message/delivery-status\r\n" +
+	"\r\n" +
+	"Reporting-MTA: dns; mx.example.com\r\n" +
+	"Arrival-Date: Mon, 1 Apr 2019 00:00:00 +0000\r\n" +
+	"\r\n" +
+	"Final-Recipient: rfc822; gone@example.com\r\n" +
+	"Action: failed\r\n" +
+	"Status: 5.1.1\r\n" +
+	"Diagnostic-Code: smtp; 550 5.1.1 <gone@example.com>:\r\n" +
+	" Recipient address rejected: User unknown\r\n" +
+	"\r\n" +
+	"Final-Recipient: rfc822; full@example.com\r\n" +
+	"Action: delayed\r\n" +
+	"Status: 4.2.2\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/rfc822-headers\r\n" +
+	"\r\n" +
+	"From: noreply@example.com\r\n" +
+	"To: gone@example.com, full@example.com\r\n" +
+	"Subject: Welcome\r\n" +
+	"--b1--\r\n"
+
+const arf = "From: abuse@isp.example.net\r\n" +
+	"To: bounces@example.com\r\n" +
+	"Subject: FW: Welcome\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/report; report-type=feedback-report; boundary=\"b2\"\r\n" +
+	"\r\n" +
+	"--b2\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"This is an e-mail abuse report.\r\n" +
+	"--b2\r\n" +
+	"Content-Type: message/feedback-report\r\n" +
+	"\r\n" +
+	"Feedback-Type: abuse\r\n" +
+	"User-Agent: SomeGenerator/1.0\r\n" +
+	"Version: 1\r\n" +
+	"\r\n" +
+	"--b2\r\n" +
+	"Content-Type: message/rfc822\r\n" +
+	"\r\n" +
+	"From: noreply@example.com\r\n" +
+	"To: \"Angry Gopher\" <angry@example.net>\r\n" +
+	"Subject: Welcome\r\n" +
+	"\r\n" +
+	"Welcome.\r\n" +
+	"--b2--\r\n"
+
+func TestParseReport(t *testing.T) {
+	t.Log("Given the need to read what mail servers send back.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen reading a delivery status notification.", testID)
+		{
+			rep, err := fmail.ParseReport(strings.NewReader(dsn))
+			if err != nil || rep.Type != fmail.ReportDeliveryStatus || rep.MessageID != "20190401000000.1@mx.example.com" || len(rep.Recipients) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to parse the report : %+v %v.", failed, testID, rep, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to parse the report.", success, testID)
+
+			got := rep.Recipients[0]
+			want := fmail.ReportRecipient{
+				Address:    "gone@example.com",
+				Action:     "failed",
+				Status:     "5.1.1",
+				Diagnostic: "550 5.1.1 <gone@example.com>: Recipient address rejected: User unknown",
+			}
+			if got != want {
+				t.Fatalf("\t%s\tTest %d:\tShould read every recipient : got %+v, want %+v.", failed, testID, got, want)
+			}
+			if got := rep.Recipients[1]; got.Address != "full@example.com" || got.Action != "delayed" || got.Status != "4.2.2" {
+				t.Fatalf("\t%s\tTest %d:\tShould read every recipient : %+v.", failed, testID, got)
+			}
+			t.Logf("\t%s\tTest %d:\tShould read every recipient.", success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen reading a feedback report.", testID)
+		{
+			rep, err := fmail.ParseReport(strings.NewReader(arf))
+			if err != nil || rep.Type != fmail.ReportFeedback || rep.FeedbackType != "abuse" {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to parse the report : %+v %v.", failed, testID, rep, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to parse the report.", success, testID)
+
+			if len(rep.Recipients) != 1 || rep.Recipients[0].Address != "angry@example.net" {
+				t.Fatalf("\t%s\tTest %d:\tShould find the recipient in the original message : %+v.", failed, testID, rep.Recipients)
+			}
+			t.Logf("\t%s\tTest %d:\tShould find the recipient in the original message.", success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen reading any other message.", testID)
+		{
+			msg := "From: user@example.com\r\nSubject: Out of office\r\n\r\nI am away.\r\n"
+			if _, err := fmail.ParseReport(strings.NewReader(msg)); !errors.Is(err, fmail.ErrNoReport) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT treat it as a report : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT treat it as a report.", success, testID)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package mail
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Transport delivers messages.
+type Transport interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// =============================================================================
+
+// Log is a transport that only logs the messages, for running without a mail
+// server.
+type Log struct {
+	log *zap.SugaredLogger
+}
+
+// NewLog constructs a transport that logs the messages.
+func NewLog(log *zap.SugaredLogger) *Log {
+	return &Log{
+		log: log,
+	}
+}
+
+// Send logs the envelope and the subject of the message.
+func (l *Log) Send(ctx context.Context, m Message) error {
+	b, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+
+	l.log.Infow("mail", "from", m.From, "to", m.To, "subject", m.Subject, "size", len(b))
+
+	return nil
+}
+
+// =============================================================================
+
+// Memory is a transport that keeps the messages in memory, for tests and
+// for inspecting the mail sent in development.
+type Memory struct {
+	mu       sync.Mutex
+	limit    int
+	messages []Message
+}
+
+// NewMemory constructs a transport that keeps up to limit messages, dropping
+// the oldest ones first. A limit of zero keeps every message.
+func NewMemory(limit int) *Memory {
+	return &Memory{
+		limit: limit,
+	}
+}
+
+// Send captures the message.
+func (mem *Memory) Send(ctx context.Context, m Message) error {
+	if _, err := m.Bytes(); err != nil {
+		return err
+	}
+
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
+	mem.messages = append(mem.messages, m)
+	if mem.limit > 0 && len(mem.messages) > mem.limit {
+		mem.messages = append([]Message(nil), mem.messages[len(mem.messages)-mem.limit:]...)
+	}
+
+	return nil
+}
+
+// Messages returns the captured messages, oldest first.
+func (mem *Memory) Messages() []Message {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
+	return append([]Message(nil), mem.messages...)
+}
+
+// Reset drops the captured messages.
+func (mem *Memory) Reset() {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
+	mem.messages = nil
+}
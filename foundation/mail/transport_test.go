@@ -0,0 +1,175 @@
+package mail_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fmail "github.com/dudakovict/social-network/foundation/mail"
+)
+
+func TestTransports(t *testing.T) {
+	m := fmail.Message{
+		From:    "noreply@example.com",
+		To:      []string{"user@example.com"},
+		Subject: "Hello",
+		Text:    "Hello there.",
+	}
+
+	t.Log("Given the need to deliver e-mail messages.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen capturing messages in memory.", testID)
+		{
+			mem := fmail.NewMemory(2)
+			for _, subject := range []string{"1", "2", "3"} {
+				m := m
+				m.Subject = subject
+				if err := mem.Send(context.Background(), m); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to send : %s.", failed, testID, err)
+				}
+			}
+
+			got := mem.Messages()
+			if len(got) != 2 || got[0].Subject != "2" || got[1].Subject != "3" {
+				t.Fatalf("\t%s\tTest %d:\tShould keep the newest messages : %+v.", failed, testID, got)
+			}
+			t.Logf("\t%s\tTest %d:\tShould keep the newest messages.", success, testID)
+
+			mem.Reset()
+			if len(mem.Messages()) != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould drop the messages on reset.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould drop the messages on reset.", success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen delivering into a Maildir.", testID)
+		{
+			dir := t.TempDir()
+			md, err := fmail.NewMaildir(dir)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create the Maildir : %s.", failed, testID, err)
+			}
+
+			for i := 0; i < 2; i++ {
+				if err := md.Send(context.Background(), m); err != nil {
+					t.Fatalf("\t%s\tTest %d:\tShould be able to send : %s.", failed, testID, err)
+				}
+			}
+
+			files, _ := filepath.Glob(filepath.Join(dir, "new", "*"))
+			if len(files) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould have a file per message : %v.", failed, testID, files)
+			}
+			b, err := os.ReadFile(files[0])
+			if err != nil || !strings.Contains(string(b), "Subject: Hello") {
+				t.Fatalf("\t%s\tTest %d:\tShould write the message : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould have a file per message.", success, testID)
+
+			var bodies []string
+			n, err := md.Receive(func(r io.Reader) error {
+				b, err := io.ReadAll(r)
+				bodies = append(bodies, string(b))
+				return err
+			})
+			if err != nil || n != 2 || len(bodies) != 2 {
+				t.Fatalf("\t%s\tTest %d:\tShould receive every new message : %d %v.", failed, testID, n, err)
+			}
+			if n, err := md.Receive(func(r io.Reader) error { return nil }); err != nil || n != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT receive a message twice : %d %v.", failed, testID, n, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould receive every new message once.", success, testID)
+		}
+
+		testID++
+		t.Logf("\tTest %d:\tWhen delivering to an SMTP server.", testID)
+		{
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to listen : %s.", failed, testID, err)
+			}
+			defer l.Close()
+
+			received := make(chan []string, 1)
+			go fakeSMTP(l, received)
+
+			host, port, _ := net.SplitHostPort(l.Addr().String())
+			s, err := fmail.NewSMTP(fmail.SMTPConfig{Host: host, Port: port, TLS: fmail.TLSNone})
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to construct the transport : %s.", failed, testID, err)
+			}
+
+			if err := s.Send(context.Background(), m); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to send : %s.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to send.", success, testID)
+
+			cmds := strings.Join(<-received, "\n")
+			if !strings.Contains(cmds, "MAIL FROM:<noreply@example.com>") || !strings.Contains(cmds, "RCPT TO:<user@example.com>") || !strings.Contains(cmds, "Subject: Hello") {
+				t.Fatalf("\t%s\tTest %d:\tShould deliver the envelope and the message : %s.", failed, testID, cmds)
+			}
+			t.Logf("\t%s\tTest %d:\tShould deliver the envelope and the message.", success, testID)
+
+			if _, err := fmail.NewSMTP(fmail.SMTPConfig{TLS: "ssl"}); err == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT accept an unknown TLS mode.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT accept an unknown TLS mode.", success, testID)
+		}
+	}
+}
+
+// fakeSMTP accepts one connection, plays the server side of a plain SMTP
+// session and reports every line the client sent.
+func fakeSMTP(l net.Listener, received chan<- []string) {
+	conn, err := l.Accept()
+	if err != nil {
+		close(received)
+		return
+	}
+	defer conn.Close()
+
+	var lines []string
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	data := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			break
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+
+		if data {
+			if line == "." {
+				data = false
+				reply("250 queued")
+			}
+			continue
+		}
+
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "DATA":
+			data = true
+			reply("354 go ahead")
+		case "QUIT":
+			reply("221 bye")
+			received <- lines
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+	received <- lines
+}
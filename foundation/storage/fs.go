@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FS stores blobs as files under a root directory on the local filesystem.
+type FS struct {
+	root string
+}
+
+// NewFS constructs a store rooted at the specified directory, creating it if
+// it does not exist.
+func NewFS(root string) (*FS, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("creating root: %w", err)
+	}
+
+	return &FS{root: root}, nil
+}
+
+// Put writes the blob to a temporary file first and moves it in place once
+// it is complete so readers never see a partial blob.
+func (s *FS) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	path := filepath.Join(s.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating directory: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	// Read one byte past the declared size to detect larger blobs.
+	n, err := io.Copy(f, io.LimitReader(r, size+1))
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("writing blob: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing blob: %w", err)
+	}
+
+	if n != size {
+		return ErrSizeMismatch
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("moving blob: %w", err)
+	}
+
+	return nil
+}
+
+// Get opens the file holding the blob.
+func (s *FS) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(s.root, filepath.FromSlash(key)))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("opening blob: %w", err)
+	}
+
+	return f, nil
+}
+
+// Delete removes the file holding the blob.
+func (s *FS) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	if err := os.Remove(filepath.Join(s.root, filepath.FromSlash(key))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing blob: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+// Package storage provides support for storing blobs such as uploaded media.
+// Blobs are addressed by a key made of slash separated segments.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Set of errors returned by the storage implementations.
+var (
+	ErrNotFound     = errors.New("blob not found")
+	ErrInvalidKey   = errors.New("key is not in its proper form")
+	ErrSizeMismatch = errors.New("blob size does not match the declared size")
+)
+
+// Storage is the behavior a blob store needs to provide.
+type Storage interface {
+
+	// Put stores exactly size bytes read from r under the specified key.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get opens the blob stored under the specified key. The caller must
+	// close the returned reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the blob stored under the specified key. Deleting a
+	// blob that does not exist is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// checkKey validates that a key can not escape the area of the store it is
+// meant for.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return ErrInvalidKey
+	}
+
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." || strings.ContainsRune(seg, '\\') {
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/social-network/foundation/docker"
+	"github.com/dudakovict/social-network/foundation/storage"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+var mc *docker.Container
+
+// TestMain starts a MinIO container for the S3 tests. The filesystem tests
+// still run when docker is not available.
+func TestMain(m *testing.M) {
+	var err error
+	mc, err = docker.StartContainer("bitnami/minio:2022", "9000",
+		"-e", "MINIO_ROOT_USER=minioadmin",
+		"-e", "MINIO_ROOT_PASSWORD=minioadmin",
+		"-e", "MINIO_DEFAULT_BUCKETS=attachments",
+	)
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		defer docker.StopContainer(mc.ID)
+	}
+
+	m.Run()
+}
+
+func TestFS(t *testing.T) {
+	s, err := storage.NewFS(t.TempDir())
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to construct the store : %s.", failed, err)
+	}
+
+	testStorage(t, s)
+}
+
+func TestS3(t *testing.T) {
+	if mc == nil {
+		t.Skip("MinIO container is not running")
+	}
+
+	s, err := storage.NewS3(storage.S3Config{
+		Endpoint:  "http://" + mc.Host,
+		Bucket:    "attachments",
+		AccessKey: "minioadmin",
+		SecretKey: "minioadmin",
+	})
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to construct the store : %s.", failed, err)
+	}
+
+	// Give MinIO time to start and create the bucket.
+	ctx := context.Background()
+	for i := 0; ; i++ {
+		err := s.Delete(ctx, "ping")
+		if err == nil {
+			break
+		}
+		if i == 20 {
+			docker.DumpContainerLogs(t, mc.ID)
+			t.Fatalf("\t%s\tShould be able to reach MinIO : %s.", failed, err)
+		}
+		time.Sleep(time.Duration(i) * 100 * time.Millisecond)
+	}
+
+	testStorage(t, s)
+}
+
+func testStorage(t *testing.T, s storage.Storage) {
+	t.Log("Given the need to store blobs.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen handling a single blob.", testID)
+		{
+			ctx := context.Background()
+			key := "attachments/3dc0a440-2e05-11ed-a261-0242ac120002"
+			data := "not really an image"
+
+			if err := s.Put(ctx, key, strings.NewReader(data), int64(len(data)), "image/png"); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to put blob : %s.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould be able to put blob.", success, testID)
+
+			r, err := s.Get(ctx, key)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to get blob : %s.", failed, testID, err)
+			}
+			got, err := io.ReadAll(r)
+			r.Close()
+			if err != nil || string(got) != data {
+				t.Fatalf("\t%s\tTest %d:\tShould get back the same blob : %q %v.", failed, testID, got, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get back the same blob.", success, testID)
+
+			if err := s.Delete(ctx, key); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to delete blob : %s.", failed, testID, err)
+			}
+
+			if _, err := s.Get(ctx, key); !errors.Is(err, storage.ErrNotFound) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT find a deleted blob : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT find a deleted blob.", success, testID)
+
+			if err := s.Put(ctx, "../escape", strings.NewReader(data), int64(len(data)), "image/png"); !errors.Is(err, storage.ErrInvalidKey) {
+				t.Fatalf("\t%s\tTest %d:\tShould NOT accept keys escaping the store : %v.", failed, testID, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould NOT accept keys escaping the store.", success, testID)
+		}
+	}
+}
+
+func TestFSSizeMismatch(t *testing.T) {
+	dir := t.TempDir()
+	s, err := storage.NewFS(dir)
+	if err != nil {
+		t.Fatalf("\t%s\tShould be able to construct the store : %s.", failed, err)
+	}
+
+	if err := s.Put(context.Background(), "blob", strings.NewReader("too long"), 3, "image/png"); !errors.Is(err, storage.ErrSizeMismatch) {
+		t.Fatalf("\t%s\tShould NOT store a blob larger than declared : %v.", failed, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("\t%s\tShould NOT leave a partial blob behind : %v %v.", failed, entries, err)
+	}
+	t.Logf("\t%s\tShould NOT store a blob larger than declared.", success)
+}
@@ -0,0 +1,289 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Set of ways a route is secured. Routes take a bearer token unless they
+// are described otherwise.
+const (
+	SecurityBearer = ""
+	SecurityBasic  = "basic"
+	SecurityNone   = "none"
+)
+
+// Route is a route registered with the app, along with its description in
+// the OpenAPI document. The path includes the group.
+type Route struct {
+	Method string
+	Group  string
+	Path   string
+	Doc    Doc
+}
+
+// Describe sets the description of the route.
+func (rt *Route) Describe(d Doc) {
+	rt.Doc = d
+}
+
+// Doc describes a route in the OpenAPI document. Request and Response are
+// values of the types of the JSON bodies, their schemas are derived from the
+// fields and the validate tags. Routes that take or return anything but JSON
+// name the media type instead. The status is the one of a successful
+// response, 200 when not set.
+type Doc struct {
+	Summary      string
+	Query        []string
+	Request      interface{}
+	RequestType  string
+	Response     interface{}
+	ResponseType string
+	Status       int
+	Security     string
+}
+
+// OpenAPIConfig is the information the OpenAPI document is generated with.
+// Error is a value of the type every error response has, sent as the media
+// type of ErrorType or JSON when not set.
+type OpenAPIConfig struct {
+	Title     string
+	Version   string
+	Error     interface{}
+	ErrorType string
+}
+
+// HandleOpenAPI serves the OpenAPI document of every route of the app at the
+// path. The document is generated on the first request, once every route is
+// registered.
+func (a *App) HandleOpenAPI(group string, path string, cfg OpenAPIConfig) {
+	var once sync.Once
+	var doc Document
+
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		once.Do(func() {
+			doc = a.OpenAPI(cfg)
+		})
+		return Respond(ctx, w, doc, http.StatusOK)
+	}
+
+	a.Handle(http.MethodGet, group, path, h).Describe(Doc{
+		Summary:  "Describe the API in OpenAPI 3",
+		Security: SecurityNone,
+	})
+}
+
+// OpenAPI generates the OpenAPI document of every route of the app.
+func (a *App) OpenAPI(cfg OpenAPIConfig) Document {
+	schemas := make(Schemas)
+
+	doc := Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:   cfg.Title,
+			Version: cfg.Version,
+		},
+		Paths: make(map[string]PathItem),
+		Components: Components{
+			Schemas: schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"basic":  {Type: "http", Scheme: "basic"},
+			},
+		},
+	}
+
+	var errSchema *Schema
+	if cfg.Error != nil {
+		errSchema = schemas.Of(reflect.TypeOf(cfg.Error))
+	}
+
+	for _, rt := range a.routes {
+		path, params := openAPIPath(rt.Path)
+
+		op := Operation{
+			Tags:       []string{routeTag(rt)},
+			Summary:    rt.Doc.Summary,
+			Parameters: params,
+			Responses:  make(map[string]Response),
+		}
+
+		for _, q := range rt.Doc.Query {
+			op.Parameters = append(op.Parameters, Parameter{Name: q, In: "query", Schema: &Schema{Type: "string"}})
+		}
+
+		switch {
+		case rt.Doc.Request != nil:
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  content(mediaType(rt.Doc.RequestType), schemas.Of(reflect.TypeOf(rt.Doc.Request))),
+			}
+		case rt.Doc.RequestType != "":
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  content(rt.Doc.RequestType, rawSchema(rt.Doc.RequestType)),
+			}
+		}
+
+		status := rt.Doc.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		resp := Response{
+			Description: http.StatusText(status),
+		}
+		switch {
+		case rt.Doc.Response != nil:
+			resp.Content = content(mediaType(rt.Doc.ResponseType), schemas.Of(reflect.TypeOf(rt.Doc.Response)))
+		case rt.Doc.ResponseType != "":
+			resp.Content = content(rt.Doc.ResponseType, rawSchema(rt.Doc.ResponseType))
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+
+		if errSchema != nil {
+			op.Responses["default"] = Response{
+				Description: "Error",
+				Content:     content(mediaType(cfg.ErrorType), errSchema),
+			}
+		}
+
+		switch rt.Doc.Security {
+		case SecurityBearer:
+			op.Security = []map[string][]string{{"bearer": {}}}
+		case SecurityBasic:
+			op.Security = []map[string][]string{{"basic": {}}}
+		}
+
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(rt.Method)] = &op
+	}
+
+	return doc
+}
+
+// =============================================================================
+
+// Document is an OpenAPI 3 document.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info is the title and the version of the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem holds the operations of a path by method.
+type PathItem map[string]*Operation
+
+// Operation describes a single route.
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter describes a path or query parameter.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of content.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the schemas of named types and the security schemes.
+type Components struct {
+	Schemas         Schemas                   `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme describes how requests are authenticated.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// =============================================================================
+
+// openAPIPath converts the parameters of the path to the OpenAPI form.
+func openAPIPath(path string) (string, []Parameter) {
+	var params []Parameter
+
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, ":") && !strings.HasPrefix(seg, "*") {
+			continue
+		}
+
+		name := seg[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+// routeTag groups the routes by the first segment of the path after the
+// group.
+func routeTag(rt *Route) string {
+	path := strings.TrimPrefix(rt.Path, "/"+rt.Group)
+	tag, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return tag
+}
+
+// mediaType defaults to JSON.
+func mediaType(mt string) string {
+	if mt == "" {
+		return "application/json"
+	}
+	return mt
+}
+
+// rawSchema is the schema of content that is not JSON.
+func rawSchema(mt string) *Schema {
+	if strings.HasPrefix(mt, "text/") {
+		return &Schema{Type: "string"}
+	}
+	return &Schema{Type: "string", Format: "binary"}
+}
+
+// content holds the schema under the media type.
+func content(mt string, schema *Schema) map[string]MediaType {
+	return map[string]MediaType{
+		mt: {Schema: schema},
+	}
+}
@@ -0,0 +1,111 @@
+package web_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/social-network/foundation/web"
+)
+
+type newGopher struct {
+	Name    string   `json:"name" validate:"required,max=20"`
+	Email   string   `json:"email" validate:"required,email"`
+	Age     int      `json:"age" validate:"gt=0"`
+	Kind    string   `json:"kind" validate:"omitempty,oneof=go gopher"`
+	Friends []string `json:"friends" validate:"min=1,dive,uuid"`
+	Secret  string   `json:"-"`
+}
+
+type gopher struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Best        *gopher   `json:"best,omitempty"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	app := web.NewApp(make(chan os.Signal, 1))
+
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+	app.Handle(http.MethodPost, "v1", "/gophers", h).Describe(web.Doc{
+		Summary:  "Create a gopher",
+		Request:  newGopher{},
+		Response: gopher{},
+		Status:   http.StatusCreated,
+	})
+	app.Handle(http.MethodGet, "v1", "/gophers/:id/photo", h).Describe(web.Doc{
+		Summary:      "Get the photo of a gopher",
+		Query:        []string{"size"},
+		ResponseType: "image/png",
+		Security:     web.SecurityNone,
+	})
+	app.HandleOpenAPI("v1", "/openapi.json", web.OpenAPIConfig{Title: "gophers", Version: "1.0", Error: errorResponse{}})
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, r)
+
+	t.Log("Given the need to describe the API.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen asking for the OpenAPI document.", testID)
+		{
+			var doc web.Document
+			if err := json.NewDecoder(w.Body).Decode(&doc); err != nil || w.Code != http.StatusOK {
+				t.Fatalf("\t%s\tTest %d:\tShould get the document : %d %v.", failed, testID, w.Code, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the document.", success, testID)
+
+			create := doc.Paths["/v1/gophers"]["post"]
+			if create == nil || create.Security == nil || create.Responses["201"].Content["application/json"].Schema.Ref != "#/components/schemas/web_test.gopher" || create.Responses["default"].Content == nil {
+				t.Fatalf("\t%s\tTest %d:\tShould describe the route : %+v.", failed, testID, create)
+			}
+			t.Logf("\t%s\tTest %d:\tShould describe the route.", success, testID)
+
+			ng := doc.Components.Schemas["web_test.newGopher"]
+			if ng == nil || !reflect.DeepEqual(ng.Required, []string{"name", "email"}) || ng.Properties["Secret"] != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould derive the schema from the fields : %+v.", failed, testID, ng)
+			}
+			if p := ng.Properties["name"]; p.MaxLength == nil || *p.MaxLength != 20 {
+				t.Fatalf("\t%s\tTest %d:\tShould bound the length of strings : %+v.", failed, testID, p)
+			}
+			if p := ng.Properties["age"]; p.Type != "integer" || p.Minimum == nil || *p.Minimum != 0 || !p.ExclusiveMinimum {
+				t.Fatalf("\t%s\tTest %d:\tShould bound numbers : %+v.", failed, testID, p)
+			}
+			if p := ng.Properties["kind"]; !reflect.DeepEqual(p.Enum, []string{"go", "gopher"}) {
+				t.Fatalf("\t%s\tTest %d:\tShould list the values : %+v.", failed, testID, p)
+			}
+			if p := ng.Properties["friends"]; p.MinItems == nil || *p.MinItems != 1 || p.Items.Format != "uuid" {
+				t.Fatalf("\t%s\tTest %d:\tShould constrain the items : %+v.", failed, testID, p)
+			}
+			t.Logf("\t%s\tTest %d:\tShould derive the schema from the validate tags.", success, testID)
+
+			g := doc.Components.Schemas["web_test.gopher"]
+			if g == nil || g.Properties["best"].Ref != "#/components/schemas/web_test.gopher" || g.Properties["date_created"].Format != "date-time" {
+				t.Fatalf("\t%s\tTest %d:\tShould reference named types : %+v.", failed, testID, g)
+			}
+			t.Logf("\t%s\tTest %d:\tShould reference named types.", success, testID)
+
+			photo := doc.Paths["/v1/gophers/{id}/photo"]["get"]
+			if photo == nil || len(photo.Parameters) != 2 || photo.Parameters[0].In != "path" || photo.Parameters[1].In != "query" || photo.Security != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould describe the parameters : %+v.", failed, testID, photo)
+			}
+			if photo.Responses["200"].Content["image/png"].Schema.Format != "binary" {
+				t.Fatalf("\t%s\tTest %d:\tShould describe other content : %+v.", failed, testID, photo.Responses)
+			}
+			t.Logf("\t%s\tTest %d:\tShould describe the parameters and other content.", success, testID)
+		}
+	}
+}
@@ -3,6 +3,9 @@ package web
 import (
 	"encoding/json"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/dimfeld/httptreemux/v5"
 )
@@ -26,3 +29,48 @@ func Decode(r *http.Request, val interface{}) error {
 
 	return nil
 }
+
+// Languages returns the language tags of the Accept-Language header of the
+// request, the most preferred first. Tags the client refuses with a quality
+// of zero and the wildcard are left out.
+func Languages(r *http.Request) []string {
+	type language struct {
+		tag string
+		q   float64
+	}
+
+	var langs []language
+	for _, h := range r.Header.Values("Accept-Language") {
+		for _, part := range strings.Split(h, ",") {
+			tag, params, _ := strings.Cut(part, ";")
+			tag = strings.TrimSpace(tag)
+			if tag == "" || tag == "*" {
+				continue
+			}
+
+			q := 1.0
+			if name, v, ok := strings.Cut(params, "="); ok && strings.TrimSpace(name) == "q" {
+				f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+				if err != nil {
+					continue
+				}
+				q = f
+			}
+			if q <= 0 {
+				continue
+			}
+
+			langs = append(langs, language{tag: tag, q: q})
+		}
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].q > langs[j].q
+	})
+
+	tags := make([]string, len(langs))
+	for i, l := range langs {
+		tags[i] = l.tag
+	}
+	return tags
+}
@@ -0,0 +1,36 @@
+package web_test
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/dudakovict/social-network/foundation/web"
+)
+
+func TestLanguages(t *testing.T) {
+	tt := []struct {
+		name   string
+		header string
+		want   []string
+	}{
+		{name: "none", header: "", want: []string{}},
+		{name: "single", header: "hr", want: []string{"hr"}},
+		{name: "ordered by quality", header: "en;q=0.5, hr-HR, hr;q=0.9", want: []string{"hr-HR", "hr", "en"}},
+		{name: "refused and wildcard", header: "de;q=0, *;q=0.1, en", want: []string{"en"}},
+		{name: "bad quality", header: "fr;q=x, en", want: []string{"en"}},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tst.header != "" {
+				r.Header.Set("Accept-Language", tst.header)
+			}
+
+			if got := web.Languages(r); !reflect.DeepEqual(got, tst.want) {
+				t.Fatalf("Should get the languages in order : got %v, want %v.", got, tst.want)
+			}
+		})
+	}
+}
@@ -3,11 +3,18 @@ package web
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 )
 
 // Respond converts a Go value to JSON and sends it to the client.
 func Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statusCode int) error {
+	return RespondJSON(ctx, w, data, "application/json", statusCode)
+}
+
+// RespondJSON converts a Go value to JSON and sends it to the client as the
+// content type, which is a JSON based media type like application/problem+json.
+func RespondJSON(ctx context.Context, w http.ResponseWriter, data interface{}, contentType string, statusCode int) error {
 
 	// Set the status code for the request logger middleware.
 	SetStatusCode(ctx, statusCode)
@@ -25,7 +32,7 @@ func Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statu
 	}
 
 	// Set the content type and headers once we know marshaling has succeeded.
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", contentType)
 
 	// Write the status code to the response.
 	w.WriteHeader(statusCode)
@@ -37,3 +44,23 @@ func Respond(ctx context.Context, w http.ResponseWriter, data interface{}, statu
 
 	return nil
 }
+
+// RespondStream copies the contents of r to the client as is.
+func RespondStream(ctx context.Context, w http.ResponseWriter, r io.Reader, contentType string, statusCode int) error {
+
+	// Set the status code for the request logger middleware.
+	SetStatusCode(ctx, statusCode)
+
+	// Set the content type and headers before anything is written.
+	w.Header().Set("Content-Type", contentType)
+
+	// Write the status code to the response.
+	w.WriteHeader(statusCode)
+
+	// Send the contents back to the client.
+	if _, err := io.Copy(w, r); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package web
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is the JSON schema of a value in an OpenAPI document.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// Schemas holds the schemas of named struct types, by the name of the
+// package and the type.
+type Schemas map[string]*Schema
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// Of returns the schema of the type. Named struct types are added to the
+// schemas and referenced.
+func (s Schemas) Of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.Of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.Of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+
+		name := path.Base(t.PkgPath()) + "." + t.Name()
+		if _, ok := s[name]; !ok {
+
+			// Add the schema before its fields so types referring to
+			// themselves end.
+			obj := Schema{}
+			s[name] = &obj
+			obj = *s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	return &Schema{}
+}
+
+// object returns the schema of the fields of the struct type, named after
+// their JSON tags. Embedded structs are flattened like the encoding/json
+// package does.
+func (s Schemas) object(t reflect.Type) *Schema {
+	obj := Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	s.fields(t, &obj)
+
+	return &obj
+}
+
+// fields adds the fields of the struct type to the object.
+func (s Schemas) fields(t reflect.Type, obj *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			s.fields(ft, obj)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := s.Of(f.Type)
+		if constrain(fs, ft, f.Tag.Get("validate")) {
+			obj.Required = append(obj.Required, name)
+		}
+		obj.Properties[name] = fs
+	}
+}
+
+// constrain adds the constraints of the validate tag to the schema of a
+// value of the type, and reports if the value is required. The constraints
+// following dive apply to the items of the value.
+func constrain(schema *Schema, t reflect.Type, tag string) bool {
+	var required bool
+
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "dive":
+			items := schema.Items
+			if items == nil {
+				items = schema.AdditionalProperties
+			}
+			if items != nil {
+				elem := t.Elem()
+				for elem.Kind() == reflect.Ptr {
+					elem = elem.Elem()
+				}
+				constrain(items, elem, strings.Join(rules[i+1:], ","))
+			}
+			return required
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "uuid":
+			schema.Format = "uuid"
+		case "hexadecimal":
+			schema.Pattern = "^(0[xX])?[0-9a-fA-F]+$"
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "min", "gte":
+			bound(schema, param, true, false)
+		case "gt":
+			bound(schema, param, true, true)
+		case "max", "lte":
+			bound(schema, param, false, false)
+		case "lt":
+			bound(schema, param, false, true)
+		case "len":
+			bound(schema, param, true, false)
+			bound(schema, param, false, false)
+		}
+	}
+
+	return required
+}
+
+// bound sets the lower or the upper bound of the schema. Strings are bound
+// in length and arrays in items.
+func bound(schema *Schema, param string, lower bool, exclusive bool) {
+	f, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch schema.Type {
+	case "integer", "number":
+		if lower {
+			schema.Minimum = &f
+			schema.ExclusiveMinimum = exclusive
+		} else {
+			schema.Maximum = &f
+			schema.ExclusiveMaximum = exclusive
+		}
+		return
+	}
+
+	n := int(f)
+	switch {
+	case exclusive && lower:
+		n++
+	case exclusive:
+		n--
+	}
+
+	switch {
+	case schema.Type == "string" && lower:
+		schema.MinLength = &n
+	case schema.Type == "string":
+		schema.MaxLength = &n
+	case schema.Type == "array" && lower:
+		schema.MinItems = &n
+	case schema.Type == "array":
+		schema.MaxItems = &n
+	}
+}
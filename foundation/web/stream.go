@@ -0,0 +1,381 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBadHandshake is returned for WebSocket upgrade requests that do not
+// follow the opening handshake of RFC 6455.
+var ErrBadHandshake = errors.New("bad websocket handshake")
+
+// Stream is a long-lived connection the server pushes messages to the client
+// over. Streams are one way, anything the client sends besides the control
+// messages of the protocol is discarded.
+type Stream interface {
+
+	// Send pushes a message to the client.
+	Send(data []byte) error
+
+	// Heartbeat lets the client and any proxy in between know the stream is
+	// still alive.
+	Heartbeat() error
+
+	// Done is closed once the stream ends, either because the client went
+	// away or because it was closed.
+	Done() <-chan struct{}
+
+	// Close ends the stream.
+	Close() error
+}
+
+// IsStream reports whether the request asks for a stream, either by asking
+// to upgrade to a WebSocket or by accepting Server-Sent Events.
+func IsStream(r *http.Request) bool {
+	return isWebSocket(r) || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// OpenStream takes over the connection of the request and opens a stream on
+// it. Requests asking to upgrade get a WebSocket and every other request gets
+// Server-Sent Events. Taking the connection over keeps the timeouts of the
+// server from cutting the stream off, so every write has to finish within
+// writeTimeout instead.
+//
+// Errors are only returned before the connection is taken over. From then on
+// the response belongs to the stream, so handlers should return nil once the
+// stream ends instead of an error that expects to write a response.
+func OpenStream(ctx context.Context, w http.ResponseWriter, r *http.Request, writeTimeout time.Duration) (Stream, error) {
+	ws := isWebSocket(r)
+
+	var accept string
+	if ws {
+		key := r.Header.Get("Sec-WebSocket-Key")
+		if r.Method != http.MethodGet || r.Header.Get("Sec-WebSocket-Version") != "13" || !validKey(key) {
+			return nil, ErrBadHandshake
+		}
+		accept = acceptKey(key)
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("response writer does not support streaming")
+	}
+
+	nc, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("hijack: %w", err)
+	}
+
+	c := conn{
+		nc:      nc,
+		r:       brw.Reader,
+		timeout: writeTimeout,
+		done:    make(chan struct{}),
+	}
+
+	var header string
+	var s interface {
+		Stream
+		read()
+	}
+	switch {
+	case ws:
+		SetStatusCode(ctx, http.StatusSwitchingProtocols)
+		header = "HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\n" +
+			"Connection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + accept + "\r\n\r\n"
+		s = webSocket{conn: &c}
+
+	default:
+		SetStatusCode(ctx, http.StatusOK)
+		header = "HTTP/1.1 200 OK\r\n" +
+			"Content-Type: text/event-stream\r\n" +
+			"Cache-Control: no-cache\r\n" +
+			"Connection: close\r\n\r\n"
+		s = eventStream{conn: &c}
+	}
+
+	// The server set deadlines for reading the request and writing the
+	// response that would otherwise end the stream.
+	if err := nc.SetDeadline(time.Time{}); err != nil {
+		c.close()
+		return s, nil
+	}
+
+	if err := c.write([]byte(header)); err != nil {
+		c.close()
+		return s, nil
+	}
+
+	go s.read()
+
+	return s, nil
+}
+
+// =============================================================================
+
+// conn is the connection a stream has taken over. Writes are serialized since
+// the protocol may answer the client while a message is being sent.
+type conn struct {
+	nc      net.Conn
+	r       *bufio.Reader
+	timeout time.Duration
+	mu      sync.Mutex
+	done    chan struct{}
+	once    sync.Once
+}
+
+func (c *conn) write(p []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.nc.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
+		return err
+	}
+
+	if _, err := c.nc.Write(p); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *conn) close() error {
+	var err error
+	c.once.Do(func() {
+		close(c.done)
+		err = c.nc.Close()
+	})
+	return err
+}
+
+// Done is closed once the stream ends.
+func (c *conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// =============================================================================
+
+// eventStream pushes messages as Server-Sent Events.
+type eventStream struct {
+	*conn
+}
+
+// Send pushes the message as the data of an event.
+func (es eventStream) Send(data []byte) error {
+	var buf bytes.Buffer
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+
+	return es.write(buf.Bytes())
+}
+
+// Heartbeat sends a comment, which clients ignore.
+func (es eventStream) Heartbeat() error {
+	return es.write([]byte(": heartbeat\n\n"))
+}
+
+// Close ends the stream.
+func (es eventStream) Close() error {
+	return es.close()
+}
+
+// read waits for the client to go away. Clients do not send anything over an
+// event stream.
+func (es eventStream) read() {
+	io.Copy(io.Discard, es.r)
+	es.close()
+}
+
+// =============================================================================
+
+// Set of WebSocket opcodes.
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xA
+)
+
+// maxMessage is the size of the largest message accepted from the client.
+const maxMessage = 64 << 10
+
+// webSocket pushes messages as text messages of a WebSocket.
+type webSocket struct {
+	*conn
+}
+
+// Send pushes the message as a text message.
+func (ws webSocket) Send(data []byte) error {
+	return ws.writeFrame(opText, data)
+}
+
+// Heartbeat sends a ping, which clients answer with a pong.
+func (ws webSocket) Heartbeat() error {
+	return ws.writeFrame(opPing, nil)
+}
+
+// Close sends a normal closure to the client and ends the stream.
+func (ws webSocket) Close() error {
+	ws.writeFrame(opClose, closePayload(1000))
+	return ws.close()
+}
+
+// writeFrame writes a single unfragmented frame. Frames sent by the server
+// are not masked.
+func (ws webSocket) writeFrame(op byte, p []byte) error {
+	var hdr [10]byte
+	hdr[0] = 0x80 | op
+
+	n := 2
+	switch l := len(p); {
+	case l < 126:
+		hdr[1] = byte(l)
+	case l <= 0xFFFF:
+		hdr[1] = 126
+		binary.BigEndian.PutUint16(hdr[2:], uint16(l))
+		n += 2
+	default:
+		hdr[1] = 127
+		binary.BigEndian.PutUint64(hdr[2:], uint64(l))
+		n += 8
+	}
+
+	return ws.write(append(hdr[:n:n], p...))
+}
+
+// read handles the frames sent by the client until it goes away. Pings are
+// answered, closes are echoed and messages are discarded.
+func (ws webSocket) read() {
+	defer ws.close()
+
+	for {
+		var hdr [2]byte
+		if _, err := io.ReadFull(ws.r, hdr[:]); err != nil {
+			return
+		}
+
+		op := hdr[0] & 0x0F
+		masked := hdr[1]&0x80 != 0
+		n := uint64(hdr[1] & 0x7F)
+
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+				return
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(ws.r, ext[:]); err != nil {
+				return
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+
+		// Clients have to mask every frame.
+		if !masked {
+			ws.writeFrame(opClose, closePayload(1002))
+			return
+		}
+
+		var mask [4]byte
+		if _, err := io.ReadFull(ws.r, mask[:]); err != nil {
+			return
+		}
+
+		// Messages from the client are not used so they are skipped.
+		if op&0x8 == 0 {
+			if n > maxMessage {
+				ws.writeFrame(opClose, closePayload(1009))
+				return
+			}
+			if _, err := io.CopyN(io.Discard, ws.r, int64(n)); err != nil {
+				return
+			}
+			continue
+		}
+
+		// Control frames can not carry more than 125 bytes.
+		if n > 125 {
+			ws.writeFrame(opClose, closePayload(1002))
+			return
+		}
+
+		p := make([]byte, n)
+		if _, err := io.ReadFull(ws.r, p); err != nil {
+			return
+		}
+		for i := range p {
+			p[i] ^= mask[i%4]
+		}
+
+		switch op {
+		case opPing:
+			if err := ws.writeFrame(opPong, p); err != nil {
+				return
+			}
+		case opClose:
+			if len(p) > 2 {
+				p = p[:2]
+			}
+			ws.writeFrame(opClose, p)
+			return
+		}
+	}
+}
+
+// isWebSocket reports whether the request asks to upgrade to a WebSocket.
+func isWebSocket(r *http.Request) bool {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+
+	for _, v := range r.Header.Values("Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// validKey reports whether the key is the base64 encoding of 16 bytes.
+func validKey(key string) bool {
+	b, err := base64.StdEncoding.DecodeString(key)
+	return err == nil && len(b) == 16
+}
+
+// acceptKey computes the Sec-WebSocket-Accept header for the key.
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// closePayload is the payload of a close frame with the status code.
+func closePayload(code uint16) []byte {
+	p := make([]byte, 2)
+	binary.BigEndian.PutUint16(p, code)
+	return p
+}
@@ -0,0 +1,177 @@
+package web_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/social-network/foundation/web"
+)
+
+// Success and failure markers.
+const (
+	success = "\u2713"
+	failed  = "\u2717"
+)
+
+func newStreamServer(t *testing.T) string {
+	app := web.NewApp(make(chan os.Signal, 1))
+
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		s, err := web.OpenStream(ctx, w, r, time.Second)
+		if err != nil {
+			if errors.Is(err, web.ErrBadHandshake) {
+				w.WriteHeader(http.StatusBadRequest)
+				return nil
+			}
+			return err
+		}
+		defer s.Close()
+
+		s.Send([]byte("hello\nworld"))
+		s.Heartbeat()
+		<-s.Done()
+
+		return nil
+	}
+	app.Handle(http.MethodGet, "", "/stream", h)
+
+	srv := httptest.NewServer(app)
+	t.Cleanup(srv.Close)
+
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestEventStream(t *testing.T) {
+	host := newStreamServer(t)
+
+	t.Log("Given the need to push Server-Sent Events.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen accepting an event stream.", testID)
+		{
+			req, err := http.NewRequest(http.MethodGet, "http://"+host+"/stream", nil)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a request : %s.", failed, testID, err)
+			}
+			req.Header.Set("Accept", "text/event-stream")
+
+			if !web.IsStream(req) {
+				t.Fatalf("\t%s\tTest %d:\tShould recognize the request as a stream.", failed, testID)
+			}
+			t.Logf("\t%s\tTest %d:\tShould recognize the request as a stream.", success, testID)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to open the stream : %s.", failed, testID, err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+				t.Fatalf("\t%s\tTest %d:\tShould get an event stream : %d %s.", failed, testID, resp.StatusCode, resp.Header.Get("Content-Type"))
+			}
+			t.Logf("\t%s\tTest %d:\tShould get an event stream.", success, testID)
+
+			want := "data: hello\ndata: world\n\n: heartbeat\n\n"
+			got := make([]byte, len(want))
+			if _, err := io.ReadFull(resp.Body, got); err != nil || string(got) != want {
+				t.Fatalf("\t%s\tTest %d:\tShould get the event and the heartbeat : %q %v.", failed, testID, got, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the event and the heartbeat.", success, testID)
+		}
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	host := newStreamServer(t)
+
+	t.Log("Given the need to push messages over a WebSocket.")
+	{
+		testID := 0
+		t.Logf("\tTest %d:\tWhen completing the opening handshake.", testID)
+		{
+			nc, err := net.DialTimeout("tcp", host, time.Second)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to connect : %s.", failed, testID, err)
+			}
+			defer nc.Close()
+			nc.SetDeadline(time.Now().Add(5 * time.Second))
+
+			// The key and accept values are the example of RFC 6455.
+			req := "GET /stream HTTP/1.1\r\n" +
+				"Host: " + host + "\r\n" +
+				"Upgrade: websocket\r\n" +
+				"Connection: keep-alive, Upgrade\r\n" +
+				"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+				"Sec-WebSocket-Version: 13\r\n\r\n"
+			if _, err := nc.Write([]byte(req)); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to send the handshake : %s.", failed, testID, err)
+			}
+
+			r := bufio.NewReader(nc)
+			resp, err := http.ReadResponse(r, nil)
+			if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+				t.Fatalf("\t%s\tTest %d:\tShould switch protocols : %+v %v.", failed, testID, resp, err)
+			}
+			if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+				t.Fatalf("\t%s\tTest %d:\tShould accept the key : %s.", failed, testID, got)
+			}
+			t.Logf("\t%s\tTest %d:\tShould switch protocols.", success, testID)
+
+			frame := make([]byte, 2+len("hello\nworld"))
+			if _, err := io.ReadFull(r, frame); err != nil || frame[0] != 0x81 || int(frame[1]) != len("hello\nworld") || string(frame[2:]) != "hello\nworld" {
+				t.Fatalf("\t%s\tTest %d:\tShould get the message as a text frame : %q %v.", failed, testID, frame, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the message as a text frame.", success, testID)
+
+			ping := make([]byte, 2)
+			if _, err := io.ReadFull(r, ping); err != nil || ping[0] != 0x89 || ping[1] != 0 {
+				t.Fatalf("\t%s\tTest %d:\tShould get the heartbeat as a ping : %q %v.", failed, testID, ping, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the heartbeat as a ping.", success, testID)
+
+			// Clients mask their frames, a zero mask keeps the payload as is.
+			if _, err := nc.Write([]byte{0x88, 0x82, 0, 0, 0, 0, 0x03, 0xE8}); err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to close the stream : %s.", failed, testID, err)
+			}
+
+			closing := make([]byte, 4)
+			if _, err := io.ReadFull(r, closing); err != nil || closing[0] != 0x88 || closing[2] != 0x03 || closing[3] != 0xE8 {
+				t.Fatalf("\t%s\tTest %d:\tShould get the close echoed : %q %v.", failed, testID, closing, err)
+			}
+			t.Logf("\t%s\tTest %d:\tShould get the close echoed.", success, testID)
+		}
+
+		testID = 1
+		t.Logf("\tTest %d:\tWhen sending a bad handshake.", testID)
+		{
+			req, err := http.NewRequest(http.MethodGet, "http://"+host+"/stream", nil)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould be able to create a request : %s.", failed, testID, err)
+			}
+			req.Header.Set("Upgrade", "websocket")
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Sec-WebSocket-Version", "13")
+			req.Header.Set("Sec-WebSocket-Key", "short")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("\t%s\tTest %d:\tShould get a response : %s.", failed, testID, err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("\t%s\tTest %d:\tShould reject the handshake : %d.", failed, testID, resp.StatusCode)
+			}
+			t.Logf("\t%s\tTest %d:\tShould reject the handshake.", success, testID)
+		}
+	}
+}
@@ -19,12 +19,13 @@ type Handler func(ctx context.Context, w http.ResponseWriter, r *http.Request) e
 
 // App is the entrypoint into our application and what configures our context
 // object for each of our http handlers. Feel free to add any configuration
-// data/logic on this App struct.
+// data/logic on this App struct. The routes are kept to describe the API.
 type App struct {
 	mux      *httptreemux.ContextMux
 	otmux    http.Handler
 	shutdown chan os.Signal
 	mw       []Middleware
+	routes   []*Route
 }
 
 // NewApp creates an App value that handle a set of routes for the application.
@@ -62,8 +63,9 @@ func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // Handle sets a handler function for a given HTTP method and path pair
-// to the application server mux.
-func (a *App) Handle(method string, group string, path string, handler Handler, mw ...Middleware) {
+// to the application server mux. The returned route is described for the
+// OpenAPI document of the app.
+func (a *App) Handle(method string, group string, path string, handler Handler, mw ...Middleware) *Route {
 
 	// First wrap handler specific middleware around this handler.
 	handler = wrapMiddleware(mw, handler)
@@ -101,4 +103,13 @@ func (a *App) Handle(method string, group string, path string, handler Handler,
 		finalPath = "/" + group + path
 	}
 	a.mux.Handle(method, finalPath, h)
+
+	rt := Route{
+		Method: method,
+		Group:  group,
+		Path:   finalPath,
+	}
+	a.routes = append(a.routes, &rt)
+
+	return &rt
 }